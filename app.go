@@ -25,6 +25,10 @@ type App struct {
 	operations    *operations.Manager
 	rollback      *operations.RollbackManager
 	numberManager *operations.NumberManager
+	// wideSnapshots liga o snapshot também para limpeza, exclusão de emitente
+	// e mesclagem, que relêem coleções inteiras duas vezes; as demais
+	// operações arriscadas sempre têm snapshot.
+	wideSnapshots bool
 	logs          []string
 	senhaHasheada string
 }
@@ -160,8 +164,12 @@ func (a *App) InactivateZeroProducts() (int, error) {
 
 	a.addLog("Buscando estoques zerados ou negativos...")
 
-	count, err := a.operations.InactivateZeroProducts(func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpInactivateProducts, func() (err error) {
+		count, err = a.operations.InactivateZeroProducts(func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
 
 	if err != nil {
@@ -180,8 +188,12 @@ func (a *App) ChangeTributationByNCM(ncms []string, tributationID string) (int,
 
 	a.addLog(fmt.Sprintf("Alterando tributação para NCMs: %v", ncms))
 
-	count, err := a.operations.ChangeTributationByNCM(ncms, tributationID, func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpChangeTributation, func() (err error) {
+		count, err = a.operations.ChangeTributationByNCM(ncms, tributationID, func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
 
 	if err != nil {
@@ -211,8 +223,10 @@ func (a *App) ChangeFederalTributationByNCM(ncms []string, tribID string) int {
 	}
 
 	a.addLog(fmt.Sprintf("Iniciando alteração de Tributação FEDERAL para NCMs: %v", ncms))
-	err := a.operations.ChangeFederalTributationByNCM(ncms, tribID, func(msg string) {
-		a.addLog(msg)
+	err := a.withSnapshot(operations.OpChangeTribFederal, func() error {
+		return a.operations.ChangeFederalTributationByNCM(ncms, tribID, func(msg string) {
+			a.addLog(msg)
+		})
 	})
 
 	if err != nil {
@@ -247,8 +261,10 @@ func (a *App) ChangeIbsCbsTributationByNCM(ncms []string, tribID string) int {
 	}
 
 	a.addLog(fmt.Sprintf("Iniciando alteração de Tributação IBS/CBS para NCMs: %v", ncms))
-	err := a.operations.ChangeIbsCbsTributationByNCM(ncms, tribID, func(msg string) {
-		a.addLog(msg)
+	err := a.withSnapshot(operations.OpChangeTribIbsCbs, func() error {
+		return a.operations.ChangeIbsCbsTributationByNCM(ncms, tribID, func(msg string) {
+			a.addLog(msg)
+		})
 	})
 
 	if err != nil {
@@ -265,8 +281,12 @@ func (a *App) EnableMEI() (int, error) {
 
 	a.addLog("Habilitando ajuste de estoque MEI...")
 
-	count, err := a.operations.EnableMEI(func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpEnableMEI, func() (err error) {
+		count, err = a.operations.EnableMEI(func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
 
 	if err != nil {
@@ -318,6 +338,283 @@ func (a *App) FindObjectIdInDatabase(searchID string) ([]map[string]string, erro
 	return results, nil
 }
 
+func (a *App) SearchObjectId(searchID string, opts operations.ObjectIdSearchOptions) ([]operations.SearchMatch, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	a.addLog(fmt.Sprintf("Buscando ObjectId %s em todas as coleções...", searchID))
+
+	results, err := a.operations.SearchObjectId(searchID, opts, func(match operations.SearchMatch) {
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "search:match", match)
+		}
+	}, func(msg string) {
+		a.addLog(msg)
+	})
+
+	if err != nil {
+		a.addLog(fmt.Sprintf("Erro: %s", err.Error()))
+		return nil, err
+	}
+
+	a.addLog(fmt.Sprintf("Busca concluída. Encontradas %d referências", len(results)))
+	return results, nil
+}
+
+func (a *App) SearchValue(value string, opts operations.ValueSearchOptions) ([]operations.SearchMatch, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	results, err := a.operations.SearchValue(value, opts, func(match operations.SearchMatch) {
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "search:match", match)
+		}
+	}, func(msg string) {
+		a.addLog(msg)
+	})
+
+	if err != nil {
+		a.addLog(fmt.Sprintf("Erro: %s", err.Error()))
+		return nil, err
+	}
+
+	a.addLog(fmt.Sprintf("Busca concluída. Encontradas %d ocorrências", len(results)))
+	return results, nil
+}
+
+func (a *App) GetDocument(collection, id string) (string, error) {
+	if a.operations == nil {
+		return "", fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.GetDocument(collection, id)
+}
+
+func (a *App) UpdateDocumentField(collection, id, field, value string, allowTypeChange bool) error {
+	if a.operations == nil {
+		return fmt.Errorf("operações não inicializadas")
+	}
+	return a.withSnapshotSpecs(operations.OpEditDocumentField, func() ([]operations.SnapshotSpec, error) {
+		return operations.DocumentSnapshotSpecs(collection, id, field), nil
+	}, func() error {
+		return a.operations.UpdateDocumentField(collection, id, field, value, allowTypeChange, func(msg string) {
+			a.addLog(msg)
+		})
+	})
+}
+
+func (a *App) GetDigisatVersion() (*operations.DigisatVersion, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.DetectDigisatVersion()
+}
+
+func (a *App) CheckOperationCompatibility(operation string) (*operations.CompatibilityStatus, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.CheckCompatibility(operations.OperationType(operation))
+}
+
+func (a *App) GetAuditLog(limit int) ([]operations.AuditEntry, error) {
+	return operations.ReadAuditLog(limit)
+}
+
+func (a *App) RunQuery(req operations.QueryRequest) (*operations.QueryResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.RunQuery(req, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) ExportQuery(req operations.QueryRequest, format string) (int, error) {
+	if a.operations == nil {
+		return 0, fmt.Errorf("operações não inicializadas")
+	}
+
+	format = strings.ToUpper(format)
+	defaultName := fmt.Sprintf("Consulta_%s_%s", req.Collection, time.Now().Format("20060102_150405"))
+
+	var filters []runtime.FileFilter
+	if format == "CSV" {
+		filters = []runtime.FileFilter{{DisplayName: "Arquivos CSV (*.csv)", Pattern: "*.csv"}}
+		defaultName += ".csv"
+	} else if format == "EXCEL" || format == "XLSX" {
+		filters = []runtime.FileFilter{{DisplayName: "Arquivos Excel (*.xlsx)", Pattern: "*.xlsx"}}
+		defaultName += ".xlsx"
+	} else {
+		return 0, fmt.Errorf("formato de exportação inválido: %s", format)
+	}
+
+	selectedPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Exportar Consulta Como...",
+		DefaultFilename: defaultName,
+		Filters:         filters,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erro ao selecionar arquivo: %v", err)
+	}
+	if selectedPath == "" {
+		return 0, fmt.Errorf("salvamento cancelado")
+	}
+
+	return a.operations.ExportQuery(req, format, selectedPath, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) GenerateSchemaCatalog(sampleSize int) (map[string]interface{}, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	catalog, err := a.operations.BuildSchemaCatalog(sampleSize, func(msg string) {
+		a.addLog(msg)
+	})
+	if err != nil {
+		a.addLog(fmt.Sprintf("Erro: %s", err.Error()))
+		return nil, err
+	}
+
+	selectedPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Salvar Catálogo de Esquema Como...",
+		DefaultFilename: fmt.Sprintf("Esquema_%s_%s.json", catalog.Database, time.Now().Format("20060102")),
+		Filters:         []runtime.FileFilter{{DisplayName: "Arquivos JSON (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao selecionar arquivo: %v", err)
+	}
+	if selectedPath != "" {
+		if err := operations.SaveSchemaCatalog(catalog, selectedPath); err != nil {
+			return nil, err
+		}
+		a.addLog(fmt.Sprintf("Catálogo salvo em %s", selectedPath))
+	}
+
+	return map[string]interface{}{
+		"catalog":    catalog,
+		"outputPath": selectedPath,
+	}, nil
+}
+
+func (a *App) SelectSchemaCatalogFile(title string) (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: title,
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Arquivos JSON (*.json)", Pattern: "*.json"},
+		},
+	})
+}
+
+func (a *App) CompareSchemaCatalogs(referencePath, currentPath string) ([]operations.SchemaChange, error) {
+	reference, err := operations.LoadSchemaCatalog(referencePath)
+	if err != nil {
+		return nil, err
+	}
+	current, err := operations.LoadSchemaCatalog(currentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := operations.CompareSchemaCatalogs(reference, current)
+	a.addLog(fmt.Sprintf("Comparação de esquema: %d diferenças", len(changes)))
+	return changes, nil
+}
+
+func (a *App) CompareDatabases(opts operations.DatabaseDiffOptions) (*operations.DatabaseDiffReport, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.CompareDatabases(opts, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) ExportDatabaseDiff(report *operations.DatabaseDiffReport) (string, error) {
+	if report == nil {
+		return "", fmt.Errorf("nenhuma comparação para exportar")
+	}
+
+	selectedPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Exportar Comparação Como...",
+		DefaultFilename: fmt.Sprintf("Comparacao_%s.xlsx", time.Now().Format("20060102_150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "Arquivos Excel (*.xlsx)", Pattern: "*.xlsx"}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao selecionar arquivo: %v", err)
+	}
+	if selectedPath == "" {
+		return "", fmt.Errorf("salvamento cancelado")
+	}
+
+	if err := operations.ExportDatabaseDiff(report, selectedPath); err != nil {
+		return "", err
+	}
+	a.addLog(fmt.Sprintf("Comparação exportada para %s", selectedPath))
+	return selectedPath, nil
+}
+
+func (a *App) ScanOrphanReferences() ([]operations.OrphanReport, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.ScanOrphanReferences(func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) FixOrphanReferences(collection, field, mode, targetID string) (int, error) {
+	if a.operations == nil {
+		return 0, fmt.Errorf("operações não inicializadas")
+	}
+	var fixed int
+	err := a.withSnapshotSpecs(operations.OpFixOrphanReferences, func() ([]operations.SnapshotSpec, error) {
+		return operations.OrphanSnapshotSpecs(collection, field), nil
+	}, func() (err error) {
+		fixed, err = a.operations.FixOrphanReferences(collection, field, mode, targetID, func(msg string) {
+			a.addLog(msg)
+		})
+		return err
+	})
+	return fixed, err
+}
+
+func (a *App) AnonymizeDatabase(opts operations.AnonymizeOptions) (*operations.AnonymizeResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.AnonymizeDatabase(opts, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) ValidateProductIntegrity() (*operations.ProductIntegrityReport, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.ValidateProductIntegrity(func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) RepairProductIntegrity(categories []string) (int, error) {
+	if a.operations == nil {
+		return 0, fmt.Errorf("operações não inicializadas")
+	}
+	var count int
+	err := a.withSnapshot(operations.OpRepairProductIntegrity, func() (err error) {
+		count, err = a.operations.RepairProductIntegrity(categories, func(msg string) {
+			a.addLog(msg)
+		})
+		return err
+	})
+	return count, err
+}
+
 func (a *App) CleanDatabase() error {
 	if a.operations == nil {
 		return fmt.Errorf("operações não inicializadas")
@@ -338,6 +635,37 @@ func (a *App) CleanDatabase() error {
 	return nil
 }
 
+func (a *App) GetCleanupProfiles() ([]operations.CleanupProfile, error) {
+	return operations.LoadCleanupProfiles()
+}
+
+func (a *App) PreviewCleanDatabase(profileName string) ([]operations.CleanupCollectionPreview, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.PreviewCleanDatabase(profileName, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) CleanDatabaseWithProfile(profileName string) error {
+	if a.operations == nil {
+		return fmt.Errorf("operações não inicializadas")
+	}
+
+	err := a.operations.CleanDatabaseWithProfile(profileName, func(msg string) {
+		a.addLog(msg)
+	})
+
+	if err != nil {
+		a.addLog(fmt.Sprintf("Erro: %s", err.Error()))
+		return err
+	}
+
+	a.addLog("Limpeza de base concluída!")
+	return nil
+}
+
 func (a *App) CreateNewDatabase() error {
 	if a.operations == nil {
 		return fmt.Errorf("operações não inicializadas")
@@ -395,6 +723,7 @@ func (a *App) GetUndoableOperations() []map[string]interface{} {
 			"timestamp": op.Timestamp.Format("15:04:05"),
 			"label":     op.Label,
 			"undoable":  op.Undoable,
+			"changes":   op.Changes != nil,
 		}
 	}
 	return result
@@ -420,6 +749,100 @@ func (a *App) UndoOperation(opID string) error {
 	return nil
 }
 
+// withSnapshot executa a operação entre um snapshot e a comparação das
+// coleções que ela altera; o relatório fica anexado ao histórico.
+func (a *App) withSnapshot(op operations.OperationType, run func() error) error {
+	return a.withSnapshotSpecs(op, nil, run)
+}
+
+// withSnapshotSpecs é withSnapshot para operações cujas coleções dependem dos
+// parâmetros.
+func (a *App) withSnapshotSpecs(op operations.OperationType, specs func() ([]operations.SnapshotSpec, error), run func() error) error {
+	var list []operations.SnapshotSpec
+	if specs != nil {
+		var err error
+		if list, err = specs(); err != nil {
+			a.addLog(fmt.Sprintf("⚠️ Snapshot não capturado: %v (a operação segue sem relatório)", err))
+			return run()
+		}
+	}
+	_, err := a.operations.RunWithSnapshot(op, list, run, func(msg string) {
+		a.addLog(msg)
+	})
+	return err
+}
+
+// withWideSnapshot é withSnapshotSpecs para as operações que varrem coleções
+// inteiras; specs só é chamada com SetOperationSnapshots ligado.
+func (a *App) withWideSnapshot(op operations.OperationType, specs func() ([]operations.SnapshotSpec, error), run func() error) error {
+	if !a.wideSnapshots {
+		return run()
+	}
+	return a.withSnapshotSpecs(op, specs, run)
+}
+
+func (a *App) SetOperationSnapshots(enabled bool) {
+	a.wideSnapshots = enabled
+	if enabled {
+		a.addLog("Snapshot de limpeza, exclusão de emitente e mesclagem ativado")
+	} else {
+		a.addLog("Snapshot de limpeza, exclusão de emitente e mesclagem desativado")
+	}
+}
+
+func (a *App) GetOperationSnapshots() bool {
+	return a.wideSnapshots
+}
+
+// GetOperationReports lista as operações do histórico que têm relatório de
+// alterações, da mais recente para a mais antiga.
+func (a *App) GetOperationReports() ([]operations.OperationRecord, error) {
+	if a.rollback == nil {
+		return nil, fmt.Errorf("rollback não inicializado")
+	}
+	return a.rollback.GetReportedOperations(), nil
+}
+
+func (a *App) GetOperationChanges(opID string) (*operations.SnapshotDiff, error) {
+	if a.rollback == nil {
+		return nil, fmt.Errorf("rollback não inicializado")
+	}
+	op, ok := a.rollback.GetOperation(opID)
+	if !ok {
+		return nil, fmt.Errorf("operação não encontrada: %s", opID)
+	}
+	if op.Changes == nil {
+		return nil, fmt.Errorf("a operação não tem relatório de alterações")
+	}
+	return op.Changes, nil
+}
+
+func (a *App) ExportOperationChanges(opID string) (string, error) {
+	changes, err := a.GetOperationChanges(opID)
+	if err != nil {
+		return "", err
+	}
+	op, _ := a.rollback.GetOperation(opID)
+
+	selectedPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Exportar Alterações Como...",
+		DefaultFilename: fmt.Sprintf("Alteracoes_%s_%s.xlsx", op.Type, op.Timestamp.Format("20060102_150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "Arquivos Excel (*.xlsx)", Pattern: "*.xlsx"}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao selecionar arquivo: %v", err)
+	}
+	if selectedPath == "" {
+		return "", fmt.Errorf("salvamento cancelado")
+	}
+
+	if err := operations.ExportSnapshotDiff(changes, op.Label, selectedPath); err != nil {
+		return "", err
+	}
+	a.addLog(fmt.Sprintf("Alterações exportadas para %s", selectedPath))
+	return selectedPath, nil
+}
+
 func (a *App) FilterProducts(filter map[string]interface{}) (map[string]interface{}, error) {
 	if a.operations == nil {
 		return nil, fmt.Errorf("operações não inicializadas")
@@ -615,9 +1038,14 @@ func (a *App) BulkActivateProducts(productIDs []string, activate bool) (int, err
 		return 0, fmt.Errorf("operações não inicializadas")
 	}
 
-	return a.operations.BulkActivateProducts(productIDs, activate, func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpBulkActivate, func() (err error) {
+		count, err = a.operations.BulkActivateProducts(productIDs, activate, func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
+	return count, err
 }
 
 func (a *App) BulkActivateByFilter(filter map[string]interface{}, activate bool) (int, error) {
@@ -647,36 +1075,56 @@ func (a *App) BulkActivateByFilter(filter map[string]interface{}, activate bool)
 		pf.ActiveStatus = &v
 	}
 
-	return a.operations.BulkActivateByFilter(pf, activate, func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpBulkActivate, func() (err error) {
+		count, err = a.operations.BulkActivateByFilter(pf, activate, func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
+	return count, err
 }
 
 func (a *App) ZeroAllStock() (int, error) {
 	if a.operations == nil {
 		return 0, fmt.Errorf("operações não inicializadas")
 	}
-	return a.operations.ZeroAllStock(func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpZeroStock, func() (err error) {
+		count, err = a.operations.ZeroAllStock(func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
+	return count, err
 }
 
 func (a *App) ZeroNegativeStock() (int, error) {
 	if a.operations == nil {
 		return 0, fmt.Errorf("operações não inicializadas")
 	}
-	return a.operations.ZeroNegativeStock(func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpZeroNegativeStock, func() (err error) {
+		count, err = a.operations.ZeroNegativeStock(func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
+	return count, err
 }
 
 func (a *App) ZeroAllPrices() (int, error) {
 	if a.operations == nil {
 		return 0, fmt.Errorf("operações não inicializadas")
 	}
-	return a.operations.ZeroAllPrices(func(msg string) {
-		a.addLog(msg)
+	var count int
+	err := a.withSnapshot(operations.OpZeroAllPrices, func() (err error) {
+		count, err = a.operations.ZeroAllPrices(func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
+	return count, err
 }
 
 // === Phase 1: Price Operations ===
@@ -685,24 +1133,36 @@ func (a *App) AdjustPricesByPercent(filterParams map[string]interface{}, percent
 	if a.operations == nil {
 		return nil, fmt.Errorf("operações não inicializadas")
 	}
+	if err := a.operations.RequireCompatibility(operations.OpAdjustPrices, func(msg string) {
+		a.addLog(msg)
+	}); err != nil {
+		return nil, err
+	}
 
 	filter := a.buildPriceFilter(filterParams)
 	pt := operations.PriceType(priceType)
 
 	a.addLog(fmt.Sprintf("💰 Ajustando preços em %.2f%% (tipo: %s)...", percent, priceType))
 
-	result, err := a.operations.AdjustPricesByPercent(filter, percent, pt, func(msg string) {
-		a.addLog(msg)
+	var out map[string]interface{}
+	err := a.withSnapshot(operations.OpAdjustPrices, func() error {
+		result, err := a.operations.AdjustPricesByPercent(filter, percent, pt, func(msg string) {
+			a.addLog(msg)
+		})
+		if err != nil {
+			return err
+		}
+		out = map[string]interface{}{
+			"totalAffected": result.TotalAffected,
+			"totalProducts": result.TotalProducts,
+			"averageChange": result.AverageChange,
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-
-	return map[string]interface{}{
-		"totalAffected": result.TotalAffected,
-		"totalProducts": result.TotalProducts,
-		"averageChange": result.AverageChange,
-	}, nil
+	return out, nil
 }
 
 func (a *App) PreviewPriceAdjustment(filterParams map[string]interface{}, percent float64, priceType string, limit int) (map[string]interface{}, error) {
@@ -811,21 +1271,33 @@ func (a *App) ChangeNCMByFilter(oldNCMPrefix string, newNCM string) (map[string]
 	if a.operations == nil {
 		return nil, fmt.Errorf("operações não inicializadas")
 	}
+	if err := a.operations.RequireCompatibility(operations.OpChangeNCM, func(msg string) {
+		a.addLog(msg)
+	}); err != nil {
+		return nil, err
+	}
 
 	a.addLog(fmt.Sprintf("🔄 Alterando NCM: %s → %s...", oldNCMPrefix, newNCM))
 
-	result, err := a.operations.ChangeNCMByFilter(oldNCMPrefix, newNCM, func(msg string) {
-		a.addLog(msg)
+	var out map[string]interface{}
+	err := a.withSnapshot(operations.OpChangeNCM, func() error {
+		result, err := a.operations.ChangeNCMByFilter(oldNCMPrefix, newNCM, func(msg string) {
+			a.addLog(msg)
+		})
+		if err != nil {
+			return err
+		}
+		out = map[string]interface{}{
+			"totalAffected": result.TotalAffected,
+			"oldNCM":        result.OldNCM,
+			"newNCM":        result.NewNCM,
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-
-	return map[string]interface{}{
-		"totalAffected": result.TotalAffected,
-		"oldNCM":        result.OldNCM,
-		"newNCM":        result.NewNCM,
-	}, nil
+	return out, nil
 }
 
 func (a *App) PreviewNCMChange(oldNCMPrefix string, newNCM string, limit int) (map[string]interface{}, error) {
@@ -892,7 +1364,39 @@ func (a *App) CleanDatabaseByDate(beforeDate string) (int, error) {
 	if a.operations == nil {
 		return 0, fmt.Errorf("operações não inicializadas")
 	}
-	return a.operations.CleanDatabaseByDate(beforeDate, func(msg string) {
+	var removed int
+	err := a.withWideSnapshot(operations.OpCleanupByDate, operations.CleanupSnapshotSpecs, func() (err error) {
+		removed, err = a.operations.CleanDatabaseByDate(beforeDate, "", func(msg string) {
+			a.addLog(msg)
+		})
+		return err
+	})
+	return removed, err
+}
+
+func (a *App) PreviewCleanupByDate(beforeDate string) ([]operations.CleanupRulePreview, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.PreviewCleanupByDate(beforeDate, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) GetCleanupRules() ([]operations.CleanupRule, error) {
+	rules, _, err := operations.LoadCleanupRules()
+	return rules, err
+}
+
+func (a *App) SaveCleanupRules(rules []operations.CleanupRule) (string, error) {
+	return operations.SaveCleanupRules(rules)
+}
+
+func (a *App) RestoreCleanupArchive(archivePath string) (int, error) {
+	if a.operations == nil {
+		return 0, fmt.Errorf("operações não inicializadas")
+	}
+	return a.operations.RestoreCleanupArchive(archivePath, func(msg string) {
 		a.addLog(msg)
 	})
 }
@@ -929,21 +1433,34 @@ func (a *App) AdjustInventoryRebalance(targetValue float64, resetToZero bool, cu
 	if a.operations == nil {
 		return nil, fmt.Errorf("operações não inicializadas")
 	}
-	result, err := a.operations.AdjustInventoryRebalance(targetValue, resetToZero, func(msg string) {
+	if err := a.operations.RequireCompatibility(operations.OpAdjustInventory, func(msg string) {
 		a.addLog(msg)
-	}, cutoffDate)
+	}); err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	err := a.withSnapshot(operations.OpAdjustInventory, func() error {
+		result, err := a.operations.AdjustInventoryRebalance(targetValue, resetToZero, func(msg string) {
+			a.addLog(msg)
+		}, cutoffDate)
+		if err != nil {
+			return err
+		}
+		out = map[string]interface{}{
+			"adjustedCount": result.AdjustedCount,
+			"zeroedCount":   result.ZeroedCount,
+			"previousValue": result.PreviousValue,
+			"newValue":      result.NewValue,
+			"targetValue":   result.TargetValue,
+			"maxAdjustment": result.MaxAdjustment,
+			"message":       result.Message,
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"adjustedCount": result.AdjustedCount,
-		"zeroedCount":   result.ZeroedCount,
-		"previousValue": result.PreviousValue,
-		"newValue":      result.NewValue,
-		"targetValue":   result.TargetValue,
-		"maxAdjustment": result.MaxAdjustment,
-		"message":       result.Message,
-	}, nil
+	return out, nil
 }
 
 func (a *App) GenerateInventoryReport(cutoffDate string, targetValue float64, format string, companyName string, companyIE string, companyCNPJ string, bookNumber int, sheetNumber int) (map[string]interface{}, error) {
@@ -1095,7 +1612,71 @@ func (a *App) DeleteEmitente(emitenteID string) error {
 		return fmt.Errorf("operações não inicializadas")
 	}
 
-	return a.operations.DeleteEmitente(emitenteID, func(msg string) {
+	return a.withWideSnapshot(operations.OpDeleteEmitente, func() ([]operations.SnapshotSpec, error) {
+		return operations.EmitenteSnapshotSpecs(emitenteID)
+	}, func() error {
+		return a.operations.DeleteEmitente(emitenteID, func(msg string) {
+			a.addLog(msg)
+		})
+	})
+}
+
+func (a *App) MergeDatabase(opts operations.MergeOptions) (map[string]interface{}, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	var report *operations.MergeReport
+	run := func() (err error) {
+		report, err = a.operations.MergeDatabase(opts, func(msg string) {
+			a.addLog(msg)
+		})
+		return err
+	}
+	var err error
+	if opts.DryRun {
+		err = run()
+	} else {
+		err = a.withWideSnapshot(operations.OpMergeDatabase, func() ([]operations.SnapshotSpec, error) {
+			return a.operations.MergeSnapshotSpecs(opts.SourceDatabase)
+		}, run)
+	}
+	if err != nil {
+		a.addLog(fmt.Sprintf("Erro: %s", err.Error()))
+		return nil, err
+	}
+
+	prefix := "Mesclagem"
+	if report.DryRun {
+		prefix = "Simulacao_Mesclagem"
+	}
+	selectedPath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Salvar Relatório da Mesclagem Como...",
+		DefaultFilename: fmt.Sprintf("%s_%s_%s.json", prefix, report.SourceDatabase, time.Now().Format("20060102_150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "Arquivos JSON (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao selecionar arquivo: %v", err)
+	}
+	if selectedPath != "" {
+		if err := operations.SaveMergeReport(report, selectedPath); err != nil {
+			return nil, err
+		}
+		a.addLog(fmt.Sprintf("Relatório salvo em %s", selectedPath))
+	}
+
+	return map[string]interface{}{
+		"report":     report,
+		"outputPath": selectedPath,
+	}, nil
+}
+
+func (a *App) ExtractEmitente(emitenteID string, target operations.CopyTarget) (*operations.ExtractEmitenteResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.ExtractEmitente(emitenteID, target, func(msg string) {
 		a.addLog(msg)
 	})
 }
@@ -1105,9 +1686,14 @@ func (a *App) ChangeInvoiceKey(invoiceType string, oldKey string, newKey string)
 		return 0, fmt.Errorf("operações não inicializadas")
 	}
 
-	return a.operations.ChangeInvoiceKey(invoiceType, oldKey, newKey, func(msg string) {
-		a.addLog(msg)
+	var changed int
+	err := a.withSnapshot(operations.OpChangeInvoiceKey, func() (err error) {
+		changed, err = a.operations.ChangeInvoiceKey(invoiceType, oldKey, newKey, func(msg string) {
+			a.addLog(msg)
+		})
+		return err
 	})
+	return changed, err
 }
 
 func (a *App) ChangeInvoiceStatus(invoiceType string, serie string, numero string, newStatus string) error {
@@ -1115,8 +1701,10 @@ func (a *App) ChangeInvoiceStatus(invoiceType string, serie string, numero strin
 		return fmt.Errorf("operações não inicializadas")
 	}
 
-	return a.operations.ChangeInvoiceStatus(invoiceType, serie, numero, newStatus, func(msg string) {
-		a.addLog(msg)
+	return a.withSnapshot(operations.OpChangeInvoiceStatus, func() error {
+		return a.operations.ChangeInvoiceStatus(invoiceType, serie, numero, newStatus, func(msg string) {
+			a.addLog(msg)
+		})
 	})
 }
 
@@ -1186,6 +1774,70 @@ func (a *App) ListBackups(backupDir string) ([]operations.BackupResult, error) {
 	return operations.ListBackups(backupDir)
 }
 
+func (a *App) BackupDatabaseToRemote(outputDir string) (*operations.BackupResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.BackupDatabaseWithOptions(outputDir, operations.BackupOptions{Upload: true}, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) BackupDatabaseIncremental(outputDir string, upload bool) (*operations.BackupResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.BackupDatabaseWithOptions(outputDir, operations.BackupOptions{Incremental: true, Upload: upload}, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) UploadBackup(backupPath string) (*operations.BackupResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.UploadBackup(backupPath, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) ListRemoteBackups() ([]operations.BackupResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.ListRemoteBackups()
+}
+
+func (a *App) DownloadRemoteBackup(remotePath string, destDir string) (string, error) {
+	if a.operations == nil {
+		return "", fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.DownloadRemoteBackup(remotePath, destDir, func(msg string) {
+		a.addLog(msg)
+	})
+}
+
+func (a *App) PreflightBackup(outputDir string) (*operations.PreflightResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.PreflightBackup(outputDir), nil
+}
+
+func (a *App) PreflightRestore(backupPath string) (*operations.PreflightResult, error) {
+	if a.operations == nil {
+		return nil, fmt.Errorf("operações não inicializadas")
+	}
+
+	return a.operations.PreflightRestore(backupPath), nil
+}
+
 func (a *App) GetDigisatServices() ([]windows.DigiService, error) {
 	return windows.GetDigisatServices()
 }
@@ -1223,6 +1875,7 @@ func (a *App) SelectBackupFile(title string) (string, error) {
 		Title: title,
 		Filters: []runtime.FileFilter{
 			{DisplayName: "Backup ZIP (*.zip)", Pattern: "*.zip"},
+			{DisplayName: "Backup TAR (*.tar;*.tar.gz;*.tgz)", Pattern: "*.tar;*.tar.gz;*.tgz"},
 			{DisplayName: "Todos os arquivos (*.*)", Pattern: "*.*"},
 		},
 	})
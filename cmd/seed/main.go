@@ -0,0 +1,87 @@
+// Comando seed cria uma base DigisatServer fictícia para testes e
+// demonstrações. Uso:
+//
+//	go run ./cmd/seed -uri mongodb://localhost:27017 -db DigisatDemo -produtos 1000
+//
+// Por segurança o comando nunca grava no banco DigisatServer (o da loja) e
+// recusa bancos que já têm coleções, a menos que -drop seja informado.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/seed"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	def := seed.DefaultConfig()
+	cfg := def
+
+	uri := flag.String("uri", "mongodb://localhost:27017", "URI do MongoDB de destino")
+	dbName := flag.String("db", "DigisatDemo", "nome do banco a criar (DigisatServer é recusado)")
+	drop := flag.Bool("drop", false, "apaga o banco de destino antes de gerar")
+	flag.Int64Var(&cfg.Seed, "seed", def.Seed, "semente (a mesma semente gera os mesmos documentos)")
+	flag.IntVar(&cfg.Emitentes, "emitentes", def.Emitentes, "quantidade de emitentes (o primeiro é a Matriz)")
+	flag.IntVar(&cfg.Produtos, "produtos", def.Produtos, "quantidade de produtos/serviços")
+	flag.IntVar(&cfg.Clientes, "clientes", def.Clientes, "quantidade de clientes")
+	flag.IntVar(&cfg.NotasManuais, "notas", def.NotasManuais, "notas fiscais manuais por emitente")
+	flag.IntVar(&cfg.Movimentacoes, "movimentacoes", def.Movimentacoes, "movimentações por emitente")
+	flag.StringVar(&cfg.VersaoDigisat, "versao", def.VersaoDigisat, "versão do Digisat gravada em DigisatUpdate")
+	flag.Parse()
+
+	if err := run(*uri, *dbName, *drop, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "❌", err)
+		os.Exit(1)
+	}
+}
+
+// productionDatabase é o banco usado pelo Digisat em produção.
+const productionDatabase = "DigisatServer"
+
+func run(uri, dbName string, drop bool, cfg seed.Config) error {
+	if strings.EqualFold(dbName, productionDatabase) {
+		return fmt.Errorf("o banco %s é o da loja e não pode receber dados fictícios, nem com -drop; use outro nome (ex.: DigisatDemo)", productionDatabase)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetServerSelectionTimeout(5*time.Second))
+	if err != nil {
+		return fmt.Errorf("erro ao conectar ao MongoDB: %w", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	db := client.Database(dbName)
+	existing, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("erro ao listar coleções: %w", err)
+	}
+	if len(existing) > 0 {
+		if !drop {
+			return fmt.Errorf("o banco %s já tem %d coleções; use -drop para recriá-lo", dbName, len(existing))
+		}
+		fmt.Printf("🗑️ Apagando banco %s...\n", dbName)
+		if err := db.Drop(ctx); err != nil {
+			return fmt.Errorf("erro ao apagar banco: %w", err)
+		}
+	}
+
+	fmt.Printf("🌱 Gerando dados (seed %d)...\n", cfg.Seed)
+	ds := seed.Generate(cfg)
+	if err := ds.Load(ctx, db, func(msg string) { fmt.Println(msg) }); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ %d documentos gravados em %s\n", ds.Total(), dbName)
+	return nil
+}
@@ -444,6 +444,8 @@ function App() {
         onClose={() => setShowRollbackModal(false)}
         undoableOps={undoableOps}
         setUndoableOps={setUndoableOps}
+        showSuccess={showSuccess}
+        showError={showError}
       />
 
       <ConfirmModal
@@ -1,27 +1,83 @@
-import { UndoOperation, GetUndoableOperations } from '../../../wailsjs/go/main/App';
+import { useEffect, useState } from 'react';
+import {
+  UndoOperation,
+  GetUndoableOperations,
+  GetOperationReports,
+  GetOperationSnapshots,
+  SetOperationSnapshots,
+  ExportOperationChanges,
+} from '../../../wailsjs/go/main/App';
 
 interface RollbackModalProps {
   show: boolean;
   onClose: () => void;
   undoableOps: any[];
   setUndoableOps: (ops: any[]) => void;
+  showSuccess: (msg: string) => void;
+  showError: (msg: string) => void;
 }
 
-export function RollbackModal({ show, onClose, undoableOps, setUndoableOps }: RollbackModalProps) {
-  
+// changeSummary resume o relatório do snapshot: "Estoques: 3 alterados, 1 removido".
+function changeSummary(changes: any): string {
+  const parts = (changes?.collections || [])
+    .filter((c: any) => c.changed || c.created || c.removed)
+    .map((c: any) => {
+      const counts = [];
+      if (c.changed) counts.push(`${c.changed} alterados`);
+      if (c.created) counts.push(`${c.created} criados`);
+      if (c.removed) counts.push(`${c.removed} removidos`);
+      return `${c.name}: ${counts.join(', ')}`;
+    });
+  if (parts.length === 0) return 'Nenhuma alteração';
+  return parts.join(' · ') + (changes.truncated ? ' (lista parcial)' : '');
+}
+
+export function RollbackModal({ show, onClose, undoableOps, setUndoableOps, showSuccess, showError }: RollbackModalProps) {
+  const [reports, setReports] = useState<any[]>([]);
+  const [wideSnapshots, setWideSnapshots] = useState(false);
+
+  const loadReports = async () => {
+    try {
+      const ops = await GetOperationReports();
+      setReports(ops || []);
+    } catch(err) {
+      console.error(err);
+    }
+  };
+
+  useEffect(() => {
+    if (!show) return;
+    loadReports();
+    GetOperationSnapshots().then(setWideSnapshots).catch(console.error);
+  }, [show]);
+
   const handleUndo = async (opId: string) => {
     try {
       await UndoOperation(opId);
       const ops = await GetUndoableOperations();
       setUndoableOps(ops || []);
-      if (ops.length === 0) {
-        onClose();
-      }
+      await loadReports();
     } catch(err) {
       console.error(err);
+      showError(String(err));
+    }
+  };
+
+  const handleExport = async (opId: string) => {
+    try {
+      const path = await ExportOperationChanges(opId);
+      showSuccess(`✅ Alterações exportadas para ${path}`);
+    } catch(err) {
+      if (String(err).includes('cancelado')) return;
+      showError(String(err));
     }
   };
 
+  const toggleWideSnapshots = async (enabled: boolean) => {
+    await SetOperationSnapshots(enabled);
+    setWideSnapshots(enabled);
+  };
+
   if (!show) return null;
 
   return (
@@ -45,6 +101,41 @@ export function RollbackModal({ show, onClose, undoableOps, setUndoableOps }: Ro
             ))}
           </div>
         )}
+
+        <h3>📎 Relatórios de Alterações</h3>
+        {reports.length === 0 ? (
+          <p className="modal-desc">Nenhuma operação com relatório de alterações.</p>
+        ) : (
+          <div className="undo-list">
+            {reports.map((op: any) => (
+              <div key={op.id} className="undo-item">
+                <div className="undo-info">
+                  <span className="undo-label">{op.label}</span>
+                  <span className="undo-time">{op.timestamp}</span>
+                  <span className="undo-time">{changeSummary(op.changes)}</span>
+                </div>
+                <button className="undo-btn" onClick={() => handleExport(op.id)}>
+                  Exportar
+                </button>
+              </div>
+            ))}
+          </div>
+        )}
+
+        <div className="form-group">
+          <label className="checkbox-row">
+            <input
+              type="checkbox"
+              checked={wideSnapshots}
+              onChange={e => toggleWideSnapshots(e.target.checked)}
+            />
+            <span>Registrar alterações também na limpeza por data, exclusão de emitente e mesclagem</span>
+          </label>
+          <p className="modal-desc" style={{ marginTop: '0.5rem', fontSize: '0.8rem' }}>
+            Essas operações relêem coleções inteiras antes e depois; as demais sempre registram o relatório.
+          </p>
+        </div>
+
         <div className="modal-actions">
           <button onClick={onClose}>Fechar</button>
           <span></span>
@@ -9,10 +9,16 @@ export function AdjustPricesByPercent(arg1:Record<string, any>,arg2:number,arg3:
 
 export function AllowSecurityExclusions():Promise<void>;
 
+export function AnonymizeDatabase(arg1:operations.AnonymizeOptions):Promise<operations.AnonymizeResult>;
+
 export function ApplyMarkup(arg1:Record<string, any>,arg2:number):Promise<Record<string, any>>;
 
 export function BackupDatabase(arg1:string):Promise<operations.BackupResult>;
 
+export function BackupDatabaseIncremental(arg1:string,arg2:boolean):Promise<operations.BackupResult>;
+
+export function BackupDatabaseToRemote(arg1:string):Promise<operations.BackupResult>;
+
 export function BulkActivateByFilter(arg1:Record<string, any>,arg2:boolean):Promise<number>;
 
 export function BulkActivateProducts(arg1:Array<string>,arg2:boolean):Promise<number>;
@@ -33,16 +39,24 @@ export function ChangeTributationByNCM(arg1:Array<string>,arg2:string):Promise<n
 
 export function CheckConnection():Promise<boolean>;
 
+export function CheckOperationCompatibility(arg1:string):Promise<operations.CompatibilityStatus>;
+
 export function CleanDatabase():Promise<void>;
 
 export function CleanDatabaseByDate(arg1:string):Promise<number>;
 
+export function CleanDatabaseWithProfile(arg1:string):Promise<void>;
+
 export function CleanDigisatRegistry():Promise<void>;
 
 export function CleanMovements():Promise<void>;
 
 export function ClearLogs():Promise<void>;
 
+export function CompareDatabases(arg1:operations.DatabaseDiffOptions):Promise<operations.DatabaseDiffReport>;
+
+export function CompareSchemaCatalogs(arg1:string,arg2:string):Promise<Array<operations.SchemaChange>>;
+
 export function ConfirmInvoiceNumber(arg1:string,arg2:number):Promise<void>;
 
 export function CountFilteredProducts(arg1:Record<string, any>):Promise<number>;
@@ -51,28 +65,52 @@ export function CreateNewDatabase():Promise<void>;
 
 export function DeleteEmitente(arg1:string):Promise<void>;
 
+export function DownloadRemoteBackup(arg1:string,arg2:string):Promise<string>;
+
 export function EnableMEI():Promise<number>;
 
 export function ExecuteBulkOperation(arg1:string,arg2:Array<string>,arg3:Array<string>,arg4:boolean,arg5:Record<string, any>,arg6:any):Promise<Record<string, any>>;
 
+export function ExportDatabaseDiff(arg1:operations.DatabaseDiffReport):Promise<string>;
+
 export function ExportInvoiceToPDF(arg1:string,arg2:string,arg3:string):Promise<void>;
 
+export function ExportOperationChanges(arg1:string):Promise<string>;
+
+export function ExportQuery(arg1:operations.QueryRequest,arg2:string):Promise<number>;
+
+export function ExtractEmitente(arg1:string,arg2:operations.CopyTarget):Promise<operations.ExtractEmitenteResult>;
+
 export function FilterProducts(arg1:Record<string, any>):Promise<Record<string, any>>;
 
 export function FindObjectIdInDatabase(arg1:string):Promise<Array<Record<string, string>>>;
 
+export function FixOrphanReferences(arg1:string,arg2:string,arg3:string,arg4:string):Promise<number>;
+
 export function GenerateInventoryReport(arg1:string,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string,arg7:number,arg8:number):Promise<Record<string, any>>;
 
+export function GenerateSchemaCatalog(arg1:number):Promise<Record<string, any>>;
+
 export function GetAllFilteredProductIDs(arg1:Record<string, any>):Promise<Record<string, any>>;
 
+export function GetAuditLog(arg1:number):Promise<Array<operations.AuditEntry>>;
+
 export function GetBrands():Promise<Array<Record<string, any>>>;
 
+export function GetCleanupProfiles():Promise<Array<operations.CleanupProfile>>;
+
+export function GetCleanupRules():Promise<Array<operations.CleanupRule>>;
+
 export function GetDigisatProcesses():Promise<Array<windows.DigiProcess>>;
 
 export function GetDigisatServices():Promise<Array<windows.DigiService>>;
 
+export function GetDigisatVersion():Promise<operations.DigisatVersion>;
+
 export function GetDistinctNCMs():Promise<Array<Record<string, any>>>;
 
+export function GetDocument(arg1:string,arg2:string):Promise<string>;
+
 export function GetFederalTributations():Promise<Array<Record<string, any>>>;
 
 export function GetIbsCbsTributations():Promise<Array<Record<string, any>>>;
@@ -99,6 +137,12 @@ export function GetManualInvoices(arg1:number):Promise<Array<operations.InvoiceS
 
 export function GetMunicipalTributations():Promise<Array<Record<string, any>>>;
 
+export function GetOperationChanges(arg1:string):Promise<operations.SnapshotDiff>;
+
+export function GetOperationReports():Promise<Array<operations.OperationRecord>>;
+
+export function GetOperationSnapshots():Promise<boolean>;
+
 export function GetProductTypes():Promise<Array<Record<string, any>>>;
 
 export function GetSuggestedInvoiceNumber(arg1:string):Promise<number>;
@@ -117,8 +161,20 @@ export function ListBackups(arg1:string):Promise<Array<operations.BackupResult>>
 
 export function ListEmitentes():Promise<Array<Record<string, any>>>;
 
+export function ListRemoteBackups():Promise<Array<operations.BackupResult>>;
+
 export function Login(arg1:string):Promise<boolean>;
 
+export function MergeDatabase(arg1:operations.MergeOptions):Promise<Record<string, any>>;
+
+export function PreflightBackup(arg1:string):Promise<operations.PreflightResult>;
+
+export function PreflightRestore(arg1:string):Promise<operations.PreflightResult>;
+
+export function PreviewCleanDatabase(arg1:string):Promise<Array<operations.CleanupCollectionPreview>>;
+
+export function PreviewCleanupByDate(arg1:string):Promise<Array<operations.CleanupRulePreview>>;
+
 export function PreviewNCMChange(arg1:string,arg2:string,arg3:number):Promise<Record<string, any>>;
 
 export function PreviewPriceAdjustment(arg1:Record<string, any>,arg2:number,arg3:string,arg4:number):Promise<Record<string, any>>;
@@ -131,26 +187,50 @@ export function RepairMongoDBOffline():Promise<void>;
 
 export function RepairMongoDBOnline():Promise<void>;
 
+export function RepairProductIntegrity(arg1:Array<string>):Promise<number>;
+
+export function RestoreCleanupArchive(arg1:string):Promise<number>;
+
 export function RestoreDatabase(arg1:string,arg2:boolean):Promise<void>;
 
 export function RetryConnection():Promise<void>;
 
+export function RunQuery(arg1:operations.QueryRequest):Promise<operations.QueryResult>;
+
 export function SanitizePrices(arg1:number):Promise<number>;
 
+export function SaveCleanupRules(arg1:Array<operations.CleanupRule>):Promise<string>;
+
+export function ScanOrphanReferences():Promise<Array<operations.OrphanReport>>;
+
+export function SearchObjectId(arg1:string,arg2:operations.ObjectIdSearchOptions):Promise<Array<operations.SearchMatch>>;
+
+export function SearchValue(arg1:string,arg2:operations.ValueSearchOptions):Promise<Array<operations.SearchMatch>>;
+
 export function SelectBackupFile(arg1:string):Promise<string>;
 
 export function SelectDirectory(arg1:string):Promise<string>;
 
 export function SelectInfoDatFile():Promise<string>;
 
+export function SelectSchemaCatalogFile(arg1:string):Promise<string>;
+
+export function SetOperationSnapshots(arg1:boolean):Promise<void>;
+
 export function StartDigisatServices():Promise<number>;
 
 export function StopDigisatServices():Promise<number>;
 
 export function UndoOperation(arg1:string):Promise<void>;
 
+export function UpdateDocumentField(arg1:string,arg2:string,arg3:string,arg4:string,arg5:boolean):Promise<void>;
+
 export function UpdateEmitenteFromFile(arg1:string):Promise<void>;
 
+export function UploadBackup(arg1:string):Promise<operations.BackupResult>;
+
+export function ValidateProductIntegrity():Promise<operations.ProductIntegrityReport>;
+
 export function ZeroAllPrices():Promise<number>;
 
 export function ZeroAllStock():Promise<number>;
@@ -14,6 +14,10 @@ export function AllowSecurityExclusions() {
   return window['go']['main']['App']['AllowSecurityExclusions']();
 }
 
+export function AnonymizeDatabase(arg1) {
+  return window['go']['main']['App']['AnonymizeDatabase'](arg1);
+}
+
 export function ApplyMarkup(arg1, arg2) {
   return window['go']['main']['App']['ApplyMarkup'](arg1, arg2);
 }
@@ -22,6 +26,14 @@ export function BackupDatabase(arg1) {
   return window['go']['main']['App']['BackupDatabase'](arg1);
 }
 
+export function BackupDatabaseIncremental(arg1, arg2) {
+  return window['go']['main']['App']['BackupDatabaseIncremental'](arg1, arg2);
+}
+
+export function BackupDatabaseToRemote(arg1) {
+  return window['go']['main']['App']['BackupDatabaseToRemote'](arg1);
+}
+
 export function BulkActivateByFilter(arg1, arg2) {
   return window['go']['main']['App']['BulkActivateByFilter'](arg1, arg2);
 }
@@ -62,6 +74,10 @@ export function CheckConnection() {
   return window['go']['main']['App']['CheckConnection']();
 }
 
+export function CheckOperationCompatibility(arg1) {
+  return window['go']['main']['App']['CheckOperationCompatibility'](arg1);
+}
+
 export function CleanDatabase() {
   return window['go']['main']['App']['CleanDatabase']();
 }
@@ -70,6 +86,10 @@ export function CleanDatabaseByDate(arg1) {
   return window['go']['main']['App']['CleanDatabaseByDate'](arg1);
 }
 
+export function CleanDatabaseWithProfile(arg1) {
+  return window['go']['main']['App']['CleanDatabaseWithProfile'](arg1);
+}
+
 export function CleanDigisatRegistry() {
   return window['go']['main']['App']['CleanDigisatRegistry']();
 }
@@ -82,6 +102,14 @@ export function ClearLogs() {
   return window['go']['main']['App']['ClearLogs']();
 }
 
+export function CompareDatabases(arg1) {
+  return window['go']['main']['App']['CompareDatabases'](arg1);
+}
+
+export function CompareSchemaCatalogs(arg1, arg2) {
+  return window['go']['main']['App']['CompareSchemaCatalogs'](arg1, arg2);
+}
+
 export function ConfirmInvoiceNumber(arg1, arg2) {
   return window['go']['main']['App']['ConfirmInvoiceNumber'](arg1, arg2);
 }
@@ -98,6 +126,10 @@ export function DeleteEmitente(arg1) {
   return window['go']['main']['App']['DeleteEmitente'](arg1);
 }
 
+export function DownloadRemoteBackup(arg1, arg2) {
+  return window['go']['main']['App']['DownloadRemoteBackup'](arg1, arg2);
+}
+
 export function EnableMEI() {
   return window['go']['main']['App']['EnableMEI']();
 }
@@ -106,10 +138,26 @@ export function ExecuteBulkOperation(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['main']['App']['ExecuteBulkOperation'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
+export function ExportDatabaseDiff(arg1) {
+  return window['go']['main']['App']['ExportDatabaseDiff'](arg1);
+}
+
 export function ExportInvoiceToPDF(arg1, arg2, arg3) {
   return window['go']['main']['App']['ExportInvoiceToPDF'](arg1, arg2, arg3);
 }
 
+export function ExportOperationChanges(arg1) {
+  return window['go']['main']['App']['ExportOperationChanges'](arg1);
+}
+
+export function ExportQuery(arg1, arg2) {
+  return window['go']['main']['App']['ExportQuery'](arg1, arg2);
+}
+
+export function ExtractEmitente(arg1, arg2) {
+  return window['go']['main']['App']['ExtractEmitente'](arg1, arg2);
+}
+
 export function FilterProducts(arg1) {
   return window['go']['main']['App']['FilterProducts'](arg1);
 }
@@ -118,18 +166,38 @@ export function FindObjectIdInDatabase(arg1) {
   return window['go']['main']['App']['FindObjectIdInDatabase'](arg1);
 }
 
+export function FixOrphanReferences(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['FixOrphanReferences'](arg1, arg2, arg3, arg4);
+}
+
 export function GenerateInventoryReport(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
   return window['go']['main']['App']['GenerateInventoryReport'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
 }
 
+export function GenerateSchemaCatalog(arg1) {
+  return window['go']['main']['App']['GenerateSchemaCatalog'](arg1);
+}
+
 export function GetAllFilteredProductIDs(arg1) {
   return window['go']['main']['App']['GetAllFilteredProductIDs'](arg1);
 }
 
+export function GetAuditLog(arg1) {
+  return window['go']['main']['App']['GetAuditLog'](arg1);
+}
+
 export function GetBrands() {
   return window['go']['main']['App']['GetBrands']();
 }
 
+export function GetCleanupProfiles() {
+  return window['go']['main']['App']['GetCleanupProfiles']();
+}
+
+export function GetCleanupRules() {
+  return window['go']['main']['App']['GetCleanupRules']();
+}
+
 export function GetDigisatProcesses() {
   return window['go']['main']['App']['GetDigisatProcesses']();
 }
@@ -138,10 +206,18 @@ export function GetDigisatServices() {
   return window['go']['main']['App']['GetDigisatServices']();
 }
 
+export function GetDigisatVersion() {
+  return window['go']['main']['App']['GetDigisatVersion']();
+}
+
 export function GetDistinctNCMs() {
   return window['go']['main']['App']['GetDistinctNCMs']();
 }
 
+export function GetDocument(arg1, arg2) {
+  return window['go']['main']['App']['GetDocument'](arg1, arg2);
+}
+
 export function GetFederalTributations() {
   return window['go']['main']['App']['GetFederalTributations']();
 }
@@ -194,6 +270,18 @@ export function GetMunicipalTributations() {
   return window['go']['main']['App']['GetMunicipalTributations']();
 }
 
+export function GetOperationChanges(arg1) {
+  return window['go']['main']['App']['GetOperationChanges'](arg1);
+}
+
+export function GetOperationReports() {
+  return window['go']['main']['App']['GetOperationReports']();
+}
+
+export function GetOperationSnapshots() {
+  return window['go']['main']['App']['GetOperationSnapshots']();
+}
+
 export function GetProductTypes() {
   return window['go']['main']['App']['GetProductTypes']();
 }
@@ -230,10 +318,34 @@ export function ListEmitentes() {
   return window['go']['main']['App']['ListEmitentes']();
 }
 
+export function ListRemoteBackups() {
+  return window['go']['main']['App']['ListRemoteBackups']();
+}
+
 export function Login(arg1) {
   return window['go']['main']['App']['Login'](arg1);
 }
 
+export function MergeDatabase(arg1) {
+  return window['go']['main']['App']['MergeDatabase'](arg1);
+}
+
+export function PreflightBackup(arg1) {
+  return window['go']['main']['App']['PreflightBackup'](arg1);
+}
+
+export function PreflightRestore(arg1) {
+  return window['go']['main']['App']['PreflightRestore'](arg1);
+}
+
+export function PreviewCleanDatabase(arg1) {
+  return window['go']['main']['App']['PreviewCleanDatabase'](arg1);
+}
+
+export function PreviewCleanupByDate(arg1) {
+  return window['go']['main']['App']['PreviewCleanupByDate'](arg1);
+}
+
 export function PreviewNCMChange(arg1, arg2, arg3) {
   return window['go']['main']['App']['PreviewNCMChange'](arg1, arg2, arg3);
 }
@@ -258,6 +370,14 @@ export function RepairMongoDBOnline() {
   return window['go']['main']['App']['RepairMongoDBOnline']();
 }
 
+export function RepairProductIntegrity(arg1) {
+  return window['go']['main']['App']['RepairProductIntegrity'](arg1);
+}
+
+export function RestoreCleanupArchive(arg1) {
+  return window['go']['main']['App']['RestoreCleanupArchive'](arg1);
+}
+
 export function RestoreDatabase(arg1, arg2) {
   return window['go']['main']['App']['RestoreDatabase'](arg1, arg2);
 }
@@ -266,10 +386,30 @@ export function RetryConnection() {
   return window['go']['main']['App']['RetryConnection']();
 }
 
+export function RunQuery(arg1) {
+  return window['go']['main']['App']['RunQuery'](arg1);
+}
+
 export function SanitizePrices(arg1) {
   return window['go']['main']['App']['SanitizePrices'](arg1);
 }
 
+export function SaveCleanupRules(arg1) {
+  return window['go']['main']['App']['SaveCleanupRules'](arg1);
+}
+
+export function ScanOrphanReferences() {
+  return window['go']['main']['App']['ScanOrphanReferences']();
+}
+
+export function SearchObjectId(arg1, arg2) {
+  return window['go']['main']['App']['SearchObjectId'](arg1, arg2);
+}
+
+export function SearchValue(arg1, arg2) {
+  return window['go']['main']['App']['SearchValue'](arg1, arg2);
+}
+
 export function SelectBackupFile(arg1) {
   return window['go']['main']['App']['SelectBackupFile'](arg1);
 }
@@ -282,6 +422,14 @@ export function SelectInfoDatFile() {
   return window['go']['main']['App']['SelectInfoDatFile']();
 }
 
+export function SelectSchemaCatalogFile(arg1) {
+  return window['go']['main']['App']['SelectSchemaCatalogFile'](arg1);
+}
+
+export function SetOperationSnapshots(arg1) {
+  return window['go']['main']['App']['SetOperationSnapshots'](arg1);
+}
+
 export function StartDigisatServices() {
   return window['go']['main']['App']['StartDigisatServices']();
 }
@@ -294,10 +442,22 @@ export function UndoOperation(arg1) {
   return window['go']['main']['App']['UndoOperation'](arg1);
 }
 
+export function UpdateDocumentField(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['UpdateDocumentField'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function UpdateEmitenteFromFile(arg1) {
   return window['go']['main']['App']['UpdateEmitenteFromFile'](arg1);
 }
 
+export function UploadBackup(arg1) {
+  return window['go']['main']['App']['UploadBackup'](arg1);
+}
+
+export function ValidateProductIntegrity() {
+  return window['go']['main']['App']['ValidateProductIntegrity']();
+}
+
 export function ZeroAllPrices() {
   return window['go']['main']['App']['ZeroAllPrices']();
 }
@@ -4,6 +4,8 @@ export namespace operations {
 	    path: string;
 	    size: number;
 	    timestamp: string;
+	    remote: boolean;
+	    type?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new BackupResult(source);
@@ -14,6 +16,8 @@ export namespace operations {
 	        this.path = source["path"];
 	        this.size = source["size"];
 	        this.timestamp = source["timestamp"];
+	        this.remote = source["remote"];
+	        this.type = source["type"];
 	    }
 	}
 	export class InvoiceItem {
@@ -226,6 +230,926 @@ export namespace operations {
 		}
 	}
 
+	export class PreflightCheck {
+	    name: string;
+	    status: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PreflightCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	    }
+	}
+	export class PreflightResult {
+	    operation: string;
+	    requiredBytes: number;
+	    status: string;
+	    checks: PreflightCheck[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PreflightResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.operation = source["operation"];
+	        this.requiredBytes = source["requiredBytes"];
+	        this.status = source["status"];
+	        this.checks = this.convertValues(source["checks"], PreflightCheck);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CleanupRule {
+	    collection: string;
+	    dateFields: string[];
+	    type?: string;
+	    empresaReferencia?: string;
+	    disabled?: boolean;
+	    openFilter?: Record<string, any>;
+	    onOpen?: string;
+	    dependents?: CleanupDependency[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.dateFields = source["dateFields"];
+	        this.type = source["type"];
+	        this.empresaReferencia = source["empresaReferencia"];
+	        this.disabled = source["disabled"];
+	        this.openFilter = source["openFilter"];
+	        this.onOpen = source["onOpen"];
+	        this.dependents = this.convertValues(source["dependents"], CleanupDependency);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CleanupRulePreview {
+	    collection: string;
+	    dateField: string;
+	    type?: string;
+	    empresaReferencia?: string;
+	    count: number;
+	    open?: number;
+	    note?: string;
+	    dependents?: CleanupDependencyPreview[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupRulePreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.dateField = source["dateField"];
+	        this.type = source["type"];
+	        this.empresaReferencia = source["empresaReferencia"];
+	        this.count = source["count"];
+	        this.open = source["open"];
+	        this.note = source["note"];
+	        this.dependents = this.convertValues(source["dependents"], CleanupDependencyPreview);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CleanupDependency {
+	    collection: string;
+	    referenceFields: string[];
+	    openFilter?: Record<string, any>;
+	    onOpen?: string;
+	    dependents?: CleanupDependency[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupDependency(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.referenceFields = source["referenceFields"];
+	        this.openFilter = source["openFilter"];
+	        this.onOpen = source["onOpen"];
+	        this.dependents = this.convertValues(source["dependents"], CleanupDependency);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CleanupDependencyPreview {
+	    collection: string;
+	    parent?: string;
+	    count: number;
+	    open: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupDependencyPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.parent = source["parent"];
+	        this.count = source["count"];
+	        this.open = source["open"];
+	    }
+	}
+	export class CleanupProfile {
+	    name: string;
+	    description: string;
+	    keep: string[];
+	    partial?: Record<string, Record<string, any>>;
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.keep = source["keep"];
+	        this.partial = source["partial"];
+	    }
+	}
+	export class CleanupCollectionPreview {
+	    collection: string;
+	    action: string;
+	    total: number;
+	    kept: number;
+	    removed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CleanupCollectionPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.action = source["action"];
+	        this.total = source["total"];
+	        this.kept = source["kept"];
+	        this.removed = source["removed"];
+	    }
+	}
+	export class OrphanReport {
+	    collection: string;
+	    field: string;
+	    target: string;
+	    count: number;
+	    samples: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OrphanReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.field = source["field"];
+	        this.target = source["target"];
+	        this.count = source["count"];
+	        this.samples = source["samples"];
+	    }
+	}
+	export class IntegrityIssue {
+	    category: string;
+	    description: string;
+	    count: number;
+	    samples: string[];
+	    repairable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new IntegrityIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category = source["category"];
+	        this.description = source["description"];
+	        this.count = source["count"];
+	        this.samples = source["samples"];
+	        this.repairable = source["repairable"];
+	    }
+	}
+	export class ProductIntegrityReport {
+	    products: number;
+	    empresas: number;
+	    estoques: number;
+	    unreadable: number;
+	    issues: IntegrityIssue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductIntegrityReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.products = source["products"];
+	        this.empresas = source["empresas"];
+	        this.estoques = source["estoques"];
+	        this.unreadable = source["unreadable"];
+	        this.issues = this.convertValues(source["issues"], IntegrityIssue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ObjectIdSearchOptions {
+	    exhaustive: boolean;
+	    workers: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ObjectIdSearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.exhaustive = source["exhaustive"];
+	        this.workers = source["workers"];
+	    }
+	}
+	export class SearchMatch {
+	    collection: string;
+	    id: string;
+	    field: string;
+	    value?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.id = source["id"];
+	        this.field = source["field"];
+	        this.value = source["value"];
+	    }
+	}
+	export class ValueSearchOptions {
+	    mode: string;
+	    include: string[];
+	    exclude: string[];
+	    limit: number;
+	    workers: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ValueSearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	        this.limit = source["limit"];
+	        this.workers = source["workers"];
+	    }
+	}
+	export class AuditEntry {
+	    // Go type: time
+	    timestamp: any;
+	    user: string;
+	    operation: string;
+	    collection: string;
+	    documentId: string;
+	    field?: string;
+	    before?: string;
+	    after?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	        this.user = source["user"];
+	        this.operation = source["operation"];
+	        this.collection = source["collection"];
+	        this.documentId = source["documentId"];
+	        this.field = source["field"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueryRequest {
+	    collection: string;
+	    kind: string;
+	    filter: string;
+	    projection: string;
+	    sort: string;
+	    pipeline: string;
+	    limit: number;
+	    timeoutSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueryRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.kind = source["kind"];
+	        this.filter = source["filter"];
+	        this.projection = source["projection"];
+	        this.sort = source["sort"];
+	        this.pipeline = source["pipeline"];
+	        this.limit = source["limit"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	    }
+	}
+	export class QueryResult {
+	    documents: string[];
+	    count: number;
+	    truncated: boolean;
+	    elapsedMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueryResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.documents = source["documents"];
+	        this.count = source["count"];
+	        this.truncated = source["truncated"];
+	        this.elapsedMs = source["elapsedMs"];
+	    }
+	}
+	export class SchemaChange {
+	    kind: string;
+	    collection: string;
+	    path?: string;
+	    before?: string;
+	    after?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.collection = source["collection"];
+	        this.path = source["path"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	    }
+	}
+	export class CompatibilityStatus {
+	    operation: string;
+	    version: string;
+	    status: string;
+	    message: string;
+	    missingPaths?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CompatibilityStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.operation = source["operation"];
+	        this.version = source["version"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	        this.missingPaths = source["missingPaths"];
+	    }
+	}
+	export class DigisatVersion {
+	    raw: string;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DigisatVersion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.raw = source["raw"];
+	        this.source = source["source"];
+	    }
+	}
+	export class CopyTarget {
+	    database: string;
+	    outputDir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CopyTarget(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.database = source["database"];
+	        this.outputDir = source["outputDir"];
+	    }
+	}
+	export class AnonymizeOptions {
+	    target: CopyTarget;
+	    inPlace: boolean;
+	    secret: string;
+	    keepEmitentes: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnonymizeOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.target = this.convertValues(source["target"], CopyTarget);
+	        this.inPlace = source["inPlace"];
+	        this.secret = source["secret"];
+	        this.keepEmitentes = source["keepEmitentes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AnonymizeResult {
+	    location: string;
+	    collections: number;
+	    documents: number;
+	    changed: number;
+	    people: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnonymizeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.location = source["location"];
+	        this.collections = source["collections"];
+	        this.documents = source["documents"];
+	        this.changed = source["changed"];
+	        this.people = source["people"];
+	    }
+	}
+	export class ExtractEmitenteResult {
+	    location: string;
+	    emitente: string;
+	    collections: number;
+	    documents: number;
+	    products: number;
+	    stocks: number;
+	    users: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExtractEmitenteResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.location = source["location"];
+	        this.emitente = source["emitente"];
+	        this.collections = source["collections"];
+	        this.documents = source["documents"];
+	        this.products = source["products"];
+	        this.stocks = source["stocks"];
+	        this.users = source["users"];
+	    }
+	}
+	export class MergeOptions {
+	    sourceDatabase: string;
+	    dryRun: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sourceDatabase = source["sourceDatabase"];
+	        this.dryRun = source["dryRun"];
+	    }
+	}
+	export class DatabaseDiffOptions {
+	    database: string;
+	    backupPath: string;
+	    collections: string[];
+	    countsOnly: boolean;
+	    maxDocuments: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DatabaseDiffOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.database = source["database"];
+	        this.backupPath = source["backupPath"];
+	        this.collections = source["collections"];
+	        this.countsOnly = source["countsOnly"];
+	        this.maxDocuments = source["maxDocuments"];
+	    }
+	}
+	export class DiffCollection {
+	    name: string;
+	    current: number;
+	    other: number;
+	    onlyCurrent: number;
+	    onlyOther: number;
+	    changed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffCollection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.current = source["current"];
+	        this.other = source["other"];
+	        this.onlyCurrent = source["onlyCurrent"];
+	        this.onlyOther = source["onlyOther"];
+	        this.changed = source["changed"];
+	    }
+	}
+	export class DiffField {
+	    path: string;
+	    current: string;
+	    other: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffField(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.current = source["current"];
+	        this.other = source["other"];
+	    }
+	}
+	export class DiffDocument {
+	    collection: string;
+	    id: string;
+	    kind: string;
+	    fields?: DiffField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffDocument(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.fields = this.convertValues(source["fields"], DiffField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DatabaseDiffReport {
+	    current: string;
+	    other: string;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    finishedAt: any;
+	    countsOnly: boolean;
+	    collections: DiffCollection[];
+	    documents: DiffDocument[];
+	    truncated: boolean;
+	    onlyCurrent: number;
+	    onlyOther: number;
+	    changed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DatabaseDiffReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.current = source["current"];
+	        this.other = source["other"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	        this.countsOnly = source["countsOnly"];
+	        this.collections = this.convertValues(source["collections"], DiffCollection);
+	        this.documents = this.convertValues(source["documents"], DiffDocument);
+	        this.truncated = source["truncated"];
+	        this.onlyCurrent = source["onlyCurrent"];
+	        this.onlyOther = source["onlyOther"];
+	        this.changed = source["changed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SnapshotCollectionDiff {
+	    name: string;
+	    fields: string[];
+	    before: number;
+	    after: number;
+	    changed: number;
+	    created: number;
+	    removed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SnapshotCollectionDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.fields = source["fields"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	        this.changed = source["changed"];
+	        this.created = source["created"];
+	        this.removed = source["removed"];
+	    }
+	}
+	export class SnapshotField {
+	    path: string;
+	    before: string;
+	    after: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SnapshotField(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	    }
+	}
+	export class SnapshotChange {
+	    collection: string;
+	    id: string;
+	    kind: string;
+	    fields: SnapshotField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SnapshotChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = source["collection"];
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.fields = this.convertValues(source["fields"], SnapshotField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SnapshotDiff {
+	    // Go type: time
+	    takenAt: any;
+	    // Go type: time
+	    comparedAt: any;
+	    collections: SnapshotCollectionDiff[];
+	    changes: SnapshotChange[];
+	    truncated: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SnapshotDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.takenAt = this.convertValues(source["takenAt"], null);
+	        this.comparedAt = this.convertValues(source["comparedAt"], null);
+	        this.collections = this.convertValues(source["collections"], SnapshotCollectionDiff);
+	        this.changes = this.convertValues(source["changes"], SnapshotChange);
+	        this.truncated = source["truncated"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OperationRecord {
+	    id: string;
+	    type: string;
+	    // Go type: time
+	    timestamp: any;
+	    label: string;
+	    details: Record<string, any>;
+	    undoable: boolean;
+	    changes?: SnapshotDiff;
+	
+	    static createFrom(source: any = {}) {
+	        return new OperationRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.type = source["type"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	        this.label = source["label"];
+	        this.details = source["details"];
+	        this.undoable = source["undoable"];
+	        this.changes = this.convertValues(source["changes"], SnapshotDiff);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 }
 
 export namespace windows {
@@ -0,0 +1,131 @@
+// Package brdoc calcula dígitos verificadores de documentos brasileiros
+// (CPF, CNPJ) e de códigos de barras EAN-13.
+package brdoc
+
+import "strings"
+
+// OnlyDigits remove pontuação e qualquer outro caractere não numérico.
+func OnlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// mod11 devolve o dígito verificador módulo 11 usado por CPF e CNPJ.
+func mod11(digits string, weights []int) byte {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	r := sum % 11
+	if r < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - r)
+}
+
+var (
+	cpfWeights1  = []int{10, 9, 8, 7, 6, 5, 4, 3, 2}
+	cpfWeights2  = []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}
+	cnpjWeights1 = []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	cnpjWeights2 = []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+)
+
+// CompleteCPF recebe os 9 primeiros dígitos e devolve o CPF com os dígitos
+// verificadores (11 dígitos, sem pontuação).
+func CompleteCPF(base string) string {
+	base = OnlyDigits(base)
+	if len(base) != 9 {
+		return ""
+	}
+	d1 := mod11(base, cpfWeights1)
+	withD1 := base + string(d1)
+	return withD1 + string(mod11(withD1, cpfWeights2))
+}
+
+// CompleteCNPJ recebe os 12 primeiros dígitos (raiz + filial) e devolve o
+// CNPJ com os dígitos verificadores (14 dígitos, sem pontuação).
+func CompleteCNPJ(base string) string {
+	base = OnlyDigits(base)
+	if len(base) != 12 {
+		return ""
+	}
+	d1 := mod11(base, cnpjWeights1)
+	withD1 := base + string(d1)
+	return withD1 + string(mod11(withD1, cnpjWeights2))
+}
+
+// allSame rejeita sequências como 111.111.111-11, que passam no cálculo mas
+// não são documentos válidos.
+func allSame(s string) bool {
+	return strings.Count(s, s[:1]) == len(s)
+}
+
+func IsValidCPF(s string) bool {
+	d := OnlyDigits(s)
+	return len(d) == 11 && !allSame(d) && CompleteCPF(d[:9]) == d
+}
+
+func IsValidCNPJ(s string) bool {
+	d := OnlyDigits(s)
+	return len(d) == 14 && !allSame(d) && CompleteCNPJ(d[:12]) == d
+}
+
+func FormatCPF(s string) string {
+	d := OnlyDigits(s)
+	if len(d) != 11 {
+		return s
+	}
+	return d[:3] + "." + d[3:6] + "." + d[6:9] + "-" + d[9:]
+}
+
+func FormatCNPJ(s string) string {
+	d := OnlyDigits(s)
+	if len(d) != 14 {
+		return s
+	}
+	return d[:2] + "." + d[2:5] + "." + d[5:8] + "/" + d[8:12] + "-" + d[12:]
+}
+
+// CompleteEAN13 recebe os 12 primeiros dígitos e devolve o código EAN-13.
+func CompleteEAN13(base string) string {
+	base = OnlyDigits(base)
+	if len(base) != 12 {
+		return ""
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		n := int(base[i] - '0')
+		if i%2 == 1 {
+			n *= 3
+		}
+		sum += n
+	}
+	return base + string(byte('0'+(10-sum%10)%10))
+}
+
+// CompleteChaveAcesso recebe os 43 primeiros dígitos de uma chave de acesso
+// (NF-e, NFC-e, CT-e) e devolve a chave com o dígito verificador.
+func CompleteChaveAcesso(base string) string {
+	base = OnlyDigits(base)
+	if len(base) != 43 {
+		return ""
+	}
+	sum, weight := 0, 2
+	for i := len(base) - 1; i >= 0; i-- {
+		sum += int(base[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+	dv := 11 - sum%11
+	if dv >= 10 {
+		dv = 0
+	}
+	return base + string(byte('0'+dv))
+}
@@ -0,0 +1,83 @@
+package brdoc
+
+import "testing"
+
+func TestCPF(t *testing.T) {
+	tests := []struct {
+		cpf  string
+		want bool
+	}{
+		{"529.982.247-25", true},
+		{"52998224725", true},
+		{"111.444.777-35", true},
+		{"529.982.247-24", false},
+		{"529.982.247-52", false},
+		{"111.111.111-11", false},
+		{"000.000.000-00", false},
+		{"5299822472", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidCPF(tt.cpf); got != tt.want {
+			t.Errorf("IsValidCPF(%q) = %v, esperado %v", tt.cpf, got, tt.want)
+		}
+	}
+	if got := CompleteCPF("529.982.247"); got != "52998224725" {
+		t.Errorf("CompleteCPF = %q", got)
+	}
+	if got := FormatCPF("52998224725"); got != "529.982.247-25" {
+		t.Errorf("FormatCPF = %q", got)
+	}
+}
+
+func TestCNPJ(t *testing.T) {
+	tests := []struct {
+		cnpj string
+		want bool
+	}{
+		{"11.222.333/0001-81", true},
+		{"11222333000181", true},
+		{"11.444.777/0001-61", true},
+		{"11.222.333/0001-80", false},
+		{"11.222.333/0001-18", false},
+		{"11.111.111/1111-11", false},
+		{"00.000.000/0000-00", false},
+		{"1122233300018", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidCNPJ(tt.cnpj); got != tt.want {
+			t.Errorf("IsValidCNPJ(%q) = %v, esperado %v", tt.cnpj, got, tt.want)
+		}
+	}
+	if got := CompleteCNPJ("11.222.333/0001"); got != "11222333000181" {
+		t.Errorf("CompleteCNPJ = %q", got)
+	}
+	if got := FormatCNPJ("11222333000181"); got != "11.222.333/0001-81" {
+		t.Errorf("FormatCNPJ = %q", got)
+	}
+}
+
+func TestCompleteEAN13(t *testing.T) {
+	tests := map[string]string{
+		"400638133393": "4006381333931",
+		"590123412345": "5901234123457",
+		"789100031550": "7891000315507",
+		"78910003155":  "",
+	}
+	for base, want := range tests {
+		if got := CompleteEAN13(base); got != want {
+			t.Errorf("CompleteEAN13(%q) = %q, esperado %q", base, got, want)
+		}
+	}
+}
+
+func TestCompleteChaveAcesso(t *testing.T) {
+	// Exemplo do Manual de Orientação do Contribuinte da NF-e.
+	const chave = "52060433009911002506550120000007800267301615"
+	if got := CompleteChaveAcesso(chave[:43]); got != chave {
+		t.Errorf("CompleteChaveAcesso = %q, esperado %q", got, chave)
+	}
+	if got := CompleteChaveAcesso(chave); got != "" {
+		t.Errorf("chave com 44 dígitos completada: %q", got)
+	}
+}
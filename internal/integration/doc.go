@@ -0,0 +1,11 @@
+// Package integration reúne os testes de integração das operações contra um
+// mongod local, com a base de demonstração gerada por internal/seed.
+//
+// Os testes só compilam com a build tag "integration":
+//
+//	go test -tags integration ./internal/integration/
+//
+// O mongod é procurado em MONGOD_PATH e, na falta dele, no PATH. Sem mongod
+// os cenários que dependem do banco são ignorados e apenas os que passam pelos
+// repositórios rodam contra repository.NewMemory.
+package integration
@@ -0,0 +1,384 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/operations"
+	"BMongo-VIP/internal/repository"
+	"BMongo-VIP/internal/seed"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// mongoClient fica nil quando o mongod não pôde ser iniciado; mongoSkip
+	// guarda o motivo para os testes ignorados.
+	mongoClient *mongo.Client
+	mongoSkip   string
+	dbCounter   int
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	stop, err := startMongod()
+	if err != nil {
+		mongoSkip = err.Error()
+		fmt.Fprintf(os.Stderr, "⚠️ %s; cenários com MongoDB serão ignorados\n", mongoSkip)
+	} else {
+		defer stop()
+	}
+	return m.Run()
+}
+
+func mongodPath() (string, error) {
+	if p := os.Getenv("MONGOD_PATH"); p != "" {
+		if _, err := os.Stat(p); err != nil {
+			return "", fmt.Errorf("MONGOD_PATH inválido: %w", err)
+		}
+		return p, nil
+	}
+	p, err := exec.LookPath("mongod")
+	if err != nil {
+		return "", fmt.Errorf("mongod não encontrado no PATH (defina MONGOD_PATH)")
+	}
+	return p, nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// startMongod sobe um mongod descartável em um diretório temporário, apenas
+// em 127.0.0.1, e devolve a função que o encerra e apaga os dados.
+func startMongod() (func(), error) {
+	path, err := mongodPath()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "bmongo-integration-")
+	if err != nil {
+		return nil, err
+	}
+	dbPath := filepath.Join(dir, "db")
+	logPath := filepath.Join(dir, "mongod.log")
+	if err := os.Mkdir(dbPath, 0o755); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("erro ao reservar porta: %w", err)
+	}
+
+	args := []string{
+		"--dbpath", dbPath,
+		"--port", strconv.Itoa(port),
+		"--bind_ip", "127.0.0.1",
+		"--logpath", logPath,
+	}
+	if runtime.GOOS != "windows" {
+		args = append(args, "--nounixsocket")
+	}
+
+	cmd := exec.Command(path, args...)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("erro ao iniciar mongod: %w", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	stop := func() {
+		if mongoClient != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			mongoClient.Disconnect(ctx)
+			cancel()
+			mongoClient = nil
+		}
+		cmd.Process.Kill()
+		<-done
+		os.RemoveAll(dir)
+	}
+
+	client, err := waitForMongod(fmt.Sprintf("mongodb://127.0.0.1:%d", port), done)
+	if err != nil {
+		tail := logTail(logPath, 2048)
+		stop()
+		return nil, fmt.Errorf("%w\n%s", err, tail)
+	}
+	mongoClient = client
+	return stop, nil
+}
+
+func waitForMongod(uri string, done <-chan struct{}) (*mongo.Client, error) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri).SetServerSelectionTimeout(time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar no mongod: %w", err)
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		select {
+		case <-done:
+			client.Disconnect(context.Background())
+			return nil, fmt.Errorf("mongod encerrou durante a inicialização")
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := client.Ping(ctx, nil)
+		cancel()
+		if err == nil {
+			return client, nil
+		}
+		if time.Now().After(deadline) {
+			client.Disconnect(context.Background())
+			return nil, fmt.Errorf("mongod não respondeu em 30s: %w", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func logTail(path string, n int) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if len(data) > n {
+		data = data[len(data)-n:]
+	}
+	return string(data)
+}
+
+// backend é uma base carregada com o dataset de teste e as operações
+// apontadas para ela.
+type backend struct {
+	name     string
+	cfg      seed.Config
+	mgr      *operations.Manager
+	rollback *operations.RollbackManager
+	// docs devolve uma cópia de todos os documentos da coleção.
+	docs func(collection string) []bson.M
+}
+
+func testConfig() seed.Config {
+	cfg := seed.DefaultConfig()
+	cfg.Produtos = 120
+	cfg.Clientes = 20
+	cfg.NotasManuais = 8
+	cfg.Movimentacoes = 20
+	return cfg
+}
+
+func newMongoBackend(t *testing.T) *backend {
+	t.Helper()
+	if mongoClient == nil {
+		t.Skip(mongoSkip)
+	}
+
+	dbCounter++
+	db := mongoClient.Database(fmt.Sprintf("bmongo_it_%d", dbCounter))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cfg := testConfig()
+	if err := seed.Generate(cfg).Load(ctx, db, func(string) {}); err != nil {
+		t.Fatalf("erro ao carregar dataset: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+	})
+
+	conn := &database.Connection{Client: mongoClient, Database: db}
+	rollback := operations.NewRollbackManager(conn)
+	b := &backend{
+		name:     "mongo",
+		cfg:      cfg,
+		mgr:      operations.NewManagerWithRollback(conn, rollback),
+		rollback: rollback,
+	}
+	b.docs = func(collection string) []bson.M {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		cursor, err := db.Collection(collection).Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+		if err != nil {
+			t.Fatalf("erro ao ler %s: %v", collection, err)
+		}
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			t.Fatalf("erro ao ler %s: %v", collection, err)
+		}
+		return docs
+	}
+	b.mgr.Reset()
+	return b
+}
+
+func newMemoryBackend(t *testing.T) *backend {
+	t.Helper()
+	mem := repository.NewMemory()
+	cfg := testConfig()
+	if err := seed.Generate(cfg).LoadMemory(mem); err != nil {
+		t.Fatalf("erro ao carregar dataset: %v", err)
+	}
+
+	repos := mem.Repositories()
+	rollback := operations.NewRollbackManagerWithRepositories(repos)
+	b := &backend{
+		name:     "memory",
+		cfg:      cfg,
+		mgr:      operations.NewManagerWithRepositories(repos, rollback),
+		rollback: rollback,
+		docs:     mem.Documents,
+	}
+	b.mgr.Reset()
+	return b
+}
+
+// forEachBackend roda o cenário contra o repositório em memória e contra o
+// mongod, para as operações que já passam pelos repositórios.
+func forEachBackend(t *testing.T, scenario func(t *testing.T, b *backend)) {
+	t.Run("memory", func(t *testing.T) { scenario(t, newMemoryBackend(t)) })
+	t.Run("mongo", func(t *testing.T) { scenario(t, newMongoBackend(t)) })
+}
+
+func logTo(t *testing.T) operations.LogFunc {
+	return func(msg string) { t.Log(msg) }
+}
+
+// undoLast desfaz a operação mais recente, que precisa ser do tipo esperado.
+func undoLast(t *testing.T, b *backend, opType operations.OperationType) {
+	t.Helper()
+	ops := b.rollback.GetUndoableOperations()
+	if len(ops) == 0 {
+		t.Fatalf("nenhuma operação registrada para desfazer")
+	}
+	if ops[0].Type != opType {
+		t.Fatalf("última operação = %s, esperado %s", ops[0].Type, opType)
+	}
+	if err := b.rollback.UndoOperation(ops[0].ID, logTo(t)); err != nil {
+		t.Fatalf("erro ao desfazer %s: %v", opType, err)
+	}
+}
+
+// field lê um caminho com pontos, aceitando índices de array ("Quantidades.0").
+func field(doc bson.M, path string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case bson.M:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case primitive.A:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			cur = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func oid(doc bson.M, path string) primitive.ObjectID {
+	v, _ := field(doc, path)
+	id, _ := v.(primitive.ObjectID)
+	return id
+}
+
+func byID(docs []bson.M) map[primitive.ObjectID]bson.M {
+	out := make(map[primitive.ObjectID]bson.M, len(docs))
+	for _, doc := range docs {
+		out[oid(doc, "_id")] = doc
+	}
+	return out
+}
+
+// snapshot guarda o valor de um campo por _id; documentos sem o campo ficam
+// de fora do mapa.
+func snapshot(docs []bson.M, path string) map[primitive.ObjectID]interface{} {
+	out := make(map[primitive.ObjectID]interface{}, len(docs))
+	for _, doc := range docs {
+		if v, ok := field(doc, path); ok {
+			out[oid(doc, "_id")] = v
+		}
+	}
+	return out
+}
+
+func sameValue(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func assertSnapshot(t *testing.T, label string, want, got map[primitive.ObjectID]interface{}) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Errorf("%s: %d documentos com o campo, esperado %d", label, len(got), len(want))
+	}
+	diffs := 0
+	for id, w := range want {
+		if g, ok := got[id]; !ok || !sameValue(w, g) {
+			if diffs < 5 {
+				t.Errorf("%s: %s = %v, esperado %v", label, id.Hex(), g, w)
+			}
+			diffs++
+		}
+	}
+	if diffs > 5 {
+		t.Errorf("%s: mais %d diferenças", label, diffs-5)
+	}
+}
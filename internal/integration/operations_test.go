@@ -0,0 +1,519 @@
+//go:build integration
+
+package integration
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/operations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func quantity(doc bson.M) (float64, bool) {
+	v, ok := field(doc, "Quantidades.0.Quantidade")
+	if !ok {
+		return 0, false
+	}
+	return number(v)
+}
+
+func TestInactivateZeroProducts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		produtos := b.docs(database.CollectionProdutosServicos)
+		before := snapshot(produtos, "Ativo")
+		stocks := byID(b.docs(database.CollectionEstoques))
+
+		expected := map[primitive.ObjectID]bool{}
+		for _, pse := range b.docs(database.CollectionProdutosServicosEmpresa) {
+			stock, ok := stocks[oid(pse, "EstoqueReferencia")]
+			if !ok {
+				continue
+			}
+			produtoID := oid(pse, "ProdutoServicoReferencia")
+			if q, ok := quantity(stock); (!ok || q <= 0) && before[produtoID] == true {
+				expected[produtoID] = true
+			}
+		}
+		if len(expected) == 0 {
+			t.Fatal("dataset sem produtos ativos com estoque zerado")
+		}
+
+		count, err := b.mgr.InactivateZeroProducts(logTo(t))
+		if err != nil {
+			t.Fatalf("InactivateZeroProducts: %v", err)
+		}
+		if count != len(expected) {
+			t.Errorf("inativados = %d, esperado %d", count, len(expected))
+		}
+
+		want := make(map[primitive.ObjectID]interface{}, len(before))
+		for id, v := range before {
+			want[id] = v
+			if expected[id] {
+				want[id] = false
+			}
+		}
+		assertSnapshot(t, "Ativo após inativar", want, snapshot(b.docs(database.CollectionProdutosServicos), "Ativo"))
+
+		undoLast(t, b, operations.OpInactivateProducts)
+		assertSnapshot(t, "Ativo após desfazer", before, snapshot(b.docs(database.CollectionProdutosServicos), "Ativo"))
+	})
+}
+
+func TestZeroNegativeStock(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		before := snapshot(b.docs(database.CollectionEstoques), "Quantidades.0.Quantidade")
+
+		want := make(map[primitive.ObjectID]interface{}, len(before))
+		negatives := 0
+		for id, v := range before {
+			want[id] = v
+			if q, _ := number(v); q < 0 {
+				want[id] = 0.0
+				negatives++
+			}
+		}
+		if negatives == 0 {
+			t.Fatal("dataset sem estoques negativos")
+		}
+
+		count, err := b.mgr.ZeroNegativeStock(logTo(t))
+		if err != nil {
+			t.Fatalf("ZeroNegativeStock: %v", err)
+		}
+		if count != negatives {
+			t.Errorf("zerados = %d, esperado %d", count, negatives)
+		}
+		assertSnapshot(t, "quantidade após zerar", want, snapshot(b.docs(database.CollectionEstoques), "Quantidades.0.Quantidade"))
+
+		undoLast(t, b, operations.OpZeroNegativeStock)
+		assertSnapshot(t, "quantidade após desfazer", before, snapshot(b.docs(database.CollectionEstoques), "Quantidades.0.Quantidade"))
+	})
+}
+
+func TestZeroAllStock(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		stocks := b.docs(database.CollectionEstoques)
+		before := snapshot(stocks, "Quantidades.0.Quantidade")
+
+		// Estoques sem quantidade (Quantidades vazio) também são gravados: o
+		// $set posicional cria o primeiro elemento.
+		zeroed := make(map[primitive.ObjectID]interface{}, len(stocks))
+		changed := 0
+		for _, doc := range stocks {
+			id := oid(doc, "_id")
+			zeroed[id] = 0.0
+			if q, ok := quantity(doc); !ok || q != 0 {
+				changed++
+			}
+		}
+
+		count, err := b.mgr.ZeroAllStock(logTo(t))
+		if err != nil {
+			t.Fatalf("ZeroAllStock: %v", err)
+		}
+		if count != changed {
+			t.Errorf("zerados = %d, esperado %d", count, changed)
+		}
+		assertSnapshot(t, "quantidade após zerar", zeroed, snapshot(b.docs(database.CollectionEstoques), "Quantidades.0.Quantidade"))
+
+		// O undo restaura a quantidade; os estoques que estavam vazios ficam
+		// com quantidade 0.
+		want := make(map[primitive.ObjectID]interface{}, len(zeroed))
+		for id := range zeroed {
+			want[id] = 0.0
+			if v, ok := before[id]; ok {
+				want[id] = v
+			}
+		}
+		undoLast(t, b, operations.OpZeroStock)
+		assertSnapshot(t, "quantidade após desfazer", want, snapshot(b.docs(database.CollectionEstoques), "Quantidades.0.Quantidade"))
+	})
+}
+
+func TestBulkActivateProducts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		produtos := b.docs(database.CollectionProdutosServicos)
+		before := snapshot(produtos, "Ativo")
+
+		// Inclui produtos já inativos para conferir que o undo não os reativa.
+		var ids []string
+		want := make(map[primitive.ObjectID]interface{}, len(before))
+		active := 0
+		for _, doc := range produtos {
+			id := oid(doc, "_id")
+			want[id] = before[id]
+			if len(ids) < 20 || before[id] == false && len(ids) < 25 {
+				ids = append(ids, id.Hex())
+				want[id] = false
+				if before[id] == true {
+					active++
+				}
+			}
+		}
+
+		count, err := b.mgr.BulkActivateProducts(ids, false, logTo(t))
+		if err != nil {
+			t.Fatalf("BulkActivateProducts: %v", err)
+		}
+		if count != active {
+			t.Errorf("inativados = %d, esperado %d", count, active)
+		}
+		assertSnapshot(t, "Ativo após inativar", want, snapshot(b.docs(database.CollectionProdutosServicos), "Ativo"))
+
+		undoLast(t, b, operations.OpBulkActivate)
+		assertSnapshot(t, "Ativo após desfazer", before, snapshot(b.docs(database.CollectionProdutosServicos), "Ativo"))
+	})
+}
+
+func resultIDs(products []operations.FilteredProduct) []string {
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedHex(ids map[primitive.ObjectID]bool) []string {
+	out := make([]string, 0, len(ids))
+	for id := range ids {
+		out = append(out, id.Hex())
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestFilterProducts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		produtos := b.docs(database.CollectionProdutosServicos)
+		empresas := b.docs(database.CollectionProdutosServicosEmpresa)
+		stocks := byID(b.docs(database.CollectionEstoques))
+
+		t.Run("ncm", func(t *testing.T) {
+			expected := map[primitive.ObjectID]bool{}
+			pseByProduct := map[string]bson.M{}
+			for _, pse := range empresas {
+				if codigo, _ := field(pse, "NcmNbs.Codigo"); strings.HasPrefix(codigo.(string), "2202") {
+					expected[oid(pse, "ProdutoServicoReferencia")] = true
+					pseByProduct[oid(pse, "ProdutoServicoReferencia").Hex()] = pse
+				}
+			}
+
+			result, err := b.mgr.FilterProducts(operations.ProductFilter{NCMs: []string{"2202"}}, logTo(t))
+			if err != nil {
+				t.Fatalf("FilterProducts: %v", err)
+			}
+			if got, want := strings.Join(resultIDs(result.Products), ","), strings.Join(sortedHex(expected), ","); got != want {
+				t.Fatalf("produtos = %s, esperado %s", got, want)
+			}
+
+			// Preço e estoque vêm do documento da empresa ou, na falta
+			// dele, de Precos/Estoques.
+			for _, p := range result.Products {
+				if p.SalePrice <= 0 || p.CostPrice <= 0 {
+					t.Errorf("%s sem preço: custo %v, venda %v", p.ID, p.CostPrice, p.SalePrice)
+				}
+				stock := stocks[oid(pseByProduct[p.ID], "EstoqueReferencia")]
+				if q, _ := quantity(stock); p.Quantity != q {
+					t.Errorf("%s quantidade = %v, esperado %v", p.ID, p.Quantity, q)
+				}
+			}
+		})
+
+		t.Run("barcode", func(t *testing.T) {
+			var target bson.M
+			for _, doc := range produtos {
+				if _, ok := doc["CodigoBarras"]; ok {
+					target = doc
+					break
+				}
+			}
+			barcode := target["CodigoBarras"].(string)
+
+			result, err := b.mgr.FilterProducts(operations.ProductFilter{Barcode: barcode}, logTo(t))
+			if err != nil {
+				t.Fatalf("FilterProducts: %v", err)
+			}
+			if len(result.Products) != 1 || result.Products[0].ID != oid(target, "_id").Hex() {
+				t.Fatalf("produtos = %v, esperado %s", resultIDs(result.Products), oid(target, "_id").Hex())
+			}
+		})
+
+		t.Run("inativos", func(t *testing.T) {
+			expected := map[primitive.ObjectID]bool{}
+			for _, doc := range produtos {
+				if doc["Ativo"] == false {
+					expected[oid(doc, "_id")] = true
+				}
+			}
+			inactive := false
+			result, err := b.mgr.FilterProducts(operations.ProductFilter{ActiveStatus: &inactive}, logTo(t))
+			if err != nil {
+				t.Fatalf("FilterProducts: %v", err)
+			}
+			if got, want := strings.Join(resultIDs(result.Products), ","), strings.Join(sortedHex(expected), ","); got != want {
+				t.Fatalf("produtos = %s, esperado %s", got, want)
+			}
+		})
+	})
+}
+
+func TestManualInvoices(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		notas := map[string]bson.M{}
+		var latest time.Time
+		for _, doc := range b.docs(database.CollectionMovimentacoes) {
+			if tipos, _ := doc["_t"].(primitive.A); len(tipos) > 1 && tipos[1] == "NotaFiscalManual" {
+				notas[oid(doc, "_id").Hex()] = doc
+				if emissao := doc["DataHoraEmissao"].(primitive.DateTime).Time(); emissao.After(latest) {
+					latest = emissao
+				}
+			}
+		}
+
+		invoices, err := b.mgr.GetManualInvoices(5)
+		if err != nil {
+			t.Fatalf("GetManualInvoices: %v", err)
+		}
+		if len(invoices) != 5 {
+			t.Fatalf("notas = %d, esperado 5", len(invoices))
+		}
+		if !invoices[0].DataHoraEmissao.Equal(latest) {
+			t.Errorf("primeira nota emitida em %v, esperado %v", invoices[0].DataHoraEmissao, latest)
+		}
+		for i, inv := range invoices {
+			if _, ok := notas[inv.ID]; !ok {
+				t.Errorf("%s não é uma nota manual", inv.ID)
+			}
+			if i > 0 && inv.DataHoraEmissao.After(invoices[i-1].DataHoraEmissao) {
+				t.Errorf("notas fora de ordem na posição %d", i)
+			}
+		}
+
+		doc := notas[invoices[0].ID]
+		data, err := b.mgr.GetInvoiceData(invoices[0].ID)
+		if err != nil {
+			t.Fatalf("GetInvoiceData: %v", err)
+		}
+		if nome, _ := field(doc, "Pessoa.Nome"); data.Tomador.Nome != nome {
+			t.Errorf("tomador = %q, esperado %q", data.Tomador.Nome, nome)
+		}
+		if cnpj, _ := field(doc, "Empresa.Cnpj"); data.Emitente.CpfCnpj != cnpj {
+			t.Errorf("CNPJ do emitente = %q, esperado %q", data.Emitente.CpfCnpj, cnpj)
+		}
+		if data.Emitente.Cidade == "" || data.Tomador.Endereco == "" {
+			t.Errorf("endereços não preenchidos: emitente %+v, tomador %+v", data.Emitente, data.Tomador)
+		}
+		if itens, _ := doc["Itens"].(primitive.A); len(data.Itens) != len(itens) {
+			t.Errorf("itens = %d, esperado %d", len(data.Itens), len(itens))
+		}
+		// O total impresso é a soma de quantidade × valor unitário dos itens,
+		// menos o desconto e mais as despesas da nota.
+		total := 0.0
+		for _, item := range doc["Itens"].(primitive.A) {
+			qty, _ := number(item.(bson.M)["Quantidade"])
+			unit, _ := number(item.(bson.M)["ValorUnitario"])
+			total += qty * unit
+		}
+		desconto, _ := number(doc["TotalDescontoAplicado"])
+		despesas, _ := number(doc["TotalOutrasDespesas"])
+		total = total - desconto + despesas
+		if math.Abs(data.Total-total) > 0.005 {
+			t.Errorf("total = %v, esperado %v", data.Total, total)
+		}
+	})
+}
+
+func TestDetectDigisatVersion(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		version, err := b.mgr.DetectDigisatVersion()
+		if err != nil {
+			t.Fatalf("DetectDigisatVersion: %v", err)
+		}
+		if version == nil || version.Raw != b.cfg.VersaoDigisat {
+			t.Fatalf("versão = %+v, esperado %s", version, b.cfg.VersaoDigisat)
+		}
+	})
+}
+
+// As operações abaixo ainda usam a conexão diretamente e só rodam no mongod.
+
+func firstID(t *testing.T, b *backend, collection string) primitive.ObjectID {
+	t.Helper()
+	docs := b.docs(collection)
+	if len(docs) == 0 {
+		t.Fatalf("%s vazia", collection)
+	}
+	return oid(docs[0], "_id")
+}
+
+// ncmChange confere uma troca de tributação por NCM: os documentos do NCM
+// passam a apontar para tribID, os demais ficam como estavam, e o undo
+// restaura (ou remove) a referência anterior.
+func ncmChange(t *testing.T, b *backend, path string, ncm string, tribID primitive.ObjectID, opType operations.OperationType, run func() error) {
+	t.Helper()
+	empresas := b.docs(database.CollectionProdutosServicosEmpresa)
+	before := snapshot(empresas, path)
+
+	want := make(map[primitive.ObjectID]interface{}, len(before))
+	for id, v := range before {
+		want[id] = v
+	}
+	matched := 0
+	for _, pse := range empresas {
+		if codigo, _ := field(pse, "NcmNbs.Codigo"); strings.HasPrefix(codigo.(string), ncm) {
+			want[oid(pse, "_id")] = tribID
+			matched++
+		}
+	}
+	if matched == 0 {
+		t.Fatalf("dataset sem produtos com NCM %s", ncm)
+	}
+
+	if err := run(); err != nil {
+		t.Fatalf("%s: %v", opType, err)
+	}
+	assertSnapshot(t, path+" após alterar", want, snapshot(b.docs(database.CollectionProdutosServicosEmpresa), path))
+
+	undoLast(t, b, opType)
+	assertSnapshot(t, path+" após desfazer", before, snapshot(b.docs(database.CollectionProdutosServicosEmpresa), path))
+}
+
+func TestChangeTributationByNCM(t *testing.T) {
+	b := newMongoBackend(t)
+	tribID := firstID(t, b, database.CollectionTributacoesEstadual)
+	ncmChange(t, b, "TributacaoEstadualReferencia", "2202", tribID, operations.OpChangeTributation, func() error {
+		_, err := b.mgr.ChangeTributationByNCM([]string{"2202"}, tribID.Hex(), logTo(t))
+		return err
+	})
+}
+
+func TestChangeFederalTributationByNCM(t *testing.T) {
+	b := newMongoBackend(t)
+	tribID := firstID(t, b, database.CollectionTributacoesFederal)
+	ncmChange(t, b, "TributacaoFederalReferencia", "2203", tribID, operations.OpChangeTribFederal, func() error {
+		return b.mgr.ChangeFederalTributationByNCM([]string{"2203"}, tribID.Hex(), logTo(t))
+	})
+}
+
+func TestChangeIbsCbsTributationByNCM(t *testing.T) {
+	b := newMongoBackend(t)
+	tribID := firstID(t, b, database.CollectionTributacoesIbsCbs)
+	ncmChange(t, b, "TributacaoIbsCbsReferencia", "1905", tribID, operations.OpChangeTribIbsCbs, func() error {
+		return b.mgr.ChangeIbsCbsTributationByNCM([]string{"1905"}, tribID.Hex(), logTo(t))
+	})
+}
+
+func TestEnableMEI(t *testing.T) {
+	b := newMongoBackend(t)
+	const path = "MicroempreendedorIndividual.Habilitado"
+	before := snapshot(b.docs(database.CollectionPessoas), path)
+
+	want := make(map[primitive.ObjectID]interface{}, len(before))
+	for id := range before {
+		want[id] = true
+	}
+
+	count, err := b.mgr.EnableMEI(logTo(t))
+	if err != nil {
+		t.Fatalf("EnableMEI: %v", err)
+	}
+	if count != b.cfg.Emitentes {
+		t.Errorf("emitentes alterados = %d, esperado %d", count, b.cfg.Emitentes)
+	}
+	assertSnapshot(t, "MEI após habilitar", want, snapshot(b.docs(database.CollectionPessoas), path))
+
+	undoLast(t, b, operations.OpEnableMEI)
+	assertSnapshot(t, "MEI após desfazer", before, snapshot(b.docs(database.CollectionPessoas), path))
+}
+
+func TestZeroAllPrices(t *testing.T) {
+	b := newMongoBackend(t)
+
+	// Só os documentos com preços embutidos: nos que guardam o preço apenas
+	// em Precos, o $set posicional cria um subdocumento {"0": {...}}.
+	embedded := func(docs []bson.M, path string) map[primitive.ObjectID]interface{} {
+		out := map[primitive.ObjectID]interface{}{}
+		for _, doc := range docs {
+			if _, ok := doc["PrecosCustos"].(primitive.A); !ok {
+				continue
+			}
+			if v, ok := field(doc, path); ok {
+				out[oid(doc, "_id")] = v
+			}
+		}
+		return out
+	}
+
+	before := b.docs(database.CollectionProdutosServicosEmpresa)
+	for _, path := range []string{"PrecosCustos.0.Valor", "PrecosVendas.0.Valor"} {
+		if len(embedded(before, path)) == 0 {
+			t.Fatalf("dataset sem %s", path)
+		}
+	}
+
+	if _, err := b.mgr.ZeroAllPrices(logTo(t)); err != nil {
+		t.Fatalf("ZeroAllPrices: %v", err)
+	}
+	after := b.docs(database.CollectionProdutosServicosEmpresa)
+	for _, path := range []string{"PrecosCustos.0.Valor", "PrecosVendas.0.Valor"} {
+		want := embedded(before, path)
+		for id := range want {
+			want[id] = 0.0
+		}
+		assertSnapshot(t, path+" após zerar", want, embedded(after, path))
+	}
+
+	undoLast(t, b, operations.OpZeroAllPrices)
+	restored := b.docs(database.CollectionProdutosServicosEmpresa)
+	for _, path := range []string{"PrecosCustos.0.Valor", "PrecosVendas.0.Valor"} {
+		assertSnapshot(t, path+" após desfazer", embedded(before, path), embedded(restored, path))
+	}
+}
+
+// O rollback do ajuste de inventário passa pelo repositório de estoque; os
+// que ainda usam a conexão direta devolvem erro no modo sem conexão.
+func TestUndoWithRepositories(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b *backend) {
+		stocks := b.docs(database.CollectionEstoques)
+		if len(stocks) == 0 {
+			t.Fatal("dataset sem estoques")
+		}
+		id := oid(stocks[0], "_id")
+		if _, err := b.mgr.ZeroAllStock(logTo(t)); err != nil {
+			t.Fatalf("ZeroAllStock: %v", err)
+		}
+
+		b.rollback.RecordOperation(operations.OpAdjustInventory, "Ajuste de inventário",
+			map[string]interface{}{
+				"stocks": []map[string]interface{}{{"estoqueId": id.Hex(), "prevQuantity": 7.0}},
+			}, true)
+		undoLast(t, b, operations.OpAdjustInventory)
+
+		for _, doc := range b.docs(database.CollectionEstoques) {
+			if oid(doc, "_id") != id {
+				continue
+			}
+			if q, _ := quantity(doc); q != 7 {
+				t.Errorf("quantidade após desfazer = %v, esperado 7", q)
+			}
+		}
+
+		if b.name != "memory" {
+			return
+		}
+		opID := b.rollback.RecordOperation(operations.OpChangeBrand, "Alterar marca",
+			map[string]interface{}{"products": []map[string]interface{}{{"id": id.Hex()}}}, true)
+		if err := b.rollback.UndoOperation(opID, logTo(t)); err == nil {
+			t.Error("desfazer ChangeBrand sem conexão deveria falhar")
+		}
+	})
+}
@@ -0,0 +1,244 @@
+// Package models define os documentos principais do Digisat com decodificação
+// tolerante: campos com nomes alternativos entre versões e tipos numéricos
+// variados (Double, Int32, Int64, Decimal128, texto) são aceitos sem erro.
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lookup busca um caminho com notação de ponto; índices de array são aceitos
+// como partes numéricas (ex.: "PrecosVendas.0.Valor").
+func lookup(doc bson.Raw, path string) (bson.RawValue, bool) {
+	v, err := doc.LookupErr(strings.Split(path, ".")...)
+	if err != nil || v.Type == bsontype.Null {
+		return bson.RawValue{}, false
+	}
+	return v, true
+}
+
+// first devolve o primeiro caminho presente no documento.
+func first(doc bson.Raw, paths ...string) (bson.RawValue, bool) {
+	for _, p := range paths {
+		if v, ok := lookup(doc, p); ok {
+			return v, true
+		}
+	}
+	return bson.RawValue{}, false
+}
+
+func String(v bson.RawValue) string {
+	switch v.Type {
+	case bsontype.String:
+		return v.StringValue()
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex()
+	case bsontype.Int32, bsontype.Int64, bsontype.Double, bsontype.Decimal128:
+		return strconv.FormatFloat(Float(v), 'f', -1, 64)
+	}
+	return ""
+}
+
+func Float(v bson.RawValue) float64 {
+	switch v.Type {
+	case bsontype.Double:
+		return v.Double()
+	case bsontype.Int32:
+		return float64(v.Int32())
+	case bsontype.Int64:
+		return float64(v.Int64())
+	case bsontype.Decimal128:
+		f, _ := strconv.ParseFloat(v.Decimal128().String(), 64)
+		return f
+	case bsontype.String:
+		f, _ := strconv.ParseFloat(NormalizeDecimal(v.StringValue()), 64)
+		return f
+	}
+	return 0
+}
+
+// NormalizeDecimal converte números em texto no formato brasileiro
+// (1.234,56) ou americano (1,234.56) para o formato do ParseFloat. Com os dois
+// separadores, o último é o decimal; um separador repetido é de milhar.
+func NormalizeDecimal(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	comma, dot := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+	switch {
+	case comma >= 0 && dot >= 0:
+		if comma > dot {
+			return strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+		}
+		return strings.ReplaceAll(s, ",", "")
+	case comma >= 0:
+		if strings.Count(s, ",") > 1 {
+			return strings.ReplaceAll(s, ",", "")
+		}
+		return strings.Replace(s, ",", ".", 1)
+	case dot >= 0 && strings.Count(s, ".") > 1:
+		return strings.ReplaceAll(s, ".", "")
+	}
+	return s
+}
+
+func Int(v bson.RawValue) int64 {
+	switch v.Type {
+	case bsontype.Int32:
+		return int64(v.Int32())
+	case bsontype.Int64:
+		return v.Int64()
+	case bsontype.Double, bsontype.Decimal128:
+		return int64(Float(v))
+	case bsontype.String:
+		if n, err := strconv.ParseInt(strings.TrimSpace(v.StringValue()), 10, 64); err == nil {
+			return n
+		}
+		return int64(Float(v))
+	}
+	return 0
+}
+
+func Bool(v bson.RawValue) bool {
+	switch v.Type {
+	case bsontype.Boolean:
+		return v.Boolean()
+	case bsontype.Int32, bsontype.Int64, bsontype.Double:
+		return Float(v) != 0
+	case bsontype.String:
+		b, _ := strconv.ParseBool(v.StringValue())
+		return b
+	}
+	return false
+}
+
+// ObjectID aceita ObjectId ou seu hex em texto.
+func ObjectID(v bson.RawValue) primitive.ObjectID {
+	switch v.Type {
+	case bsontype.ObjectID:
+		return v.ObjectID()
+	case bsontype.String:
+		oid, _ := primitive.ObjectIDFromHex(v.StringValue())
+		return oid
+	}
+	return primitive.NilObjectID
+}
+
+func Time(v bson.RawValue) time.Time {
+	switch v.Type {
+	case bsontype.DateTime:
+		return v.Time()
+	case bsontype.String:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, v.StringValue()); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func stringAt(doc bson.Raw, paths ...string) string {
+	v, _ := first(doc, paths...)
+	return String(v)
+}
+
+func floatAt(doc bson.Raw, paths ...string) float64 {
+	v, _ := first(doc, paths...)
+	return Float(v)
+}
+
+func intAt(doc bson.Raw, paths ...string) int64 {
+	v, _ := first(doc, paths...)
+	return Int(v)
+}
+
+func boolAt(doc bson.Raw, paths ...string) bool {
+	v, _ := first(doc, paths...)
+	return Bool(v)
+}
+
+func objectIDAt(doc bson.Raw, paths ...string) primitive.ObjectID {
+	for _, p := range paths {
+		if v, ok := lookup(doc, p); ok {
+			if oid := ObjectID(v); !oid.IsZero() {
+				return oid
+			}
+		}
+	}
+	return primitive.NilObjectID
+}
+
+func timeAt(doc bson.Raw, paths ...string) time.Time {
+	v, _ := first(doc, paths...)
+	return Time(v)
+}
+
+// documents devolve os subdocumentos de um array (ignorando outros tipos).
+func documents(doc bson.Raw, paths ...string) []bson.Raw {
+	v, ok := first(doc, paths...)
+	if !ok || v.Type != bsontype.Array {
+		return nil
+	}
+	values, err := v.Array().Values()
+	if err != nil {
+		return nil
+	}
+	docs := make([]bson.Raw, 0, len(values))
+	for _, item := range values {
+		if d, ok := item.DocumentOK(); ok {
+			docs = append(docs, d)
+		}
+	}
+	return docs
+}
+
+// Discriminator é o _t do documento, que o driver C# grava como texto ou,
+// em hierarquias, como array (ex.: ["Pessoa", "PessoaJuridica", "Emitente"]).
+type Discriminator []string
+
+func discriminatorOf(doc bson.Raw) Discriminator {
+	v, ok := lookup(doc, "_t")
+	if !ok {
+		return nil
+	}
+	switch v.Type {
+	case bsontype.String:
+		return Discriminator{v.StringValue()}
+	case bsontype.Array:
+		values, err := v.Array().Values()
+		if err != nil {
+			return nil
+		}
+		t := make(Discriminator, 0, len(values))
+		for _, item := range values {
+			if s, ok := item.StringValueOK(); ok {
+				t = append(t, s)
+			}
+		}
+		return t
+	}
+	return nil
+}
+
+// Is indica se o tipo informado aparece em qualquer nível da hierarquia.
+func (d Discriminator) Is(name string) bool {
+	for _, t := range d {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Concrete devolve o tipo mais específico (o último da hierarquia).
+func (d Discriminator) Concrete() string {
+	if len(d) == 0 {
+		return ""
+	}
+	return d[len(d)-1]
+}
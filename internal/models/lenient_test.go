@@ -0,0 +1,136 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func rawValue(t *testing.T, v interface{}) bson.RawValue {
+	t.Helper()
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bson.Raw(data).Lookup("v")
+}
+
+func decimal(t *testing.T, s string) primitive.Decimal128 {
+	t.Helper()
+	d, err := primitive.ParseDecimal128(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestFloat(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want float64
+	}{
+		{"int32", int32(12), 12},
+		{"int64", int64(1234567), 1234567},
+		{"double", 10.5, 10.5},
+		{"decimal", decimal(t, "1234.56"), 1234.56},
+		{"texto com ponto", "10.5", 10.5},
+		{"texto com vírgula", "10,5", 10.5},
+		{"texto brasileiro", "1.234,56", 1234.56},
+		{"texto americano", "1,234.56", 1234.56},
+		{"milhar com pontos", "1.234.567", 1234567},
+		{"milhar com vírgulas", "1,234,567", 1234567},
+		{"texto com espaços", " 1.234,56 ", 1234.56},
+		{"texto inválido", "abc", 0},
+		{"booleano", true, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Float(rawValue(t, c.v)); got != c.want {
+				t.Fatalf("Float(%v) = %v, esperado %v", c.v, got, c.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDecimal(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"10,5", "10.5"},
+		{"10.5", "10.5"},
+		{"1.234,56", "1234.56"},
+		{"1,234.56", "1234.56"},
+		{"1.234.567", "1234567"},
+		{"1,234,567", "1234567"},
+		{" 1 234,56 ", "1234.56"},
+		{"-0,01", "-0.01"},
+		{"42", "42"},
+	}
+	for _, c := range cases {
+		if got := NormalizeDecimal(c.in); got != c.want {
+			t.Errorf("NormalizeDecimal(%q) = %q, esperado %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestIntAndString(t *testing.T) {
+	cases := []struct {
+		name    string
+		v       interface{}
+		wantInt int64
+		wantStr string
+	}{
+		{"int32", int32(7), 7, "7"},
+		{"int64", int64(42), 42, "42"},
+		{"double", 3.0, 3, "3"},
+		{"decimal", decimal(t, "2.50"), 2, "2.5"},
+		{"texto", "15", 15, "15"},
+		{"texto decimal", "1.500,00", 1500, "1.500,00"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v := rawValue(t, c.v)
+			if got := Int(v); got != c.wantInt {
+				t.Errorf("Int(%v) = %d, esperado %d", c.v, got, c.wantInt)
+			}
+			if got := String(v); got != c.wantStr {
+				t.Errorf("String(%v) = %q, esperado %q", c.v, got, c.wantStr)
+			}
+		})
+	}
+}
+
+func TestObjectIDAcceptsHex(t *testing.T) {
+	oid := primitive.NewObjectID()
+	for _, v := range []interface{}{oid, oid.Hex()} {
+		if got := ObjectID(rawValue(t, v)); got != oid {
+			t.Fatalf("ObjectID(%v) = %s, esperado %s", v, got.Hex(), oid.Hex())
+		}
+	}
+}
+
+func TestDiscriminator(t *testing.T) {
+	cases := []struct {
+		name     string
+		t        interface{}
+		concrete string
+		emitente bool
+	}{
+		{"texto", "Cliente", "Cliente", false},
+		{"hierarquia", bson.A{"Pessoa", "PessoaJuridica", "Emitente"}, "Emitente", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := bson.Marshal(bson.D{{Key: "_t", Value: c.t}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := discriminatorOf(data)
+			if d.Concrete() != c.concrete || d.Is("Emitente") != c.emitente {
+				t.Fatalf("discriminator %v: Concrete=%q Is(Emitente)=%v", d, d.Concrete(), d.Is("Emitente"))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MovimentacaoItem struct {
+	ProdutoReferencia primitive.ObjectID
+	CodigoInterno     string
+	Descricao         string
+	Quantidade        float64
+	ValorUnitario     float64
+	ValorDesconto     float64
+}
+
+// Total é quantidade × valor unitário, já descontado o desconto do item.
+func (i MovimentacaoItem) Total() float64 {
+	return i.Quantidade*i.ValorUnitario - i.ValorDesconto
+}
+
+// Movimentacao corresponde a Movimentacoes (vendas, notas, notas manuais...).
+// Os nomes de campos que variam entre versões (Itens/ItensBase,
+// Produto/ProdutoServico, ValorUnitario/PrecoUnitario) são unificados aqui.
+type Movimentacao struct {
+	ID                    primitive.ObjectID
+	Tipo                  Discriminator
+	Numero                int64
+	DataHoraEmissao       time.Time
+	EmpresaReferencia     primitive.ObjectID
+	Empresa               *Pessoa
+	Pessoa                *Pessoa
+	Itens                 []MovimentacaoItem
+	TotalDescontoAplicado float64
+	TotalOutrasDespesas   float64
+	Observacao            string
+	FormaPagamento        string
+}
+
+func (m *Movimentacao) UnmarshalBSON(data []byte) error {
+	doc := bson.Raw(data)
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+
+	*m = Movimentacao{
+		ID:                    objectIDAt(doc, "_id"),
+		Tipo:                  discriminatorOf(doc),
+		Numero:                intAt(doc, "Numero"),
+		DataHoraEmissao:       timeAt(doc, "DataHoraEmissao"),
+		EmpresaReferencia:     objectIDAt(doc, "Empresa._id", "EmpresaReferencia"),
+		TotalDescontoAplicado: floatAt(doc, "TotalDescontoAplicado"),
+		TotalOutrasDespesas:   floatAt(doc, "TotalOutrasDespesas"),
+		Observacao:            stringAt(doc, "Observacao"),
+		FormaPagamento: stringAt(doc,
+			"PagamentoRecebimento.Parcelas.0.Historico.0.EspeciePagamento.Descricao",
+			"ItensPagamentos.0.EspeciePagamento.Descricao"),
+	}
+
+	if v, ok := lookup(doc, "Empresa"); ok {
+		if d, ok := v.DocumentOK(); ok {
+			empresa := pessoaFrom(d)
+			m.Empresa = &empresa
+		}
+	}
+	if v, ok := lookup(doc, "Pessoa"); ok {
+		if d, ok := v.DocumentOK(); ok {
+			pessoa := pessoaFrom(d)
+			m.Pessoa = &pessoa
+		}
+	}
+
+	for _, item := range documents(doc, "Itens", "ItensBase") {
+		mi := MovimentacaoItem{
+			ProdutoReferencia: objectIDAt(item, "Produto._id", "ProdutoServico._id", "ProdutoServicoReferencia"),
+			CodigoInterno:     stringAt(item, "Produto.CodigoInterno", "ProdutoServico.CodigoInterno"),
+			Descricao:         stringAt(item, "Produto.Descricao", "ProdutoServico.Descricao", "Descricao"),
+			Quantidade:        floatAt(item, "Quantidade"),
+			ValorDesconto:     floatAt(item, "ValorDesconto"),
+		}
+		// Um ValorUnitario zerado também cai para PrecoUnitario.
+		mi.ValorUnitario = floatAt(item, "ValorUnitario")
+		if mi.ValorUnitario == 0 {
+			mi.ValorUnitario = floatAt(item, "PrecoUnitario")
+		}
+		m.Itens = append(m.Itens, mi)
+	}
+	return nil
+}
+
+// Total soma os itens (sem o desconto por item, como no cálculo das notas
+// manuais) e aplica o desconto e as despesas da movimentação.
+func (m *Movimentacao) Total() float64 {
+	total := 0.0
+	for _, item := range m.Itens {
+		total += item.Quantidade * item.ValorUnitario
+	}
+	return total - m.TotalDescontoAplicado + m.TotalOutrasDespesas
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMovimentacaoItens(t *testing.T) {
+	prod := primitive.NewObjectID()
+	cases := []struct {
+		name string
+		doc  bson.D
+		want MovimentacaoItem
+	}{
+		{
+			name: "Itens com Produto e ValorUnitario",
+			doc: bson.D{{Key: "Itens", Value: bson.A{bson.D{
+				{Key: "Produto", Value: bson.D{{Key: "_id", Value: prod}, {Key: "CodigoInterno", Value: "10"}, {Key: "Descricao", Value: "Arroz"}}},
+				{Key: "Quantidade", Value: int32(2)},
+				{Key: "ValorUnitario", Value: 5.5},
+			}}}},
+			want: MovimentacaoItem{ProdutoReferencia: prod, CodigoInterno: "10", Descricao: "Arroz", Quantidade: 2, ValorUnitario: 5.5},
+		},
+		{
+			name: "ItensBase com ProdutoServico e PrecoUnitario",
+			doc: bson.D{{Key: "ItensBase", Value: bson.A{bson.D{
+				{Key: "ProdutoServico", Value: bson.D{{Key: "_id", Value: prod.Hex()}, {Key: "CodigoInterno", Value: int64(10)}, {Key: "Descricao", Value: "Arroz"}}},
+				{Key: "Quantidade", Value: "1,5"},
+				{Key: "PrecoUnitario", Value: int64(4)},
+			}}}},
+			want: MovimentacaoItem{ProdutoReferencia: prod, CodigoInterno: "10", Descricao: "Arroz", Quantidade: 1.5, ValorUnitario: 4},
+		},
+		{
+			name: "ValorUnitario zerado cai para PrecoUnitario",
+			doc: bson.D{{Key: "Itens", Value: bson.A{bson.D{
+				{Key: "ProdutoServicoReferencia", Value: prod},
+				{Key: "Descricao", Value: "Arroz"},
+				{Key: "Quantidade", Value: 1.0},
+				{Key: "ValorUnitario", Value: int32(0)},
+				{Key: "PrecoUnitario", Value: "1.234,50"},
+				{Key: "ValorDesconto", Value: 0.5},
+			}}}},
+			want: MovimentacaoItem{ProdutoReferencia: prod, Descricao: "Arroz", Quantidade: 1, ValorUnitario: 1234.5, ValorDesconto: 0.5},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := bson.Marshal(c.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m Movimentacao
+			if err := bson.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Itens) != 1 {
+				t.Fatalf("itens = %d, esperado 1", len(m.Itens))
+			}
+			if m.Itens[0] != c.want {
+				t.Fatalf("item = %+v, esperado %+v", m.Itens[0], c.want)
+			}
+		})
+	}
+}
+
+func TestMovimentacaoTotal(t *testing.T) {
+	empresa := primitive.NewObjectID()
+	data, err := bson.Marshal(bson.D{
+		{Key: "_t", Value: bson.A{"Movimentacao", "NotaFiscalManual"}},
+		{Key: "Numero", Value: "15"},
+		{Key: "EmpresaReferencia", Value: empresa},
+		{Key: "TotalDescontoAplicado", Value: decimal(t, "1.50")},
+		{Key: "TotalOutrasDespesas", Value: int32(2)},
+		{Key: "Itens", Value: bson.A{
+			bson.D{{Key: "Quantidade", Value: int32(2)}, {Key: "ValorUnitario", Value: 10.0}},
+			bson.D{{Key: "Quantidade", Value: int64(1)}, {Key: "PrecoUnitario", Value: "5,25"}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Movimentacao
+	if err := bson.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Numero != 15 || m.EmpresaReferencia != empresa || m.Tipo.Concrete() != "NotaFiscalManual" {
+		t.Fatalf("cabeçalho = %+v", m)
+	}
+	if got, want := m.Total(), 25.75; got != want {
+		t.Fatalf("Total() = %v, esperado %v", got, want)
+	}
+}
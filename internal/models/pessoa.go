@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Endereco struct {
+	Logradouro string
+	Numero     string
+	Bairro     string
+	Cidade     string
+	Uf         string
+}
+
+func (e Endereco) String() string {
+	if e.Logradouro == "" && e.Numero == "" && e.Bairro == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s, %s - %s", e.Logradouro, e.Numero, e.Bairro)
+}
+
+// Pessoa corresponde a Pessoas (emitentes/matriz, clientes, fornecedores...)
+// e também aos dados da pessoa embutidos nas movimentações.
+type Pessoa struct {
+	ID                primitive.ObjectID
+	Tipo              Discriminator
+	Nome              string
+	NomeFantasia      string
+	CpfCnpj           string
+	InscricaoEstadual string
+	Ativo             bool
+	Endereco          Endereco
+	Telefone          string
+	// Imagem é o logotipo do emitente, possivelmente compactado com gzip.
+	Imagem []byte
+}
+
+func (p *Pessoa) UnmarshalBSON(data []byte) error {
+	doc := bson.Raw(data)
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	*p = pessoaFrom(doc)
+	return nil
+}
+
+func pessoaFrom(doc bson.Raw) Pessoa {
+	p := Pessoa{
+		ID:                objectIDAt(doc, "_id"),
+		Tipo:              discriminatorOf(doc),
+		Nome:              stringAt(doc, "Nome"),
+		NomeFantasia:      stringAt(doc, "NomeFantasia", "Fantasia"),
+		CpfCnpj:           stringAt(doc, "CpfCnpj", "Documento", "Cnpj", "Cpf"),
+		InscricaoEstadual: stringAt(doc, "InscricaoEstadual", "Ie", "Carteira.Ie.Numero"),
+		Ativo:             boolAt(doc, "Ativo"),
+		Telefone:          stringAt(doc, "TelefonePrincipal", "Carteira.TelefonePrincipal.Numero"),
+	}
+	if v, ok := lookup(doc, "Imagem"); ok {
+		if _, data, ok := v.BinaryOK(); ok {
+			p.Imagem = data
+		}
+	}
+
+	// O endereço principal pode vir como EnderecoPrincipal (com Municipio
+	// aninhado, na raiz ou dentro de Carteira) ou como o primeiro item de
+	// Enderecos (com Cidade/UF planos).
+	if v, ok := first(doc, "EnderecoPrincipal", "Carteira.EnderecoPrincipal"); ok {
+		if end, ok := v.DocumentOK(); ok {
+			p.Endereco = enderecoFrom(end)
+		}
+	} else if enderecos := documents(doc, "Enderecos"); len(enderecos) > 0 {
+		p.Endereco = enderecoFrom(enderecos[0])
+	}
+
+	if p.Telefone == "" {
+		if telefones := documents(doc, "Telefones"); len(telefones) > 0 {
+			p.Telefone = fmt.Sprintf("(%s) %s", stringAt(telefones[0], "Ddd"), stringAt(telefones[0], "Numero"))
+		}
+	}
+	return p
+}
+
+func enderecoFrom(doc bson.Raw) Endereco {
+	return Endereco{
+		Logradouro: stringAt(doc, "Logradouro"),
+		Numero:     stringAt(doc, "Numero"),
+		Bairro:     stringAt(doc, "Bairro"),
+		Cidade:     stringAt(doc, "Municipio.Nome", "Cidade"),
+		Uf:         stringAt(doc, "Municipio.Uf.Sigla", "UF", "Uf"),
+	}
+}
+
+func (p *Pessoa) IsEmitente() bool {
+	return p.Tipo.Is("Emitente")
+}
+
+func (p *Pessoa) IsMatriz() bool {
+	return p.Tipo.Is("Matriz")
+}
+
+func (p *Pessoa) IsCliente() bool {
+	return p.Tipo.Is("Cliente")
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Produto corresponde a ProdutosServicos.
+type Produto struct {
+	ID                   primitive.ObjectID
+	Tipo                 Discriminator
+	Descricao            string
+	CodigoInterno        string
+	CodigoBarras         string
+	Ativo                bool
+	Pesavel              bool
+	MarcaReferencia      primitive.ObjectID
+	Marca                string
+	TipoItemReferencia   primitive.ObjectID
+	TipoItem             string
+	GeneroItemReferencia primitive.ObjectID
+	GeneroItem           string
+}
+
+func (p *Produto) UnmarshalBSON(data []byte) error {
+	doc := bson.Raw(data)
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+
+	*p = Produto{
+		ID:                   objectIDAt(doc, "_id"),
+		Tipo:                 discriminatorOf(doc),
+		Descricao:            stringAt(doc, "Descricao"),
+		CodigoInterno:        stringAt(doc, "CodigoInterno"),
+		CodigoBarras:         stringAt(doc, "CodigoBarras"),
+		Ativo:                boolAt(doc, "Ativo"),
+		Pesavel:              boolAt(doc, "Pesavel"),
+		MarcaReferencia:      objectIDAt(doc, "MarcaReferencia", "Marca._id"),
+		Marca:                stringAt(doc, "Marca.Descricao"),
+		TipoItemReferencia:   objectIDAt(doc, "TipoItemReferencia", "TipoItem._id"),
+		TipoItem:             stringAt(doc, "TipoItem.Descricao"),
+		GeneroItemReferencia: objectIDAt(doc, "GeneroItemReferencia", "GeneroItem._id"),
+		GeneroItem:           stringAt(doc, "GeneroItem.Descricao"),
+	}
+	return nil
+}
+
+// ProdutoEmpresa corresponde a ProdutosServicosEmpresa: os dados do produto
+// específicos de cada empresa (preço, estoque, NCM e tributações).
+type ProdutoEmpresa struct {
+	ID                            primitive.ObjectID
+	ProdutoServicoReferencia      primitive.ObjectID
+	EmpresaReferencia             primitive.ObjectID
+	EstoqueReferencia             primitive.ObjectID
+	PrecoReferencia               primitive.ObjectID
+	Ncm                           string
+	PrecoCusto                    float64
+	PrecoVenda                    float64
+	TributacaoEstadualReferencia  primitive.ObjectID
+	TributacaoFederalReferencia   primitive.ObjectID
+	TributacaoIbsCbsReferencia    primitive.ObjectID
+	TributacaoMunicipalReferencia primitive.ObjectID
+	// EstoqueAtual só existe em algumas versões; nil quando ausente.
+	EstoqueAtual *float64
+}
+
+func (p *ProdutoEmpresa) UnmarshalBSON(data []byte) error {
+	doc := bson.Raw(data)
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+
+	*p = ProdutoEmpresa{
+		ID:                            objectIDAt(doc, "_id"),
+		ProdutoServicoReferencia:      objectIDAt(doc, "ProdutoServicoReferencia"),
+		EmpresaReferencia:             objectIDAt(doc, "EmpresaReferencia"),
+		EstoqueReferencia:             objectIDAt(doc, "EstoqueReferencia"),
+		PrecoReferencia:               objectIDAt(doc, "PrecoReferencia"),
+		Ncm:                           stringAt(doc, "NcmNbs.Codigo", "Ncm.Codigo"),
+		PrecoCusto:                    floatAt(doc, "PrecosCustos.0.Valor"),
+		PrecoVenda:                    floatAt(doc, "PrecosVendas.0.Valor"),
+		TributacaoEstadualReferencia:  objectIDAt(doc, "TributacaoEstadualReferencia"),
+		TributacaoFederalReferencia:   objectIDAt(doc, "TributacaoFederalReferencia"),
+		TributacaoIbsCbsReferencia:    objectIDAt(doc, "TributacaoIbsCbsReferencia"),
+		TributacaoMunicipalReferencia: objectIDAt(doc, "TributacaoMunicipalReferencia"),
+	}
+	if v, ok := lookup(doc, "EstoqueAtual"); ok {
+		q := Float(v)
+		p.EstoqueAtual = &q
+	}
+	return nil
+}
+
+type EstoqueQuantidade struct {
+	EmpresaReferencia primitive.ObjectID
+	Quantidade        float64
+}
+
+// Estoque corresponde a Estoques. A quantidade principal é a do primeiro
+// item de Quantidades.
+type Estoque struct {
+	ID          primitive.ObjectID
+	Quantidades []EstoqueQuantidade
+}
+
+func (e *Estoque) UnmarshalBSON(data []byte) error {
+	doc := bson.Raw(data)
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+
+	*e = Estoque{ID: objectIDAt(doc, "_id")}
+	for _, q := range documents(doc, "Quantidades") {
+		e.Quantidades = append(e.Quantidades, EstoqueQuantidade{
+			EmpresaReferencia: objectIDAt(q, "EmpresaReferencia"),
+			Quantidade:        floatAt(q, "Quantidade"),
+		})
+	}
+	return nil
+}
+
+func (e *Estoque) Quantidade() float64 {
+	if len(e.Quantidades) == 0 {
+		return 0
+	}
+	return e.Quantidades[0].Quantidade
+}
+
+// Preco corresponde a Precos, referenciado por ProdutosServicosEmpresa em
+// versões que não embutem PrecosCustos/PrecosVendas.
+type Preco struct {
+	ID    primitive.ObjectID
+	Custo float64
+	Venda float64
+}
+
+func (p *Preco) UnmarshalBSON(data []byte) error {
+	doc := bson.Raw(data)
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+
+	*p = Preco{
+		ID:    objectIDAt(doc, "_id"),
+		Custo: floatAt(doc, "Custos.0.Valor"),
+		Venda: floatAt(doc, "Vendas.0.Valor"),
+	}
+	return nil
+}
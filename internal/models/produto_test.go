@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func unmarshalProdutoEmpresa(t *testing.T, doc bson.D) ProdutoEmpresa {
+	t.Helper()
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p ProdutoEmpresa
+	if err := bson.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProdutoEmpresaEstoqueAtual(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  bson.D
+		want *float64
+	}{
+		{"ausente", bson.D{}, nil},
+		{"nulo", bson.D{{Key: "EstoqueAtual", Value: nil}}, nil},
+		{"zero", bson.D{{Key: "EstoqueAtual", Value: int32(0)}}, new(float64)},
+		{"decimal", bson.D{{Key: "EstoqueAtual", Value: decimal(t, "3.5")}}, func() *float64 { f := 3.5; return &f }()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := unmarshalProdutoEmpresa(t, c.doc).EstoqueAtual
+			switch {
+			case c.want == nil && got != nil:
+				t.Fatalf("EstoqueAtual = %v, esperado nil", *got)
+			case c.want != nil && got == nil:
+				t.Fatalf("EstoqueAtual = nil, esperado %v", *c.want)
+			case c.want != nil && *got != *c.want:
+				t.Fatalf("EstoqueAtual = %v, esperado %v", *got, *c.want)
+			}
+		})
+	}
+}
+
+func TestProdutoEmpresaCampos(t *testing.T) {
+	cases := []struct {
+		name  string
+		doc   bson.D
+		ncm   string
+		custo float64
+		venda float64
+	}{
+		{
+			name: "NcmNbs e preços Double",
+			doc: bson.D{
+				{Key: "NcmNbs", Value: bson.D{{Key: "Codigo", Value: "22030000"}}},
+				{Key: "PrecosCustos", Value: bson.A{bson.D{{Key: "Valor", Value: 4.5}}}},
+				{Key: "PrecosVendas", Value: bson.A{bson.D{{Key: "Valor", Value: 9.9}}}},
+			},
+			ncm: "22030000", custo: 4.5, venda: 9.9,
+		},
+		{
+			name: "Ncm antigo e preços em outros tipos",
+			doc: bson.D{
+				{Key: "Ncm", Value: bson.D{{Key: "Codigo", Value: int64(22030000)}}},
+				{Key: "PrecosCustos", Value: bson.A{bson.D{{Key: "Valor", Value: decimal(t, "4.50")}}}},
+				{Key: "PrecosVendas", Value: bson.A{bson.D{{Key: "Valor", Value: "1.009,90"}}}},
+			},
+			ncm: "22030000", custo: 4.5, venda: 1009.9,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := unmarshalProdutoEmpresa(t, c.doc)
+			if p.Ncm != c.ncm || p.PrecoCusto != c.custo || p.PrecoVenda != c.venda {
+				t.Fatalf("Ncm=%q PrecoCusto=%v PrecoVenda=%v, esperado %q %v %v", p.Ncm, p.PrecoCusto, p.PrecoVenda, c.ncm, c.custo, c.venda)
+			}
+		})
+	}
+}
+
+func TestProdutoReferencias(t *testing.T) {
+	marca := primitive.NewObjectID()
+	cases := []struct {
+		name string
+		doc  bson.D
+	}{
+		{"referência direta", bson.D{{Key: "MarcaReferencia", Value: marca}}},
+		{"referência em texto", bson.D{{Key: "MarcaReferencia", Value: marca.Hex()}}},
+		{"documento embutido", bson.D{{Key: "Marca", Value: bson.D{{Key: "_id", Value: marca}, {Key: "Descricao", Value: "ACME"}}}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := bson.Marshal(append(c.doc, bson.E{Key: "Ativo", Value: int32(1)}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var p Produto
+			if err := bson.Unmarshal(data, &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.MarcaReferencia != marca || !p.Ativo {
+				t.Fatalf("MarcaReferencia=%s Ativo=%v", p.MarcaReferencia.Hex(), p.Ativo)
+			}
+		})
+	}
+}
+
+func TestEstoqueQuantidade(t *testing.T) {
+	for _, v := range []interface{}{int32(-3), int64(-3), -3.0, "-3", decimal(t, "-3")} {
+		data, err := bson.Marshal(bson.D{{Key: "Quantidades", Value: bson.A{bson.D{{Key: "Quantidade", Value: v}}}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var e Estoque
+		if err := bson.Unmarshal(data, &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Quantidade() != -3 {
+			t.Fatalf("Quantidade(%T) = %v, esperado -3", v, e.Quantidade())
+		}
+	}
+}
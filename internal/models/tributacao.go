@@ -0,0 +1,30 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tributacao cobre TributacoesEstadual, TributacoesFederal e
+// TributacoesIbsCbs, que compartilham identificação e descrição.
+type Tributacao struct {
+	ID        primitive.ObjectID
+	Tipo      Discriminator
+	Descricao string
+	Ativo     bool
+}
+
+func (t *Tributacao) UnmarshalBSON(data []byte) error {
+	doc := bson.Raw(data)
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+
+	*t = Tributacao{
+		ID:        objectIDAt(doc, "_id"),
+		Tipo:      discriminatorOf(doc),
+		Descricao: stringAt(doc, "Descricao"),
+		Ativo:     boolAt(doc, "Ativo"),
+	}
+	return nil
+}
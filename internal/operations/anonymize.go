@@ -0,0 +1,785 @@
+package operations
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"BMongo-VIP/internal/brdoc"
+	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/seed"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnonymizeOptions configura a anonimização. O resultado vai para Target
+// (banco novo ou pasta de dump) ou, com InPlace, substitui os dados do banco
+// conectado, o que só é aceito em cópias (banco diferente de DigisatServer).
+type AnonymizeOptions struct {
+	Target  CopyTarget `json:"target"`
+	InPlace bool       `json:"inPlace"`
+	// Secret torna a substituição reproduzível: a mesma chave gera os mesmos
+	// dados fictícios. Sem chave é usada uma aleatória.
+	Secret        string `json:"secret"`
+	KeepEmitentes bool   `json:"keepEmitentes"`
+}
+
+type AnonymizeResult struct {
+	Location    string `json:"location"`
+	Collections int    `json:"collections"`
+	Documents   int64  `json:"documents"`
+	Changed     int64  `json:"changed"`
+	People      int    `json:"people"`
+}
+
+// AnonymizeDatabase substitui nomes, CPF/CNPJ, telefones, e-mails e endereços
+// das pessoas por dados fictícios. A substituição depende só do valor
+// original, então as cópias embutidas (Pessoa/Empresa nas movimentações)
+// recebem os mesmos dados do cadastro; CPF/CNPJ continuam com dígitos
+// verificadores válidos e as chaves de acesso são recalculadas.
+func (m *Manager) AnonymizeDatabase(opts AnonymizeOptions, log LogFunc) (*AnonymizeResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Hour)
+	defer cancel()
+
+	var sink copySink
+	if opts.InPlace {
+		if opts.Target != (CopyTarget{}) {
+			return nil, fmt.Errorf("anonimização no próprio banco não aceita destino")
+		}
+		if m.conn.Database.Name() == digisatDatabaseName {
+			return nil, fmt.Errorf("anonimização no próprio banco só é permitida em cópias (banco diferente de %s)", digisatDatabaseName)
+		}
+		sink = newInPlaceSink(m.conn.Database)
+	} else {
+		var err error
+		if sink, err = m.openCopyTarget(ctx, opts.Target, "anonimizado"); err != nil {
+			return nil, err
+		}
+	}
+
+	key := []byte(opts.Secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("erro ao gerar chave: %w", err)
+		}
+		log("ℹ️ Sem chave informada: os dados fictícios não se repetirão em outra execução")
+	}
+
+	a := newAnonymizer(key, opts.KeepEmitentes)
+	result := &AnonymizeResult{Location: sink.location()}
+
+	err := m.anonymizeInto(ctx, a, sink, opts.InPlace, result, log)
+	if err == nil {
+		err = sink.close(ctx)
+	}
+	if err != nil {
+		sink.discard(ctx)
+		return nil, err
+	}
+
+	log(fmt.Sprintf("✅ Anonimização concluída: %d pessoas, %d de %d documentos alterados em %s",
+		result.People, result.Changed, result.Documents, result.Location))
+	return result, nil
+}
+
+func (m *Manager) anonymizeInto(ctx context.Context, a *anonymizer, sink copySink, inPlace bool, result *AnonymizeResult, log LogFunc) error {
+	pessoas := m.conn.GetCollection(database.CollectionPessoas)
+
+	if a.keepEmitentes {
+		cursor, err := pessoas.Find(ctx, bson.M{"_t": "Emitente"})
+		if err != nil {
+			return fmt.Errorf("erro ao buscar emitentes: %w", err)
+		}
+		for cursor.Next(ctx) {
+			var doc bson.D
+			if err := bson.Unmarshal(cursor.Current, &doc); err != nil {
+				cursor.Close(ctx)
+				return fmt.Errorf("erro ao ler emitente: %w", err)
+			}
+			a.keep(doc)
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar emitentes: %w", err)
+		}
+		log(fmt.Sprintf("🏢 %d emitentes mantidos sem alteração", len(a.keepIDs)))
+	}
+
+	// Primeira passada em Pessoas: monta a tabela de valores originais →
+	// fictícios, usada para trocar os mesmos dados em textos livres (XML,
+	// observações) das demais coleções.
+	log("🔎 Lendo cadastro de pessoas...")
+	cursor, err := pessoas.Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("erro ao ler pessoas: %w", err)
+	}
+	for cursor.Next(ctx) {
+		var doc bson.D
+		if err := bson.Unmarshal(cursor.Current, &doc); err != nil {
+			cursor.Close(ctx)
+			return fmt.Errorf("erro ao ler pessoa: %w", err)
+		}
+		if _, changed := a.value("", doc); changed {
+			result.People++
+		}
+	}
+	err = cursor.Err()
+	cursor.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao ler pessoas: %w", err)
+	}
+	a.buildText()
+	log(fmt.Sprintf("👤 %d pessoas serão anonimizadas", result.People))
+
+	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("erro ao listar coleções: %w", err)
+	}
+	sort.Strings(collections)
+
+	for _, colName := range collections {
+		if strings.HasPrefix(colName, "system.") {
+			continue
+		}
+		if m.state.ShouldStop() {
+			return fmt.Errorf("operação cancelada")
+		}
+
+		coll := m.conn.GetCollection(colName)
+		if !inPlace {
+			specs, err := listIndexSpecs(ctx, coll)
+			if err != nil {
+				return fmt.Errorf("erro ao ler índices de %s: %w", colName, err)
+			}
+			if err := sink.indexes(ctx, colName, specs); err != nil {
+				return fmt.Errorf("erro ao criar índices de %s: %w", colName, err)
+			}
+		}
+
+		cursor, err := coll.Find(ctx, bson.M{})
+		if err != nil {
+			return fmt.Errorf("erro ao ler %s: %w", colName, err)
+		}
+
+		var docs, changed int64
+		for cursor.Next(ctx) {
+			if docs%1000 == 0 && m.state.ShouldStop() {
+				cursor.Close(ctx)
+				return fmt.Errorf("operação cancelada")
+			}
+			docs++
+
+			raw := cursor.Current
+			var doc bson.D
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				cursor.Close(ctx)
+				return fmt.Errorf("erro ao ler documento de %s: %w", colName, err)
+			}
+			if v, c := a.value("", doc); c {
+				out, err := bson.Marshal(v)
+				if err != nil {
+					cursor.Close(ctx)
+					return fmt.Errorf("erro ao gravar documento de %s: %w", colName, err)
+				}
+				raw = out
+				changed++
+			} else if inPlace {
+				continue
+			} else {
+				raw = append(bson.Raw(nil), raw...)
+			}
+
+			if err := sink.write(ctx, colName, raw); err != nil {
+				cursor.Close(ctx)
+				return err
+			}
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return fmt.Errorf("erro ao ler %s: %w", colName, err)
+		}
+
+		result.Collections++
+		result.Documents += docs
+		result.Changed += changed
+		if changed > 0 {
+			log(fmt.Sprintf("   🕶️ %s: %d de %d documentos anonimizados", colName, changed, docs))
+		}
+	}
+	return nil
+}
+
+// anonymizer troca dados pessoais por valores fictícios derivados de
+// HMAC(chave, valor original).
+type anonymizer struct {
+	key           []byte
+	keepEmitentes bool
+	keepIDs       map[primitive.ObjectID]bool
+	keepDocs      map[string]bool
+	// pairs guarda original → fictício dos valores longos o bastante para
+	// serem procurados em textos livres. Os que são só dígitos (CPF/CNPJ e
+	// telefone sem pontuação) ficam em digitPairs e só trocam sequências de
+	// dígitos inteiras, para não alterar números maiores que os contenham.
+	pairs      map[string]string
+	digitPairs map[string]string
+	text       *strings.Replacer
+}
+
+func newAnonymizer(key []byte, keepEmitentes bool) *anonymizer {
+	return &anonymizer{
+		key:           key,
+		keepEmitentes: keepEmitentes,
+		keepIDs:       make(map[primitive.ObjectID]bool),
+		keepDocs:      make(map[string]bool),
+		pairs:         make(map[string]string),
+		digitPairs:    make(map[string]string),
+	}
+}
+
+func (a *anonymizer) keep(doc bson.D) {
+	for _, e := range doc {
+		switch {
+		case e.Key == "_id":
+			if id, ok := e.Value.(primitive.ObjectID); ok {
+				a.keepIDs[id] = true
+			}
+		case isDocumentKey(e.Key):
+			if s, ok := e.Value.(string); ok && brdoc.OnlyDigits(s) != "" {
+				a.keepDocs[brdoc.OnlyDigits(s)] = true
+			}
+		}
+	}
+}
+
+func (a *anonymizer) kept(doc bson.D) bool {
+	if !a.keepEmitentes {
+		return false
+	}
+	for _, e := range doc {
+		switch {
+		case e.Key == "_id":
+			if id, ok := e.Value.(primitive.ObjectID); ok && a.keepIDs[id] {
+				return true
+			}
+		case isDocumentKey(e.Key):
+			if s, ok := e.Value.(string); ok && a.keepDocs[brdoc.OnlyDigits(s)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildText monta o substituidor de textos livres, com os valores mais longos
+// primeiro para que um nome completo não seja trocado pela metade.
+func (a *anonymizer) buildText() {
+	originals := make([]string, 0, len(a.pairs))
+	for original := range a.pairs {
+		originals = append(originals, original)
+	}
+	sort.Slice(originals, func(i, j int) bool {
+		if len(originals[i]) != len(originals[j]) {
+			return len(originals[i]) > len(originals[j])
+		}
+		return originals[i] < originals[j]
+	})
+
+	oldnew := make([]string, 0, 2*len(originals))
+	for _, original := range originals {
+		oldnew = append(oldnew, original, a.pairs[original])
+	}
+	if len(oldnew) > 0 {
+		a.text = strings.NewReplacer(oldnew...)
+	}
+}
+
+func (a *anonymizer) rng(kind, value string) *mathrand.Rand {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	sum := mac.Sum(nil)
+	return mathrand.New(mathrand.NewSource(int64(binary.BigEndian.Uint64(sum))))
+}
+
+func (a *anonymizer) digits(kind, value string, n int) string {
+	r := a.rng(kind, value)
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + r.Intn(10))
+	}
+	return string(b)
+}
+
+func (a *anonymizer) remember(original, fake string) {
+	switch {
+	case original == fake:
+	case brdoc.OnlyDigits(original) == original:
+		a.digitPairs[original] = fake
+	default:
+		a.pairs[original] = fake
+	}
+}
+
+// value percorre um valor fora do cadastro de pessoa; key é o nome do campo.
+func (a *anonymizer) value(key string, v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case bson.D:
+		if isPerson(x, key == "") {
+			return a.person(x)
+		}
+		changed := false
+		for i, e := range x {
+			if nv, c := a.value(e.Key, e.Value); c {
+				x[i].Value = nv
+				changed = true
+			}
+		}
+		return x, changed
+	case primitive.A:
+		changed := false
+		for i, item := range x {
+			if nv, c := a.value(key, item); c {
+				x[i] = nv
+				changed = true
+			}
+		}
+		return x, changed
+	case string:
+		// CPF/CNPJ gravados fora do cadastro, como o CPF na nota do consumidor.
+		if isDocumentKey(key) {
+			out := a.cpfCnpj(x)
+			return out, out != x
+		}
+		if isChaveKey(key) && chaveFieldPattern.MatchString(x) {
+			out := a.chaveAcesso(x)
+			return out, out != x
+		}
+		out := a.freeText(x)
+		return out, out != x
+	case primitive.Binary:
+		return a.binary(x)
+	}
+	return v, false
+}
+
+var personTypes = []string{"Pessoa", "PessoaFisica", "PessoaJuridica"}
+
+// isPerson reconhece um cadastro de pessoa pelo discriminador _t ou, em
+// documentos embutidos, pela presença de CPF/CNPJ.
+func isPerson(doc bson.D, topLevel bool) bool {
+	for _, e := range doc {
+		if e.Key == "_t" && hasAnyType(e.Value, personTypes...) {
+			return true
+		}
+		if !topLevel && isDocumentKey(e.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyType(v interface{}, types ...string) bool {
+	var values []interface{}
+	switch t := v.(type) {
+	case string:
+		values = []interface{}{t}
+	case primitive.A:
+		values = t
+	}
+	for _, value := range values {
+		for _, typ := range types {
+			if value == typ {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isDocumentKey(key string) bool {
+	return strings.Contains(key, "Cpf") || strings.Contains(key, "Cnpj")
+}
+
+func isJuridica(doc bson.D) bool {
+	for _, e := range doc {
+		switch {
+		case e.Key == "_t":
+			if hasAnyType(e.Value, "PessoaJuridica") {
+				return true
+			}
+			if hasAnyType(e.Value, "PessoaFisica") {
+				return false
+			}
+		case isDocumentKey(e.Key):
+			if s, ok := e.Value.(string); ok && len(brdoc.OnlyDigits(s)) == 14 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *anonymizer) person(doc bson.D) (interface{}, bool) {
+	if a.kept(doc) {
+		return doc, false
+	}
+	return a.personDoc(doc, nil, isJuridica(doc))
+}
+
+// Campos binários do cadastro (foto, logotipo, assinatura) são removidos.
+var personBinaryKeys = map[string]bool{"Imagem": true, "Foto": true, "Assinatura": true}
+
+func (a *anonymizer) personDoc(doc bson.D, path []string, juridica bool) (bson.D, bool) {
+	out := make(bson.D, 0, len(doc))
+	changed := false
+	for _, e := range doc {
+		if _, ok := e.Value.(primitive.Binary); ok && personBinaryKeys[e.Key] {
+			changed = true
+			continue
+		}
+		nv, c := a.personValue(append(path[:len(path):len(path)], e.Key), e.Value, juridica)
+		out = append(out, bson.E{Key: e.Key, Value: nv})
+		changed = changed || c
+	}
+	return out, changed
+}
+
+func (a *anonymizer) personValue(path []string, v interface{}, juridica bool) (interface{}, bool) {
+	key := path[len(path)-1]
+	switch x := v.(type) {
+	case bson.D:
+		if isPerson(x, false) {
+			return a.person(x)
+		}
+		return a.personDoc(x, path, juridica)
+	case primitive.A:
+		changed := false
+		for i, item := range x {
+			if nv, c := a.personValue(path, item, juridica); c {
+				x[i] = nv
+				changed = true
+			}
+		}
+		return x, changed
+	case string:
+		out := a.personString(path, x, juridica)
+		return out, out != x
+	case primitive.DateTime:
+		// Da data de nascimento fica só o ano.
+		if key == "DataNascimento" {
+			t := x.Time().UTC()
+			out := primitive.NewDateTimeFromTime(time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC))
+			return out, out != x
+		}
+	case primitive.Binary:
+		return a.binary(x)
+	}
+	return v, false
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *anonymizer) personString(path []string, s string, juridica bool) string {
+	if s == "" {
+		return s
+	}
+	key := path[len(path)-1]
+	parents := strings.Join(path[:len(path)-1], ".")
+	inside := func(subs ...string) bool { return containsAny(parents, subs...) }
+
+	switch {
+	case inside("Municipio", "Uf", "Pais"):
+		return s
+	case isDocumentKey(key):
+		return a.cpfCnpj(s)
+	case strings.HasPrefix(key, "Rg") || strings.HasPrefix(key, "Inscricao") ||
+		key == "Numero" && inside("Ie", "Inscricao", "Rg"):
+		return a.sameShape("documento", s)
+	case strings.Contains(key, "Email") || key == "Endereco" && inside("Email"):
+		return a.email(s)
+	case containsAny(key, "Telefone", "Celular", "Fax", "Whatsapp") ||
+		key == "Numero" && inside("Telefone", "Celular", "Fax", "Whatsapp"):
+		return a.phone(s)
+	case key == "Cep":
+		return a.cep(s)
+	case inside("Endereco"):
+		switch key {
+		case "Logradouro", "Endereco":
+			return matchCase(s, seed.FakeStreet(a.rng("logradouro", normalizeName(s))))
+		case "Numero":
+			return a.sameShape("numero", s)
+		case "Bairro":
+			return matchCase(s, seed.FakeNeighborhood(a.rng("bairro", normalizeName(s))))
+		case "Complemento", "Referencia", "PontoReferencia":
+			return ""
+		}
+	case key == "Logradouro" || key == "Endereco":
+		return matchCase(s, seed.FakeStreet(a.rng("logradouro", normalizeName(s))))
+	case key == "NomeFantasia" || key == "Fantasia":
+		return a.name(s, juridica, true)
+	case len(path) == 1 && (key == "Nome" || key == "RazaoSocial" || key == "Apelido"):
+		return a.name(s, juridica, false)
+	case containsAny(key, "Contato", "Responsavel") ||
+		key == "Nome" && inside("Contato", "Responsavel", "Conjuge", "Pai", "Mae", "Dependente", "Socio", "Autorizado"):
+		return a.name(s, false, false)
+	case strings.HasPrefix(key, "Observac"):
+		return ""
+	}
+	return a.freeText(s)
+}
+
+func normalizeName(s string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+}
+
+// matchCase devolve fake com a mesma caixa do original: tudo em maiúsculas
+// ou com as iniciais maiúsculas.
+func matchCase(original, fake string) string {
+	if strings.ToUpper(original) == original {
+		return strings.ToUpper(fake)
+	}
+	words := strings.Fields(strings.ToLower(fake))
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// replaceDigits troca os dígitos de original, na ordem, pelos de digits,
+// mantendo a pontuação.
+func replaceDigits(original, digits string) string {
+	var b strings.Builder
+	i := 0
+	for _, r := range original {
+		if r >= '0' && r <= '9' && i < len(digits) {
+			b.WriteByte(digits[i])
+			i++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (a *anonymizer) name(s string, juridica, fantasia bool) string {
+	norm := normalizeName(s)
+	var fake string
+	switch {
+	case juridica && fantasia:
+		_, fake = seed.FakeCompanyName(a.rng("fantasia", norm))
+	case juridica:
+		fake, _ = seed.FakeCompanyName(a.rng("razao", norm))
+	default:
+		fake = seed.FakePersonName(a.rng("nome", norm))
+	}
+	fake = matchCase(s, fake)
+
+	// Só nomes compostos entram na troca em textos livres; nomes curtos
+	// gerariam substituições no meio de outras palavras.
+	if len(norm) >= 8 && strings.Contains(norm, " ") {
+		a.remember(s, fake)
+		a.remember(strings.ToUpper(s), strings.ToUpper(fake))
+	}
+	return fake
+}
+
+func (a *anonymizer) fakeDocDigits(d string) string {
+	switch len(d) {
+	case 11:
+		return brdoc.CompleteCPF(a.digits("cpf", d, 9))
+	case 14:
+		// A raiz é trocada pela raiz: matriz e filiais continuam com a mesma.
+		return brdoc.CompleteCNPJ(a.digits("cnpj", d[:8], 8) + d[8:12])
+	}
+	return a.digits("documento", d, len(d))
+}
+
+func (a *anonymizer) cpfCnpj(s string) string {
+	d := brdoc.OnlyDigits(s)
+	if d == "" || a.keepEmitentes && a.keepDocs[d] {
+		return s
+	}
+	fakeDigits := a.fakeDocDigits(d)
+	fake := replaceDigits(s, fakeDigits)
+
+	if len(d) == 11 || len(d) == 14 {
+		a.remember(s, fake)
+		a.remember(d, fakeDigits)
+		if len(d) == 11 {
+			a.remember(brdoc.FormatCPF(d), brdoc.FormatCPF(fakeDigits))
+		} else {
+			a.remember(brdoc.FormatCNPJ(d), brdoc.FormatCNPJ(fakeDigits))
+		}
+	}
+	return fake
+}
+
+func (a *anonymizer) sameShape(kind, s string) string {
+	d := brdoc.OnlyDigits(s)
+	if d == "" {
+		return s
+	}
+	return replaceDigits(s, a.digits(kind, d, len(d)))
+}
+
+func (a *anonymizer) email(s string) string {
+	if !strings.Contains(s, "@") {
+		return s
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte("email\x00" + strings.ToLower(strings.TrimSpace(s))))
+	fake := "contato." + hex.EncodeToString(mac.Sum(nil)[:4]) + "@example.com"
+	a.remember(s, fake)
+	a.remember(strings.ToLower(s), fake)
+	return fake
+}
+
+func (a *anonymizer) phone(s string) string {
+	d := brdoc.OnlyDigits(s)
+	if len(d) < 8 {
+		return s
+	}
+	// O DDD é mantido.
+	keep := 0
+	if len(d) >= 10 {
+		keep = 2
+	}
+	fakeDigits := d[:keep] + a.digits("telefone", d, len(d)-keep)
+	fake := replaceDigits(s, fakeDigits)
+	if len(d) >= 10 {
+		a.remember(s, fake)
+		a.remember(d, fakeDigits)
+	}
+	return fake
+}
+
+// cep mantém os 5 primeiros dígitos (região) e troca o sufixo.
+func (a *anonymizer) cep(s string) string {
+	d := brdoc.OnlyDigits(s)
+	if len(d) != 8 {
+		return a.sameShape("cep", s)
+	}
+	return replaceDigits(s, d[:5]+a.digits("cep", d, 3))
+}
+
+var (
+	// Só chaves em contexto de chave: a tag <chNFe> (e equivalentes) e o Id
+	// da infNFe. Códigos de barras de boleto também têm 44 dígitos e não
+	// podem ser tocados.
+	chaveTagPattern   = regexp.MustCompile(`(<ch(?:NFe|CTe|MDFe|BPe)>|Id="(?:NFe|CTe|MDFe|BPe))(\d{44})\b`)
+	chaveFieldPattern = regexp.MustCompile(`^\d{44}$`)
+	digitRunPattern   = regexp.MustCompile(`\d+`)
+)
+
+// isChaveKey reconhece campos que guardam a chave de acesso (ChaveAcesso,
+// chNFe etc.).
+func isChaveKey(key string) bool {
+	lower := strings.ToLower(key)
+	switch lower {
+	case "chnfe", "chcte", "chmdfe", "chbpe":
+		return true
+	}
+	return strings.HasPrefix(lower, "chave")
+}
+
+// chaveAcesso troca o CNPJ do emitente (posições 7 a 20) e recalcula o
+// dígito verificador.
+func (a *anonymizer) chaveAcesso(chave string) string {
+	cnpj := chave[6:20]
+	fake := a.cpfCnpj(cnpj)
+	if fake == cnpj {
+		return chave
+	}
+	return brdoc.CompleteChaveAcesso(chave[:6] + fake + chave[20:43])
+}
+
+// freeText troca as chaves de acesso marcadas no XML e os valores conhecidos
+// do cadastro de pessoas dentro de qualquer texto.
+func (a *anonymizer) freeText(s string) string {
+	if len(s) < 8 {
+		return s
+	}
+	if len(s) >= 44 {
+		s = chaveTagPattern.ReplaceAllStringFunc(s, func(m string) string {
+			prefix := m[:len(m)-44]
+			return prefix + a.chaveAcesso(m[len(prefix):])
+		})
+	}
+	if a.text != nil {
+		s = a.text.Replace(s)
+	}
+	if len(a.digitPairs) > 0 {
+		s = digitRunPattern.ReplaceAllStringFunc(s, func(run string) string {
+			if fake, ok := a.digitPairs[run]; ok {
+				return fake
+			}
+			return run
+		})
+	}
+	return s
+}
+
+// binary trata XMLs gravados como binário (puros ou compactados com gzip).
+func (a *anonymizer) binary(b primitive.Binary) (interface{}, bool) {
+	if b.Subtype != 0x00 || len(b.Data) == 0 {
+		return b, false
+	}
+
+	data := b.Data
+	compressed := len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+	if compressed {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return b, false
+		}
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return b, false
+		}
+	}
+	if !utf8.Valid(data) || !bytes.Contains(data, []byte("<")) {
+		return b, false
+	}
+
+	text := a.freeText(string(data))
+	if text == string(data) {
+		return b, false
+	}
+
+	out := []byte(text)
+	if compressed {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(out)
+		w.Close()
+		out = buf.Bytes()
+	}
+	return primitive.Binary{Subtype: b.Subtype, Data: out}, true
+}
@@ -0,0 +1,267 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+
+	"BMongo-VIP/internal/brdoc"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	testCPF  = brdoc.CompleteCPF("123456789")
+	testCNPJ = brdoc.CompleteCNPJ("112223330001")
+)
+
+func testCliente(id primitive.ObjectID) bson.D {
+	return bson.D{
+		{Key: "_id", Value: id},
+		{Key: "_t", Value: bson.A{"Pessoa", "PessoaFisica", "Cliente"}},
+		{Key: "Nome", Value: "MARIA DA SILVA SOUZA"},
+		{Key: "CpfCnpj", Value: brdoc.FormatCPF(testCPF)},
+		{Key: "TelefonePrincipal", Value: "(11) 98765-4321"},
+	}
+}
+
+func testEmitente(id primitive.ObjectID) bson.D {
+	return bson.D{
+		{Key: "_id", Value: id},
+		{Key: "_t", Value: bson.A{"Pessoa", "PessoaJuridica", "Emitente"}},
+		{Key: "Nome", Value: "MERCADO BOM PRECO LTDA"},
+		{Key: "CpfCnpj", Value: testCNPJ},
+	}
+}
+
+// anonymizeDocs passa os cadastros e depois os demais documentos, como a
+// anonimização faz com Pessoas antes das outras coleções.
+func anonymizeDocs(secret string, keepEmitentes bool, people []bson.D, others ...bson.D) ([]bson.D, []bson.D) {
+	a := newAnonymizer([]byte(secret), keepEmitentes)
+	if keepEmitentes {
+		for _, p := range people {
+			if hasAnyType(lookupD(p, "_t"), "Emitente") {
+				a.keep(p)
+			}
+		}
+	}
+	outPeople := make([]bson.D, len(people))
+	for i, p := range people {
+		v, _ := a.value("", cloneD(p))
+		outPeople[i] = v.(bson.D)
+	}
+	a.buildText()
+	outOthers := make([]bson.D, len(others))
+	for i, o := range others {
+		v, _ := a.value("", cloneD(o))
+		outOthers[i] = v.(bson.D)
+	}
+	return outPeople, outOthers
+}
+
+func cloneD(d bson.D) bson.D {
+	data, err := bson.Marshal(d)
+	if err != nil {
+		panic(err)
+	}
+	var out bson.D
+	if err := bson.Unmarshal(data, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// digitMask troca os dígitos por # para comparar só a pontuação.
+func digitMask(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return '#'
+		}
+		return r
+	}, s)
+}
+
+func lookupD(d bson.D, path ...string) interface{} {
+	var cur interface{} = d
+	for _, key := range path {
+		doc, ok := cur.(bson.D)
+		if !ok {
+			return nil
+		}
+		cur = nil
+		for _, e := range doc {
+			if e.Key == key {
+				cur = e.Value
+			}
+		}
+	}
+	return cur
+}
+
+func TestAnonymizeDeterministic(t *testing.T) {
+	id := primitive.NewObjectID()
+	first, _ := anonymizeDocs("segredo", false, []bson.D{testCliente(id)})
+	second, _ := anonymizeDocs("segredo", false, []bson.D{testCliente(id)})
+	other, _ := anonymizeDocs("outro segredo", false, []bson.D{testCliente(id)})
+
+	for _, key := range []string{"Nome", "CpfCnpj", "TelefonePrincipal"} {
+		a, b, c := lookupD(first[0], key), lookupD(second[0], key), lookupD(other[0], key)
+		if a != b {
+			t.Errorf("%s com a mesma chave: %v ≠ %v", key, a, b)
+		}
+		if a == c {
+			t.Errorf("%s igual com chaves diferentes: %v", key, a)
+		}
+		if a == lookupD(testCliente(id), key) {
+			t.Errorf("%s não foi trocado: %v", key, a)
+		}
+	}
+}
+
+func TestAnonymizeDocumentsStayValid(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid func(string) bool
+	}{
+		{"CPF formatado", brdoc.FormatCPF(testCPF), brdoc.IsValidCPF},
+		{"CPF só dígitos", testCPF, brdoc.IsValidCPF},
+		{"CNPJ formatado", brdoc.FormatCNPJ(testCNPJ), brdoc.IsValidCNPJ},
+		{"CNPJ só dígitos", testCNPJ, brdoc.IsValidCNPJ},
+	}
+	a := newAnonymizer([]byte("segredo"), false)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := a.cpfCnpj(c.value)
+			if fake == c.value || !c.valid(fake) {
+				t.Fatalf("cpfCnpj(%s) = %s, esperado documento válido diferente", c.value, fake)
+			}
+			if digitMask(fake) != digitMask(c.value) {
+				t.Fatalf("cpfCnpj(%s) = %s: pontuação alterada", c.value, fake)
+			}
+		})
+	}
+
+	// Matriz e filial continuam com a mesma raiz.
+	filial := brdoc.CompleteCNPJ(testCNPJ[:8] + "0002")
+	if a.cpfCnpj(filial)[:8] != a.cpfCnpj(testCNPJ)[:8] {
+		t.Error("raiz do CNPJ da filial diferente da matriz")
+	}
+}
+
+func TestAnonymizeEmbeddedCopies(t *testing.T) {
+	id := primitive.NewObjectID()
+	venda := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "Pessoa", Value: bson.D{
+			{Key: "_id", Value: id},
+			{Key: "Nome", Value: "MARIA DA SILVA SOUZA"},
+			{Key: "CpfCnpj", Value: testCPF},
+		}},
+		{Key: "Observacao", Value: "Cliente MARIA DA SILVA SOUZA, CPF " + testCPF + ", fone 11987654321"},
+	}
+
+	people, others := anonymizeDocs("segredo", false, []bson.D{testCliente(id)}, venda)
+	nome := lookupD(people[0], "Nome").(string)
+	cpf := brdoc.OnlyDigits(lookupD(people[0], "CpfCnpj").(string))
+
+	if got := lookupD(others[0], "Pessoa", "Nome"); got != nome {
+		t.Errorf("nome na venda = %v, esperado %s", got, nome)
+	}
+	if got := lookupD(others[0], "Pessoa", "CpfCnpj"); got != cpf {
+		t.Errorf("CPF na venda = %v, esperado %s", got, cpf)
+	}
+	obs := lookupD(others[0], "Observacao").(string)
+	if strings.Contains(obs, "MARIA DA SILVA") || strings.Contains(obs, testCPF) || strings.Contains(obs, "987654321") {
+		t.Errorf("observação com dados originais: %s", obs)
+	}
+	if !strings.Contains(obs, nome) || !strings.Contains(obs, cpf) {
+		t.Errorf("observação sem os dados fictícios do cadastro: %s", obs)
+	}
+}
+
+func TestAnonymizeDigitsOnlyWholeTokens(t *testing.T) {
+	_, others := anonymizeDocs("segredo", false, []bson.D{testCliente(primitive.NewObjectID())},
+		bson.D{{Key: "Texto", Value: "pedido 9" + testCPF + " e código " + testCPF + "1 e CPF " + testCPF + "."}})
+
+	text := lookupD(others[0], "Texto").(string)
+	if !strings.Contains(text, "9"+testCPF) || !strings.Contains(text, testCPF+"1") {
+		t.Errorf("números maiores que contêm o CPF foram alterados: %s", text)
+	}
+	if strings.Contains(text, "CPF "+testCPF+".") {
+		t.Errorf("CPF isolado não foi trocado: %s", text)
+	}
+}
+
+func TestAnonymizeKeepEmitentes(t *testing.T) {
+	emitenteID, clienteID := primitive.NewObjectID(), primitive.NewObjectID()
+	nota := bson.D{
+		{Key: "Empresa", Value: bson.D{
+			{Key: "_id", Value: emitenteID},
+			{Key: "Nome", Value: "MERCADO BOM PRECO LTDA"},
+			{Key: "CpfCnpj", Value: testCNPJ},
+		}},
+		{Key: "Pessoa", Value: bson.D{
+			{Key: "_id", Value: clienteID},
+			{Key: "Nome", Value: "MARIA DA SILVA SOUZA"},
+			{Key: "CpfCnpj", Value: testCPF},
+		}},
+	}
+
+	people, others := anonymizeDocs("segredo", true, []bson.D{testEmitente(emitenteID), testCliente(clienteID)}, nota)
+
+	if got := lookupD(people[0], "Nome"); got != "MERCADO BOM PRECO LTDA" {
+		t.Errorf("emitente alterado: %v", got)
+	}
+	if got := lookupD(others[0], "Empresa", "CpfCnpj"); got != testCNPJ {
+		t.Errorf("CNPJ do emitente na nota alterado: %v", got)
+	}
+	if got := lookupD(others[0], "Pessoa", "CpfCnpj"); got == testCPF {
+		t.Error("CPF do cliente na nota não foi trocado")
+	}
+
+	_, others = anonymizeDocs("segredo", false, []bson.D{testEmitente(emitenteID)}, nota)
+	if got := lookupD(others[0], "Empresa", "CpfCnpj"); got == testCNPJ {
+		t.Error("sem KeepEmitentes o CNPJ do emitente deveria ser trocado")
+	}
+}
+
+func TestAnonymizeChaveOnlyInChaveContext(t *testing.T) {
+	chave := brdoc.CompleteChaveAcesso("352001" + testCNPJ + "55001000000001100000001")
+	boleto := "23793381286000000000300000000400184340000010000"[:44]
+	linha := "23793.38128 60000.000003 00000.000400 1 84340000010000"
+	xml := `<infNFe Id="NFe` + chave + `"><chNFe>` + chave + `</chNFe><obsCont>boleto ` + boleto + `</obsCont></infNFe>`
+
+	untouched := map[string]string{
+		"CodigoBarras":          boleto,
+		"LinhaDigitavel":        linha,
+		"LinhaDigitavelNumeros": brdoc.OnlyDigits(linha),
+		"Observacao":            "boleto " + boleto,
+	}
+	doc := bson.D{{Key: "ChaveAcesso", Value: chave}, {Key: "Xml", Value: xml}}
+	for key, v := range untouched {
+		doc = append(doc, bson.E{Key: key, Value: v})
+	}
+
+	_, others := anonymizeDocs("segredo", false, nil, doc)
+	doc = others[0]
+
+	for key, want := range untouched {
+		if got := lookupD(doc, key); got != want {
+			t.Errorf("%s alterado: %v", key, got)
+		}
+	}
+
+	fake := lookupD(doc, "ChaveAcesso").(string)
+	if fake == chave || len(fake) != 44 || brdoc.CompleteChaveAcesso(fake[:43]) != fake {
+		t.Errorf("chave anonimizada inválida: %s", fake)
+	}
+	if fake[:6] != chave[:6] || fake[20:43] != chave[20:43] {
+		t.Errorf("chave anonimizada alterou mais que o CNPJ: %s", fake)
+	}
+
+	want := `<infNFe Id="NFe` + fake + `"><chNFe>` + fake + `</chNFe><obsCont>boleto ` + boleto + `</obsCont></infNFe>`
+	if got := lookupD(doc, "Xml"); got != want {
+		t.Errorf("xml = %v\nesperado %s", got, want)
+	}
+}
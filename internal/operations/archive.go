@@ -0,0 +1,680 @@
+package operations
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"BMongo-VIP/internal/windows"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type archiveKind int
+
+const (
+	archiveNone archiveKind = iota
+	archiveZip
+	archiveTar
+	archiveTarGz
+)
+
+func detectArchiveKind(filePath string) archiveKind {
+	lower := strings.ToLower(filePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveTar
+	}
+	return archiveNone
+}
+
+// cleanArchiveName normaliza o nome de uma entrada de arquivo compactado e
+// rejeita caminhos absolutos, letras de unidade e qualquer segmento "..".
+func cleanArchiveName(name string) (string, error) {
+	n := strings.ReplaceAll(name, `\`, "/")
+
+	if n == "" || strings.ContainsRune(n, 0) {
+		return "", fmt.Errorf("caminho inválido no arquivo: %q", name)
+	}
+	if strings.HasPrefix(n, "/") || (len(n) >= 2 && n[1] == ':') {
+		return "", fmt.Errorf("caminho absoluto não permitido no arquivo: %s", name)
+	}
+	for _, part := range strings.Split(n, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("caminho inválido no arquivo: %s", name)
+		}
+	}
+
+	return path.Clean(n), nil
+}
+
+func safeArchivePath(destDir, name string) (string, error) {
+	clean, err := cleanArchiveName(name)
+	if err != nil {
+		return "", err
+	}
+
+	root := filepath.Clean(destDir)
+	fpath := filepath.Join(root, filepath.FromSlash(clean))
+	if fpath != root && !strings.HasPrefix(fpath, root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("caminho inválido no arquivo: %s", name)
+	}
+
+	return fpath, nil
+}
+
+func extractZip(zipPath, destDir string, log LogFunc) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var required uint64
+	for _, f := range r.File {
+		if _, err := safeArchivePath(destDir, f.Name); err != nil {
+			return err
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("links simbólicos não são permitidos no ZIP: %s", f.Name)
+		}
+		required += f.UncompressedSize64
+	}
+
+	check := newPreflight("extração")
+	check.checkSpace("extração", destDir, required)
+	check.Log(log)
+	if err := check.Err(); err != nil {
+		return err
+	}
+
+	for _, f := range r.File {
+		fpath, _ := safeArchivePath(destDir, f.Name)
+
+		if f.FileInfo().IsDir() {
+			os.MkdirAll(fpath, os.ModePerm)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			return err
+		}
+
+		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			outFile.Close()
+			return err
+		}
+
+		// Limita a cópia ao tamanho declarado para não aceitar entradas que se expandem além do informado.
+		written, err := io.Copy(outFile, io.LimitReader(rc, int64(f.UncompressedSize64)+1))
+		outFile.Close()
+		rc.Close()
+
+		if err != nil {
+			return err
+		}
+		if uint64(written) > f.UncompressedSize64 {
+			return fmt.Errorf("entrada %s excede o tamanho declarado no ZIP", f.Name)
+		}
+	}
+
+	return nil
+}
+
+type dumpEntryKind int
+
+const (
+	dumpEntrySkip dumpEntryKind = iota
+	dumpEntryData
+	dumpEntryMetadata
+)
+
+// classifyDumpEntry identifica arquivos no layout do mongodump
+// (DigisatServer/<coleção>.bson[.gz] e <coleção>.metadata.json[.gz]). Entradas
+// de outros bancos ou fora da pasta do banco são ignoradas.
+func classifyDumpEntry(name string) (collection string, kind dumpEntryKind, gzipped bool) {
+	if path.Base(path.Dir(name)) != digisatDatabaseName {
+		return "", dumpEntrySkip, false
+	}
+
+	base := path.Base(name)
+	if strings.HasSuffix(base, ".gz") {
+		gzipped = true
+		base = strings.TrimSuffix(base, ".gz")
+	}
+
+	switch {
+	case strings.HasSuffix(base, ".metadata.json"):
+		collection = strings.TrimSuffix(base, ".metadata.json")
+		kind = dumpEntryMetadata
+	case strings.HasSuffix(base, ".bson"):
+		collection = strings.TrimSuffix(base, ".bson")
+		kind = dumpEntryData
+	default:
+		return "", dumpEntrySkip, false
+	}
+
+	if collection == "" || collection == "oplog" || strings.HasPrefix(collection, "system.") || strings.ContainsAny(collection, "$\x00") {
+		return "", dumpEntrySkip, false
+	}
+
+	return collection, kind, gzipped
+}
+
+// restoreFromTarStream restaura um .tar/.tar.gz sem extrair para disco:
+// cada <coleção>.bson é enviado direto ao stdin do mongorestore e os índices
+// dos arquivos de metadados são recriados ao final.
+func (m *Manager) restoreFromTarStream(ctx context.Context, archivePath string, kind archiveKind, toolPath string, connArgs []string, dropExisting bool, log LogFunc) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if kind == archiveTarGz {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("erro ao descompactar arquivo: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	dbName := m.conn.Database.Name()
+	metadata := make(map[string][]byte)
+	restored := make(map[string]bool)
+
+	tr := tar.NewReader(reader)
+	for {
+		if m.state.ShouldStop() {
+			return fmt.Errorf("operação cancelada")
+		}
+
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler arquivo: %w", err)
+		}
+
+		name, err := cleanArchiveName(hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return fmt.Errorf("tipo de entrada não suportado no arquivo: %s", hdr.Name)
+		}
+
+		collection, entryKind, gzipped := classifyDumpEntry(name)
+		if entryKind == dumpEntrySkip {
+			log(fmt.Sprintf("⏭️ Ignorando: %s", name))
+			continue
+		}
+
+		var entry io.Reader = tr
+		var entryGz *gzip.Reader
+		if gzipped {
+			entryGz, err = gzip.NewReader(tr)
+			if err != nil {
+				return fmt.Errorf("erro ao descompactar %s: %w", name, err)
+			}
+			entry = entryGz
+		}
+
+		if entryKind == dumpEntryMetadata {
+			data, err := io.ReadAll(entry)
+			if entryGz != nil {
+				entryGz.Close()
+			}
+			if err != nil {
+				return fmt.Errorf("erro ao ler %s: %w", name, err)
+			}
+			metadata[collection] = data
+			continue
+		}
+
+		log(fmt.Sprintf("📥 Restaurando coleção %s...", collection))
+
+		args := append([]string{}, connArgs...)
+		args = append(args, fmt.Sprintf("--db=%s", dbName), fmt.Sprintf("--collection=%s", collection))
+		if dropExisting {
+			args = append(args, "--drop")
+		}
+		args = append(args, "--dir=-")
+
+		cmd := exec.CommandContext(ctx, toolPath, args...)
+		cmd.Stdin = entry
+		output, err := cmd.CombinedOutput()
+		if entryGz != nil {
+			entryGz.Close()
+		}
+		if err != nil {
+			log(fmt.Sprintf("❌ Erro no mongorestore (%s): %s", collection, string(output)))
+			return fmt.Errorf("erro ao restaurar %s: %w - %s", collection, err, string(output))
+		}
+
+		restored[collection] = true
+	}
+
+	db := m.conn.Client.Database(dbName)
+	for collection, data := range metadata {
+		if dropExisting && !restored[collection] {
+			db.Collection(collection).Drop(ctx)
+		}
+		if err := applyDumpMetadata(ctx, db, collection, data, log); err != nil {
+			log(fmt.Sprintf("⚠️ Índices de %s não recriados: %v", collection, err))
+		}
+	}
+
+	log(fmt.Sprintf("✅ %d coleções restauradas via streaming", len(restored)))
+	return nil
+}
+
+func applyDumpMetadata(ctx context.Context, db *mongo.Database, collection string, data []byte, log LogFunc) error {
+	var meta struct {
+		Indexes []bson.D `bson:"indexes"`
+	}
+	if err := bson.UnmarshalExtJSON(data, false, &meta); err != nil {
+		return fmt.Errorf("metadados inválidos: %w", err)
+	}
+
+	var indexes bson.A
+	for _, idx := range meta.Indexes {
+		spec := bson.D{}
+		isID := false
+		for _, e := range idx {
+			switch e.Key {
+			case "v", "ns":
+				continue
+			case "name":
+				if e.Value == "_id_" {
+					isID = true
+				}
+			}
+			spec = append(spec, e)
+		}
+		if !isID {
+			indexes = append(indexes, spec)
+		}
+	}
+
+	if len(indexes) == 0 {
+		err := db.CreateCollection(ctx, collection)
+		if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == 48 {
+			return nil
+		}
+		return err
+	}
+
+	cmd := bson.D{{Key: "createIndexes", Value: collection}, {Key: "indexes", Value: indexes}}
+	if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+		return err
+	}
+
+	log(fmt.Sprintf("   🔑 %s: %d índices recriados", collection, len(indexes)))
+	return nil
+}
+
+// packBackupArchive compacta uma pasta de backup em .tar.gz mantendo o layout
+// do mongodump, de modo que o arquivo possa ser restaurado via streaming. O
+// arquivo é gravado com outro nome e renomeado só ao final, para que uma
+// compactação interrompida nunca deixe um .tar.gz truncado no lugar.
+func packBackupArchive(srcDir, archivePath string) error {
+	tmpPath := archivePath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo: %w", err)
+	}
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	// O manifest vai na frente para que possa ser lido sem percorrer o
+	// arquivo inteiro.
+	manifestPath := filepath.Join(srcDir, backupManifestFile)
+	if info, statErr := os.Stat(manifestPath); statErr == nil {
+		err = addTarFile(tw, manifestPath, backupManifestFile, info)
+	}
+
+	walkErr := filepath.Walk(srcDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil || rel == "." || p == manifestPath {
+			return err
+		}
+
+		return addTarFile(tw, p, filepath.ToSlash(rel), info)
+	})
+	if err == nil {
+		err = walkErr
+	}
+
+	if cerr := tw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, archivePath)
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("erro ao compactar backup: %w", err)
+	}
+	return nil
+}
+
+func addTarFile(tw *tar.Writer, filePath, name string, info os.FileInfo) error {
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// verifyBackupArchive lê o .tar.gz inteiro e confirma que o gzip e o tar
+// terminam corretamente.
+func verifyBackupArchive(archivePath string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return err
+		}
+	}
+	// Garante que o gzip também chegou ao fim e teve o CRC conferido.
+	_, err = io.Copy(io.Discard, gz)
+	return err
+}
+
+// archiveBaseName devolve o nome do arquivo sem a extensão do formato
+// compactado (backup_x.tar.gz → backup_x).
+func archiveBaseName(archivePath string) string {
+	name := filepath.Base(archivePath)
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// readArchiveFile devolve o conteúdo da primeira entrada aceita por match,
+// ou os.ErrNotExist quando nenhuma é encontrada.
+func readArchiveFile(archivePath string, kind archiveKind, match func(name string) bool) ([]byte, error) {
+	if kind == archiveZip {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		for _, f := range r.File {
+			name, err := cleanArchiveName(f.Name)
+			if err != nil || f.FileInfo().IsDir() || !match(name) {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			return data, err
+		}
+		return nil, os.ErrNotExist
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if kind == archiveTarGz {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, os.ErrNotExist
+		}
+		if err != nil {
+			return nil, err
+		}
+		name, err := cleanArchiveName(hdr.Name)
+		if err != nil || hdr.Typeflag != tar.TypeReg || !match(name) {
+			continue
+		}
+		return io.ReadAll(tr)
+	}
+}
+
+// extractTar extrai um .tar/.tar.gz para destDir com as mesmas proteções do
+// ZIP: sem caminhos fora da pasta e apenas arquivos e pastas comuns.
+func extractTar(archivePath string, kind archiveKind, destDir string, log LogFunc) error {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return err
+	}
+	required := uint64(info.Size())
+	if kind == archiveTarGz {
+		required = gzipUncompressedSize(archivePath, required)
+	}
+
+	check := newPreflight("extração")
+	check.checkSpace("extração", destDir, required)
+	check.Log(log)
+	if err := check.Err(); err != nil {
+		return err
+	}
+
+	// O tamanho descompactado de um .tar.gz é só uma estimativa (o gzip guarda
+	// o tamanho módulo 4 GiB), então o limite passa a ser o espaço livre.
+	budget := int64(required)
+	if kind == archiveTarGz {
+		if free, err := windows.FreeDiskSpace(destDir); err == nil && free > required {
+			budget = int64(free)
+		}
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if kind == archiveTarGz {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("erro ao descompactar arquivo: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler arquivo: %w", err)
+		}
+
+		fpath, err := safeArchivePath(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		case tar.TypeReg:
+		default:
+			return fmt.Errorf("tipo de entrada não suportado no arquivo: %s", hdr.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		err = copyArchiveEntry(out, tr, hdr.Name, hdr.Size, &budget)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// copyArchiveEntry copia uma entrada limitada ao tamanho declarado e desconta
+// o que foi gravado de budget, recusando entradas que se expandem além disso.
+func copyArchiveEntry(dst io.Writer, src io.Reader, name string, size int64, budget *int64) error {
+	limit := size
+	if *budget < limit {
+		limit = *budget
+	}
+	written, err := io.Copy(dst, io.LimitReader(src, limit+1))
+	*budget -= written
+	if err != nil {
+		return err
+	}
+	if written > size {
+		return fmt.Errorf("entrada %s excede o tamanho declarado no arquivo", name)
+	}
+	if *budget < 0 {
+		return fmt.Errorf("entrada %s excede o espaço previsto para a extração", name)
+	}
+	return nil
+}
+
+// extractBackupArchive extrai um backup compactado para dentro de stagingDir e
+// devolve a pasta que contém o manifest (a raiz ou uma pasta de primeiro
+// nível, conforme o arquivo foi gerado).
+func extractBackupArchive(archivePath, stagingDir string, log LogFunc) (string, error) {
+	dest := filepath.Join(stagingDir, archiveBaseName(archivePath))
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return "", fmt.Errorf("erro ao criar pasta temporária: %w", err)
+	}
+
+	log(fmt.Sprintf("📂 Extraindo %s...", filepath.Base(archivePath)))
+	var err error
+	switch kind := detectArchiveKind(archivePath); kind {
+	case archiveZip:
+		err = extractZip(archivePath, dest, log)
+	case archiveTar, archiveTarGz:
+		err = extractTar(archivePath, kind, dest, log)
+	default:
+		return archivePath, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao extrair %s: %w", filepath.Base(archivePath), err)
+	}
+
+	if fileExists(filepath.Join(dest, backupManifestFile)) {
+		return dest, nil
+	}
+	entries, _ := os.ReadDir(dest)
+	for _, entry := range entries {
+		if entry.IsDir() && fileExists(filepath.Join(dest, entry.Name(), backupManifestFile)) {
+			return filepath.Join(dest, entry.Name()), nil
+		}
+	}
+	return dest, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
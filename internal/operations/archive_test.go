@@ -0,0 +1,192 @@
+package operations
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSafeArchivePath(t *testing.T) {
+	dest := t.TempDir()
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"DigisatServer/Pessoas.bson", filepath.Join(dest, "DigisatServer", "Pessoas.bson"), true},
+		{"./DigisatServer/manifest.json", filepath.Join(dest, "DigisatServer", "manifest.json"), true},
+		{`DigisatServer\Pessoas.bson`, filepath.Join(dest, "DigisatServer", "Pessoas.bson"), true},
+		{"../fora.bson", "", false},
+		{"DigisatServer/../../fora.bson", "", false},
+		{`..\fora.bson`, "", false},
+		{"/etc/passwd", "", false},
+		{"C:/Windows/win.ini", "", false},
+		{`C:\Windows\win.ini`, "", false},
+		{"a\x00b", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := safeArchivePath(dest, tt.name)
+		if tt.ok != (err == nil) {
+			t.Errorf("safeArchivePath(%q) erro = %v, esperado ok=%v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && got != tt.want {
+			t.Errorf("safeArchivePath(%q) = %s, esperado %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectArchiveKind(t *testing.T) {
+	tests := map[string]archiveKind{
+		`C:\backup\dump.ZIP`: archiveZip,
+		"dump.tar.gz":        archiveTarGz,
+		"dump.tgz":           archiveTarGz,
+		"dump.tar":           archiveTar,
+		`C:\backup\dump`:     archiveNone,
+		"Pessoas.bson.gz":    archiveNone,
+	}
+	for name, want := range tests {
+		if got := detectArchiveKind(name); got != want {
+			t.Errorf("detectArchiveKind(%q) = %v, esperado %v", name, got, want)
+		}
+	}
+}
+
+func TestClassifyDumpEntry(t *testing.T) {
+	tests := []struct {
+		name       string
+		collection string
+		kind       dumpEntryKind
+		gzipped    bool
+	}{
+		{"backup/DigisatServer/Pessoas.bson", "Pessoas", dumpEntryData, false},
+		{"DigisatServer/Pessoas.bson.gz", "Pessoas", dumpEntryData, true},
+		{"DigisatServer/Pessoas.metadata.json", "Pessoas", dumpEntryMetadata, false},
+		{"DigisatServer/Pessoas.metadata.json.gz", "Pessoas", dumpEntryMetadata, true},
+		{"admin/system.users.bson", "", dumpEntrySkip, false},
+		{"DigisatServer/system.indexes.bson", "", dumpEntrySkip, false},
+		{"DigisatServer/oplog.bson", "", dumpEntrySkip, false},
+		{"DigisatServer/manifest.json", "", dumpEntrySkip, false},
+		{"Pessoas.bson", "", dumpEntrySkip, false},
+	}
+	for _, tt := range tests {
+		collection, kind, gzipped := classifyDumpEntry(tt.name)
+		if collection != tt.collection || kind != tt.kind || gzipped != tt.gzipped {
+			t.Errorf("classifyDumpEntry(%q) = (%q, %v, %v), esperado (%q, %v, %v)",
+				tt.name, collection, kind, gzipped, tt.collection, tt.kind, tt.gzipped)
+		}
+	}
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	body     string
+}
+
+func writeTarGz(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: 0644, Size: int64(len(e.body))}
+		if e.typeflag == tar.TypeSymlink {
+			hdr.Linkname, hdr.Size = "/etc/passwd", 0
+		}
+		if e.typeflag == tar.TypeDir {
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestExtractTar(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		wantErr string
+	}{
+		{"válido", []tarEntry{
+			{"DigisatServer/", tar.TypeDir, ""},
+			{"DigisatServer/Pessoas.bson", tar.TypeReg, "conteudo"},
+		}, ""},
+		{"path traversal", []tarEntry{{"../fora.bson", tar.TypeReg, "x"}}, "caminho inválido"},
+		{"caminho absoluto", []tarEntry{{"/tmp/fora.bson", tar.TypeReg, "x"}}, "caminho absoluto"},
+		{"link simbólico", []tarEntry{{"DigisatServer/link", tar.TypeSymlink, ""}}, "tipo de entrada não suportado"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := writeTarGz(t, tt.entries)
+			dest := t.TempDir()
+			err := extractTar(archive, archiveTarGz, dest, func(string) {})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(filepath.Join(dest, "DigisatServer", "Pessoas.bson"))
+			if err != nil || string(data) != "conteudo" {
+				t.Errorf("arquivo extraído = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestCopyArchiveEntryLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		size       int64
+		budget     int64
+		wantErr    string
+		wantBudget int64
+	}{
+		{"dentro do limite", "12345", 5, 10, "", 5},
+		{"exatamente o orçamento", "12345", 5, 5, "", 0},
+		{"maior que o declarado", "1234567890", 5, 100, "tamanho declarado", 94},
+		{"maior que o orçamento", "1234567890", 10, 4, "espaço previsto", -1},
+	}
+	for _, tt := range tests {
+		budget := tt.budget
+		var out bytes.Buffer
+		err := copyArchiveEntry(&out, strings.NewReader(tt.body), "Pessoas.bson", tt.size, &budget)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: erro inesperado %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: erro = %v, esperado %q", tt.name, err, tt.wantErr)
+		}
+		if budget != tt.wantBudget {
+			t.Errorf("%s: orçamento restante = %d, esperado %d", tt.name, budget, tt.wantBudget)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package operations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const auditLogFile = "auditoria.jsonl"
+
+// AuditEntry registra uma alteração manual feita pela ferramenta. Os valores
+// antes/depois ficam em JSON estendido para preservar os tipos BSON.
+type AuditEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	User       string    `json:"user"`
+	Operation  string    `json:"operation"`
+	Collection string    `json:"collection"`
+	DocumentID string    `json:"documentId"`
+	Field      string    `json:"field,omitempty"`
+	Before     string    `json:"before,omitempty"`
+	After      string    `json:"after,omitempty"`
+}
+
+var auditMu sync.Mutex
+
+func auditLogPath() string {
+	return configFilePath("AUDIT_LOG_FILE", auditLogFile)
+}
+
+func currentUser() string {
+	if u := os.Getenv("USERNAME"); u != "" {
+		return u
+	}
+	return os.Getenv("USER")
+}
+
+// appendAudit grava a entrada no fim do arquivo de auditoria (uma linha JSON por entrada).
+func appendAudit(entry AuditEntry) error {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.User == "" {
+		entry.User = currentUser()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(auditLogPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir log de auditoria: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// ReadAuditLog devolve as últimas limit entradas, da mais recente para a mais antiga.
+func ReadAuditLog(limit int) ([]AuditEntry, error) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	entries := make([]AuditEntry, 0)
+
+	f, err := os.Open(auditLogPath())
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir log de auditoria: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
@@ -2,10 +2,8 @@ package operations
 
 import (
 	"BMongo-VIP/internal/windows"
-	"archive/zip"
 	"context"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,9 +15,20 @@ type BackupResult struct {
 	Path      string `json:"path"`
 	Size      int64  `json:"size"`
 	Timestamp string `json:"timestamp"`
+	Remote    bool   `json:"remote"`
+	Type      string `json:"type,omitempty"`
+}
+
+type BackupOptions struct {
+	Upload      bool `json:"upload"`
+	Incremental bool `json:"incremental"`
 }
 
 func (m *Manager) BackupDatabase(outputDir string, log LogFunc) (*BackupResult, error) {
+	return m.BackupDatabaseWithOptions(outputDir, BackupOptions{}, log)
+}
+
+func (m *Manager) BackupDatabaseWithOptions(outputDir string, opts BackupOptions, log LogFunc) (*BackupResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
 
@@ -29,17 +38,43 @@ func (m *Manager) BackupDatabase(outputDir string, log LogFunc) (*BackupResult,
 
 	log("🔄 Iniciando backup do banco de dados...")
 
-	host := os.Getenv("DB_HOST")
-	user := os.Getenv("DB_USER")
-	pass := os.Getenv("DB_PASS")
-	port := "12220"
-	dbName := "DigisatServer"
+	if opts.Incremental {
+		if backups := findBackupsWithManifest(outputDir); len(backups) > 0 {
+			preflight := m.preflightBackup(outputDir, false)
+			preflight.Log(log)
+			if err := preflight.Err(); err != nil {
+				return nil, err
+			}
 
-	if host == "" || user == "" || pass == "" {
-		return nil, fmt.Errorf("variáveis de ambiente DB_HOST, DB_USER, DB_PASS devem estar definidas")
+			result, err := m.incrementalBackup(ctx, outputDir, backups[len(backups)-1], log)
+			if err != nil {
+				return nil, err
+			}
+			if opts.Upload {
+				if _, err := m.UploadBackup(result.Path, log); err != nil {
+					log(fmt.Sprintf("❌ Backup local mantido, mas o envio falhou: %v", err))
+					return result, err
+				}
+			}
+			return result, nil
+		}
+		log("ℹ️ Nenhum backup anterior com manifest encontrado: executando backup completo.")
+	}
+
+	preflight := m.PreflightBackup(outputDir)
+	preflight.Log(log)
+	if err := preflight.Err(); err != nil {
+		return nil, err
 	}
 
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	connArgs, err := mongoToolConnArgs()
+	if err != nil {
+		return nil, err
+	}
+	dbName := "DigisatServer"
+
+	startedAt := time.Now()
+	timestamp := startedAt.Format("2006-01-02_15-04-05")
 	backupPath := filepath.Join(outputDir, fmt.Sprintf("backup_%s", timestamp))
 
 	if err := os.MkdirAll(backupPath, 0755); err != nil {
@@ -48,14 +83,10 @@ func (m *Manager) BackupDatabase(outputDir string, log LogFunc) (*BackupResult,
 
 	log(fmt.Sprintf("📁 Diretório de backup: %s", backupPath))
 
-	args := []string{
-		fmt.Sprintf("--host=%s:%s", host, port),
-		fmt.Sprintf("--username=%s", user),
-		fmt.Sprintf("--password=%s", pass),
-		"--authenticationDatabase=admin",
+	args := append(connArgs,
 		fmt.Sprintf("--db=%s", dbName),
 		fmt.Sprintf("--out=%s", backupPath),
-	}
+	)
 
 	log("🚀 Executando mongodump...")
 
@@ -76,6 +107,10 @@ func (m *Manager) BackupDatabase(outputDir string, log LogFunc) (*BackupResult,
 
 	log(string(output))
 
+	if _, err := writeBackupManifest(backupPath, dbName, timestamp, startedAt); err != nil {
+		log(fmt.Sprintf("⚠️ Erro ao gerar manifest: %v", err))
+	}
+
 	var totalSize int64
 	err = filepath.Walk(backupPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -95,43 +130,105 @@ func (m *Manager) BackupDatabase(outputDir string, log LogFunc) (*BackupResult,
 		Path:      backupPath,
 		Size:      totalSize,
 		Timestamp: timestamp,
+		Type:      BackupTypeFull,
 	}
 
 	log(fmt.Sprintf("✅ Backup concluído! Tamanho: %.2f MB", float64(totalSize)/1024/1024))
 
+	if opts.Upload {
+		if _, err := m.UploadBackup(backupPath, log); err != nil {
+			log(fmt.Sprintf("❌ Backup local mantido, mas o envio falhou: %v", err))
+			return result, err
+		}
+	}
+
 	return result, nil
 }
 
 func (m *Manager) RestoreDatabase(backupPath string, dropExisting bool, log LogFunc) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
-	defer cancel()
-
 	if m.state.ShouldStop() {
 		return fmt.Errorf("operação cancelada")
 	}
 
 	log("🔄 Iniciando restauração do banco de dados...")
 
-	var tempDir string
-	var cleanupTemp bool
-	if strings.HasSuffix(strings.ToLower(backupPath), ".zip") {
+	locator := m.newBackupLocator(backupPath, log)
+	defer locator.cleanup()
+
+	if isRemoteBackupPath(backupPath) {
+		localPath, err := locator.download(backupPath)
+		if err != nil {
+			return err
+		}
+		backupPath = localPath
+	}
+
+	// Um incremental restaurado sozinho (com --drop) apagaria tudo que não
+	// mudou desde o backup base, por isso o manifest é lido também de dentro
+	// de arquivos compactados.
+	manifest, err := readBackupManifestAt(backupPath)
+	switch {
+	case err == nil && manifest.Type == BackupTypeIncremental:
+		log("🔗 Backup incremental detectado: restaurando a cadeia completa")
+		return m.restoreBackupChain(backupPath, locator, dropExisting, log)
+	case err != nil && !os.IsNotExist(err):
+		return fmt.Errorf("erro ao ler manifest do backup: %w", err)
+	}
+
+	if err := m.restoreFull(backupPath, dropExisting, log); err != nil {
+		return err
+	}
+
+	log("✅ Restauração concluída com sucesso! Reiniciando serviços do Digisat...")
+	if _, err := windows.StartDigisatServices(log); err != nil {
+		log(fmt.Sprintf("⚠️ Aviso: Falha ao reiniciar serviços: %v", err))
+	}
+	return nil
+}
+
+// restoreFull executa o mongorestore de um backup completo local (pasta,
+// .zip ou .tar/.tar.gz), sem reiniciar os serviços do Digisat.
+func (m *Manager) restoreFull(backupPath string, dropExisting bool, log LogFunc) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	preflight := m.PreflightRestore(backupPath)
+	preflight.Log(log)
+	if err := preflight.Err(); err != nil {
+		return err
+	}
+
+	connArgs, err := mongoToolConnArgs()
+	if err != nil {
+		return err
+	}
+
+	mongorestorePath := findMongoTool("mongorestore")
+	if mongorestorePath == "" {
+		return fmt.Errorf("mongorestore não encontrado. Verifique se MongoDB Tools está instalado")
+	}
+
+	switch detectArchiveKind(backupPath) {
+	case archiveTar, archiveTarGz:
+		log(fmt.Sprintf("📦 Detectado arquivo TAR: %s (restauração em streaming)", filepath.Base(backupPath)))
+		log(fmt.Sprintf("📍 Usando: %s", mongorestorePath))
+
+		return m.restoreFromTarStream(ctx, backupPath, detectArchiveKind(backupPath), mongorestorePath, connArgs, dropExisting, log)
+
+	case archiveZip:
 		log(fmt.Sprintf("📦 Detectado arquivo ZIP: %s", filepath.Base(backupPath)))
 
-		var err error
-		tempDir, err = os.MkdirTemp("", "digisat_restore_")
+		tempDir, err := os.MkdirTemp("", "digisat_restore_")
 		if err != nil {
 			return fmt.Errorf("erro ao criar pasta temporária: %w", err)
 		}
-		cleanupTemp = true
 		defer func() {
-			if cleanupTemp {
-				log(fmt.Sprintf("🧹 Limpando pasta temporária: %s", tempDir))
-				os.RemoveAll(tempDir)
-			}
+			log(fmt.Sprintf("🧹 Limpando pasta temporária: %s", tempDir))
+			os.RemoveAll(tempDir)
 		}()
 
 		log(fmt.Sprintf("📂 Extraindo para: %s", tempDir))
-		if err := extractZip(backupPath, tempDir); err != nil {
+		if err := extractZip(backupPath, tempDir, log); err != nil {
 			return fmt.Errorf("erro ao extrair ZIP: %w", err)
 		}
 		log("✅ ZIP extraído com sucesso!")
@@ -143,18 +240,6 @@ func (m *Manager) RestoreDatabase(backupPath string, dropExisting bool, log LogF
 		return fmt.Errorf("caminho de backup não encontrado: %s", backupPath)
 	}
 
-	_ = tempDir
-	_ = cleanupTemp
-
-	host := os.Getenv("DB_HOST")
-	user := os.Getenv("DB_USER")
-	pass := os.Getenv("DB_PASS")
-	port := "12220"
-
-	if host == "" || user == "" || pass == "" {
-		return fmt.Errorf("variáveis de ambiente DB_HOST, DB_USER, DB_PASS devem estar definidas")
-	}
-
 	log(fmt.Sprintf("📁 Restaurando de: %s", backupPath))
 
 	useGzip := false
@@ -193,13 +278,7 @@ func (m *Manager) RestoreDatabase(backupPath string, dropExisting bool, log LogF
 		}
 	}
 
-	args := []string{
-		fmt.Sprintf("--host=%s:%s", host, port),
-		fmt.Sprintf("--username=%s", user),
-		fmt.Sprintf("--password=%s", pass),
-		"--authenticationDatabase=admin",
-		"--verbose",
-	}
+	args := append(connArgs, "--verbose")
 
 	if useGzip {
 		args = append(args, "--gzip")
@@ -220,12 +299,6 @@ func (m *Manager) RestoreDatabase(backupPath string, dropExisting bool, log LogF
 	args = append(args, backupPath)
 
 	log("🚀 Executando mongorestore...")
-
-	mongorestorePath := findMongoTool("mongorestore")
-	if mongorestorePath == "" {
-		return fmt.Errorf("mongorestore não encontrado. Verifique se MongoDB Tools está instalado")
-	}
-
 	log(fmt.Sprintf("📍 Usando: %s", mongorestorePath))
 
 	cmd := exec.CommandContext(ctx, mongorestorePath, args...)
@@ -237,13 +310,6 @@ func (m *Manager) RestoreDatabase(backupPath string, dropExisting bool, log LogF
 	}
 
 	log(string(output))
-	log("✅ Restauração concluída com sucesso! Reiniciando serviços do Digisat...")
-
-	// Reiniciar serviços do Digisat
-	if _, err := windows.StartDigisatServices(log); err != nil {
-		log(fmt.Sprintf("⚠️ Aviso: Falha ao reiniciar serviços: %v", err))
-	}
-
 	return nil
 }
 
@@ -271,10 +337,16 @@ func ListBackups(backupDir string) ([]BackupResult, error) {
 				return nil
 			})
 
+			backupType := ""
+			if manifest, err := readBackupManifest(path); err == nil {
+				backupType = manifest.Type
+			}
+
 			backups = append(backups, BackupResult{
 				Path:      path,
 				Size:      size,
 				Timestamp: entry.Name()[7:],
+				Type:      backupType,
 			})
 		}
 	}
@@ -282,6 +354,24 @@ func ListBackups(backupDir string) ([]BackupResult, error) {
 	return backups, nil
 }
 
+func mongoToolConnArgs() ([]string, error) {
+	host := os.Getenv("DB_HOST")
+	user := os.Getenv("DB_USER")
+	pass := os.Getenv("DB_PASS")
+	port := "12220"
+
+	if host == "" || user == "" || pass == "" {
+		return nil, fmt.Errorf("variáveis de ambiente DB_HOST, DB_USER, DB_PASS devem estar definidas")
+	}
+
+	return []string{
+		fmt.Sprintf("--host=%s:%s", host, port),
+		fmt.Sprintf("--username=%s", user),
+		fmt.Sprintf("--password=%s", pass),
+		"--authenticationDatabase=admin",
+	}, nil
+}
+
 func findMongoTool(toolName string) string {
 
 	commonPaths := []string{
@@ -317,50 +407,3 @@ func findMongoTool(toolName string) string {
 
 	return ""
 }
-
-func extractZip(zipPath, destDir string) error {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-
-	for _, f := range r.File {
-
-		fpath := filepath.Join(destDir, f.Name)
-
-		if !strings.HasPrefix(fpath, filepath.Clean(destDir)+string(os.PathSeparator)) {
-			return fmt.Errorf("caminho inválido no ZIP: %s", f.Name)
-		}
-
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
-			return err
-		}
-
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return err
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			return err
-		}
-
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
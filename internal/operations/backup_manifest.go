@@ -0,0 +1,180 @@
+package operations
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const backupManifestFile = "manifest.json"
+
+const (
+	BackupTypeFull        = "full"
+	BackupTypeIncremental = "incremental"
+)
+
+type ManifestCollection struct {
+	Name      string `json:"name"`
+	Documents int64  `json:"documents"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type BackupManifest struct {
+	Version     int                  `json:"version"`
+	Type        string               `json:"type"`
+	Timestamp   string               `json:"timestamp"`
+	Database    string               `json:"database"`
+	StartedAt   time.Time            `json:"startedAt"`
+	FinishedAt  time.Time            `json:"finishedAt"`
+	Collections []ManifestCollection `json:"collections"`
+
+	// Somente em backups incrementais: pasta do backup anterior na cadeia,
+	// instante de corte e campo de alteração usado em cada coleção.
+	Base           string            `json:"base,omitempty"`
+	Since          *time.Time        `json:"since,omitempty"`
+	TrackingFields map[string]string `json:"trackingFields,omitempty"`
+}
+
+// writeBackupManifest descreve o conteúdo de um backup gerado pelo mongodump
+// (contagem de documentos e tamanho de cada coleção) em manifest.json.
+func writeBackupManifest(backupPath, dbName, timestamp string, startedAt time.Time) (*BackupManifest, error) {
+	manifest := &BackupManifest{
+		Version:     1,
+		Type:        BackupTypeFull,
+		Timestamp:   timestamp,
+		Database:    dbName,
+		StartedAt:   startedAt,
+		FinishedAt:  time.Now(),
+		Collections: make([]ManifestCollection, 0),
+	}
+
+	entries, err := os.ReadDir(filepath.Join(backupPath, dbName))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler backup: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".bson") || strings.HasSuffix(name, ".bson.gz")) {
+			continue
+		}
+
+		filePath := filepath.Join(backupPath, dbName, name)
+		docs, err := countBSONDocuments(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", name, err)
+		}
+
+		info, _ := entry.Info()
+		var size int64
+		if info != nil {
+			size = info.Size()
+		}
+
+		manifest.Collections = append(manifest.Collections, ManifestCollection{
+			Name:      strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".bson"),
+			Documents: docs,
+			Bytes:     size,
+		})
+	}
+
+	sort.Slice(manifest.Collections, func(i, j int) bool {
+		return manifest.Collections[i].Name < manifest.Collections[j].Name
+	})
+
+	if err := saveBackupManifest(backupPath, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func saveBackupManifest(backupPath string, manifest *BackupManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(backupPath, backupManifestFile), data, 0644)
+}
+
+func readBackupManifest(backupPath string) (*BackupManifest, error) {
+	data, err := os.ReadFile(filepath.Join(backupPath, backupManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	return parseBackupManifest(data)
+}
+
+// readBackupManifestAt lê o manifest de uma pasta de backup ou de dentro de um
+// .zip/.tar/.tar.gz (na raiz ou em uma pasta de primeiro nível). Sem manifest,
+// o erro satisfaz os.IsNotExist.
+func readBackupManifestAt(backupPath string) (*BackupManifest, error) {
+	kind := detectArchiveKind(backupPath)
+	if kind == archiveNone {
+		return readBackupManifest(backupPath)
+	}
+
+	data, err := readArchiveFile(backupPath, kind, func(name string) bool {
+		dir, base := path.Split(name)
+		return base == backupManifestFile && strings.Count(dir, "/") <= 1
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseBackupManifest(data)
+}
+
+func parseBackupManifest(data []byte) (*BackupManifest, error) {
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("manifest inválido: %w", err)
+	}
+	return &manifest, nil
+}
+
+// countBSONDocuments percorre um arquivo .bson(.gz) lendo apenas o prefixo
+// de tamanho de cada documento.
+func countBSONDocuments(filePath string) (int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(filePath, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var count int64
+	var lenBuf [4]byte
+	for {
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			if err == io.EOF {
+				return count, nil
+			}
+			return count, err
+		}
+
+		size := int64(binary.LittleEndian.Uint32(lenBuf[:]))
+		if size < 5 {
+			return count, fmt.Errorf("documento BSON inválido")
+		}
+		if _, err := io.CopyN(io.Discard, r, size-4); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
@@ -5,42 +5,30 @@ import (
 	"fmt"
 	"time"
 
-	"BMongo-VIP/internal/database"
-
 	"go.mongodb.org/mongo-driver/bson"
 )
 
 
 func (m *Manager) CleanDatabase(log LogFunc) error {
+	return m.CleanDatabaseWithProfile(DefaultCleanupProfileName, log)
+}
+
+
+func (m *Manager) CleanDatabaseWithProfile(profileName string, log LogFunc) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
 	defer cancel()
 
-	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	profile, err := findCleanupProfile(profileName)
 	if err != nil {
-		return fmt.Errorf("erro ao listar coleções: %w", err)
+		return err
 	}
 
-
-	preserve := map[string]bool{
-		"system.indexes":             true,
-		"system.users":               true,
-		"system.version":             true,
-		"startup_log":                true,
-		"ConfiguracoesServidor":      true,
-		"ConfiguracoesSincronizacao": true,
-		"DigisatUpdate":              true,
-		"Pessoas":                    true,
-		"SequenciasDocumentos":       true,
-		"Estados":                    true,
-		"Cidades":                    true,
+	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("erro ao listar coleções: %w", err)
 	}
 
-
-
-
-
-
-	log("Iniciando limpeza da base de dados...")
+	log(fmt.Sprintf("Iniciando limpeza da base de dados (perfil \"%s\")...", profile.Name))
 
 	for _, colName := range collections {
 		if m.state.ShouldStop() {
@@ -48,14 +36,19 @@ func (m *Manager) CleanDatabase(log LogFunc) error {
 			return nil
 		}
 
-		if preserve[colName] {
-			if colName == database.CollectionPessoas {
+		switch profile.action(colName) {
+		case CleanupActionKeep:
+			continue
+		case CleanupActionPartial:
+			filter, err := extJSONFilter(profile.Partial[colName])
+			if err != nil {
+				return fmt.Errorf("perfil %s, coleção %s: %w", profile.Name, colName, err)
+			}
 
-				log(fmt.Sprintf("Limpando coleção %s (mantendo Emitentes)...", colName))
-				_, err := m.conn.GetCollection(colName).DeleteMany(ctx, bson.M{"_t": bson.M{"$ne": "Emitente"}})
-				if err != nil {
-					log(fmt.Sprintf("Erro ao limpar %s: %s", colName, err.Error()))
-				}
+			log(fmt.Sprintf("Limpando coleção %s (mantendo registros do perfil)...", colName))
+			_, err = m.conn.GetCollection(colName).DeleteMany(ctx, bson.M{"$nor": []bson.M{filter}})
+			if err != nil {
+				log(fmt.Sprintf("Erro ao limpar %s: %s", colName, err.Error()))
 			}
 			continue
 		}
@@ -86,7 +79,7 @@ func (m *Manager) CreateNewDatabase(log LogFunc) error {
 }
 
 
-func (m *Manager) CleanDatabaseByDate(beforeDate string, log LogFunc) (int, error) {
+func (m *Manager) CleanDatabaseByDate(beforeDate string, archiveDir string, log LogFunc) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
 	defer cancel()
 
@@ -98,38 +91,78 @@ func (m *Manager) CleanDatabaseByDate(beforeDate string, log LogFunc) (int, erro
 		return 0, fmt.Errorf("formato de data inválido (use YYYY-MM-DD): %w", err)
 	}
 
+	if archiveDir == "" {
+		archiveDir = defaultCleanupArchiveDir()
+	}
+
+	rules, err := m.resolveCleanupRules(ctx, log)
+	if err != nil {
+		return 0, err
+	}
+
 	totalDeleted := 0
 
+	archiveName := fmt.Sprintf("limpeza_antes_%s_%s.bson.gz", beforeDate, time.Now().Format("2006-01-02_15-04-05"))
+	archive, err := createCleanupArchive(archiveDir, archiveName)
+	if err != nil {
+		return 0, err
+	}
+
+	log(fmt.Sprintf("🗄️ Arquivando registros em %s...", archive.path))
+
+	sel := newCleanupSelection()
+	for _, rule := range rules {
+		if m.state.ShouldStop() {
+			archive.discard()
+			log("Operação cancelada")
+			return totalDeleted, nil
+		}
+
+		if rule.DateField == "" {
+			log(fmt.Sprintf("⚠️ %s: nenhum campo de data encontrado, regra ignorada", rule.Collection))
+			continue
+		}
+
+		if err := m.selectForCleanup(ctx, rule, rule.filter(rule.DateField, date), archive, sel, log); err != nil {
+			archive.discard()
+			return 0, err
+		}
+	}
 
-	collections := map[string]string{
-		"Movimentacoes":          "DataMovimentacao",
-		"ContasReceber":          "DataEmissao",
-		"ContasPagar":            "DataEmissao",
-		"DocumentosFiscaisSaida": "DataEmissao",
+	if err := archive.Close(); err != nil {
+		return 0, fmt.Errorf("erro ao finalizar arquivo de limpeza: %w", err)
 	}
 
-	for collName, dateField := range collections {
+	log(fmt.Sprintf("🗄️ %d registros arquivados", archive.count))
+
+	// Remove exatamente os documentos arquivados, em lotes por _id. Os
+	// dependentes, visitados por último, são removidos antes dos principais
+	// para que uma interrupção não deixe referências apontando para o nada.
+	for i := len(sel.order) - 1; i >= 0; i-- {
+		collName := sel.order[i]
+		ids := sel.ids[collName]
 		if m.state.ShouldStop() {
 			log("Operação cancelada")
 			return totalDeleted, nil
 		}
 
 		coll := m.conn.GetCollection(collName)
-		filter := bson.M{dateField: bson.M{"$lt": date}}
-
-		result, err := coll.DeleteMany(ctx, filter)
-		if err != nil {
-			log(fmt.Sprintf("⚠️ Erro em %s: %s", collName, err.Error()))
-			continue
+		deleted := 0
+		for _, batch := range idBatches(ids) {
+			result, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": batch}})
+			if err != nil {
+				log(fmt.Sprintf("⚠️ Erro em %s: %s", collName, err.Error()))
+				break
+			}
+			deleted += int(result.DeletedCount)
 		}
 
-		deleted := int(result.DeletedCount)
 		totalDeleted += deleted
 		if deleted > 0 {
 			log(fmt.Sprintf("📦 %s: %d registros removidos", collName, deleted))
 		}
 	}
 
-	log(fmt.Sprintf("✅ Total: %d registros removidos", totalDeleted))
+	log(fmt.Sprintf("✅ Total: %d registros removidos (arquivo: %s)", totalDeleted, archive.path))
 	return totalDeleted, nil
 }
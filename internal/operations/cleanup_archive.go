@@ -0,0 +1,193 @@
+package operations
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Cada registro do arquivo de limpeza é um documento BSON
+// {collection: <nome>, document: <documento original>}, gravado em sequência
+// num fluxo gzip. O formato preserva todos os tipos BSON sem conversão.
+type archivedDocument struct {
+	Collection string   `bson:"collection"`
+	Document   bson.Raw `bson:"document"`
+}
+
+type cleanupArchiveWriter struct {
+	path  string
+	file  *os.File
+	gz    *gzip.Writer
+	w     *bufio.Writer
+	count int
+}
+
+func createCleanupArchive(dir, name string) (*cleanupArchiveWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar pasta de arquivamento: %w", err)
+	}
+
+	p := filepath.Join(dir, name)
+	f, err := os.Create(p)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de arquivamento: %w", err)
+	}
+
+	gz := gzip.NewWriter(f)
+	return &cleanupArchiveWriter{path: p, file: f, gz: gz, w: bufio.NewWriter(gz)}, nil
+}
+
+func (a *cleanupArchiveWriter) write(collection string, doc bson.Raw) error {
+	data, err := bson.Marshal(archivedDocument{Collection: collection, Document: doc})
+	if err != nil {
+		return err
+	}
+	if _, err := a.w.Write(data); err != nil {
+		return err
+	}
+	a.count++
+	return nil
+}
+
+func (a *cleanupArchiveWriter) Close() error {
+	if err := a.w.Flush(); err != nil {
+		a.file.Close()
+		return err
+	}
+	if err := a.gz.Close(); err != nil {
+		a.file.Close()
+		return err
+	}
+	if err := a.file.Sync(); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
+
+// discard fecha e apaga um arquivo de limpeza cuja operação foi abortada
+// antes de qualquer remoção.
+func (a *cleanupArchiveWriter) discard() {
+	a.Close()
+	os.Remove(a.path)
+}
+
+func defaultCleanupArchiveDir() string {
+	if exe, err := os.Executable(); err == nil {
+		return filepath.Join(filepath.Dir(exe), "arquivos_limpeza")
+	}
+	return filepath.Join(os.TempDir(), "arquivos_limpeza")
+}
+
+// RestoreCleanupArchive reimporta um arquivo gerado por CleanDatabaseByDate.
+// Documentos que já existem na base (mesmo _id) são mantidos e contados como ignorados.
+func (m *Manager) RestoreCleanupArchive(archivePath string, log LogFunc) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("arquivo de limpeza inválido: %w", err)
+	}
+	defer gz.Close()
+
+	log(fmt.Sprintf("📥 Reimportando %s...", filepath.Base(archivePath)))
+
+	r := bufio.NewReader(gz)
+	batches := make(map[string][]interface{})
+	inserted := make(map[string]int)
+	skipped := 0
+
+	flush := func(collection string) error {
+		docs := batches[collection]
+		if len(docs) == 0 {
+			return nil
+		}
+		batches[collection] = nil
+
+		failed := 0
+		_, err := m.conn.GetCollection(collection).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+		if err != nil {
+			bulkErr, ok := err.(mongo.BulkWriteException)
+			if !ok {
+				return err
+			}
+			for _, we := range bulkErr.WriteErrors {
+				if we.Code != 11000 {
+					return err
+				}
+			}
+			failed = len(bulkErr.WriteErrors)
+			skipped += failed
+		}
+		inserted[collection] += len(docs) - failed
+		return nil
+	}
+
+	for {
+		if m.state.ShouldStop() {
+			log("Operação cancelada")
+			break
+		}
+
+		raw, err := readBSONDocument(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sumCounts(inserted), fmt.Errorf("erro ao ler arquivo: %w", err)
+		}
+
+		var entry archivedDocument
+		if err := bson.Unmarshal(raw, &entry); err != nil || entry.Collection == "" {
+			return sumCounts(inserted), fmt.Errorf("registro inválido no arquivo de limpeza")
+		}
+
+		batches[entry.Collection] = append(batches[entry.Collection], entry.Document)
+		if len(batches[entry.Collection]) >= 500 {
+			if err := flush(entry.Collection); err != nil {
+				return sumCounts(inserted), fmt.Errorf("erro ao reimportar %s: %w", entry.Collection, err)
+			}
+		}
+	}
+
+	for collection := range batches {
+		if err := flush(collection); err != nil {
+			return sumCounts(inserted), fmt.Errorf("erro ao reimportar %s: %w", collection, err)
+		}
+	}
+
+	for collection, n := range inserted {
+		log(fmt.Sprintf("📦 %s: %d registros reimportados", collection, n))
+	}
+	if skipped > 0 {
+		log(fmt.Sprintf("⏭️ %d registros já existiam e foram mantidos", skipped))
+	}
+
+	total := sumCounts(inserted)
+	log(fmt.Sprintf("✅ Total: %d registros reimportados", total))
+	return total, nil
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
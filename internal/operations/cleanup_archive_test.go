@@ -0,0 +1,101 @@
+package operations
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCleanupArchiveRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "arquivos_limpeza")
+	archive, err := createCleanupArchive(dir, "limpeza.bson.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	price, _ := primitive.ParseDecimal128("10.50")
+	docs := []struct {
+		collection string
+		doc        bson.D
+	}{
+		{"Movimentacoes", bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "DataHoraEmissao", Value: primitive.NewDateTimeFromTime(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))},
+			{Key: "Total", Value: price},
+		}},
+		{"Recebimentos", bson.D{{Key: "_id", Value: int64(42)}, {Key: "Quitado", Value: false}}},
+	}
+	for _, d := range docs {
+		raw, err := bson.Marshal(d.doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := archive.write(d.collection, raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if archive.count != len(docs) {
+		t.Errorf("count = %d, esperado %d", archive.count, len(docs))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "limpeza.bson.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(gz)
+
+	for i, want := range docs {
+		raw, err := readBSONDocument(r)
+		if err != nil {
+			t.Fatalf("registro %d: %v", i, err)
+		}
+		var entry archivedDocument
+		if err := bson.Unmarshal(raw, &entry); err != nil {
+			t.Fatal(err)
+		}
+		wantRaw, _ := bson.Marshal(want.doc)
+		if entry.Collection != want.collection || !bytes.Equal(entry.Document, wantRaw) {
+			t.Errorf("registro %d = %s %v, esperado %s %v", i, entry.Collection, entry.Document, want.collection, bson.Raw(wantRaw))
+		}
+	}
+	if _, err := readBSONDocument(r); err != io.EOF {
+		t.Errorf("fim do arquivo: %v", err)
+	}
+}
+
+func TestCleanupArchiveDiscard(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := createCleanupArchive(dir, "abortada.bson.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive.discard()
+	if _, err := os.Stat(filepath.Join(dir, "abortada.bson.gz")); !os.IsNotExist(err) {
+		t.Errorf("arquivo descartado ainda existe: %v", err)
+	}
+}
+
+func TestSumCounts(t *testing.T) {
+	if got := sumCounts(map[string]int{"Movimentacoes": 3, "Recebimentos": 4}); got != 7 {
+		t.Errorf("sumCounts = %d, esperado 7", got)
+	}
+	if got := sumCounts(nil); got != 0 {
+		t.Errorf("sumCounts(nil) = %d", got)
+	}
+}
@@ -0,0 +1,396 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Política aplicada quando um dependente ainda está em aberto.
+const (
+	CleanupOnOpenRefuse = "recusar"
+	CleanupOnOpenKeep   = "manter"
+)
+
+const cleanupIDBatch = 1000
+
+// CleanupDependency descreve uma coleção cujos documentos apontam para os
+// documentos removidos por uma regra (ou por outro dependente, em
+// Dependents). Os dependentes são arquivados e removidos junto com o
+// documento principal. Quando OpenFilter casa com algum dependente, a limpeza
+// é recusada ou, com OnOpen "manter", o documento principal e seus
+// dependentes ficam fora da limpeza.
+type CleanupDependency struct {
+	Collection      string                 `json:"collection"`
+	ReferenceFields []string               `json:"referenceFields"`
+	OpenFilter      map[string]interface{} `json:"openFilter,omitempty"`
+	OnOpen          string                 `json:"onOpen,omitempty"`
+
+	Dependents []CleanupDependency `json:"dependents,omitempty"`
+}
+
+type CleanupDependencyPreview struct {
+	Collection string `json:"collection"`
+	// Parent é a coleção referenciada, quando o dependente é de outro dependente.
+	Parent string `json:"parent,omitempty"`
+	Count  int64  `json:"count"`
+	Open   int64  `json:"open"`
+}
+
+// receivableDependents são os boletos gerados para um recebimento.
+func receivableDependents() []CleanupDependency {
+	return []CleanupDependency{
+		{
+			Collection:      database.CollectionBoletos,
+			ReferenceFields: []string{"RecebimentoReferencia"},
+			OpenFilter:      map[string]interface{}{"Quitado": false},
+		},
+	}
+}
+
+func defaultMovementDependents() []CleanupDependency {
+	return []CleanupDependency{
+		{
+			Collection:      database.CollectionRecebimentos,
+			ReferenceFields: []string{"MovimentacaoReferencia", "Parcelas.MovimentacaoReferencia"},
+			OpenFilter:      map[string]interface{}{"Quitado": false},
+			Dependents:      receivableDependents(),
+		},
+		{
+			Collection:      database.CollectionBoletos,
+			ReferenceFields: []string{"MovimentacaoReferencia"},
+			OpenFilter:      map[string]interface{}{"Quitado": false},
+		},
+		{Collection: database.CollectionXmlMovimentacoes, ReferenceFields: []string{"MovimentacaoReferencia"}},
+		{Collection: database.CollectionCartasCorrecao, ReferenceFields: []string{"MovimentacaoReferencia", "DocumentoReferencia"}},
+	}
+}
+
+func (d CleanupDependency) referenceFilter(ids []interface{}) bson.M {
+	conditions := make([]bson.M, 0, len(d.ReferenceFields))
+	for _, field := range d.ReferenceFields {
+		conditions = append(conditions, bson.M{field: bson.M{"$in": ids}})
+	}
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return bson.M{"$or": conditions}
+}
+
+func (d CleanupDependency) openFilter(ids []interface{}) bson.M {
+	return bson.M{"$and": []bson.M{bson.M(d.OpenFilter), d.referenceFilter(ids)}}
+}
+
+// openPolicy decide o que fazer quando open dependentes estão em aberto:
+// manter os principais vinculados (keep) ou recusar a limpeza.
+func (d CleanupDependency) openPolicy(parent string, open int64) (keep bool, err error) {
+	if open == 0 {
+		return false, nil
+	}
+	if d.OnOpen != CleanupOnOpenKeep {
+		return false, fmt.Errorf("limpeza recusada: %d registro(s) de %s em aberto vinculados a %s", open, d.Collection, parent)
+	}
+	return true, nil
+}
+
+func idKey(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+func idBatches(ids []interface{}) [][]interface{} {
+	var batches [][]interface{}
+	for start := 0; start < len(ids); start += cleanupIDBatch {
+		end := start + cleanupIDBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches = append(batches, ids[start:end])
+	}
+	return batches
+}
+
+// cleanupSelection acumula os _id arquivados por coleção, sem repetições,
+// na ordem em que as coleções foram visitadas.
+type cleanupSelection struct {
+	order []string
+	ids   map[string][]interface{}
+	seen  map[string]map[string]bool
+}
+
+func newCleanupSelection() *cleanupSelection {
+	return &cleanupSelection{
+		ids:  make(map[string][]interface{}),
+		seen: make(map[string]map[string]bool),
+	}
+}
+
+func (s *cleanupSelection) add(collection string, id interface{}) bool {
+	seen, ok := s.seen[collection]
+	if !ok {
+		seen = make(map[string]bool)
+		s.seen[collection] = seen
+		s.order = append(s.order, collection)
+	}
+	key := idKey(id)
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	s.ids[collection] = append(s.ids[collection], id)
+	return true
+}
+
+func (m *Manager) collectIDs(ctx context.Context, collection string, filter bson.M) ([]interface{}, error) {
+	cursor, err := m.conn.GetCollection(collection).Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []interface{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID)
+	}
+	return ids, cursor.Err()
+}
+
+// checkOpenDependents devolve os _id principais que devem ser mantidos por
+// terem dependentes em aberto, ou erro quando a política do dependente é recusar.
+func (m *Manager) checkOpenDependents(ctx context.Context, rule resolvedCleanupRule, ids []interface{}, log LogFunc) (map[string]bool, error) {
+	blocked := make(map[string]bool)
+
+	for _, dep := range rule.Dependents {
+		parents, err := m.blockedParents(ctx, rule.Collection, dep, ids)
+		if err != nil {
+			return nil, err
+		}
+		for key := range parents {
+			blocked[key] = true
+		}
+	}
+
+	if len(blocked) > 0 {
+		log(fmt.Sprintf("⏸️ %s: %d registros mantidos por possuírem dependentes em aberto", rule.Collection, len(blocked)))
+	}
+	return blocked, nil
+}
+
+// blockedParents devolve, dentre ids da coleção parent, os que têm em dep (ou
+// nos dependentes de dep) documentos em aberto com a política "manter".
+func (m *Manager) blockedParents(ctx context.Context, parent string, dep CleanupDependency, ids []interface{}) (map[string]interface{}, error) {
+	blocked := make(map[string]interface{})
+	coll := m.conn.GetCollection(dep.Collection)
+
+	for _, batch := range idBatches(ids) {
+		// Filtros dos documentos de dep que impedem a remoção do principal.
+		var blocking []bson.M
+
+		if len(dep.OpenFilter) > 0 {
+			filter := dep.openFilter(batch)
+			open, err := coll.CountDocuments(ctx, filter)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao verificar %s: %w", dep.Collection, err)
+			}
+			keep, err := dep.openPolicy(parent, open)
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				blocking = append(blocking, filter)
+			}
+		}
+
+		if len(dep.Dependents) > 0 {
+			depIDs, err := m.collectIDs(ctx, dep.Collection, dep.referenceFilter(batch))
+			if err != nil {
+				return nil, fmt.Errorf("erro ao verificar %s: %w", dep.Collection, err)
+			}
+			var kept []interface{}
+			for _, sub := range dep.Dependents {
+				subBlocked, err := m.blockedParents(ctx, dep.Collection, sub, depIDs)
+				if err != nil {
+					return nil, err
+				}
+				for _, id := range subBlocked {
+					kept = append(kept, id)
+				}
+			}
+			for _, keptBatch := range idBatches(kept) {
+				blocking = append(blocking, bson.M{"$and": []bson.M{{"_id": bson.M{"$in": keptBatch}}, dep.referenceFilter(batch)}})
+			}
+		}
+
+		if len(blocking) == 0 {
+			continue
+		}
+
+		inBatch := make(map[string]interface{}, len(batch))
+		for _, id := range batch {
+			inBatch[idKey(id)] = id
+		}
+		for _, filter := range blocking {
+			for _, field := range dep.ReferenceFields {
+				values, err := coll.Distinct(ctx, field, filter)
+				if err != nil {
+					return nil, fmt.Errorf("erro ao verificar %s: %w", dep.Collection, err)
+				}
+				for _, v := range values {
+					if id, ok := inBatch[idKey(v)]; ok {
+						blocked[idKey(v)] = id
+					}
+				}
+			}
+		}
+	}
+	return blocked, nil
+}
+
+// archiveMatching arquiva os documentos ainda não selecionados que casam com
+// filter e devolve os _id de todos os que casaram.
+func (m *Manager) archiveMatching(ctx context.Context, archive *cleanupArchiveWriter, sel *cleanupSelection, collection string, filter bson.M) ([]interface{}, error) {
+	cursor, err := m.conn.GetCollection(collection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var matched []interface{}
+	for cursor.Next(ctx) {
+		var id interface{}
+		if err := cursor.Current.Lookup("_id").Unmarshal(&id); err != nil {
+			return nil, err
+		}
+		matched = append(matched, id)
+		if !sel.add(collection, id) {
+			continue
+		}
+		if err := archive.write(collection, cursor.Current); err != nil {
+			return nil, err
+		}
+	}
+	return matched, cursor.Err()
+}
+
+// archiveDependents arquiva os dependentes que apontam para parentIDs e, em
+// cascata, os dependentes deles.
+func (m *Manager) archiveDependents(ctx context.Context, archive *cleanupArchiveWriter, sel *cleanupSelection, deps []CleanupDependency, parentIDs []interface{}) error {
+	for _, dep := range deps {
+		matched, err := m.archiveMatching(ctx, archive, sel, dep.Collection, dep.referenceFilter(parentIDs))
+		if err != nil {
+			return fmt.Errorf("erro ao arquivar %s: %w", dep.Collection, err)
+		}
+		if len(dep.Dependents) == 0 {
+			continue
+		}
+		for _, batch := range idBatches(matched) {
+			if err := m.archiveDependents(ctx, archive, sel, dep.Dependents, batch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectForCleanup arquiva os documentos da regra e de seus dependentes,
+// registrando em sel os _id que serão removidos.
+func (m *Manager) selectForCleanup(ctx context.Context, rule resolvedCleanupRule, filter bson.M, archive *cleanupArchiveWriter, sel *cleanupSelection, log LogFunc) error {
+	filter, kept, err := m.excludeOpen(ctx, rule, filter)
+	if err != nil {
+		return err
+	}
+	if kept > 0 {
+		log(fmt.Sprintf("⏸️ %s: %d registros em aberto mantidos", rule.Collection, kept))
+	}
+
+	ids, err := m.collectIDs(ctx, rule.Collection, filter)
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s para arquivamento: %w", rule.Collection, err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if len(rule.Dependents) > 0 {
+		blocked, err := m.checkOpenDependents(ctx, rule, ids, log)
+		if err != nil {
+			return err
+		}
+		if len(blocked) > 0 {
+			kept := ids[:0]
+			for _, id := range ids {
+				if !blocked[idKey(id)] {
+					kept = append(kept, id)
+				}
+			}
+			ids = kept
+		}
+	}
+
+	for _, batch := range idBatches(ids) {
+		if m.state.ShouldStop() {
+			return fmt.Errorf("operação cancelada")
+		}
+		if _, err := m.archiveMatching(ctx, archive, sel, rule.Collection, bson.M{"_id": bson.M{"$in": batch}}); err != nil {
+			return fmt.Errorf("erro ao arquivar %s: %w", rule.Collection, err)
+		}
+		if err := m.archiveDependents(ctx, archive, sel, rule.Dependents, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// previewDependents conta os dependentes dos ids da regra, em cascata, e
+// registra em sel os _id encontrados para que o total da prévia não conte
+// duas vezes um documento alcançado por mais de um caminho.
+func (m *Manager) previewDependents(ctx context.Context, parent string, deps []CleanupDependency, ids []interface{}, sel *cleanupSelection, nested bool) ([]CleanupDependencyPreview, error) {
+	var previews []CleanupDependencyPreview
+	for _, dep := range deps {
+		coll := m.conn.GetCollection(dep.Collection)
+		preview := CleanupDependencyPreview{Collection: dep.Collection}
+		if nested {
+			preview.Parent = parent
+		}
+
+		var depIDs []interface{}
+		for _, batch := range idBatches(ids) {
+			found, err := m.collectIDs(ctx, dep.Collection, dep.referenceFilter(batch))
+			if err != nil {
+				return nil, err
+			}
+			depIDs = append(depIDs, found...)
+
+			if len(dep.OpenFilter) > 0 {
+				open, err := coll.CountDocuments(ctx, dep.openFilter(batch))
+				if err != nil {
+					return nil, err
+				}
+				preview.Open += open
+			}
+		}
+		preview.Count = int64(len(depIDs))
+		for _, id := range depIDs {
+			sel.add(dep.Collection, id)
+		}
+		previews = append(previews, preview)
+
+		if len(dep.Dependents) > 0 && len(depIDs) > 0 {
+			sub, err := m.previewDependents(ctx, dep.Collection, dep.Dependents, depIDs, sel, true)
+			if err != nil {
+				return nil, err
+			}
+			previews = append(previews, sub...)
+		}
+	}
+	return previews, nil
+}
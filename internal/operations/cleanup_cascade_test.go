@@ -0,0 +1,110 @@
+package operations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCleanupDependencyOpenPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		onOpen   string
+		open     int64
+		wantKeep bool
+		wantErr  bool
+	}{
+		{"nada em aberto", CleanupOnOpenRefuse, 0, false, false},
+		{"recusar", CleanupOnOpenRefuse, 2, false, true},
+		{"padrão é recusar", "", 1, false, true},
+		{"manter", CleanupOnOpenKeep, 3, true, false},
+	}
+	for _, tt := range tests {
+		dep := CleanupDependency{Collection: database.CollectionBoletos, OnOpen: tt.onOpen}
+		keep, err := dep.openPolicy(database.CollectionMovimentacoes, tt.open)
+		if keep != tt.wantKeep || (err != nil) != tt.wantErr {
+			t.Errorf("%s: keep = %v, erro = %v", tt.name, keep, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "limpeza recusada") {
+			t.Errorf("%s: mensagem = %v", tt.name, err)
+		}
+	}
+}
+
+func TestCleanupDependencyFilters(t *testing.T) {
+	ids := []interface{}{primitive.NewObjectID(), primitive.NewObjectID()}
+
+	single := CleanupDependency{Collection: database.CollectionBoletos, ReferenceFields: []string{"MovimentacaoReferencia"}}
+	if got, want := single.referenceFilter(ids), (bson.M{"MovimentacaoReferencia": bson.M{"$in": ids}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("referenceFilter = %v, esperado %v", got, want)
+	}
+
+	multi := CleanupDependency{
+		Collection:      database.CollectionRecebimentos,
+		ReferenceFields: []string{"MovimentacaoReferencia", "Parcelas.MovimentacaoReferencia"},
+		OpenFilter:      map[string]interface{}{"Quitado": false},
+	}
+	wantRef := bson.M{"$or": []bson.M{
+		{"MovimentacaoReferencia": bson.M{"$in": ids}},
+		{"Parcelas.MovimentacaoReferencia": bson.M{"$in": ids}},
+	}}
+	if got := multi.referenceFilter(ids); !reflect.DeepEqual(got, wantRef) {
+		t.Errorf("referenceFilter = %v, esperado %v", got, wantRef)
+	}
+	wantOpen := bson.M{"$and": []bson.M{{"Quitado": false}, wantRef}}
+	if got := multi.openFilter(ids); !reflect.DeepEqual(got, wantOpen) {
+		t.Errorf("openFilter = %v, esperado %v", got, wantOpen)
+	}
+}
+
+func TestIDBatches(t *testing.T) {
+	ids := make([]interface{}, cleanupIDBatch*2+1)
+	for i := range ids {
+		ids[i] = i
+	}
+	batches := idBatches(ids)
+	if len(batches) != 3 || len(batches[0]) != cleanupIDBatch || len(batches[2]) != 1 {
+		t.Errorf("lotes = %d", len(batches))
+	}
+	if idBatches(nil) != nil {
+		t.Error("lista vazia gerou lotes")
+	}
+}
+
+func TestCleanupSelectionDeduplicates(t *testing.T) {
+	sel := newCleanupSelection()
+	id := primitive.NewObjectID()
+
+	if !sel.add(database.CollectionBoletos, id) {
+		t.Error("primeiro _id recusado")
+	}
+	if sel.add(database.CollectionBoletos, id) {
+		t.Error("_id repetido aceito")
+	}
+	// O mesmo valor em outra coleção, ou com outro tipo, é outro documento.
+	sel.add(database.CollectionRecebimentos, id)
+	sel.add(database.CollectionBoletos, id.Hex())
+
+	if want := []string{database.CollectionBoletos, database.CollectionRecebimentos}; !reflect.DeepEqual(sel.order, want) {
+		t.Errorf("ordem = %v, esperado %v", sel.order, want)
+	}
+	if n := len(sel.ids[database.CollectionBoletos]); n != 2 {
+		t.Errorf("%d _id em Boletos, esperado 2", n)
+	}
+}
+
+func TestDefaultDependentsAreValid(t *testing.T) {
+	for _, rule := range DefaultCleanupRules() {
+		if err := validateDependents(rule.Dependents); err != nil {
+			t.Errorf("%s: %v", rule.Collection, err)
+		}
+		if err := validateOnOpen(rule.OnOpen); err != nil {
+			t.Errorf("%s: %v", rule.Collection, err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	cleanupProfilesFile       = "perfis_limpeza.json"
+	DefaultCleanupProfileName = "padrao"
+)
+
+// Ação aplicada a cada coleção por um perfil de limpeza.
+const (
+	CleanupActionKeep    = "manter"
+	CleanupActionPartial = "parcial"
+	CleanupActionDrop    = "remover"
+)
+
+// CleanupProfile define o que CleanDatabase preserva. Coleções em Keep são
+// mantidas inteiras; em Partial, apenas os documentos que casam com o filtro
+// (JSON estendido, ex.: {"$oid": "..."}) são mantidos. As demais são removidas.
+type CleanupProfile struct {
+	Name        string                            `json:"name"`
+	Description string                            `json:"description"`
+	Keep        []string                          `json:"keep"`
+	Partial     map[string]map[string]interface{} `json:"partial,omitempty"`
+}
+
+type CleanupCollectionPreview struct {
+	Collection string `json:"collection"`
+	Action     string `json:"action"`
+	Total      int64  `json:"total"`
+	Kept       int64  `json:"kept"`
+	Removed    int64  `json:"removed"`
+}
+
+var baseCleanupKeep = []string{
+	"ConfiguracoesServidor",
+	"ConfiguracoesSincronizacao",
+	"DigisatUpdate",
+	"SequenciasDocumentos",
+	"Estados",
+	"Cidades",
+}
+
+var productCleanupKeep = []string{
+	database.CollectionProdutosServicos,
+	database.CollectionProdutosServicosEmpresa,
+	database.CollectionEstoques,
+	database.CollectionEstoquesQuantidade,
+	database.CollectionTributacoesEstadual,
+	database.CollectionTributacoesFederal,
+	database.CollectionTributacoesIbsCbs,
+	database.CollectionTributacoesMunicipal,
+	database.CollectionPrecos,
+	database.CollectionItensPreco,
+	database.CollectionMarcas,
+	database.CollectionTiposItem,
+	database.CollectionGenerosItem,
+}
+
+func withKeep(lists ...[]string) []string {
+	var keep []string
+	for _, l := range lists {
+		keep = append(keep, l...)
+	}
+	return keep
+}
+
+func emitentesOnly() map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{
+		database.CollectionPessoas: {"_t": "Emitente"},
+	}
+}
+
+func DefaultCleanupProfiles() []CleanupProfile {
+	return []CleanupProfile{
+		{
+			Name:        DefaultCleanupProfileName,
+			Description: "Mantém configurações, sequências e emitentes",
+			Keep:        withKeep(baseCleanupKeep),
+			Partial:     emitentesOnly(),
+		},
+		{
+			Name:        "somente_configuracao",
+			Description: "Mantém apenas configurações, sequências e emitentes",
+			Keep:        withKeep(baseCleanupKeep, []string{database.CollectionConfiguracoes}),
+			Partial:     emitentesOnly(),
+		},
+		{
+			Name:        "produtos_clientes",
+			Description: "Mantém produtos, tributações, preços, emitentes e clientes",
+			Keep:        withKeep(baseCleanupKeep, productCleanupKeep),
+			Partial: map[string]map[string]interface{}{
+				database.CollectionPessoas: {"_t": map[string]interface{}{"$in": []interface{}{"Emitente", "Cliente"}}},
+			},
+		},
+		{
+			Name:        "produtos_usuarios",
+			Description: "Mantém produtos, tributações, preços, emitentes e usuários",
+			Keep:        withKeep(baseCleanupKeep, productCleanupKeep, []string{database.CollectionUsuarios}),
+			Partial:     emitentesOnly(),
+		},
+	}
+}
+
+func cleanupProfilesPath() string {
+	return configFilePath("CLEANUP_PROFILES_FILE", cleanupProfilesFile)
+}
+
+// LoadCleanupProfiles devolve os perfis padrão acrescidos dos perfis de
+// perfis_limpeza.json; um perfil do arquivo com o mesmo nome substitui o padrão.
+func LoadCleanupProfiles() ([]CleanupProfile, error) {
+	profiles := DefaultCleanupProfiles()
+
+	p := cleanupProfilesPath()
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler %s: %w", p, err)
+	}
+
+	var custom []CleanupProfile
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("perfis de limpeza inválidos em %s: %w", p, err)
+	}
+
+	for _, profile := range custom {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("perfil sem nome em %s", p)
+		}
+		for coll, filter := range profile.Partial {
+			if _, err := extJSONFilter(filter); err != nil {
+				return nil, fmt.Errorf("perfil %s, coleção %s: %w", profile.Name, coll, err)
+			}
+		}
+
+		replaced := false
+		for i := range profiles {
+			if profiles[i].Name == profile.Name {
+				profiles[i] = profile
+				replaced = true
+			}
+		}
+		if !replaced {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+func findCleanupProfile(name string) (*CleanupProfile, error) {
+	if name == "" {
+		name = DefaultCleanupProfileName
+	}
+	profiles, err := LoadCleanupProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("perfil de limpeza não encontrado: %s", name)
+}
+
+// extJSONFilter converte um filtro lido de JSON comum em bson.M, interpretando
+// tipos do JSON estendido como $oid e $date.
+func extJSONFilter(filter map[string]interface{}) (bson.M, error) {
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	var out bson.M
+	if err := bson.UnmarshalExtJSON(data, false, &out); err != nil {
+		return nil, fmt.Errorf("filtro inválido: %w", err)
+	}
+	return out, nil
+}
+
+func isSystemCollection(name string) bool {
+	return strings.HasPrefix(name, "system.") || name == "startup_log"
+}
+
+func (p *CleanupProfile) action(collection string) string {
+	if isSystemCollection(collection) {
+		return CleanupActionKeep
+	}
+	if _, ok := p.Partial[collection]; ok {
+		return CleanupActionPartial
+	}
+	for _, keep := range p.Keep {
+		if keep == collection {
+			return CleanupActionKeep
+		}
+	}
+	return CleanupActionDrop
+}
+
+// PreviewCleanDatabase mostra, por coleção, o que CleanDatabaseWithProfile faria.
+func (m *Manager) PreviewCleanDatabase(profileName string, log LogFunc) ([]CleanupCollectionPreview, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	profile, err := findCleanupProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar coleções: %w", err)
+	}
+	sort.Strings(collections)
+
+	log(fmt.Sprintf("🔎 Prévia da limpeza com o perfil \"%s\"", profile.Name))
+
+	previews := make([]CleanupCollectionPreview, 0, len(collections))
+	var removed int64
+	for _, colName := range collections {
+		coll := m.conn.GetCollection(colName)
+		total, err := coll.EstimatedDocumentCount(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao contar %s: %w", colName, err)
+		}
+
+		preview := CleanupCollectionPreview{Collection: colName, Action: profile.action(colName), Total: total}
+		switch preview.Action {
+		case CleanupActionKeep:
+			preview.Kept = total
+		case CleanupActionDrop:
+			preview.Removed = total
+		case CleanupActionPartial:
+			filter, err := extJSONFilter(profile.Partial[colName])
+			if err != nil {
+				return nil, err
+			}
+			kept, err := coll.CountDocuments(ctx, filter)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao contar %s: %w", colName, err)
+			}
+			preview.Kept = kept
+			preview.Removed = total - kept
+			if preview.Removed < 0 {
+				preview.Removed = 0
+			}
+		}
+
+		removed += preview.Removed
+		previews = append(previews, preview)
+		if preview.Action != CleanupActionKeep {
+			log(fmt.Sprintf("   %s: %s (%d mantidos, %d removidos)", colName, preview.Action, preview.Kept, preview.Removed))
+		}
+	}
+
+	log(fmt.Sprintf("🔎 Total previsto: %d registros removidos", removed))
+	return previews, nil
+}
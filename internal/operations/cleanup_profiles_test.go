@@ -0,0 +1,130 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDefaultCleanupProfilesKeepBase(t *testing.T) {
+	for _, profile := range DefaultCleanupProfiles() {
+		for _, coll := range baseCleanupKeep {
+			if got := profile.action(coll); got != CleanupActionKeep {
+				t.Errorf("perfil %s: %s = %s, esperado %s", profile.Name, coll, got, CleanupActionKeep)
+			}
+		}
+		if got := profile.action(database.CollectionPessoas); got != CleanupActionPartial {
+			t.Errorf("perfil %s: Pessoas = %s, esperado %s", profile.Name, got, CleanupActionPartial)
+		}
+	}
+}
+
+func TestCleanupProfileAction(t *testing.T) {
+	profiles := make(map[string]CleanupProfile)
+	for _, p := range DefaultCleanupProfiles() {
+		profiles[p.Name] = p
+	}
+
+	tests := []struct {
+		profile    string
+		collection string
+		want       string
+	}{
+		{"somente_configuracao", "SequenciasDocumentos", CleanupActionKeep},
+		{"somente_configuracao", database.CollectionConfiguracoes, CleanupActionKeep},
+		{"somente_configuracao", database.CollectionProdutosServicos, CleanupActionDrop},
+		{"padrao", database.CollectionMovimentacoes, CleanupActionDrop},
+		{"padrao", "system.views", CleanupActionKeep},
+		{"padrao", "startup_log", CleanupActionKeep},
+		{"produtos_clientes", database.CollectionEstoques, CleanupActionKeep},
+		{"produtos_clientes", database.CollectionUsuarios, CleanupActionDrop},
+		{"produtos_usuarios", database.CollectionUsuarios, CleanupActionKeep},
+	}
+	for _, tt := range tests {
+		p := profiles[tt.profile]
+		if got := p.action(tt.collection); got != tt.want {
+			t.Errorf("%s: %s = %s, esperado %s", tt.profile, tt.collection, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCleanupProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(t *testing.T, profiles []CleanupProfile)
+		wantErr string
+	}{
+		{
+			name:    "perfil novo",
+			content: `[{"name":"so_filial","keep":["Estados"],"partial":{"Pessoas":{"_id":{"$oid":"5f0000000000000000000001"}}}}]`,
+			check: func(t *testing.T, profiles []CleanupProfile) {
+				if n := len(profiles); n != len(DefaultCleanupProfiles())+1 {
+					t.Errorf("%d perfis", n)
+				}
+			},
+		},
+		{
+			name:    "substitui o padrão",
+			content: `[{"name":"padrao","keep":["Estados"]}]`,
+			check: func(t *testing.T, profiles []CleanupProfile) {
+				if len(profiles) != len(DefaultCleanupProfiles()) || len(profiles[0].Keep) != 1 {
+					t.Errorf("perfil padrão não substituído: %+v", profiles[0])
+				}
+			},
+		},
+		{name: "sem nome", content: `[{"keep":["Estados"]}]`, wantErr: "perfil sem nome"},
+		{name: "filtro inválido", content: `[{"name":"x","partial":{"Pessoas":{"_id":{"$oid":"zz"}}}}]`, wantErr: "filtro inválido"},
+		{name: "json inválido", content: `{`, wantErr: "perfis de limpeza inválidos"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "perfis_limpeza.json")
+			if err := os.WriteFile(p, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("CLEANUP_PROFILES_FILE", p)
+
+			profiles, err := LoadCleanupProfiles()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, profiles)
+		})
+	}
+}
+
+func TestFindCleanupProfile(t *testing.T) {
+	t.Setenv("CLEANUP_PROFILES_FILE", filepath.Join(t.TempDir(), "ausente.json"))
+
+	profile, err := findCleanupProfile("")
+	if err != nil || profile.Name != DefaultCleanupProfileName {
+		t.Errorf("perfil vazio = %v, %v", profile, err)
+	}
+	if _, err := findCleanupProfile("inexistente"); err == nil {
+		t.Error("perfil inexistente aceito")
+	}
+}
+
+func TestExtJSONFilter(t *testing.T) {
+	filter, err := extJSONFilter(map[string]interface{}{
+		"_id": map[string]interface{}{"$oid": "5f0000000000000000000001"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := filter["_id"].(primitive.ObjectID); !ok {
+		t.Errorf("_id = %T, esperado ObjectID", filter["_id"])
+	}
+}
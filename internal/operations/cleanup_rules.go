@@ -0,0 +1,333 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const cleanupRulesFile = "regras_limpeza.json"
+
+// CleanupRule define quais documentos de uma coleção entram na limpeza por data.
+// DateFields lista os nomes possíveis do campo de data em ordem de preferência,
+// já que o nome varia entre versões do Digisat; o primeiro presente na coleção é usado.
+// OpenFilter e OnOpen seguem a mesma política dos dependentes, aplicada aos
+// próprios documentos da regra (ex.: contas ainda não quitadas).
+type CleanupRule struct {
+	Collection        string                 `json:"collection"`
+	DateFields        []string               `json:"dateFields"`
+	Type              string                 `json:"type,omitempty"`
+	EmpresaReferencia string                 `json:"empresaReferencia,omitempty"`
+	Disabled          bool                   `json:"disabled,omitempty"`
+	OpenFilter        map[string]interface{} `json:"openFilter,omitempty"`
+	OnOpen            string                 `json:"onOpen,omitempty"`
+
+	Dependents []CleanupDependency `json:"dependents,omitempty"`
+}
+
+type CleanupRulePreview struct {
+	Collection        string `json:"collection"`
+	DateField         string `json:"dateField"`
+	Type              string `json:"type,omitempty"`
+	EmpresaReferencia string `json:"empresaReferencia,omitempty"`
+	Count             int64  `json:"count"`
+	Open              int64  `json:"open,omitempty"`
+	Note              string `json:"note,omitempty"`
+
+	Dependents []CleanupDependencyPreview `json:"dependents,omitempty"`
+}
+
+func DefaultCleanupRules() []CleanupRule {
+	return []CleanupRule{
+		{
+			Collection: database.CollectionMovimentacoes,
+			DateFields: []string{"DataMovimentacao", "DataHoraEmissao"},
+			Dependents: defaultMovementDependents(),
+		},
+		{
+			Collection: "ContasReceber",
+			DateFields: []string{"DataEmissao"},
+			OpenFilter: map[string]interface{}{"Quitado": false},
+			OnOpen:     CleanupOnOpenKeep,
+		},
+		{
+			Collection: "ContasPagar",
+			DateFields: []string{"DataEmissao"},
+			OpenFilter: map[string]interface{}{"Quitado": false},
+			OnOpen:     CleanupOnOpenKeep,
+		},
+		{Collection: "DocumentosFiscaisSaida", DateFields: []string{"DataEmissao", "DataHoraEmissao"}},
+		{
+			Collection: database.CollectionRecebimentos,
+			DateFields: []string{"DataHoraRecebimento", "DataRecebimento", "DataEmissao"},
+			OpenFilter: map[string]interface{}{"Quitado": false},
+			OnOpen:     CleanupOnOpenKeep,
+			Dependents: receivableDependents(),
+		},
+		{Collection: database.CollectionPagamentos, DateFields: []string{"DataHoraPagamento", "DataPagamento", "DataEmissao"}},
+		{Collection: database.CollectionTurnosLancamentos, DateFields: []string{"DataHoraLancamento", "DataLancamento", "DataHora"}},
+		{Collection: database.CollectionXmlMovimentacoes, DateFields: []string{"DataHoraEmissao", "DataEmissao"}},
+		{Collection: database.CollectionAbastecimentos, DateFields: []string{"DataHoraAbastecimento", "DataAbastecimento", "DataHora"}},
+	}
+}
+
+// configFilePath usa a variável de ambiente quando definida; caso contrário,
+// procura o arquivo na pasta do executável.
+func configFilePath(envVar, name string) string {
+	if p := os.Getenv(envVar); p != "" {
+		return p
+	}
+	if exe, err := os.Executable(); err == nil {
+		return filepath.Join(filepath.Dir(exe), name)
+	}
+	return name
+}
+
+func cleanupRulesPath() string {
+	return configFilePath("CLEANUP_RULES_FILE", cleanupRulesFile)
+}
+
+// LoadCleanupRules lê as regras do arquivo de configuração. Sem arquivo,
+// retorna as regras padrão.
+func LoadCleanupRules() ([]CleanupRule, string, error) {
+	p := cleanupRulesPath()
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultCleanupRules(), "", nil
+	}
+	if err != nil {
+		return nil, p, fmt.Errorf("erro ao ler %s: %w", p, err)
+	}
+
+	var rules []CleanupRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, p, fmt.Errorf("regras de limpeza inválidas em %s: %w", p, err)
+	}
+
+	for i, rule := range rules {
+		if rule.Collection == "" || len(rule.DateFields) == 0 {
+			return nil, p, fmt.Errorf("regra %d em %s precisa de collection e dateFields", i+1, p)
+		}
+		if rule.EmpresaReferencia != "" {
+			if _, err := primitive.ObjectIDFromHex(rule.EmpresaReferencia); err != nil {
+				return nil, p, fmt.Errorf("regra %d em %s: empresaReferencia inválida", i+1, p)
+			}
+		}
+		if err := validateOnOpen(rule.OnOpen); err != nil {
+			return nil, p, fmt.Errorf("regra %d em %s: %w", i+1, p, err)
+		}
+		if err := validateDependents(rule.Dependents); err != nil {
+			return nil, p, fmt.Errorf("regra %d em %s: %w", i+1, p, err)
+		}
+	}
+	return rules, p, nil
+}
+
+func validateOnOpen(onOpen string) error {
+	if onOpen != "" && onOpen != CleanupOnOpenRefuse && onOpen != CleanupOnOpenKeep {
+		return fmt.Errorf("onOpen deve ser %q ou %q", CleanupOnOpenRefuse, CleanupOnOpenKeep)
+	}
+	return nil
+}
+
+func validateDependents(deps []CleanupDependency) error {
+	for _, dep := range deps {
+		if dep.Collection == "" || len(dep.ReferenceFields) == 0 {
+			return fmt.Errorf("dependente precisa de collection e referenceFields")
+		}
+		if err := validateOnOpen(dep.OnOpen); err != nil {
+			return err
+		}
+		if err := validateDependents(dep.Dependents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveCleanupRules grava as regras no arquivo de configuração, criando-o
+// com as regras padrão quando rules for nil.
+func SaveCleanupRules(rules []CleanupRule) (string, error) {
+	if rules == nil {
+		rules = DefaultCleanupRules()
+	}
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	p := cleanupRulesPath()
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return "", fmt.Errorf("erro ao gravar %s: %w", p, err)
+	}
+	return p, nil
+}
+
+func (r CleanupRule) filter(dateField string, before time.Time) bson.M {
+	filter := bson.M{dateField: bson.M{"$lt": before}}
+	if r.Type != "" {
+		// Em coleções com herança o _t pode ser string ou array; a igualdade cobre os dois casos.
+		filter["_t"] = r.Type
+	}
+	if r.EmpresaReferencia != "" {
+		if oid, err := primitive.ObjectIDFromHex(r.EmpresaReferencia); err == nil {
+			filter["EmpresaReferencia"] = oid
+		}
+	}
+	return filter
+}
+
+// excludeOpen aplica o OpenFilter da própria regra: com a política "manter"
+// os documentos em aberto saem do filtro; com "recusar" a limpeza é recusada
+// se houver algum. Devolve também quantos estão em aberto.
+func (m *Manager) excludeOpen(ctx context.Context, rule resolvedCleanupRule, filter bson.M) (bson.M, int64, error) {
+	if len(rule.OpenFilter) == 0 {
+		return filter, 0, nil
+	}
+
+	openFilter := bson.M(rule.OpenFilter)
+	open, err := m.conn.GetCollection(rule.Collection).CountDocuments(ctx, bson.M{"$and": []bson.M{filter, openFilter}})
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao verificar %s: %w", rule.Collection, err)
+	}
+	if open == 0 {
+		return filter, 0, nil
+	}
+	if rule.OnOpen != CleanupOnOpenKeep {
+		return nil, open, fmt.Errorf("limpeza recusada: %d registro(s) de %s em aberto anteriores à data", open, rule.Collection)
+	}
+	return bson.M{"$and": []bson.M{filter, {"$nor": []bson.M{openFilter}}}}, open, nil
+}
+
+func (m *Manager) resolveDateField(ctx context.Context, rule CleanupRule) string {
+	coll := m.conn.GetCollection(rule.Collection)
+	for _, field := range rule.DateFields {
+		n, err := coll.CountDocuments(ctx, bson.M{field: bson.M{"$exists": true}}, options.Count().SetLimit(1))
+		if err == nil && n > 0 {
+			return field
+		}
+	}
+	return ""
+}
+
+type resolvedCleanupRule struct {
+	CleanupRule
+	DateField string
+}
+
+func (m *Manager) resolveCleanupRules(ctx context.Context, log LogFunc) ([]resolvedCleanupRule, error) {
+	rules, path, err := LoadCleanupRules()
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		log(fmt.Sprintf("📋 Regras de limpeza carregadas de %s", path))
+	} else {
+		log("📋 Usando regras de limpeza padrão")
+	}
+
+	resolved := make([]resolvedCleanupRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		resolved = append(resolved, resolvedCleanupRule{CleanupRule: rule, DateField: m.resolveDateField(ctx, rule)})
+	}
+	return resolved, nil
+}
+
+// PreviewCleanupByDate conta, por regra, quantos documentos seriam removidos
+// por CleanDatabaseByDate, sem alterar a base.
+func (m *Manager) PreviewCleanupByDate(beforeDate string, log LogFunc) ([]CleanupRulePreview, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	date, err := time.Parse("2006-01-02", beforeDate)
+	if err != nil {
+		return nil, fmt.Errorf("formato de data inválido (use YYYY-MM-DD): %w", err)
+	}
+
+	rules, err := m.resolveCleanupRules(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]CleanupRulePreview, 0, len(rules))
+	// Uma coleção pode ser regra e dependente ao mesmo tempo (ex.:
+	// Recebimentos); o total conta cada documento uma vez só.
+	sel := newCleanupSelection()
+	for _, rule := range rules {
+		preview := CleanupRulePreview{
+			Collection:        rule.Collection,
+			DateField:         rule.DateField,
+			Type:              rule.Type,
+			EmpresaReferencia: rule.EmpresaReferencia,
+		}
+
+		if rule.DateField == "" {
+			preview.Note = "nenhum campo de data encontrado na coleção"
+			previews = append(previews, preview)
+			continue
+		}
+
+		filter, open, err := m.excludeOpen(ctx, rule, rule.filter(rule.DateField, date))
+		preview.Open = open
+		if err != nil {
+			preview.Note = err.Error()
+			log(fmt.Sprintf("   ⛔ %s: %v", rule.Collection, err))
+			previews = append(previews, preview)
+			continue
+		}
+
+		ids, err := m.collectIDs(ctx, rule.Collection, filter)
+		if err != nil {
+			preview.Note = err.Error()
+		}
+		preview.Count = int64(len(ids))
+		for _, id := range ids {
+			sel.add(rule.Collection, id)
+		}
+
+		msg := fmt.Sprintf("   📦 %s (%s): %d registros", rule.Collection, rule.DateField, preview.Count)
+		if open > 0 {
+			msg += fmt.Sprintf(" (%d em aberto mantidos)", open)
+		}
+		log(msg)
+
+		if len(rule.Dependents) > 0 && len(ids) > 0 {
+			deps, err := m.previewDependents(ctx, rule.Collection, rule.Dependents, ids, sel, false)
+			if err != nil {
+				preview.Note = err.Error()
+			}
+			preview.Dependents = deps
+			for _, dep := range deps {
+				msg := fmt.Sprintf("      ↳ %s: %d vinculados", dep.Collection, dep.Count)
+				if dep.Parent != "" {
+					msg = fmt.Sprintf("      ↳ %s (via %s): %d vinculados", dep.Collection, dep.Parent, dep.Count)
+				}
+				if dep.Open > 0 {
+					msg += fmt.Sprintf(" (%d em aberto)", dep.Open)
+				}
+				log(msg)
+			}
+		}
+		previews = append(previews, preview)
+	}
+
+	var total int64
+	for _, ids := range sel.ids {
+		total += int64(len(ids))
+	}
+	log(fmt.Sprintf("🔎 Total previsto: %d registros anteriores a %s", total, beforeDate))
+	return previews, nil
+}
@@ -0,0 +1,102 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLoadCleanupRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr string
+	}{
+		{"regras válidas", `[{"collection":"Movimentacoes","dateFields":["DataHoraEmissao"],"type":"Venda"}]`, 1, ""},
+		{"json inválido", `[{`, 0, "regras de limpeza inválidas"},
+		{"sem dateFields", `[{"collection":"Movimentacoes"}]`, 0, "precisa de collection e dateFields"},
+		{"empresa inválida", `[{"collection":"Movimentacoes","dateFields":["DataHoraEmissao"],"empresaReferencia":"xyz"}]`, 0, "empresaReferencia inválida"},
+		{"onOpen inválido", `[{"collection":"ContasReceber","dateFields":["DataEmissao"],"onOpen":"apagar"}]`, 0, "onOpen deve ser"},
+		{"dependente sem campos", `[{"collection":"Movimentacoes","dateFields":["DataHoraEmissao"],"dependents":[{"collection":"Recebimentos"}]}]`, 0, "referenceFields"},
+		{"dependente aninhado inválido", `[{"collection":"Movimentacoes","dateFields":["DataHoraEmissao"],"dependents":[{"collection":"Recebimentos","referenceFields":["MovimentacaoReferencia"],"dependents":[{"collection":"Boletos","referenceFields":["RecebimentoReferencia"],"onOpen":"x"}]}]}]`, 0, "onOpen deve ser"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "regras_limpeza.json")
+			if err := os.WriteFile(p, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("CLEANUP_RULES_FILE", p)
+
+			rules, path, err := LoadCleanupRules()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro = %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != p || len(rules) != tt.want {
+				t.Errorf("regras = %d de %s", len(rules), path)
+			}
+		})
+	}
+}
+
+func TestLoadCleanupRulesDefaultsWithoutFile(t *testing.T) {
+	t.Setenv("CLEANUP_RULES_FILE", filepath.Join(t.TempDir(), "ausente.json"))
+	rules, path, err := LoadCleanupRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "" || !reflect.DeepEqual(rules, DefaultCleanupRules()) {
+		t.Errorf("sem arquivo deveria devolver as regras padrão, caminho %q", path)
+	}
+}
+
+func TestSaveCleanupRulesRoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "regras_limpeza.json")
+	t.Setenv("CLEANUP_RULES_FILE", p)
+
+	if _, err := SaveCleanupRules(nil); err != nil {
+		t.Fatal(err)
+	}
+	rules, _, err := LoadCleanupRules()
+	if err != nil {
+		t.Fatalf("regras padrão gravadas não passam na validação: %v", err)
+	}
+	if len(rules) != len(DefaultCleanupRules()) {
+		t.Errorf("%d regras lidas, esperado %d", len(rules), len(DefaultCleanupRules()))
+	}
+}
+
+func TestCleanupRuleFilter(t *testing.T) {
+	before := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	empresa := primitive.NewObjectID()
+
+	tests := []struct {
+		name string
+		rule CleanupRule
+		want bson.M
+	}{
+		{"só data", CleanupRule{}, bson.M{"DataHoraEmissao": bson.M{"$lt": before}}},
+		{"com tipo", CleanupRule{Type: "Venda"}, bson.M{"DataHoraEmissao": bson.M{"$lt": before}, "_t": "Venda"}},
+		{"com empresa", CleanupRule{EmpresaReferencia: empresa.Hex()},
+			bson.M{"DataHoraEmissao": bson.M{"$lt": before}, "EmpresaReferencia": empresa}},
+		{"empresa inválida ignorada", CleanupRule{EmpresaReferencia: "xyz"}, bson.M{"DataHoraEmissao": bson.M{"$lt": before}}},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.filter("DataHoraEmissao", before); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: filtro = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
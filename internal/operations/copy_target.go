@@ -0,0 +1,306 @@
+package operations
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CopyTarget indica onde gravar uma cópia (total ou parcial) da base: outro
+// banco no mesmo servidor ou uma pasta no formato do mongodump, com
+// manifest.json, que a restauração normal aceita.
+type CopyTarget struct {
+	Database  string `json:"database"`
+	OutputDir string `json:"outputDir"`
+}
+
+// digisatDatabaseName é o banco de produção do Digisat e também o nome da
+// pasta do banco dentro dos dumps, que a restauração procura.
+const digisatDatabaseName = "DigisatServer"
+
+// copySink recebe os documentos da cópia, uma coleção de cada vez.
+type copySink interface {
+	indexes(ctx context.Context, collection string, specs []bson.Raw) error
+	write(ctx context.Context, collection string, doc bson.Raw) error
+	close(ctx context.Context) error
+	// discard desfaz uma cópia interrompida: apaga a pasta ou o banco novo.
+	discard(ctx context.Context)
+	// location descreve o destino para logs e resultados.
+	location() string
+}
+
+// openCopyTarget valida o destino e prepara a gravação. prefix nomeia a
+// pasta criada em OutputDir (ex.: "anonimizado" → anonimizado_2025-01-31_10-00-00).
+func (m *Manager) openCopyTarget(ctx context.Context, target CopyTarget, prefix string) (copySink, error) {
+	switch {
+	case target.Database != "" && target.OutputDir != "":
+		return nil, fmt.Errorf("informe apenas um destino: banco ou pasta")
+	case target.Database != "":
+		return m.openDatabaseSink(ctx, target.Database)
+	case target.OutputDir != "":
+		return openDumpSink(target.OutputDir, prefix)
+	}
+	return nil, fmt.Errorf("nenhum destino informado")
+}
+
+const copyBatchSize = 1000
+
+type databaseSink struct {
+	db      *mongo.Database
+	pending map[string][]interface{}
+}
+
+func (m *Manager) openDatabaseSink(ctx context.Context, name string) (*databaseSink, error) {
+	if name == m.conn.Database.Name() {
+		return nil, fmt.Errorf("o banco de destino deve ser diferente do banco atual (%s)", name)
+	}
+	db := m.conn.Client.Database(name)
+	existing, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar banco de destino: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("o banco de destino %s já tem %d coleções; use um banco novo", name, len(existing))
+	}
+	return &databaseSink{db: db, pending: make(map[string][]interface{})}, nil
+}
+
+func (s *databaseSink) indexes(ctx context.Context, collection string, specs []bson.Raw) error {
+	var create bson.A
+	for _, spec := range specs {
+		name, _ := spec.Lookup("name").StringValueOK()
+		if name == "_id_" {
+			continue
+		}
+		// createIndexes não aceita "ns" nem "v" de versões antigas.
+		var doc bson.D
+		if err := bson.Unmarshal(spec, &doc); err != nil {
+			return err
+		}
+		clean := make(bson.D, 0, len(doc))
+		for _, e := range doc {
+			if e.Key != "ns" && e.Key != "v" {
+				clean = append(clean, e)
+			}
+		}
+		create = append(create, clean)
+	}
+	if len(create) == 0 {
+		return nil
+	}
+	return s.db.RunCommand(ctx, bson.D{
+		{Key: "createIndexes", Value: collection},
+		{Key: "indexes", Value: create},
+	}).Err()
+}
+
+func (s *databaseSink) write(ctx context.Context, collection string, doc bson.Raw) error {
+	s.pending[collection] = append(s.pending[collection], doc)
+	if len(s.pending[collection]) >= copyBatchSize {
+		return s.flush(ctx, collection)
+	}
+	return nil
+}
+
+func (s *databaseSink) flush(ctx context.Context, collection string) error {
+	docs := s.pending[collection]
+	if len(docs) == 0 {
+		return nil
+	}
+	s.pending[collection] = nil
+	if _, err := s.db.Collection(collection).InsertMany(ctx, docs); err != nil {
+		return fmt.Errorf("erro ao gravar %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (s *databaseSink) close(ctx context.Context) error {
+	for collection := range s.pending {
+		if err := s.flush(ctx, collection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *databaseSink) discard(ctx context.Context) {
+	s.db.Drop(ctx)
+}
+
+func (s *databaseSink) location() string {
+	return "banco " + s.db.Name()
+}
+
+// dumpSink grava <pasta>/DigisatServer/<coleção>.bson e .metadata.json, como
+// o mongodump, e o manifest.json ao final.
+type dumpSink struct {
+	path      string
+	dataDir   string
+	startedAt time.Time
+	files     map[string]*dumpFile
+}
+
+type dumpFile struct {
+	file *os.File
+	w    *bufio.Writer
+	docs int64
+	size int64
+}
+
+func openDumpSink(outputDir, prefix string) (*dumpSink, error) {
+	startedAt := time.Now()
+	path := filepath.Join(outputDir, fmt.Sprintf("%s_%s", prefix, startedAt.Format("2006-01-02_15-04-05")))
+	dataDir := filepath.Join(path, digisatDatabaseName)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar pasta de destino: %w", err)
+	}
+	return &dumpSink{path: path, dataDir: dataDir, startedAt: startedAt, files: make(map[string]*dumpFile)}, nil
+}
+
+func (s *dumpSink) indexes(ctx context.Context, collection string, specs []bson.Raw) error {
+	indexes := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		data, err := bson.MarshalExtJSON(spec, true, false)
+		if err != nil {
+			return err
+		}
+		indexes = append(indexes, string(data))
+	}
+	metadata := fmt.Sprintf(`{"options":{},"indexes":[%s]}`, strings.Join(indexes, ","))
+	return os.WriteFile(filepath.Join(s.dataDir, collection+".metadata.json"), []byte(metadata), 0644)
+}
+
+func (s *dumpSink) write(ctx context.Context, collection string, doc bson.Raw) error {
+	f, ok := s.files[collection]
+	if !ok {
+		file, err := os.Create(filepath.Join(s.dataDir, collection+".bson"))
+		if err != nil {
+			return fmt.Errorf("erro ao criar arquivo de %s: %w", collection, err)
+		}
+		f = &dumpFile{file: file, w: bufio.NewWriter(file)}
+		s.files[collection] = f
+	}
+	n, err := f.w.Write(doc)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar %s: %w", collection, err)
+	}
+	f.docs++
+	f.size += int64(n)
+	return nil
+}
+
+func (s *dumpSink) close(ctx context.Context) error {
+	manifest := &BackupManifest{
+		Version:     1,
+		Type:        BackupTypeFull,
+		Timestamp:   s.startedAt.Format("2006-01-02_15-04-05"),
+		Database:    digisatDatabaseName,
+		StartedAt:   s.startedAt,
+		Collections: make([]ManifestCollection, 0, len(s.files)),
+	}
+
+	var firstErr error
+	for name, f := range s.files {
+		if err := f.w.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := f.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		manifest.Collections = append(manifest.Collections, ManifestCollection{Name: name, Documents: f.docs, Bytes: f.size})
+	}
+	if firstErr != nil {
+		return fmt.Errorf("erro ao fechar arquivos de destino: %w", firstErr)
+	}
+
+	sort.Slice(manifest.Collections, func(i, j int) bool {
+		return manifest.Collections[i].Name < manifest.Collections[j].Name
+	})
+	manifest.FinishedAt = time.Now()
+	return saveBackupManifest(s.path, manifest)
+}
+
+func (s *dumpSink) discard(ctx context.Context) {
+	for _, f := range s.files {
+		f.file.Close()
+	}
+	os.RemoveAll(s.path)
+}
+
+func (s *dumpSink) location() string {
+	return s.path
+}
+
+// inPlaceSink substitui os documentos no próprio banco, pelo _id.
+type inPlaceSink struct {
+	db      *mongo.Database
+	pending map[string][]mongo.WriteModel
+}
+
+func newInPlaceSink(db *mongo.Database) *inPlaceSink {
+	return &inPlaceSink{db: db, pending: make(map[string][]mongo.WriteModel)}
+}
+
+func (s *inPlaceSink) indexes(ctx context.Context, collection string, specs []bson.Raw) error {
+	return nil
+}
+
+func (s *inPlaceSink) write(ctx context.Context, collection string, doc bson.Raw) error {
+	model := mongo.NewReplaceOneModel().SetFilter(bson.D{{Key: "_id", Value: doc.Lookup("_id")}}).SetReplacement(doc)
+	s.pending[collection] = append(s.pending[collection], model)
+	if len(s.pending[collection]) >= copyBatchSize {
+		return s.flush(ctx, collection)
+	}
+	return nil
+}
+
+func (s *inPlaceSink) flush(ctx context.Context, collection string) error {
+	models := s.pending[collection]
+	if len(models) == 0 {
+		return nil
+	}
+	s.pending[collection] = nil
+	if _, err := s.db.Collection(collection).BulkWrite(ctx, models); err != nil {
+		return fmt.Errorf("erro ao gravar %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (s *inPlaceSink) close(ctx context.Context) error {
+	for collection := range s.pending {
+		if err := s.flush(ctx, collection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discard não tem o que desfazer: os lotes já gravados ficam no banco.
+func (s *inPlaceSink) discard(ctx context.Context) {}
+
+func (s *inPlaceSink) location() string {
+	return "banco " + s.db.Name()
+}
+
+// listIndexSpecs devolve as especificações de índice da coleção de origem.
+func listIndexSpecs(ctx context.Context, coll *mongo.Collection) ([]bson.Raw, error) {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var specs []bson.Raw
+	for cursor.Next(ctx) {
+		specs = append(specs, append(bson.Raw(nil), cursor.Current...))
+	}
+	return specs, cursor.Err()
+}
@@ -0,0 +1,583 @@
+package operations
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DatabaseDiffOptions compara o banco atual com outro banco do mesmo servidor
+// (Database) ou com um backup completo (BackupPath), em pasta ou compactado
+// (.zip, .tar.gz, .tgz, .tar).
+type DatabaseDiffOptions struct {
+	Database   string `json:"database"`
+	BackupPath string `json:"backupPath"`
+	// Collections limita a comparação; vazio compara todas.
+	Collections []string `json:"collections"`
+	// CountsOnly compara só as contagens, sem ler os documentos.
+	CountsOnly bool `json:"countsOnly"`
+	// MaxDocuments limita os documentos detalhados no relatório (padrão 1.000).
+	MaxDocuments int `json:"maxDocuments"`
+}
+
+const (
+	DiffChanged     = "alterado"
+	DiffOnlyCurrent = "somente_atual"
+	DiffOnlyOther   = "somente_outro"
+)
+
+type DiffField struct {
+	Path    string `json:"path"`
+	Current string `json:"current"`
+	Other   string `json:"other"`
+}
+
+type DiffDocument struct {
+	Collection string      `json:"collection"`
+	ID         string      `json:"id"`
+	Kind       string      `json:"kind"`
+	Fields     []DiffField `json:"fields,omitempty"`
+}
+
+type DiffCollection struct {
+	Name        string `json:"name"`
+	Current     int64  `json:"current"`
+	Other       int64  `json:"other"`
+	OnlyCurrent int64  `json:"onlyCurrent"`
+	OnlyOther   int64  `json:"onlyOther"`
+	Changed     int64  `json:"changed"`
+}
+
+type DatabaseDiffReport struct {
+	Current     string           `json:"current"`
+	Other       string           `json:"other"`
+	StartedAt   time.Time        `json:"startedAt"`
+	FinishedAt  time.Time        `json:"finishedAt"`
+	CountsOnly  bool             `json:"countsOnly"`
+	Collections []DiffCollection `json:"collections"`
+	Documents   []DiffDocument   `json:"documents"`
+	// Truncated indica que havia mais documentos diferentes que MaxDocuments.
+	Truncated   bool  `json:"truncated"`
+	OnlyCurrent int64 `json:"onlyCurrent"`
+	OnlyOther   int64 `json:"onlyOther"`
+	Changed     int64 `json:"changed"`
+}
+
+// diffSource é um dos lados da comparação.
+type diffSource interface {
+	name() string
+	collections(ctx context.Context) ([]string, error)
+	count(ctx context.Context, collection string) (int64, error)
+	scan(ctx context.Context, collection string, fn func(bson.Raw) error) error
+	// fetch devolve os documentos dos _id pedidos (chave: RawValue.String()).
+	fetch(ctx context.Context, collection string, ids map[string]bson.RawValue) (map[string]bson.Raw, error)
+}
+
+type databaseDiffSource struct {
+	db *mongo.Database
+}
+
+func (s databaseDiffSource) name() string { return "banco " + s.db.Name() }
+
+func (s databaseDiffSource) collections(ctx context.Context) ([]string, error) {
+	names, err := s.db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar coleções de %s: %w", s.db.Name(), err)
+	}
+	return names, nil
+}
+
+func (s databaseDiffSource) count(ctx context.Context, collection string) (int64, error) {
+	return s.db.Collection(collection).CountDocuments(ctx, bson.M{})
+}
+
+func (s databaseDiffSource) scan(ctx context.Context, collection string, fn func(bson.Raw) error) error {
+	cursor, err := s.db.Collection(collection).Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		if err := fn(cursor.Current); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (s databaseDiffSource) fetch(ctx context.Context, collection string, ids map[string]bson.RawValue) (map[string]bson.Raw, error) {
+	docs := make(map[string]bson.Raw, len(ids))
+	in := make(bson.A, 0, mergeLookupBatch)
+	flush := func() error {
+		if len(in) == 0 {
+			return nil
+		}
+		cursor, err := s.db.Collection(collection).Find(ctx, bson.M{"_id": bson.M{"$in": in}})
+		if err != nil {
+			return fmt.Errorf("erro ao ler %s: %w", collection, err)
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			doc := append(bson.Raw(nil), cursor.Current...)
+			docs[doc.Lookup("_id").String()] = doc
+		}
+		in = in[:0]
+		return cursor.Err()
+	}
+	for _, id := range ids {
+		in = append(in, id)
+		if len(in) >= mergeLookupBatch {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return docs, flush()
+}
+
+// dumpDiffSource lê os arquivos .bson/.bson.gz de um backup completo.
+type dumpDiffSource struct {
+	path string
+	dir  string
+	// staging é a pasta temporária de um backup compactado, apagada em close.
+	staging string
+}
+
+// openDumpDiffSource aceita a pasta do backup, a subpasta DigisatServer, uma
+// pasta com os .bson direto nela ou um backup compactado, como a restauração.
+func openDumpDiffSource(path string, log LogFunc) (*dumpDiffSource, error) {
+	if manifest, err := readBackupManifestAt(path); err == nil && manifest.Type == BackupTypeIncremental {
+		return nil, fmt.Errorf("backup incremental não pode ser comparado sozinho; restaure a cadeia em outro banco e compare com ele")
+	}
+
+	s := &dumpDiffSource{path: path}
+	root := path
+	if detectArchiveKind(path) != archiveNone {
+		if !fileExists(path) {
+			return nil, fmt.Errorf("arquivo de backup não encontrado: %s", path)
+		}
+		staging, err := os.MkdirTemp("", "digisat_diff_")
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criar pasta temporária: %w", err)
+		}
+		s.staging = staging
+		if root, err = extractBackupArchive(path, staging, log); err != nil {
+			s.close()
+			return nil, err
+		}
+		// Sem manifesto, a pasta do backup pode vir dentro do compactado.
+		if !dirExists(filepath.Join(root, digisatDatabaseName)) {
+			entries, _ := os.ReadDir(root)
+			for _, entry := range entries {
+				if entry.IsDir() && dirExists(filepath.Join(root, entry.Name(), digisatDatabaseName)) {
+					root = filepath.Join(root, entry.Name())
+					break
+				}
+			}
+		}
+	}
+
+	s.dir = root
+	if sub := filepath.Join(root, digisatDatabaseName); dirExists(sub) {
+		s.dir = sub
+	}
+	if !dirExists(s.dir) {
+		s.close()
+		return nil, fmt.Errorf("caminho de backup não encontrado: %s", path)
+	}
+	return s, nil
+}
+
+func (s *dumpDiffSource) close() {
+	if s.staging != "" {
+		os.RemoveAll(s.staging)
+	}
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func (s *dumpDiffSource) name() string { return "backup " + s.path }
+
+func (s *dumpDiffSource) collections(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler backup: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".bson") || strings.HasSuffix(name, ".bson.gz")) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".bson"))
+	}
+	return names, nil
+}
+
+func (s *dumpDiffSource) file(collection string) string {
+	path := filepath.Join(s.dir, collection+".bson")
+	if _, err := os.Stat(path); err != nil {
+		path += ".gz"
+	}
+	return path
+}
+
+func (s *dumpDiffSource) count(ctx context.Context, collection string) (int64, error) {
+	count, err := countBSONDocuments(s.file(collection))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (s *dumpDiffSource) scan(ctx context.Context, collection string, fn func(bson.Raw) error) error {
+	f, err := os.Open(s.file(collection))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(f.Name(), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	br := bufio.NewReader(r)
+	for {
+		doc, err := readBSONDocument(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler %s: %w", collection, err)
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *dumpDiffSource) fetch(ctx context.Context, collection string, ids map[string]bson.RawValue) (map[string]bson.Raw, error) {
+	docs := make(map[string]bson.Raw, len(ids))
+	err := s.scan(ctx, collection, func(doc bson.Raw) error {
+		key := doc.Lookup("_id").String()
+		if _, ok := ids[key]; ok {
+			docs[key] = doc
+		}
+		return nil
+	})
+	return docs, err
+}
+
+// CompareDatabases compara o banco atual com outro banco ou backup: primeiro
+// as contagens de cada coleção, depois os documentos pelo _id (presentes só
+// de um lado ou com conteúdo diferente), com a lista de campos alterados.
+func (m *Manager) CompareDatabases(opts DatabaseDiffOptions, log LogFunc) (*DatabaseDiffReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Hour)
+	defer cancel()
+
+	var other diffSource
+	switch {
+	case opts.Database != "" && opts.BackupPath != "":
+		return nil, fmt.Errorf("informe apenas um banco ou um backup para comparar")
+	case opts.Database != "":
+		if opts.Database == m.conn.Database.Name() {
+			return nil, fmt.Errorf("escolha um banco diferente do atual (%s)", opts.Database)
+		}
+		other = databaseDiffSource{db: m.conn.Client.Database(opts.Database)}
+	case opts.BackupPath != "":
+		src, err := openDumpDiffSource(opts.BackupPath, log)
+		if err != nil {
+			return nil, err
+		}
+		defer src.close()
+		other = src
+	default:
+		return nil, fmt.Errorf("informe o banco ou o backup para comparar")
+	}
+	current := databaseDiffSource{db: m.conn.Database}
+	maxDocs := clampLimit(opts.MaxDocuments, 1000, 100000)
+
+	collections, err := diffCollections(ctx, current, other, opts.Collections)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DatabaseDiffReport{Current: current.name(), Other: other.name(), StartedAt: time.Now(), CountsOnly: opts.CountsOnly}
+	log(fmt.Sprintf("🔍 Comparando %s com %s (%d coleções)...", report.Current, report.Other, len(collections)))
+
+	for _, name := range collections {
+		if m.state.ShouldStop() {
+			return nil, fmt.Errorf("operação cancelada")
+		}
+
+		c := DiffCollection{Name: name}
+		if c.Current, err = current.count(ctx, name); err != nil {
+			return nil, fmt.Errorf("erro ao contar %s: %w", name, err)
+		}
+		if c.Other, err = other.count(ctx, name); err != nil {
+			return nil, fmt.Errorf("erro ao contar %s no outro lado: %w", name, err)
+		}
+
+		if !opts.CountsOnly {
+			if err := m.diffCollectionDocuments(ctx, current, other, &c, report, maxDocs); err != nil {
+				return nil, err
+			}
+		}
+
+		report.Collections = append(report.Collections, c)
+		report.OnlyCurrent += c.OnlyCurrent
+		report.OnlyOther += c.OnlyOther
+		report.Changed += c.Changed
+
+		if c.Current != c.Other || c.OnlyCurrent+c.OnlyOther+c.Changed > 0 {
+			log(fmt.Sprintf("   ≠ %s: %d × %d documentos (%d só no atual, %d só no outro, %d alterados)",
+				name, c.Current, c.Other, c.OnlyCurrent, c.OnlyOther, c.Changed))
+		}
+	}
+	report.FinishedAt = time.Now()
+
+	if report.Truncated {
+		log(fmt.Sprintf("ℹ️ Detalhes limitados aos primeiros %d documentos", maxDocs))
+	}
+	log(fmt.Sprintf("✅ Comparação concluída: %d só no atual, %d só no outro, %d alterados",
+		report.OnlyCurrent, report.OnlyOther, report.Changed))
+	return report, nil
+}
+
+// diffCollections junta as coleções dos dois lados, respeitando o filtro.
+func diffCollections(ctx context.Context, current, other diffSource, filter []string) ([]string, error) {
+	if len(filter) > 0 {
+		names := append([]string(nil), filter...)
+		sort.Strings(names)
+		return names, nil
+	}
+	seen := make(map[string]bool)
+	for _, src := range []diffSource{current, other} {
+		names, err := src.collections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(name, "system.") {
+				seen[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// diffCollectionDocuments guarda o hash de cada documento do outro lado,
+// percorre o banco atual comparando os hashes e só então busca, dos dois
+// lados, os documentos alterados que entram no relatório.
+func (m *Manager) diffCollectionDocuments(ctx context.Context, current, other diffSource, c *DiffCollection, report *DatabaseDiffReport, maxDocs int) error {
+	type entry struct {
+		id   bson.RawValue
+		hash [sha256.Size]byte
+	}
+	others := make(map[string]entry)
+	err := other.scan(ctx, c.Name, func(doc bson.Raw) error {
+		id := copyRawValue(doc.Lookup("_id"))
+		others[id.String()] = entry{id: id, hash: sha256.Sum256(doc)}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Os alterados só entram no relatório no fim, mas já ocupam espaço.
+	changed := make(map[string]bson.RawValue)
+	room := func() bool {
+		if len(report.Documents)+len(changed) >= maxDocs {
+			report.Truncated = true
+			return false
+		}
+		return true
+	}
+
+	err = current.scan(ctx, c.Name, func(doc bson.Raw) error {
+		id := doc.Lookup("_id")
+		key := id.String()
+		o, ok := others[key]
+		if !ok {
+			c.OnlyCurrent++
+			if room() {
+				report.Documents = append(report.Documents, DiffDocument{Collection: c.Name, ID: formatRawID(id), Kind: DiffOnlyCurrent})
+			}
+			return nil
+		}
+		delete(others, key)
+		if o.hash != sha256.Sum256(doc) {
+			c.Changed++
+			if room() {
+				changed[key] = o.id
+			} else {
+				report.Truncated = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.OnlyOther = int64(len(others))
+	keys := make([]string, 0, len(others))
+	for key := range others {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !room() {
+			break
+		}
+		report.Documents = append(report.Documents, DiffDocument{Collection: c.Name, ID: formatRawID(others[key].id), Kind: DiffOnlyOther})
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+	currentDocs, err := current.fetch(ctx, c.Name, changed)
+	if err != nil {
+		return err
+	}
+	otherDocs, err := other.fetch(ctx, c.Name, changed)
+	if err != nil {
+		return err
+	}
+
+	keys = keys[:0]
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		report.Documents = append(report.Documents, DiffDocument{
+			Collection: c.Name,
+			ID:         formatRawID(changed[key]),
+			Kind:       DiffChanged,
+			Fields:     diffDocumentFields(currentDocs[key], otherDocs[key]),
+		})
+	}
+	return nil
+}
+
+// diffDocumentFields lista os campos (achatados como no console de consultas)
+// que diferem entre os dois documentos.
+func diffDocumentFields(current, other bson.Raw) []DiffField {
+	flatten := func(doc bson.Raw) (map[string]string, []string) {
+		row := make(map[string]string)
+		var columns []string
+		if doc != nil {
+			flattenDocument(doc, "", row, &columns, make(map[string]bool))
+		}
+		return row, columns
+	}
+	a, aCols := flatten(current)
+	b, bCols := flatten(other)
+
+	var fields []DiffField
+	for _, path := range aCols {
+		if v, ok := b[path]; !ok || v != a[path] {
+			fields = append(fields, DiffField{Path: path, Current: a[path], Other: v})
+		}
+	}
+	for _, path := range bCols {
+		if _, ok := a[path]; !ok {
+			fields = append(fields, DiffField{Path: path, Other: b[path]})
+		}
+	}
+	if len(fields) == 0 && !bytes.Equal(current, other) {
+		// Mesmos valores exibidos, mas outro tipo numérico ou ordem de campos.
+		fields = append(fields, DiffField{Path: "(tipos ou ordem dos campos)"})
+	}
+	return fields
+}
+
+// ExportDatabaseDiff grava o relatório em XLSX: uma planilha com o resumo por
+// coleção e outra com uma linha por campo diferente.
+func ExportDatabaseDiff(report *DatabaseDiffReport, path string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", "Resumo"); err != nil {
+		return err
+	}
+	rows := [][]interface{}{
+		{"Atual", report.Current},
+		{"Outro", report.Other},
+		{"Data", report.StartedAt.Format("2006-01-02 15:04:05")},
+		{},
+		{"Coleção", "Atual", "Outro", "Só no atual", "Só no outro", "Alterados"},
+	}
+	for _, c := range report.Collections {
+		rows = append(rows, []interface{}{c.Name, c.Current, c.Other, c.OnlyCurrent, c.OnlyOther, c.Changed})
+	}
+	rows = append(rows, []interface{}{"Total", "", "", report.OnlyCurrent, report.OnlyOther, report.Changed})
+	if err := setSheetRows(f, "Resumo", rows); err != nil {
+		return err
+	}
+
+	if _, err := f.NewSheet("Diferenças"); err != nil {
+		return err
+	}
+	rows = [][]interface{}{{"Coleção", "_id", "Tipo", "Campo", "Atual", "Outro"}}
+	for _, d := range report.Documents {
+		if len(d.Fields) == 0 {
+			rows = append(rows, []interface{}{d.Collection, d.ID, d.Kind})
+			continue
+		}
+		for _, field := range d.Fields {
+			rows = append(rows, []interface{}{d.Collection, d.ID, d.Kind, field.Path, field.Current, field.Other})
+		}
+	}
+	if err := setSheetRows(f, "Diferenças", rows); err != nil {
+		return err
+	}
+
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("erro ao salvar planilha: %w", err)
+	}
+	return nil
+}
+
+func setSheetRows(f *excelize.File, sheet string, rows [][]interface{}) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
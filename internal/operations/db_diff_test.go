@@ -0,0 +1,121 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// memoryDiffSource é um lado da comparação com os documentos em memória.
+type memoryDiffSource map[string][]bson.Raw
+
+func (s memoryDiffSource) name() string { return "memória" }
+
+func (s memoryDiffSource) collections(ctx context.Context) ([]string, error) {
+	var names []string
+	for name := range s {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (s memoryDiffSource) count(ctx context.Context, collection string) (int64, error) {
+	return int64(len(s[collection])), nil
+}
+
+func (s memoryDiffSource) scan(ctx context.Context, collection string, fn func(bson.Raw) error) error {
+	for _, doc := range s[collection] {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s memoryDiffSource) fetch(ctx context.Context, collection string, ids map[string]bson.RawValue) (map[string]bson.Raw, error) {
+	docs := make(map[string]bson.Raw)
+	for _, doc := range s[collection] {
+		if key := doc.Lookup("_id").String(); ids[key].Type != 0 {
+			docs[key] = doc
+		}
+	}
+	return docs, nil
+}
+
+func mustMarshal(t *testing.T, v interface{}) bson.Raw {
+	t.Helper()
+	data, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDiffCollectionDocumentsLimit(t *testing.T) {
+	current, other := memoryDiffSource{}, memoryDiffSource{}
+	for i := 0; i < 5; i++ {
+		current["Pessoas"] = append(current["Pessoas"], mustMarshal(t, bson.D{{Key: "_id", Value: int32(i)}, {Key: "Nome", Value: "atual"}}))
+		other["Pessoas"] = append(other["Pessoas"], mustMarshal(t, bson.D{{Key: "_id", Value: int32(i)}, {Key: "Nome", Value: "outro"}}))
+	}
+	for i := 5; i < 8; i++ {
+		current["Pessoas"] = append(current["Pessoas"], mustMarshal(t, bson.D{{Key: "_id", Value: int32(i)}}))
+	}
+
+	m := &Manager{}
+	c := &DiffCollection{Name: "Pessoas"}
+	report := &DatabaseDiffReport{}
+	if err := m.diffCollectionDocuments(context.Background(), current, other, c, report, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Changed != 5 || c.OnlyCurrent != 3 {
+		t.Fatalf("alterados=%d só no atual=%d, esperado 5 e 3", c.Changed, c.OnlyCurrent)
+	}
+	if len(report.Documents) != 4 || !report.Truncated {
+		t.Fatalf("documentos no relatório = %d (truncado=%v), esperado 4 e truncado", len(report.Documents), report.Truncated)
+	}
+	for _, d := range report.Documents {
+		if d.Kind == DiffChanged && len(d.Fields) == 0 {
+			t.Errorf("documento alterado %s sem campos", d.ID)
+		}
+	}
+}
+
+func TestOpenDumpDiffSourceArchive(t *testing.T) {
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "backup_teste")
+	dump := filepath.Join(backup, digisatDatabaseName)
+	if err := os.MkdirAll(dump, 0755); err != nil {
+		t.Fatal(err)
+	}
+	doc := mustMarshal(t, bson.D{{Key: "_id", Value: "a"}})
+	if err := os.WriteFile(filepath.Join(dump, "Pessoas.bson"), doc, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(dir, "backup_teste.tar.gz")
+	if err := packBackupArchive(backup, archive); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := openDumpDiffSource(archive, func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := src.collections(context.Background())
+	if err != nil || len(names) != 1 || names[0] != "Pessoas" {
+		t.Fatalf("coleções = %v (%v), esperado [Pessoas]", names, err)
+	}
+	if n, err := src.count(context.Background(), "Pessoas"); err != nil || n != 1 {
+		t.Fatalf("contagem = %d (%v), esperado 1", n, err)
+	}
+
+	staging := src.staging
+	src.close()
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Fatalf("pasta temporária não foi apagada: %s", staging)
+	}
+}
@@ -0,0 +1,379 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	digisatUpdateCollection = "DigisatUpdate"
+	digisatCompatFile       = "compatibilidade_digisat.json"
+)
+
+// Situação de uma operação frente à versão instalada do Digisat.
+const (
+	CompatibilitySupported    = "homologada"
+	CompatibilityUnknown      = "desconhecida"
+	CompatibilityIncompatible = "incompativel"
+)
+
+var versionPattern = regexp.MustCompile(`^\d+(\.\d+){1,3}$`)
+
+type DigisatVersion struct {
+	Raw    string `json:"raw"`
+	Source string `json:"source"`
+	parts  []int
+}
+
+func parseVersionParts(s string) ([]int, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if !versionPattern.MatchString(s) {
+		return nil, false
+	}
+	fields := strings.Split(s, ".")
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// compareVersionParts compara só até o tamanho de b, de modo que "4.2.1.7"
+// é igual a "4.2" (útil para limites como "até 4.2").
+func compareVersionParts(a, b []int) int {
+	for i := range b {
+		var av int
+		if i < len(a) {
+			av = a[i]
+		}
+		if av != b[i] {
+			if av < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// VersionRange é um intervalo inclusivo de versões; limites vazios são abertos.
+type VersionRange struct {
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+}
+
+func (r VersionRange) contains(v []int) bool {
+	if min, ok := parseVersionParts(r.Min); ok && compareVersionParts(v, min) < 0 {
+		return false
+	}
+	if max, ok := parseVersionParts(r.Max); ok && compareVersionParts(v, max) > 0 {
+		return false
+	}
+	return true
+}
+
+type PathProbe struct {
+	Collection string `json:"collection"`
+	Path       string `json:"path"`
+}
+
+// OperationRequirement declara as versões do Digisat em que a operação foi
+// homologada e os caminhos de campo fixos que ela usa.
+type OperationRequirement struct {
+	Operation OperationType  `json:"operation"`
+	Versions  []VersionRange `json:"versions"`
+	Paths     []PathProbe    `json:"paths"`
+}
+
+// homologatedDigisatVersions são as versões do Digisat em que as operações
+// com caminhos fixos foram testadas.
+var homologatedDigisatVersions = []VersionRange{{Min: "4.2", Max: "4.2"}}
+
+// DefaultOperationRequirements lista as operações que gravam em caminhos fixos,
+// com as versões homologadas. compatibilidade_digisat.json pode substituir as
+// versões de cada operação sem recompilar.
+func DefaultOperationRequirements() []OperationRequirement {
+	return []OperationRequirement{
+		{
+			Operation: OpInactivateProducts,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionEstoques, "Quantidades.0.Quantidade"}},
+		},
+		{
+			Operation: OpZeroStock,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionEstoques, "Quantidades.0.Quantidade"}},
+		},
+		{
+			Operation: OpZeroNegativeStock,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionEstoques, "Quantidades.0.Quantidade"}},
+		},
+		{
+			Operation: OpAdjustInventory,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionEstoques, "Quantidades.0.Quantidade"}},
+		},
+		{
+			Operation: OpZeroAllPrices,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionProdutosServicosEmpresa, "PrecosVendas.0.Valor"}},
+		},
+		{
+			Operation: OpAdjustPrices,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionProdutosServicosEmpresa, "PrecosVendas.0.Valor"}},
+		},
+		{
+			Operation: OpEnableMEI,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionPessoas, "_t.2"}},
+		},
+		{
+			Operation: OpChangeTributation,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionProdutosServicosEmpresa, "NcmNbs.Codigo"}},
+		},
+		{
+			Operation: OpChangeTribFederal,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionProdutosServicosEmpresa, "NcmNbs.Codigo"}},
+		},
+		{
+			Operation: OpChangeTribIbsCbs,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionProdutosServicosEmpresa, "NcmNbs.Codigo"}},
+		},
+		{
+			Operation: OpChangeNCM,
+			Versions:  homologatedDigisatVersions,
+			Paths:     []PathProbe{{database.CollectionProdutosServicosEmpresa, "NcmNbs.Codigo"}},
+		},
+	}
+}
+
+func digisatCompatPath() string {
+	return configFilePath("DIGISAT_COMPAT_FILE", digisatCompatFile)
+}
+
+// LoadOperationRequirements substitui as versões homologadas padrão pelas do
+// arquivo de compatibilidade, no formato {"ZeroStock": [{"min": "4.0", "max": "4.2"}]}.
+func LoadOperationRequirements() ([]OperationRequirement, error) {
+	reqs := DefaultOperationRequirements()
+
+	data, err := os.ReadFile(digisatCompatPath())
+	if os.IsNotExist(err) {
+		return reqs, nil
+	}
+	if err != nil {
+		return reqs, fmt.Errorf("erro ao ler %s: %w", digisatCompatPath(), err)
+	}
+
+	var versions map[OperationType][]VersionRange
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return reqs, fmt.Errorf("arquivo de compatibilidade inválido (%s): %w", digisatCompatPath(), err)
+	}
+	for i := range reqs {
+		if v, ok := versions[reqs[i].Operation]; ok {
+			reqs[i].Versions = v
+		}
+	}
+	return reqs, nil
+}
+
+// blockUnknownVersion indica se versões não homologadas devem bloquear as
+// operações (DIGISAT_VERSION_POLICY=bloquear) em vez de apenas avisar.
+func blockUnknownVersion() bool {
+	return strings.EqualFold(os.Getenv("DIGISAT_VERSION_POLICY"), "bloquear")
+}
+
+// findVersionFields procura campos cujo nome contém "Versao"/"Version" com
+// valor no formato de versão (ex.: 4.2.1.7).
+func findVersionFields(doc bson.Raw) []string {
+	var fields []fieldMatch
+	walkRawDocument(doc, "", func(v bson.RawValue) bool {
+		s, ok := v.StringValueOK()
+		if !ok {
+			return false
+		}
+		_, ok = parseVersionParts(s)
+		return ok
+	}, &fields)
+
+	var found []string
+	for _, f := range fields {
+		name := strings.ToLower(f.Path)
+		if strings.Contains(name, "versao") || strings.Contains(name, "version") {
+			found = append(found, f.Value.StringValue())
+		}
+	}
+	return found
+}
+
+// DetectDigisatVersion lê a versão instalada em DigisatUpdate e, na falta
+// dela, em ConfiguracoesServidor. O resultado fica em cache no Manager.
+func (m *Manager) DetectDigisatVersion() (*DigisatVersion, error) {
+	m.versionMu.Lock()
+	defer m.versionMu.Unlock()
+	if m.digisatVersion != nil {
+		return m.digisatVersion, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, colName := range []string{digisatUpdateCollection, database.CollectionConfiguracoesServidor} {
+		docs, err := m.repos.Metadata.LatestDocuments(ctx, colName, 50)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", colName, err)
+		}
+
+		var best *DigisatVersion
+		for _, doc := range docs {
+			for _, raw := range findVersionFields(doc) {
+				parts, _ := parseVersionParts(raw)
+				// Versões com mais partes (4.2.1.7) têm preferência sobre versões de
+				// layout fiscal (4.00) que também aparecem em campos "Versao".
+				if best == nil || len(parts) > len(best.parts) || len(parts) == len(best.parts) && compareVersionParts(parts, best.parts) > 0 {
+					best = &DigisatVersion{Raw: raw, Source: colName, parts: parts}
+				}
+			}
+		}
+
+		if best != nil {
+			m.digisatVersion = best
+			return best, nil
+		}
+	}
+	return nil, nil
+}
+
+type CompatibilityStatus struct {
+	Operation    OperationType `json:"operation"`
+	Version      string        `json:"version"`
+	Status       string        `json:"status"`
+	Message      string        `json:"message"`
+	MissingPaths []string      `json:"missingPaths,omitempty"`
+}
+
+// missingPaths devolve os caminhos ausentes em coleções que têm documentos.
+func (m *Manager) missingPaths(ctx context.Context, probes []PathProbe) ([]string, error) {
+	var missing []string
+	for _, p := range probes {
+		hasDocuments, found, err := m.repos.Metadata.HasField(ctx, p.Collection, p.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !hasDocuments {
+			continue
+		}
+		if !found {
+			missing = append(missing, p.Collection+"."+p.Path)
+		}
+	}
+	return missing, nil
+}
+
+// CheckCompatibility avalia a operação frente à versão instalada: homologada,
+// desconhecida (versão fora da lista, mas caminhos presentes) ou incompatível
+// (algum caminho usado pela operação não existe mais na base).
+func (m *Manager) CheckCompatibility(op OperationType) (*CompatibilityStatus, error) {
+	reqs, err := LoadOperationRequirements()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &CompatibilityStatus{Operation: op, Status: CompatibilitySupported}
+
+	var req *OperationRequirement
+	for i := range reqs {
+		if reqs[i].Operation == op {
+			req = &reqs[i]
+			break
+		}
+	}
+	if req == nil {
+		status.Message = "operação sem requisitos de versão"
+		return status, nil
+	}
+
+	version, err := m.DetectDigisatVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version != nil {
+		status.Version = version.Raw
+		for _, r := range req.Versions {
+			if r.contains(version.parts) {
+				status.Message = fmt.Sprintf("versão %s homologada para %s", version.Raw, op)
+				return status, nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	missing, err := m.missingPaths(ctx, req.Paths)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar estrutura da base: %w", err)
+	}
+	if len(missing) > 0 {
+		status.Status = CompatibilityIncompatible
+		status.MissingPaths = missing
+		status.Message = fmt.Sprintf("campos esperados não encontrados: %s", strings.Join(missing, ", "))
+		return status, nil
+	}
+
+	status.Status = CompatibilityUnknown
+	if version == nil {
+		status.Message = "versão do Digisat não identificada"
+	} else {
+		status.Message = fmt.Sprintf("versão %s não homologada para %s", version.Raw, op)
+	}
+	return status, nil
+}
+
+// requireCompatibility é chamada no início das operações com caminhos fixos
+// e antes de desfazê-las: bloqueia quando a estrutura da base não confere ou
+// quando a verificação falha e, para versões não homologadas, avisa (ou
+// bloqueia, conforme DIGISAT_VERSION_POLICY).
+func (m *Manager) requireCompatibility(op OperationType, log LogFunc) error {
+	status, err := m.CheckCompatibility(op)
+	if err != nil {
+		return fmt.Errorf("operação bloqueada: não foi possível verificar a compatibilidade com o Digisat: %w", err)
+	}
+
+	switch status.Status {
+	case CompatibilityIncompatible:
+		return fmt.Errorf("operação bloqueada: %s", status.Message)
+	case CompatibilityUnknown:
+		if blockUnknownVersion() {
+			return fmt.Errorf("operação bloqueada: %s", status.Message)
+		}
+		log(fmt.Sprintf("⚠️ %s; prosseguindo", status.Message))
+	}
+	return nil
+}
+
+// RequireCompatibility expõe a verificação para operações disparadas fora do
+// pacote. AdjustPricesByPercent, ChangeNCMByFilter e AdjustInventoryRebalance
+// ainda não estão neste pacote; até que estejam e chamem requireCompatibility
+// no início, como as demais, o App faz a verificação antes de invocá-las.
+func (m *Manager) RequireCompatibility(op OperationType, log LogFunc) error {
+	return m.requireCompatibility(op, log)
+}
@@ -0,0 +1,259 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var arrayIndexPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// normalizeFieldPath aceita o formato da busca (Itens[0].Quantidade) e o
+// converte para a notação de ponto do MongoDB (Itens.0.Quantidade).
+func normalizeFieldPath(path string) string {
+	return strings.TrimPrefix(arrayIndexPattern.ReplaceAllString(path, ".$1"), ".")
+}
+
+// documentIDFilter interpreta o _id informado. Texto entre aspas ("abc") é
+// sempre string; JSON estendido (ex.: {"$numberLong": "10"}) mantém o tipo;
+// um hex de 24 caracteres sem aspas casa tanto com o ObjectId quanto com a
+// string, já que o Digisat tem coleções com os dois formatos.
+func documentIDFilter(id string) interface{} {
+	trimmed := strings.TrimSpace(id)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, `"`) {
+		var wrapper bson.Raw
+		if err := bson.UnmarshalExtJSON([]byte(`{"v":`+trimmed+`}`), false, &wrapper); err == nil {
+			var v interface{}
+			if wrapper.Lookup("v").Unmarshal(&v) == nil {
+				return v
+			}
+		}
+	}
+	if oid, err := primitive.ObjectIDFromHex(trimmed); err == nil {
+		return bson.M{"$in": bson.A{oid, trimmed}}
+	}
+	return id
+}
+
+func valueToExtJSON(v bson.RawValue) string {
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, true, false)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// GetDocument devolve o documento em JSON estendido relaxado, indentado.
+func (m *Manager) GetDocument(collection, id string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	raw, err := m.conn.GetCollection(collection).FindOne(ctx, bson.M{"_id": documentIDFilter(id)}).DecodeBytes()
+	if err == mongo.ErrNoDocuments {
+		return "", fmt.Errorf("documento %s não encontrado em %s", id, collection)
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar documento: %w", err)
+	}
+
+	data, err := bson.MarshalExtJSONIndent(raw, false, false, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("erro ao converter documento: %w", err)
+	}
+	return string(data), nil
+}
+
+// coerceToType converte o novo valor para o tipo BSON do valor atual quando a
+// conversão não perde informação (ex.: 10 → Double, "2024-01-01" → Date).
+func coerceToType(v interface{}, target bsontype.Type) (interface{}, bool) {
+	switch target {
+	case bsontype.Double:
+		switch n := v.(type) {
+		case float64:
+			return n, true
+		case int32:
+			return float64(n), true
+		case int64:
+			return float64(n), true
+		}
+	case bsontype.Int32:
+		switch n := v.(type) {
+		case int32:
+			return n, true
+		case int64:
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int32(n), true
+			}
+		case float64:
+			if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int32(n), true
+			}
+		}
+	case bsontype.Int64:
+		switch n := v.(type) {
+		case int64:
+			return n, true
+		case int32:
+			return int64(n), true
+		case float64:
+			if n == math.Trunc(n) {
+				return int64(n), true
+			}
+		}
+	case bsontype.Decimal128:
+		switch n := v.(type) {
+		case primitive.Decimal128:
+			return n, true
+		case int32, int64, float64:
+			if d, err := primitive.ParseDecimal128(fmt.Sprint(n)); err == nil {
+				return d, true
+			}
+		case string:
+			if d, err := primitive.ParseDecimal128(n); err == nil {
+				return d, true
+			}
+		}
+	case bsontype.DateTime:
+		switch t := v.(type) {
+		case primitive.DateTime:
+			return t, true
+		case string:
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+				if parsed, err := time.Parse(layout, t); err == nil {
+					return primitive.NewDateTimeFromTime(parsed), true
+				}
+			}
+		}
+	case bsontype.ObjectID:
+		switch o := v.(type) {
+		case primitive.ObjectID:
+			return o, true
+		case string:
+			if oid, err := primitive.ObjectIDFromHex(o); err == nil {
+				return oid, true
+			}
+		}
+	case bsontype.String:
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+	case bsontype.Boolean:
+		if b, ok := v.(bool); ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// UpdateDocumentField altera um único campo. value é um valor em JSON estendido
+// (ex.: 10.5, "texto", {"$oid": "..."}); o tipo atual do campo é mantido
+// sempre que a conversão é segura, e a troca de tipo exige allowTypeChange.
+// O valor anterior fica registrado para rollback e no log de auditoria.
+func (m *Manager) UpdateDocumentField(collection, id, field, value string, allowTypeChange bool, log LogFunc) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	field = normalizeFieldPath(field)
+	if field == "" || field == "_id" || strings.HasPrefix(field, "_id.") {
+		return fmt.Errorf("campo inválido para edição: %s", field)
+	}
+
+	var wrapper bson.Raw
+	if err := bson.UnmarshalExtJSON([]byte(`{"v":`+value+`}`), false, &wrapper); err != nil {
+		return fmt.Errorf("valor inválido (use JSON, ex.: \"texto\", 10.5, true): %w", err)
+	}
+	var newValue interface{}
+	if err := wrapper.Lookup("v").Unmarshal(&newValue); err != nil {
+		return fmt.Errorf("valor inválido: %w", err)
+	}
+
+	coll := m.conn.GetCollection(collection)
+
+	before, err := coll.FindOne(ctx, bson.M{"_id": documentIDFilter(id)}).DecodeBytes()
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("documento %s não encontrado em %s", id, collection)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar documento: %w", err)
+	}
+	var docID interface{}
+	if err := before.Lookup("_id").Unmarshal(&docID); err != nil {
+		return fmt.Errorf("erro ao ler _id do documento: %w", err)
+	}
+
+	prev, lookupErr := before.LookupErr(strings.Split(field, ".")...)
+	existed := lookupErr == nil
+
+	if existed && prev.Type != bsontype.Null {
+		newType := wrapper.Lookup("v").Type
+		if newType != prev.Type {
+			if coerced, ok := coerceToType(newValue, prev.Type); ok {
+				newValue = coerced
+			} else if !allowTypeChange {
+				return fmt.Errorf("o campo %s é do tipo %s e o novo valor é %s; confirme a troca de tipo", field, prev.Type, newType)
+			}
+		}
+	}
+
+	// O valor lido entra no filtro: se outra pessoa alterou o campo entre a
+	// leitura e a gravação, nada é atualizado.
+	filter := bson.M{"_id": docID, field: bson.M{"$exists": false}}
+	if existed {
+		filter[field] = prev
+	}
+	result, err := coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{field: newValue}})
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar documento: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("o campo %s foi alterado por outro usuário desde a leitura; recarregue o documento e tente de novo", field)
+	}
+
+	prevJSON := ""
+	if existed {
+		prevJSON = valueToExtJSON(prev)
+	}
+	afterJSON := ""
+	if data, err := bson.MarshalExtJSON(bson.M{"v": newValue}, true, false); err == nil {
+		afterJSON = string(data)
+	}
+
+	if m.rollback != nil {
+		beforeImage, _ := bson.MarshalExtJSON(before, true, false)
+		m.rollback.RecordOperation(OpEditDocumentField,
+			fmt.Sprintf("Editar %s.%s (%s)", collection, field, id),
+			map[string]interface{}{
+				"collection":  collection,
+				"id":          docID,
+				"field":       field,
+				"existed":     existed,
+				"prev":        prevJSON,
+				"after":       afterJSON,
+				"idText":      id,
+				"beforeImage": string(beforeImage),
+			},
+			true)
+	}
+
+	if err := appendAudit(AuditEntry{
+		Operation:  string(OpEditDocumentField),
+		Collection: collection,
+		DocumentID: id,
+		Field:      field,
+		Before:     prevJSON,
+		After:      afterJSON,
+	}); err != nil {
+		log(fmt.Sprintf("⚠️ Falha ao gravar auditoria: %v", err))
+	}
+
+	log(fmt.Sprintf("✅ %s.%s atualizado no documento %s", collection, field, id))
+	return nil
+}
@@ -0,0 +1,90 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNormalizeFieldPath(t *testing.T) {
+	tests := map[string]string{
+		"Itens[0].Quantidade":    "Itens.0.Quantidade",
+		"Itens[1][2]":            "Itens.1.2",
+		"[0].Valor":              "0.Valor",
+		"PrecosVendas.0.Valor":   "PrecosVendas.0.Valor",
+		"Pessoa.Endereco.Cidade": "Pessoa.Endereco.Cidade",
+	}
+	for in, want := range tests {
+		if got := normalizeFieldPath(in); got != want {
+			t.Errorf("normalizeFieldPath(%q) = %q, esperado %q", in, got, want)
+		}
+	}
+}
+
+func TestDocumentIDFilter(t *testing.T) {
+	oid, _ := primitive.ObjectIDFromHex("5f0000000000000000000001")
+	tests := []struct {
+		name string
+		id   string
+		want interface{}
+	}{
+		{"hex casa ObjectId e texto", "5f0000000000000000000001", bson.M{"$in": bson.A{oid, "5f0000000000000000000001"}}},
+		{"hex com espaços", " 5f0000000000000000000001 ", bson.M{"$in": bson.A{oid, "5f0000000000000000000001"}}},
+		{"hex entre aspas é texto", `"5f0000000000000000000001"`, "5f0000000000000000000001"},
+		{"$oid", `{"$oid": "5f0000000000000000000001"}`, oid},
+		{"$numberLong", `{"$numberLong": "10"}`, int64(10)},
+		{"texto livre", "CFG-01", "CFG-01"},
+		{"json inválido fica como texto", `{"$oid": "zz"}`, `{"$oid": "zz"}`},
+	}
+	for _, tt := range tests {
+		if got := documentIDFilter(tt.id); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: documentIDFilter(%q) = %#v, esperado %#v", tt.name, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestCoerceToType(t *testing.T) {
+	oid := primitive.NewObjectID()
+	dec, _ := primitive.ParseDecimal128("10.5")
+	date := primitive.NewDateTimeFromTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		target bsontype.Type
+		want   interface{}
+		ok     bool
+	}{
+		{"int32 para double", int32(10), bsontype.Double, 10.0, true},
+		{"int64 para double", int64(7), bsontype.Double, 7.0, true},
+		{"texto para double", "10", bsontype.Double, nil, false},
+		{"double inteiro para int32", 42.0, bsontype.Int32, int32(42), true},
+		{"double fracionário para int32", 42.5, bsontype.Int32, nil, false},
+		{"int64 fora do int32", int64(1) << 40, bsontype.Int32, nil, false},
+		{"int32 para int64", int32(5), bsontype.Int64, int64(5), true},
+		{"double para int64", 3.0, bsontype.Int64, int64(3), true},
+		{"double para decimal", 10.5, bsontype.Decimal128, dec, true},
+		{"texto para decimal", "10.5", bsontype.Decimal128, dec, true},
+		{"texto inválido para decimal", "dez", bsontype.Decimal128, nil, false},
+		{"data ISO", "2024-01-02", bsontype.DateTime, date, true},
+		{"data RFC3339", "2024-01-02T00:00:00Z", bsontype.DateTime, date, true},
+		{"data inválida", "02/01/2024", bsontype.DateTime, nil, false},
+		{"hex para ObjectId", oid.Hex(), bsontype.ObjectID, oid, true},
+		{"texto para ObjectId", "abc", bsontype.ObjectID, nil, false},
+		{"número para texto", int32(1), bsontype.String, nil, false},
+		{"texto", "abc", bsontype.String, "abc", true},
+		{"booleano", true, bsontype.Boolean, true, true},
+		{"texto para booleano", "true", bsontype.Boolean, nil, false},
+		{"tipo sem conversão", "x", bsontype.Array, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := coerceToType(tt.value, tt.target)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: coerceToType(%#v, %s) = %#v, %v; esperado %#v, %v", tt.name, tt.value, tt.target, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -362,6 +362,99 @@ func (m *Manager) ListEmitentes(log LogFunc) ([]EmitenteBasic, error) {
 	return emitentes, nil
 }
 
+// emitenteCollectionGroup agrupa, para os logs, coleções cujos documentos
+// pertencem a um único emitente pelo campo EmpresaReferencia.
+type emitenteCollectionGroup struct {
+	label       string
+	collections []string
+}
+
+// emitenteDataGroups são as coleções de movimento do emitente, usadas na
+// exclusão e na extração de um emitente.
+var emitenteDataGroups = []emitenteCollectionGroup{
+	{"💰 Financeiro...", []string{
+		database.CollectionMovimentacoes,
+		database.CollectionRecebimentos,
+		database.CollectionPagamentos,
+		database.CollectionMovimentosConta,
+		database.CollectionBoletos,
+		database.CollectionBoletosSemParcelaRecebimentoBoleto,
+		database.CollectionCheques,
+		database.CollectionComissoes,
+		database.CollectionConsultasServicoCredito,
+		database.CollectionItensCreditoDebitoCliente,
+		database.CollectionItensCreditoDebitoCashback,
+	}},
+	{"📦 Movimentações...", []string{
+		database.CollectionAbastecimentos,
+		database.CollectionDevolucoes,
+		database.CollectionEntregasDelivery,
+		database.CollectionCartasCorrecao,
+		database.CollectionInutilizacoes,
+		database.CollectionManifestacaoDestinatario,
+		database.CollectionManifestosEletronicoDocumentoFiscal,
+		database.CollectionConhecimentosTransporteEletronico,
+		database.CollectionConhecimentosTransporteRodoviarioCargas,
+		database.CollectionRomaneiosCarga,
+		database.CollectionXmlMovimentacoes,
+	}},
+	{"🍽️ Restaurante/Food Service...", []string{
+		database.CollectionItensMesaConta,
+		database.CollectionItemMesaContaOcorrencias,
+		database.CollectionItensPedidoRestaurante,
+		database.CollectionMesasContasClienteBloqueadas,
+		database.CollectionOrdensCardapio,
+		database.CollectionReceitas,
+	}},
+	{"📊 Estoques...", []string{
+		database.CollectionEstoquesFisicos,
+		database.CollectionEstoquesFisicosMovimentacaoInterna,
+		database.CollectionConferenciasEstoque,
+		database.CollectionBicos,
+		database.CollectionDescontinuidadesEncerrante,
+		database.CollectionFolhasLmc,
+		database.CollectionSaldosIcmsStRetido,
+	}},
+	{"🏭 Produção/Indústria...", []string{
+		database.CollectionOrdensProducao,
+		database.CollectionOrcamentosIndustria,
+		database.CollectionMaosObra,
+	}},
+	{"🔗 Integrações...", []string{
+		database.CollectionAnunciosMercadoLivre,
+		database.CollectionDadosDigisatContabil,
+		database.CollectionDadosDigisatScanntech,
+		database.CollectionArquivosDigisatContabil,
+		database.CollectionArquivosSngpc,
+	}},
+	{"📅 Agendamentos/Pessoal...", []string{
+		database.CollectionAgendamentos,
+		database.CollectionTurnos,
+		database.CollectionTurnosLancamentos,
+		database.CollectionJornadasTrabalho,
+		database.CollectionInternacoes,
+	}},
+	{"📋 Contratos/Controle...", []string{
+		database.CollectionGestaoContratos,
+		database.CollectionControlesEntrega,
+		database.CollectionValesPresente,
+		database.CollectionRegistrosPafEcf,
+	}},
+}
+
+// emitenteConfigGroups são sequências e configurações do emitente, removidas
+// por último na exclusão.
+var emitenteConfigGroups = []emitenteCollectionGroup{
+	{"🔢 Sequências e Tokens...", []string{
+		database.CollectionSequenciasMovimentacoes,
+		database.CollectionTokens,
+	}},
+	{"⚙️ Configurações (crítico para o servidor)...", []string{
+		database.CollectionConfiguracoesServidor,
+		database.CollectionConfiguracoes,
+	}},
+}
+
 func (m *Manager) DeleteEmitente(emitenteID string, log LogFunc) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
@@ -404,73 +497,12 @@ func (m *Manager) DeleteEmitente(emitenteID string, log LogFunc) error {
 	log("🔄 Removendo dados vinculados ao emitente...")
 	log("   (Limpeza completa de 50+ coleções)")
 
-	log("💰 Financeiro...")
-	deleteFromCollection(database.CollectionMovimentacoes)
-	deleteFromCollection(database.CollectionRecebimentos)
-	deleteFromCollection(database.CollectionPagamentos)
-	deleteFromCollection(database.CollectionMovimentosConta)
-	deleteFromCollection(database.CollectionBoletos)
-	deleteFromCollection(database.CollectionBoletosSemParcelaRecebimentoBoleto)
-	deleteFromCollection(database.CollectionCheques)
-	deleteFromCollection(database.CollectionComissoes)
-	deleteFromCollection(database.CollectionConsultasServicoCredito)
-	deleteFromCollection(database.CollectionItensCreditoDebitoCliente)
-	deleteFromCollection(database.CollectionItensCreditoDebitoCashback)
-
-	log("📦 Movimentações...")
-	deleteFromCollection(database.CollectionAbastecimentos)
-	deleteFromCollection(database.CollectionDevolucoes)
-	deleteFromCollection(database.CollectionEntregasDelivery)
-	deleteFromCollection(database.CollectionCartasCorrecao)
-	deleteFromCollection(database.CollectionInutilizacoes)
-	deleteFromCollection(database.CollectionManifestacaoDestinatario)
-	deleteFromCollection(database.CollectionManifestosEletronicoDocumentoFiscal)
-	deleteFromCollection(database.CollectionConhecimentosTransporteEletronico)
-	deleteFromCollection(database.CollectionConhecimentosTransporteRodoviarioCargas)
-	deleteFromCollection(database.CollectionRomaneiosCarga)
-	deleteFromCollection(database.CollectionXmlMovimentacoes)
-
-	log("🍽️ Restaurante/Food Service...")
-	deleteFromCollection(database.CollectionItensMesaConta)
-	deleteFromCollection(database.CollectionItemMesaContaOcorrencias)
-	deleteFromCollection(database.CollectionItensPedidoRestaurante)
-	deleteFromCollection(database.CollectionMesasContasClienteBloqueadas)
-	deleteFromCollection(database.CollectionOrdensCardapio)
-	deleteFromCollection(database.CollectionReceitas)
-
-	log("📊 Estoques...")
-	deleteFromCollection(database.CollectionEstoquesFisicos)
-	deleteFromCollection(database.CollectionEstoquesFisicosMovimentacaoInterna)
-	deleteFromCollection(database.CollectionConferenciasEstoque)
-	deleteFromCollection(database.CollectionBicos)
-	deleteFromCollection(database.CollectionDescontinuidadesEncerrante)
-	deleteFromCollection(database.CollectionFolhasLmc)
-	deleteFromCollection(database.CollectionSaldosIcmsStRetido)
-
-	log("🏭 Produção/Indústria...")
-	deleteFromCollection(database.CollectionOrdensProducao)
-	deleteFromCollection(database.CollectionOrcamentosIndustria)
-	deleteFromCollection(database.CollectionMaosObra)
-
-	log("🔗 Integrações...")
-	deleteFromCollection(database.CollectionAnunciosMercadoLivre)
-	deleteFromCollection(database.CollectionDadosDigisatContabil)
-	deleteFromCollection(database.CollectionDadosDigisatScanntech)
-	deleteFromCollection(database.CollectionArquivosDigisatContabil)
-	deleteFromCollection(database.CollectionArquivosSngpc)
-
-	log("📅 Agendamentos/Pessoal...")
-	deleteFromCollection(database.CollectionAgendamentos)
-	deleteFromCollection(database.CollectionTurnos)
-	deleteFromCollection(database.CollectionTurnosLancamentos)
-	deleteFromCollection(database.CollectionJornadasTrabalho)
-	deleteFromCollection(database.CollectionInternacoes)
-
-	log("📋 Contratos/Controle...")
-	deleteFromCollection(database.CollectionGestaoContratos)
-	deleteFromCollection(database.CollectionControlesEntrega)
-	deleteFromCollection(database.CollectionValesPresente)
-	deleteFromCollection(database.CollectionRegistrosPafEcf)
+	for _, group := range emitenteDataGroups {
+		log(group.label)
+		for _, collectionName := range group.collections {
+			deleteFromCollection(collectionName)
+		}
+	}
 
 	log("🔗 Produtos/Serviços vinculados...")
 	pseCollection := m.conn.GetCollection(database.CollectionProdutosServicosEmpresa)
@@ -507,13 +539,12 @@ func (m *Manager) DeleteEmitente(emitenteID string, log LogFunc) error {
 		}
 	}
 
-	log("🔢 Sequências e Tokens...")
-	deleteFromCollection(database.CollectionSequenciasMovimentacoes)
-	deleteFromCollection(database.CollectionTokens)
-
-	log("⚙️ Configurações (crítico para o servidor)...")
-	deleteFromCollection(database.CollectionConfiguracoesServidor)
-	deleteFromCollection(database.CollectionConfiguracoes)
+	for _, group := range emitenteConfigGroups {
+		log(group.label)
+		for _, collectionName := range group.collections {
+			deleteFromCollection(collectionName)
+		}
+	}
 
 	log("👤 Removendo perfis de usuários vinculados ao emitente...")
 	if err := m.removeUsuarioPerfis(ctx, oid, log); err != nil {
@@ -0,0 +1,288 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExtractEmitenteResult struct {
+	Location    string `json:"location"`
+	Emitente    string `json:"emitente"`
+	Collections int    `json:"collections"`
+	Documents   int64  `json:"documents"`
+	Products    int    `json:"products"`
+	Stocks      int    `json:"stocks"`
+	Users       int    `json:"users"`
+}
+
+// ExtractEmitente copia um emitente para um banco novo ou pasta de dump: as
+// coleções que a exclusão de emitente conhece (filtradas por
+// EmpresaReferencia), os produtos e estoques vinculados pelo
+// ProdutosServicosEmpresa, os usuários com perfil no emitente e todo o
+// cadastro compartilhado (pessoas, tributações, grupos...), sem os documentos
+// dos demais emitentes. É o inverso de DeleteEmitente, para separar uma filial
+// vendida; a base de origem não é alterada.
+func (m *Manager) ExtractEmitente(emitenteID string, target CopyTarget, log LogFunc) (*ExtractEmitenteResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Hour)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(emitenteID)
+	if err != nil {
+		return nil, fmt.Errorf("ID inválido: %v", err)
+	}
+
+	pessoas := m.conn.GetCollection(database.CollectionPessoas)
+	var emitente struct {
+		Nome string `bson:"Nome"`
+		Cnpj string `bson:"Cnpj"`
+	}
+	if err := pessoas.FindOne(ctx, bson.M{"_id": oid}).Decode(&emitente); err != nil {
+		return nil, fmt.Errorf("emitente não encontrado: %w", err)
+	}
+
+	others, err := m.otherEmitentes(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, err := m.openCopyTarget(ctx, target, "emitente")
+	if err != nil {
+		return nil, err
+	}
+
+	log(fmt.Sprintf("📤 Extraindo emitente %s (%s) para %s...", emitente.Nome, emitente.Cnpj, sink.location()))
+	if len(others) > 0 {
+		log(fmt.Sprintf("   %d outros emitentes e seus dados ficarão de fora", len(others)))
+	}
+
+	result := &ExtractEmitenteResult{Location: sink.location(), Emitente: emitente.Nome}
+	err = m.extractEmitenteInto(ctx, oid, others, sink, result, log)
+	if err == nil {
+		err = sink.close(ctx)
+	}
+	if err != nil {
+		sink.discard(ctx)
+		return nil, err
+	}
+
+	log(fmt.Sprintf("✅ Emitente extraído: %d documentos em %d coleções (%d produtos, %d estoques, %d usuários) em %s",
+		result.Documents, result.Collections, result.Products, result.Stocks, result.Users, result.Location))
+	return result, nil
+}
+
+func (m *Manager) otherEmitentes(ctx context.Context, oid primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := m.conn.GetCollection(database.CollectionPessoas).Find(ctx, bson.M{
+		"_id": bson.M{"$ne": oid},
+		"_t":  bson.M{"$in": []string{"Emitente", "Matriz", "Filial"}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar emitentes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// Nunca nil: vai para $nin, que não aceita null.
+	ids := []primitive.ObjectID{}
+	for cursor.Next(ctx) {
+		if id, ok := cursor.Current.Lookup("_id").ObjectIDOK(); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, cursor.Err()
+}
+
+func (m *Manager) extractEmitenteInto(ctx context.Context, oid primitive.ObjectID, others []primitive.ObjectID, sink copySink, result *ExtractEmitenteResult, log LogFunc) error {
+	scoped := make(map[string]bool)
+	for _, groups := range [][]emitenteCollectionGroup{emitenteDataGroups, emitenteConfigGroups} {
+		for _, group := range groups {
+			for _, name := range group.collections {
+				scoped[name] = true
+			}
+		}
+	}
+
+	names, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("erro ao listar coleções: %w", err)
+	}
+	exists := make(map[string]bool, len(names))
+	for _, name := range names {
+		exists[name] = true
+	}
+
+	// ProdutosServicosEmpresa vem primeiro: dele saem os produtos e estoques
+	// do emitente.
+	products := make(map[primitive.ObjectID]bool)
+	stocks := make(map[primitive.ObjectID]bool)
+	if exists[database.CollectionProdutosServicosEmpresa] {
+		log("🔗 Produtos/Serviços do emitente...")
+		err := m.copyFiltered(ctx, sink, database.CollectionProdutosServicosEmpresa, bson.M{"EmpresaReferencia": oid}, result,
+			func(doc bson.Raw) (bson.Raw, bool) {
+				if id, ok := doc.Lookup("ProdutoServicoReferencia").ObjectIDOK(); ok {
+					products[id] = true
+				}
+				if id, ok := doc.Lookup("EstoqueReferencia").ObjectIDOK(); ok {
+					stocks[id] = true
+				}
+				return doc, true
+			}, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.HasPrefix(name, "system.") || name == database.CollectionProdutosServicosEmpresa {
+			continue
+		}
+		if m.state.ShouldStop() {
+			return fmt.Errorf("operação cancelada")
+		}
+
+		filter, keep := emitenteExtractFilter(name, oid, others, scoped, products, stocks, result)
+		if err := m.copyFiltered(ctx, sink, name, filter, result, keep, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitenteExtractFilter escolhe, para cada coleção, o filtro da consulta e o
+// keep aplicado a cada documento: coleções do emitente pela
+// EmpresaReferencia, produtos e estoques pelos vínculos lidos de
+// ProdutosServicosEmpresa, usuários só com os perfis do emitente e os
+// cadastros compartilhados sem o que pertence aos outros emitentes.
+func emitenteExtractFilter(name string, oid primitive.ObjectID, others []primitive.ObjectID, scoped map[string]bool, products, stocks map[primitive.ObjectID]bool, result *ExtractEmitenteResult) (interface{}, func(bson.Raw) (bson.Raw, bool)) {
+	switch {
+	case scoped[name]:
+		return bson.M{"EmpresaReferencia": oid}, nil
+	case name == database.CollectionProdutosServicos:
+		return bson.M{}, keepByID(products, &result.Products)
+	case name == database.CollectionEstoques:
+		return bson.M{}, keepByID(stocks, &result.Stocks)
+	case name == database.CollectionUsuarios:
+		return bson.M{}, func(doc bson.Raw) (bson.Raw, bool) {
+			out, ok := onlyEmitentePerfis(doc, oid)
+			if ok {
+				result.Users++
+			}
+			return out, ok
+		}
+	case name == database.CollectionPessoas:
+		return bson.M{"_id": bson.M{"$nin": others}}, nil
+	}
+	// Cadastros compartilhados: tudo, menos o que for de outro emitente.
+	return bson.M{"EmpresaReferencia": bson.M{"$nin": others}}, nil
+}
+
+// copyFiltered copia os documentos de collection que passam pelo filtro e por
+// keep (opcional), que também pode alterar o documento.
+func (m *Manager) copyFiltered(ctx context.Context, sink copySink, collection string, filter interface{}, result *ExtractEmitenteResult, keep func(bson.Raw) (bson.Raw, bool), log LogFunc) error {
+	coll := m.conn.GetCollection(collection)
+	specs, err := listIndexSpecs(ctx, coll)
+	if err != nil {
+		return fmt.Errorf("erro ao ler índices de %s: %w", collection, err)
+	}
+	if err := sink.indexes(ctx, collection, specs); err != nil {
+		return fmt.Errorf("erro ao criar índices de %s: %w", collection, err)
+	}
+
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var copied int64
+	for cursor.Next(ctx) {
+		doc := append(bson.Raw(nil), cursor.Current...)
+		if keep != nil {
+			var ok bool
+			if doc, ok = keep(doc); !ok {
+				continue
+			}
+		}
+		if err := sink.write(ctx, collection, doc); err != nil {
+			return err
+		}
+		copied++
+		if copied%1000 == 0 && m.state.ShouldStop() {
+			return fmt.Errorf("operação cancelada")
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", collection, err)
+	}
+
+	result.Collections++
+	result.Documents += copied
+	if copied > 0 {
+		log(fmt.Sprintf("   ✓ %s: %d documentos", collection, copied))
+	}
+	return nil
+}
+
+func keepByID(ids map[primitive.ObjectID]bool, count *int) func(bson.Raw) (bson.Raw, bool) {
+	return func(doc bson.Raw) (bson.Raw, bool) {
+		id, ok := doc.Lookup("_id").ObjectIDOK()
+		if ok && ids[id] {
+			*count++
+			return doc, true
+		}
+		return doc, false
+	}
+}
+
+// onlyEmitentePerfis mantém do usuário apenas os perfis do emitente. Usuários
+// sem perfil algum (ex.: administradores) são copiados como estão; os que só
+// têm perfis em outros emitentes ficam de fora.
+func onlyEmitentePerfis(doc bson.Raw, oid primitive.ObjectID) (bson.Raw, bool) {
+	perfis, ok := doc.Lookup("Perfis").ArrayOK()
+	if !ok {
+		return doc, true
+	}
+	values, err := perfis.Values()
+	if err != nil || len(values) == 0 {
+		return doc, true
+	}
+
+	var kept bson.A
+	for _, v := range values {
+		perfil, ok := v.DocumentOK()
+		if !ok {
+			continue
+		}
+		if id, ok := perfil.Lookup("EmpresaReferencia").ObjectIDOK(); ok && id == oid {
+			kept = append(kept, perfil)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, false
+	}
+	if len(kept) == len(values) {
+		return doc, true
+	}
+
+	var user bson.D
+	if err := bson.Unmarshal(doc, &user); err != nil {
+		return nil, false
+	}
+	for i := range user {
+		if user[i].Key == "Perfis" {
+			user[i].Value = kept
+		}
+	}
+	out, err := bson.Marshal(user)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
@@ -0,0 +1,113 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEmitenteExtractFilter(t *testing.T) {
+	emitente, outro := oid(1), oid(2)
+	others := []primitive.ObjectID{outro}
+	scoped := map[string]bool{database.CollectionMovimentacoes: true}
+	products := idSet(oid(10))
+	stocks := idSet(oid(20))
+
+	tests := []struct {
+		collection string
+		want       interface{}
+		keep       bool
+	}{
+		{database.CollectionMovimentacoes, bson.M{"EmpresaReferencia": emitente}, false},
+		{database.CollectionPessoas, bson.M{"_id": bson.M{"$nin": others}}, false},
+		{"Marcas", bson.M{"EmpresaReferencia": bson.M{"$nin": others}}, false},
+		{database.CollectionProdutosServicos, bson.M{}, true},
+		{database.CollectionEstoques, bson.M{}, true},
+		{database.CollectionUsuarios, bson.M{}, true},
+	}
+	for _, tt := range tests {
+		filter, keep := emitenteExtractFilter(tt.collection, emitente, others, scoped, products, stocks, &ExtractEmitenteResult{})
+		if !reflect.DeepEqual(filter, tt.want) {
+			t.Errorf("%s: filtro = %v, esperado %v", tt.collection, filter, tt.want)
+		}
+		if (keep != nil) != tt.keep {
+			t.Errorf("%s: keep presente = %v, esperado %v", tt.collection, keep != nil, tt.keep)
+		}
+	}
+
+	result := &ExtractEmitenteResult{}
+	_, keepProducts := emitenteExtractFilter(database.CollectionProdutosServicos, emitente, others, scoped, products, stocks, result)
+	_, keepStocks := emitenteExtractFilter(database.CollectionEstoques, emitente, others, scoped, products, stocks, result)
+	for _, tt := range []struct {
+		keep func(bson.Raw) (bson.Raw, bool)
+		id   primitive.ObjectID
+		want bool
+	}{
+		{keepProducts, oid(10), true},
+		{keepProducts, oid(11), false},
+		{keepProducts, oid(20), false},
+		{keepStocks, oid(20), true},
+		{keepStocks, oid(10), false},
+	} {
+		if _, ok := tt.keep(mustMarshal(t, bson.M{"_id": tt.id})); ok != tt.want {
+			t.Errorf("keep(%s) = %v, esperado %v", tt.id.Hex(), ok, tt.want)
+		}
+	}
+	if result.Products != 1 || result.Stocks != 1 {
+		t.Errorf("contagem = %d produtos e %d estoques, esperado 1 e 1", result.Products, result.Stocks)
+	}
+}
+
+func TestOnlyEmitentePerfis(t *testing.T) {
+	emitente, outro := oid(1), oid(2)
+	perfil := func(empresa primitive.ObjectID, nome string) bson.M {
+		return bson.M{"EmpresaReferencia": empresa, "Nome": nome}
+	}
+
+	tests := []struct {
+		name   string
+		perfis interface{}
+		want   []string
+		ok     bool
+	}{
+		{"sem perfis (administrador)", nil, nil, true},
+		{"perfis vazios", bson.A{}, nil, true},
+		{"só do emitente", bson.A{perfil(emitente, "caixa")}, []string{"caixa"}, true},
+		{"misto", bson.A{perfil(outro, "gerente"), perfil(emitente, "caixa")}, []string{"caixa"}, true},
+		{"só de outro emitente", bson.A{perfil(outro, "gerente")}, nil, false},
+	}
+	for _, tt := range tests {
+		user := bson.M{"_id": oid(50), "Login": "ana"}
+		if tt.perfis != nil {
+			user["Perfis"] = tt.perfis
+		}
+		out, ok := onlyEmitentePerfis(mustMarshal(t, user), emitente)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, esperado %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if login := out.Lookup("Login").StringValue(); login != "ana" {
+			t.Errorf("%s: Login = %q", tt.name, login)
+		}
+		var got []string
+		if arr, isArr := out.Lookup("Perfis").ArrayOK(); isArr {
+			values, _ := arr.Values()
+			for _, v := range values {
+				got = append(got, v.Document().Lookup("Nome").StringValue())
+			}
+		}
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: perfis = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
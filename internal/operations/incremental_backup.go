@@ -0,0 +1,484 @@
+package operations
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/windows"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Campos de data de alteração usados pelo Digisat, em ordem de preferência.
+// Quando uma coleção possui um deles, documentos antigos que foram alterados
+// depois do último backup também entram no incremental.
+var changeTrackingFields = []string{
+	"DataHoraAlteracao",
+	"DataAlteracao",
+	"DataHoraUltimaAlteracao",
+	"UltimaAlteracao",
+	"DataModificacao",
+}
+
+type backupEntry struct {
+	Path     string
+	Manifest *BackupManifest
+}
+
+func findBackupsWithManifest(backupDir string) []backupEntry {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return nil
+	}
+
+	var backups []backupEntry
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "backup_") {
+			continue
+		}
+		p := filepath.Join(backupDir, entry.Name())
+		if manifest, err := readBackupManifest(p); err == nil {
+			backups = append(backups, backupEntry{Path: p, Manifest: manifest})
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Manifest.StartedAt.Before(backups[j].Manifest.StartedAt)
+	})
+	return backups
+}
+
+func (m *Manager) detectTrackingField(ctx context.Context, coll *mongo.Collection) string {
+	for _, field := range changeTrackingFields {
+		n, err := coll.CountDocuments(ctx, bson.M{field: bson.M{"$exists": true}}, options.Count().SetLimit(1))
+		if err == nil && n > 0 {
+			return field
+		}
+	}
+	return ""
+}
+
+func (m *Manager) usesObjectIDs(ctx context.Context, coll *mongo.Collection) bool {
+	var doc struct {
+		ID interface{} `bson:"_id"`
+	}
+	if err := coll.FindOne(ctx, bson.M{}, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&doc); err != nil {
+		return true
+	}
+	_, ok := doc.ID.(primitive.ObjectID)
+	return ok
+}
+
+// incrementalBackup exporta apenas os documentos criados (pelo timestamp do
+// ObjectId) ou alterados (pelo campo de controle) desde o início do backup
+// anterior, no mesmo layout .bson usado pelo mongodump.
+func (m *Manager) incrementalBackup(ctx context.Context, outputDir string, base backupEntry, log LogFunc) (*BackupResult, error) {
+	since := base.Manifest.StartedAt
+	startedAt := time.Now()
+	timestamp := startedAt.Format("2006-01-02_15-04-05")
+	dbName := m.conn.Database.Name()
+
+	backupPath := filepath.Join(outputDir, fmt.Sprintf("backup_%s_incremental", timestamp))
+	dataDir := filepath.Join(backupPath, dbName)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de backup: %w", err)
+	}
+
+	log(fmt.Sprintf("📁 Backup incremental sobre %s (desde %s)", filepath.Base(base.Path), since.Format("02/01/2006 15:04:05")))
+
+	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar coleções: %w", err)
+	}
+	sort.Strings(collections)
+
+	manifest := &BackupManifest{
+		Version:        1,
+		Type:           BackupTypeIncremental,
+		Timestamp:      timestamp,
+		Database:       dbName,
+		StartedAt:      startedAt,
+		Base:           filepath.Base(base.Path),
+		Since:          &since,
+		TrackingFields: make(map[string]string),
+		Collections:    make([]ManifestCollection, 0),
+	}
+
+	var totalSize int64
+
+	for _, colName := range collections {
+		if m.state.ShouldStop() {
+			return nil, fmt.Errorf("operação cancelada")
+		}
+		if strings.HasPrefix(colName, "system.") {
+			continue
+		}
+
+		coll := m.conn.GetCollection(colName)
+
+		field := m.detectTrackingField(ctx, coll)
+		if field != "" {
+			manifest.TrackingFields[colName] = field
+		}
+		filter := incrementalFilter(m.usesObjectIDs(ctx, coll), field, since)
+
+		docs, size, err := exportCollectionBSON(ctx, coll, filter, filepath.Join(dataDir, colName+".bson"))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao exportar %s: %w", colName, err)
+		}
+		if docs == 0 {
+			continue
+		}
+
+		totalSize += size
+		manifest.Collections = append(manifest.Collections, ManifestCollection{Name: colName, Documents: docs, Bytes: size})
+		log(fmt.Sprintf("   📦 %s: %d documentos", colName, docs))
+	}
+
+	manifest.FinishedAt = time.Now()
+	if err := saveBackupManifest(backupPath, manifest); err != nil {
+		return nil, fmt.Errorf("erro ao gravar manifest: %w", err)
+	}
+
+	log(fmt.Sprintf("✅ Backup incremental concluído! %d coleções alteradas, %.2f MB", len(manifest.Collections), float64(totalSize)/1024/1024))
+	log("ℹ️ Documentos excluídos após o backup base não são registrados no incremental.")
+
+	return &BackupResult{
+		Path:      backupPath,
+		Size:      totalSize,
+		Timestamp: timestamp,
+		Type:      BackupTypeIncremental,
+	}, nil
+}
+
+// incrementalFilter seleciona os documentos criados (pelo timestamp do
+// ObjectId) ou alterados (pelo campo de controle) a partir de since.
+func incrementalFilter(usesObjectIDs bool, trackingField string, since time.Time) bson.M {
+	conditions := []bson.M{}
+	if usesObjectIDs {
+		// NewObjectIDFromTimestamp preenche contador e bytes aleatórios; o limite
+		// precisa ter zeros para incluir tudo o que foi criado no mesmo segundo.
+		var boundary primitive.ObjectID
+		binary.BigEndian.PutUint32(boundary[:4], uint32(since.Unix()))
+		conditions = append(conditions, bson.M{"_id": bson.M{"$gte": boundary}})
+	}
+	if trackingField != "" {
+		conditions = append(conditions, bson.M{trackingField: bson.M{"$gte": since}})
+	}
+
+	switch len(conditions) {
+	case 0:
+		// Sem ObjectId nem campo de controle não há como saber o que mudou: exporta tudo.
+		return bson.M{}
+	case 1:
+		return conditions[0]
+	default:
+		return bson.M{"$or": conditions}
+	}
+}
+
+func exportCollectionBSON(ctx context.Context, coll *mongo.Collection, filter bson.M, filePath string) (int64, int64, error) {
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var out *os.File
+	var w *bufio.Writer
+	var docs, size int64
+
+	for cursor.Next(ctx) {
+		if out == nil {
+			out, err = os.Create(filePath)
+			if err != nil {
+				return 0, 0, err
+			}
+			defer out.Close()
+			w = bufio.NewWriter(out)
+		}
+
+		n, err := w.Write(cursor.Current)
+		if err != nil {
+			return docs, size, err
+		}
+		docs++
+		size += int64(n)
+	}
+	if err := cursor.Err(); err != nil {
+		return docs, size, err
+	}
+
+	if w != nil {
+		if err := w.Flush(); err != nil {
+			return docs, size, err
+		}
+	}
+	return docs, size, nil
+}
+
+// backupLocator encontra os backups de uma cadeia incremental pelo nome
+// gravado no manifest: pasta ou arquivo compactado ao lado do backup pedido e,
+// com o armazenamento remoto configurado, o .tar.gz no bucket. Downloads e
+// extrações ficam em pastas temporárias removidas por cleanup.
+type backupLocator struct {
+	m         *Manager
+	dir       string
+	remoteDir string
+	staging   string
+	downloads []string
+	log       LogFunc
+}
+
+func (m *Manager) newBackupLocator(backupPath string, log LogFunc) *backupLocator {
+	l := &backupLocator{m: m, log: log}
+	if isRemoteBackupPath(backupPath) {
+		l.remoteDir = backupPath[:strings.LastIndex(backupPath, "/")+1]
+	} else {
+		l.dir = filepath.Dir(filepath.Clean(backupPath))
+	}
+	return l
+}
+
+// download baixa um backup remoto para a pasta fixa de downloads, onde um
+// download interrompido pode ser retomado.
+func (l *backupLocator) download(remotePath string) (string, error) {
+	downloadDir := filepath.Join(os.TempDir(), "digisat_remote_backups")
+	localPath, err := l.m.DownloadRemoteBackup(remotePath, downloadDir, l.log)
+	if err != nil {
+		return "", err
+	}
+	l.downloads = append(l.downloads, localPath)
+	return localPath, nil
+}
+
+func (l *backupLocator) locate(name string) (string, error) {
+	if l.dir != "" {
+		candidates := []string{filepath.Join(l.dir, name)}
+		for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+			candidates = append(candidates, filepath.Join(l.dir, name+ext))
+		}
+		for _, candidate := range candidates {
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+		}
+	}
+
+	remoteDir := l.remoteDir
+	if remoteDir == "" {
+		if client, err := remoteStorage(); err == nil {
+			remoteDir = client.URL(client.Key(""))
+		}
+	}
+	if remoteDir != "" {
+		localPath, err := l.download(remoteDir + name + ".tar.gz")
+		if err == nil {
+			return localPath, nil
+		}
+		l.log(fmt.Sprintf("⚠️ %s não disponível no armazenamento remoto: %v", name, err))
+	}
+
+	return "", fmt.Errorf("backup base %s não encontrado localmente nem no armazenamento remoto", name)
+}
+
+// folder devolve a pasta de um backup, extraindo-o se estiver compactado.
+func (l *backupLocator) folder(backupPath string) (string, error) {
+	if detectArchiveKind(backupPath) == archiveNone {
+		return backupPath, nil
+	}
+	if l.staging == "" {
+		dir, err := os.MkdirTemp("", "digisat_restore_chain_")
+		if err != nil {
+			return "", fmt.Errorf("erro ao criar pasta temporária: %w", err)
+		}
+		l.staging = dir
+	}
+	return extractBackupArchive(backupPath, l.staging, l.log)
+}
+
+func (l *backupLocator) cleanup() {
+	if l.staging != "" {
+		os.RemoveAll(l.staging)
+	}
+	for _, p := range l.downloads {
+		os.Remove(p)
+	}
+}
+
+// resolveBackupChain monta a sequência backup completo → incrementais que
+// termina em backupPath, seguindo o campo Base de cada manifest.
+func resolveBackupChain(backupPath string, locate func(name string) (string, error)) ([]backupEntry, error) {
+	var chain []backupEntry
+	current := backupPath
+	seen := make(map[string]bool)
+
+	for {
+		if seen[current] {
+			return nil, fmt.Errorf("cadeia de backups circular em %s", filepath.Base(current))
+		}
+		seen[current] = true
+
+		manifest, err := readBackupManifestAt(current)
+		if err != nil {
+			return nil, fmt.Errorf("manifest não encontrado em %s: %w", filepath.Base(current), err)
+		}
+		chain = append([]backupEntry{{Path: current, Manifest: manifest}}, chain...)
+
+		if manifest.Type != BackupTypeIncremental {
+			return chain, nil
+		}
+		if manifest.Base == "" {
+			return nil, fmt.Errorf("backup incremental %s sem backup base", filepath.Base(current))
+		}
+		if current, err = locate(manifest.Base); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// restoreBackupChain restaura o backup completo da cadeia e aplica os
+// incrementais em ordem. Toda a cadeia é localizada e extraída antes de o
+// banco ser alterado, e os serviços do Digisat só são reiniciados ao final.
+func (m *Manager) restoreBackupChain(backupPath string, locator *backupLocator, dropExisting bool, log LogFunc) error {
+	chain, err := resolveBackupChain(backupPath, locator.locate)
+	if err != nil {
+		return fmt.Errorf("restauração cancelada, nada foi alterado: %w", err)
+	}
+
+	log(fmt.Sprintf("🔗 Cadeia de restauração: %d backups", len(chain)))
+	for _, b := range chain {
+		log(fmt.Sprintf("   • %s (%s)", filepath.Base(b.Path), b.Manifest.Type))
+	}
+
+	for i := 1; i < len(chain); i++ {
+		folder, err := locator.folder(chain[i].Path)
+		if err != nil {
+			return fmt.Errorf("restauração cancelada, nada foi alterado: %w", err)
+		}
+		chain[i].Path = folder
+	}
+
+	if err := m.restoreFull(chain[0].Path, dropExisting, log); err != nil {
+		return fmt.Errorf("erro ao restaurar backup completo: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	for _, b := range chain[1:] {
+		if m.state.ShouldStop() {
+			return fmt.Errorf("operação cancelada")
+		}
+		log(fmt.Sprintf("➕ Aplicando incremental %s...", filepath.Base(b.Path)))
+		if err := m.applyIncrementalBackup(ctx, b, log); err != nil {
+			return fmt.Errorf("erro ao aplicar %s: %w", filepath.Base(b.Path), err)
+		}
+	}
+
+	log("✅ Cadeia de backups restaurada com sucesso! Reiniciando serviços do Digisat...")
+	if _, err := windows.StartDigisatServices(log); err != nil {
+		log(fmt.Sprintf("⚠️ Aviso: Falha ao reiniciar serviços: %v", err))
+	}
+	return nil
+}
+
+// applyIncrementalBackup grava os documentos do incremental com upsert por _id,
+// substituindo as versões restauradas pelos backups anteriores.
+func (m *Manager) applyIncrementalBackup(ctx context.Context, b backupEntry, log LogFunc) error {
+	dataDir := filepath.Join(b.Path, b.Manifest.Database)
+
+	for _, col := range b.Manifest.Collections {
+		f, err := os.Open(filepath.Join(dataDir, col.Name+".bson"))
+		if err != nil {
+			return err
+		}
+
+		coll := m.conn.GetCollection(col.Name)
+		r := bufio.NewReader(f)
+		var models []mongo.WriteModel
+		applied := 0
+
+		flush := func() error {
+			if len(models) == 0 {
+				return nil
+			}
+			// Em erro parcial o resultado ainda traz o que foi gravado.
+			res, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+			if res != nil {
+				applied += int(res.MatchedCount + res.UpsertedCount)
+			}
+			models = models[:0]
+			return err
+		}
+
+		for {
+			raw, err := readBSONDocument(r)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return err
+			}
+
+			id := raw.Lookup("_id")
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.D{{Key: "_id", Value: id}}).
+				SetReplacement(raw).
+				SetUpsert(true))
+
+			if len(models) >= 500 {
+				if err := flush(); err != nil {
+					f.Close()
+					return err
+				}
+			}
+		}
+		err = flush()
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		log(fmt.Sprintf("   ✓ %s: %d documentos aplicados", col.Name, applied))
+	}
+	return nil
+}
+
+// maxBSONDocumentSize é o limite de documento do MongoDB; um tamanho maior só
+// aparece em arquivo corrompido e não deve virar uma alocação gigante.
+const maxBSONDocumentSize = 16 << 20
+
+func readBSONDocument(r io.Reader) (bson.Raw, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(lenBuf[:])
+	if size < 5 {
+		return nil, fmt.Errorf("documento BSON inválido")
+	}
+	if size > maxBSONDocumentSize {
+		return nil, fmt.Errorf("documento BSON inválido: %d bytes excede o limite de 16 MB", size)
+	}
+
+	doc := make([]byte, size)
+	copy(doc, lenBuf[:])
+	if _, err := io.ReadFull(r, doc[4:]); err != nil {
+		return nil, err
+	}
+	return bson.Raw(doc), nil
+}
@@ -0,0 +1,157 @@
+package operations
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReadBSONDocument(t *testing.T) {
+	doc, err := bson.Marshal(bson.M{"_id": 1, "Nome": "Cliente"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := func(size uint32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, size)
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr bool
+		eof     bool
+	}{
+		{"documento válido", doc, false, false},
+		{"fim do arquivo", nil, true, true},
+		{"tamanho menor que o mínimo", header(4), true, false},
+		{"acima de 16 MB", header(maxBSONDocumentSize + 1), true, false},
+		{"truncado", doc[:len(doc)-3], true, false},
+	}
+	for _, tt := range tests {
+		raw, err := readBSONDocument(bytes.NewReader(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: erro = %v", tt.name, err)
+			continue
+		}
+		if tt.eof && err != io.EOF {
+			t.Errorf("%s: erro = %v, esperado io.EOF", tt.name, err)
+		}
+		if !tt.wantErr && !bytes.Equal(raw, doc) {
+			t.Errorf("%s: documento = %v", tt.name, raw)
+		}
+	}
+}
+
+func TestIncrementalFilter(t *testing.T) {
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	boundary, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%08x0000000000000000", since.Unix()))
+	byID := bson.M{"_id": bson.M{"$gte": boundary}}
+	byField := bson.M{"DataHoraAlteracao": bson.M{"$gte": since}}
+
+	tests := []struct {
+		name          string
+		usesObjectIDs bool
+		field         string
+		want          bson.M
+	}{
+		{"ObjectId e campo de controle", true, "DataHoraAlteracao", bson.M{"$or": []bson.M{byID, byField}}},
+		{"somente ObjectId", true, "", byID},
+		{"somente campo de controle", false, "DataHoraAlteracao", byField},
+		{"sem critério exporta tudo", false, "", bson.M{}},
+	}
+	for _, tt := range tests {
+		if got := incrementalFilter(tt.usesObjectIDs, tt.field, since); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: filtro = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func writeManifestDir(t *testing.T, dir, name string, manifest BackupManifest) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(p, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveBackupManifest(p, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestResolveBackupChain(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	full := writeManifestDir(t, dir, "backup_full", BackupManifest{Type: BackupTypeFull, StartedAt: start})
+	inc1 := writeManifestDir(t, dir, "backup_inc1", BackupManifest{Type: BackupTypeIncremental, Base: "backup_full", StartedAt: start.Add(time.Hour)})
+	inc2 := writeManifestDir(t, dir, "backup_inc2", BackupManifest{Type: BackupTypeIncremental, Base: "backup_inc1", StartedAt: start.Add(2 * time.Hour)})
+	orphan := writeManifestDir(t, dir, "backup_orphan", BackupManifest{Type: BackupTypeIncremental, Base: "backup_ausente"})
+	noBase := writeManifestDir(t, dir, "backup_nobase", BackupManifest{Type: BackupTypeIncremental})
+	loop := writeManifestDir(t, dir, "backup_loop", BackupManifest{Type: BackupTypeIncremental, Base: "backup_loop"})
+
+	locate := func(name string) (string, error) {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err != nil {
+			return "", fmt.Errorf("backup base %s não encontrado", name)
+		}
+		return p, nil
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{"completo", full, []string{full}, false},
+		{"dois incrementais", inc2, []string{full, inc1, inc2}, false},
+		{"base ausente", orphan, nil, true},
+		{"incremental sem base", noBase, nil, true},
+		{"cadeia circular", loop, nil, true},
+		{"sem manifest", filepath.Join(dir, "vazio"), nil, true},
+	}
+	for _, tt := range tests {
+		chain, err := resolveBackupChain(tt.path, locate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: erro = %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, b := range chain {
+			got = append(got, b.Path)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: cadeia = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindBackupsWithManifestOrdersByStart(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	writeManifestDir(t, dir, "backup_b", BackupManifest{Type: BackupTypeIncremental, StartedAt: start.Add(time.Hour)})
+	writeManifestDir(t, dir, "backup_a", BackupManifest{Type: BackupTypeFull, StartedAt: start})
+	writeManifestDir(t, dir, "outro", BackupManifest{Type: BackupTypeFull, StartedAt: start})
+	if err := os.MkdirAll(filepath.Join(dir, "backup_sem_manifest"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backups := findBackupsWithManifest(dir)
+	var names []string
+	for _, b := range backups {
+		names = append(names, filepath.Base(b.Path))
+	}
+	if want := []string{"backup_a", "backup_b"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("backups = %v, esperado %v", names, want)
+	}
+}
@@ -1,8 +1,11 @@
 package operations
 
 import (
+	"sync"
+
 	"BMongo-VIP/internal/config"
 	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/repository"
 )
 
 type LogFunc func(string)
@@ -11,25 +14,47 @@ type Manager struct {
 	conn     *database.Connection
 	state    *config.OperationState
 	rollback *RollbackManager
+	repos    *repository.Repositories
+
+	versionMu      sync.Mutex
+	digisatVersion *DigisatVersion
 }
 
 func NewManager(conn *database.Connection) *Manager {
 	return &Manager{
 		conn:  conn,
 		state: config.GetState(),
+		repos: repository.NewMongo(conn.Database),
 	}
 }
 
 func NewManagerWithRollback(conn *database.Connection, rollback *RollbackManager) *Manager {
-	return &Manager{
-		conn:     conn,
-		state:    config.GetState(),
-		rollback: rollback,
+	m := &Manager{
+		conn:  conn,
+		state: config.GetState(),
+		repos: repository.NewMongo(conn.Database),
 	}
+	m.SetRollback(rollback)
+	return m
+}
+
+// NewManagerWithRepositories cria um Manager sem conexão direta, apenas com
+// os repositórios (ex.: repository.NewMemory em testes). Só as operações que
+// já passam pelos repositórios funcionam nesse modo.
+func NewManagerWithRepositories(repos *repository.Repositories, rollback *RollbackManager) *Manager {
+	m := &Manager{
+		state: config.GetState(),
+		repos: repos,
+	}
+	m.SetRollback(rollback)
+	return m
 }
 
 func (m *Manager) SetRollback(rollback *RollbackManager) {
 	m.rollback = rollback
+	if rollback != nil {
+		rollback.compat = m.requireCompatibility
+	}
 }
 
 func (m *Manager) GetRollback() *RollbackManager {
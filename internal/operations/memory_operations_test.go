@@ -0,0 +1,241 @@
+package operations
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Produtos p1 (zerado), p2 (com estoque) e p3 (zerado, já inativo); o estoque
+// s4 é negativo e não tem vínculo com produto.
+var (
+	memP1, memP2, memP3        = oid(1), oid(2), oid(3)
+	memS1, memS2, memS3, memS4 = oid(11), oid(12), oid(13), oid(14)
+	memE1, memE2, memE3        = oid(21), oid(22), oid(23)
+	memIDs                     = map[primitive.ObjectID]string{memP1: "p1", memP2: "p2", memP3: "p3"}
+)
+
+func newMemoryManager(t *testing.T) (*Manager, *RollbackManager, *repository.Memory) {
+	t.Helper()
+	t.Setenv("DIGISAT_COMPAT_FILE", filepath.Join(t.TempDir(), "ausente.json"))
+	t.Setenv("DIGISAT_VERSION_POLICY", "")
+
+	mem := repository.NewMemory()
+	inserts := map[string][]interface{}{
+		digisatUpdateCollection: {bson.M{"Versao": "4.2.1.7"}},
+		database.CollectionProdutosServicos: {
+			bson.M{"_id": memP1, "Descricao": "Arroz Tipo 1", "CodigoInterno": "001", "Ativo": true},
+			bson.M{"_id": memP2, "Descricao": "Feijão", "CodigoInterno": "002", "Ativo": true},
+			bson.M{"_id": memP3, "Descricao": "Sal", "CodigoInterno": "003", "Ativo": false},
+		},
+		database.CollectionProdutosServicosEmpresa: {
+			bson.M{"_id": memE1, "ProdutoServicoReferencia": memP1, "EstoqueReferencia": memS1},
+			bson.M{"_id": memE2, "ProdutoServicoReferencia": memP2, "EstoqueReferencia": memS2},
+			bson.M{"_id": memE3, "ProdutoServicoReferencia": memP3, "EstoqueReferencia": memS3},
+		},
+		database.CollectionEstoques: {
+			bson.M{"_id": memS1, "Quantidades": bson.A{bson.M{"Quantidade": 0.0}}},
+			bson.M{"_id": memS2, "Quantidades": bson.A{bson.M{"Quantidade": 5.0}}},
+			bson.M{"_id": memS3, "Quantidades": bson.A{}},
+			bson.M{"_id": memS4, "Quantidades": bson.A{bson.M{"Quantidade": -2.0}}},
+		},
+	}
+	for coll, docs := range inserts {
+		if err := mem.Insert(coll, docs...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repos := mem.Repositories()
+	rollback := NewRollbackManagerWithRepositories(repos)
+	mgr := NewManagerWithRepositories(repos, rollback)
+	mgr.Reset()
+	return mgr, rollback, mem
+}
+
+func activeByID(mem *repository.Memory) map[string]bool {
+	out := make(map[string]bool)
+	for _, doc := range mem.Documents(database.CollectionProdutosServicos) {
+		out[memIDs[doc["_id"].(primitive.ObjectID)]], _ = doc["Ativo"].(bool)
+	}
+	return out
+}
+
+func quantityByID(mem *repository.Memory) map[primitive.ObjectID]float64 {
+	out := make(map[primitive.ObjectID]float64)
+	for _, doc := range mem.Documents(database.CollectionEstoques) {
+		if q, ok := doc["Quantidades"].(primitive.A); ok && len(q) > 0 {
+			out[doc["_id"].(primitive.ObjectID)], _ = q[0].(bson.M)["Quantidade"].(float64)
+		}
+	}
+	return out
+}
+
+func undoLatest(t *testing.T, rollback *RollbackManager, op OperationType) {
+	t.Helper()
+	ops := rollback.GetUndoableOperations()
+	if len(ops) == 0 || ops[0].Type != op {
+		t.Fatalf("operações registradas = %+v, esperado %s", ops, op)
+	}
+	if err := rollback.UndoOperation(ops[0].ID, func(string) {}); err != nil {
+		t.Fatalf("erro ao desfazer %s: %v", op, err)
+	}
+}
+
+func TestInactivateZeroProductsMemory(t *testing.T) {
+	mgr, rollback, mem := newMemoryManager(t)
+
+	count, err := mgr.InactivateZeroProducts(func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%d produtos inativados, esperado 1", count)
+	}
+	if got, want := activeByID(mem), map[string]bool{"p1": false, "p2": true, "p3": false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status = %v, esperado %v", got, want)
+	}
+
+	undoLatest(t, rollback, OpInactivateProducts)
+	if got, want := activeByID(mem), map[string]bool{"p1": true, "p2": true, "p3": false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status após desfazer = %v, esperado %v", got, want)
+	}
+	if ops := rollback.GetUndoableOperations(); len(ops) != 0 {
+		t.Errorf("operação desfeita continua no histórico: %+v", ops)
+	}
+}
+
+func TestInactivateZeroProductsBlockedByStructure(t *testing.T) {
+	mgr, rollback, mem := newMemoryManager(t)
+	// Sem versão homologada e sem o caminho Quantidades.0.Quantidade, a
+	// operação precisa ser bloqueada antes de gravar.
+	mgr.digisatVersion = &DigisatVersion{Raw: "9.9", parts: []int{9, 9}}
+	mem2 := repository.NewMemory()
+	if err := mem2.Insert(database.CollectionEstoques, bson.M{"Saldo": 0.0}); err != nil {
+		t.Fatal(err)
+	}
+	mgr.repos.Metadata = mem2.Repositories().Metadata
+
+	if _, err := mgr.InactivateZeroProducts(func(string) {}); err == nil || !strings.Contains(err.Error(), "bloqueada") {
+		t.Fatalf("erro = %v, esperado bloqueio", err)
+	}
+	if got := activeByID(mem); !got["p1"] {
+		t.Error("produto inativado apesar do bloqueio")
+	}
+	if ops := rollback.GetUndoableOperations(); len(ops) != 0 {
+		t.Errorf("operação bloqueada registrada: %+v", ops)
+	}
+}
+
+func TestFilterProductsMemory(t *testing.T) {
+	active := true
+	tests := []struct {
+		name   string
+		filter ProductFilter
+		want   []string
+	}{
+		{"nome (produto primeiro)", ProductFilter{Name: "arroz"}, []string{"p1"}},
+		{"vários códigos", ProductFilter{InternalCode: "001, 003"}, []string{"p1", "p3"}},
+		{"sem resultado", ProductFilter{Name: "inexistente"}, []string{}},
+		{"status (empresa primeiro)", ProductFilter{ActiveStatus: &active}, []string{"p1", "p2"}},
+		{"sem filtros", ProductFilter{}, []string{"p1", "p2", "p3"}},
+	}
+
+	mgr, _, _ := newMemoryManager(t)
+	for _, tt := range tests {
+		res, err := mgr.FilterProducts(tt.filter, func(string) {})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got := []string{}
+		for _, p := range res.Products {
+			id, _ := primitive.ObjectIDFromHex(p.ID)
+			got = append(got, memIDs[id])
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) || res.Total != int64(len(tt.want)) {
+			t.Errorf("%s: %v (total %d), esperado %v", tt.name, got, res.Total, tt.want)
+		}
+	}
+
+	res, err := mgr.FilterProducts(ProductFilter{InternalCode: "002"}, func(string) {})
+	if err != nil || len(res.Products) != 1 {
+		t.Fatalf("filtro por código = %+v, %v", res, err)
+	}
+	if p := res.Products[0]; p.Quantity != 5 || p.EmpresaID != memE2.Hex() {
+		t.Errorf("produto = %+v, esperado quantidade 5 e empresa %s", p, memE2.Hex())
+	}
+}
+
+func TestZeroStockUndoMemory(t *testing.T) {
+	mgr, rollback, mem := newMemoryManager(t)
+	before := quantityByID(mem)
+
+	if _, err := mgr.ZeroNegativeStock(func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	if q := quantityByID(mem)[memS4]; q != 0 {
+		t.Errorf("estoque negativo = %v após zerar", q)
+	}
+	undoLatest(t, rollback, OpZeroNegativeStock)
+
+	if _, err := mgr.ZeroAllStock(func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	for id, q := range quantityByID(mem) {
+		if q != 0 {
+			t.Errorf("estoque %s = %v após zerar tudo", id.Hex(), q)
+		}
+	}
+	undoLatest(t, rollback, OpZeroStock)
+
+	// Como no MongoDB, o $set em Quantidades.0.Quantidade cria a posição no
+	// estoque sem quantidades; o backup só guarda os que tinham valor.
+	before[memS3] = 0
+	if got := quantityByID(mem); !reflect.DeepEqual(got, before) {
+		t.Errorf("estoques após desfazer = %v, esperado %v", got, before)
+	}
+}
+
+func TestUndoWithoutConnection(t *testing.T) {
+	_, rollback, mem := newMemoryManager(t)
+
+	id := rollback.RecordOperation(OpZeroAllPrices, "Zerou preços", map[string]interface{}{"prices": []interface{}{}}, true)
+	err := rollback.UndoOperation(id, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "exige conexão direta") {
+		t.Errorf("erro = %v, esperado recusa por falta de conexão", err)
+	}
+	if len(rollback.GetUndoableOperations()) != 1 {
+		t.Error("operação recusada saiu do histórico")
+	}
+
+	id = rollback.RecordOperation(OpBulkActivate, "Ativou produtos", map[string]interface{}{
+		"products": []interface{}{
+			map[string]interface{}{"id": memP3.Hex(), "wasActive": true},
+			map[string]interface{}{"id": memP1.Hex(), "wasActive": false},
+		},
+	}, true)
+	if err := rollback.UndoOperation(id, func(string) {}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := activeByID(mem), map[string]bool{"p1": false, "p2": true, "p3": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("status após desfazer = %v, esperado %v", got, want)
+	}
+
+	if err := rollback.UndoOperation("inexistente", func(string) {}); err == nil {
+		t.Error("operação inexistente desfeita")
+	}
+	id = rollback.RecordOperation(OpZeroStock, "Sem undo", map[string]interface{}{}, false)
+	if err := rollback.UndoOperation(id, func(string) {}); err == nil {
+		t.Error("operação não reversível desfeita")
+	}
+}
@@ -0,0 +1,610 @@
+package operations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/brdoc"
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MergeOptions configura a mesclagem de SourceDatabase (outro banco no mesmo
+// servidor, por exemplo uma base restaurada com outro nome) no banco atual.
+type MergeOptions struct {
+	SourceDatabase string `json:"sourceDatabase"`
+	DryRun         bool   `json:"dryRun"`
+}
+
+// MergeMatch é um documento da origem reconhecido como o mesmo do destino.
+type MergeMatch struct {
+	Collection string `json:"collection"`
+	Key        string `json:"key"`
+	SourceID   string `json:"sourceId"`
+	TargetID   string `json:"targetId"`
+}
+
+// MergeSkip é um documento da origem que não foi copiado.
+type MergeSkip struct {
+	Collection string `json:"collection"`
+	ID         string `json:"id"`
+	Reason     string `json:"reason"`
+}
+
+type MergeCollectionReport struct {
+	Name         string `json:"name"`
+	Source       int64  `json:"source"`
+	Inserted     int64  `json:"inserted"`
+	Remapped     int64  `json:"remapped"`
+	Deduplicated int64  `json:"deduplicated"`
+	Identical    int64  `json:"identical"`
+	Skipped      int64  `json:"skipped"`
+}
+
+type MergeReport struct {
+	SourceDatabase string                  `json:"sourceDatabase"`
+	TargetDatabase string                  `json:"targetDatabase"`
+	DryRun         bool                    `json:"dryRun"`
+	StartedAt      time.Time               `json:"startedAt"`
+	FinishedAt     time.Time               `json:"finishedAt"`
+	Inserted       int64                   `json:"inserted"`
+	Identical      int64                   `json:"identical"`
+	References     int64                   `json:"references"`
+	Collections    []MergeCollectionReport `json:"collections"`
+	Matches        []MergeMatch            `json:"matches"`
+	Skipped        []MergeSkip             `json:"skipped"`
+}
+
+// MergeDatabase importa outra base Digisat para o banco atual:
+//   - pessoas com o mesmo CPF/CNPJ e produtos com o mesmo código de barras
+//     (ou mesmo código interno e descrição) passam a usar o cadastro do
+//     destino, assim como o ProdutosServicosEmpresa e o estoque do mesmo
+//     produto na mesma empresa;
+//   - documentos idênticos (mesmo _id e mesmo conteúdo) já existem no destino
+//     e não são copiados;
+//   - documentos cujo _id já existe no destino com outro conteúdo recebem um
+//     ObjectId novo;
+//   - toda referência a um _id trocado é reescrita, inclusive nas cópias
+//     embutidas (Pessoa, Produto, Empresa dentro das movimentações).
+//
+// Configurações e sequências de um emitente que já existe no destino, a versão
+// do Digisat e documentos com _id não-ObjectId repetido ficam de fora e
+// aparecem no relatório. Com DryRun nada é gravado.
+func (m *Manager) MergeDatabase(opts MergeOptions, log LogFunc) (*MergeReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	defer cancel()
+
+	target := m.conn.Database.Name()
+	switch {
+	case opts.SourceDatabase == "":
+		return nil, fmt.Errorf("informe o banco de origem")
+	case opts.SourceDatabase == target:
+		return nil, fmt.Errorf("o banco de origem deve ser diferente do banco atual (%s)", target)
+	}
+
+	src := m.conn.Client.Database(opts.SourceDatabase)
+	names, err := src.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar coleções da origem: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("o banco de origem %s está vazio ou não existe", opts.SourceDatabase)
+	}
+	collections := names[:0]
+	for _, name := range names {
+		if !strings.HasPrefix(name, "system.") {
+			collections = append(collections, name)
+		}
+	}
+	sort.Strings(collections)
+
+	g := &merger{
+		m:      m,
+		src:    src,
+		dst:    m.conn.Database,
+		ids:    make(map[primitive.ObjectID]primitive.ObjectID),
+		drop:   make(map[string]map[string]bool),
+		stats:  make(map[string]*MergeCollectionReport),
+		report: &MergeReport{SourceDatabase: opts.SourceDatabase, TargetDatabase: target, DryRun: opts.DryRun, StartedAt: time.Now()},
+	}
+	for _, name := range collections {
+		g.stats[name] = &MergeCollectionReport{Name: name}
+	}
+
+	if opts.DryRun {
+		log(fmt.Sprintf("🔍 Simulando mesclagem de %s em %s (nada será gravado)...", opts.SourceDatabase, target))
+	} else {
+		log(fmt.Sprintf("🔀 Mesclando %s em %s...", opts.SourceDatabase, target))
+	}
+
+	steps := []struct {
+		label string
+		run   func(context.Context) error
+	}{
+		{"👤 Comparando pessoas por CPF/CNPJ...", g.dedupePeople},
+		{"📦 Comparando produtos por código de barras e código interno...", g.dedupeProducts},
+		{"🔗 Comparando produtos por empresa e estoques...", g.dedupeProductsEmpresa},
+		{"⚙️ Verificando configurações de emitentes já existentes...", g.skipSharedConfig},
+		{"🆔 Verificando _id repetidos...", func(ctx context.Context) error { return g.findConflicts(ctx, collections) }},
+	}
+	for _, step := range steps {
+		if m.state.ShouldStop() {
+			return nil, fmt.Errorf("operação cancelada")
+		}
+		log(step.label)
+		if err := step.run(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	log("📥 Copiando documentos...")
+	inserted, err := g.copyAll(ctx, collections, opts.DryRun, log)
+	if !opts.DryRun && m.rollback != nil && len(inserted) > 0 {
+		m.rollback.RecordOperation(OpMergeDatabase,
+			fmt.Sprintf("Mesclagem de %s (%d documentos)", opts.SourceDatabase, g.report.Inserted),
+			map[string]interface{}{"inserted": inserted}, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range collections {
+		if s := g.stats[name]; s.Source > 0 {
+			g.report.Collections = append(g.report.Collections, *s)
+		}
+	}
+	g.report.FinishedAt = time.Now()
+
+	verb := "inseridos"
+	if opts.DryRun {
+		verb = "seriam inseridos"
+	}
+	log(fmt.Sprintf("✅ Mesclagem concluída: %d documentos %s, %d já existentes, %d cadastros unificados, %d documentos ignorados, %d referências reescritas",
+		g.report.Inserted, verb, g.report.Identical, len(g.report.Matches), len(g.report.Skipped), g.report.References))
+	return g.report, nil
+}
+
+func SaveMergeReport(report *MergeReport, path string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("erro ao salvar relatório: %w", err)
+	}
+	return nil
+}
+
+type merger struct {
+	m        *Manager
+	src, dst *mongo.Database
+	// ids leva o _id da origem ao _id usado no destino (cadastro unificado ou
+	// ObjectId novo).
+	ids map[primitive.ObjectID]primitive.ObjectID
+	// drop marca, por coleção, os _id da origem que não serão inseridos.
+	drop   map[string]map[string]bool
+	stats  map[string]*MergeCollectionReport
+	report *MergeReport
+}
+
+func (g *merger) stat(collection string) *MergeCollectionReport {
+	s, ok := g.stats[collection]
+	if !ok {
+		s = &MergeCollectionReport{Name: collection}
+		g.stats[collection] = s
+	}
+	return s
+}
+
+func (g *merger) dropped(collection string, id bson.RawValue) bool {
+	return g.drop[collection][id.String()]
+}
+
+func (g *merger) markDropped(collection string, id bson.RawValue) {
+	if g.drop[collection] == nil {
+		g.drop[collection] = make(map[string]bool)
+	}
+	g.drop[collection][id.String()] = true
+}
+
+func (g *merger) match(collection, key string, src, dst bson.RawValue) {
+	srcID, ok1 := src.ObjectIDOK()
+	dstID, ok2 := dst.ObjectIDOK()
+	if !ok1 || !ok2 || g.dropped(collection, src) {
+		return
+	}
+	g.ids[srcID] = dstID
+	g.markDropped(collection, src)
+	g.stat(collection).Deduplicated++
+	g.report.Matches = append(g.report.Matches, MergeMatch{Collection: collection, Key: key, SourceID: srcID.Hex(), TargetID: dstID.Hex()})
+}
+
+// identical marca um documento da origem igual ao do destino: ele não é
+// inserido e as referências a ele continuam com o mesmo _id.
+func (g *merger) identical(collection string, id bson.RawValue) {
+	if oid, ok := id.ObjectIDOK(); ok {
+		g.ids[oid] = oid
+	}
+	g.markDropped(collection, id)
+	g.stat(collection).Identical++
+	g.report.Identical++
+}
+
+func (g *merger) skip(collection string, id bson.RawValue, reason string) {
+	if g.dropped(collection, id) {
+		return
+	}
+	g.markDropped(collection, id)
+	g.stat(collection).Skipped++
+	g.report.Skipped = append(g.report.Skipped, MergeSkip{Collection: collection, ID: formatRawID(id), Reason: reason})
+}
+
+// each percorre os documentos de uma coleção com a projeção informada.
+func each(ctx context.Context, coll *mongo.Collection, filter interface{}, projection interface{}, fn func(bson.Raw)) error {
+	opts := options.Find()
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", coll.Name(), err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		fn(cursor.Current)
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", coll.Name(), err)
+	}
+	return nil
+}
+
+// personDocument devolve o CPF/CNPJ (só dígitos) do cadastro.
+func personDocument(doc bson.Raw) string {
+	elements, _ := doc.Elements()
+	for _, e := range elements {
+		if !isDocumentKey(e.Key()) {
+			continue
+		}
+		if s, ok := e.Value().StringValueOK(); ok {
+			if d := brdoc.OnlyDigits(s); len(d) == 11 || len(d) == 14 {
+				return d
+			}
+		}
+	}
+	return ""
+}
+
+func (g *merger) dedupePeople(ctx context.Context) error {
+	coll := database.CollectionPessoas
+	existing := make(map[string]bson.RawValue)
+	err := each(ctx, g.dst.Collection(coll), bson.M{}, nil, func(doc bson.Raw) {
+		if d := personDocument(doc); d != "" {
+			if _, ok := existing[d]; !ok {
+				existing[d] = copyRawValue(doc.Lookup("_id"))
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return each(ctx, g.src.Collection(coll), bson.M{}, nil, func(doc bson.Raw) {
+		if d := personDocument(doc); d != "" {
+			if dst, ok := existing[d]; ok {
+				g.match(coll, "CPF/CNPJ "+d, copyRawValue(doc.Lookup("_id")), dst)
+			}
+		}
+	})
+}
+
+func copyRawValue(v bson.RawValue) bson.RawValue {
+	v.Value = append([]byte(nil), v.Value...)
+	return v
+}
+
+// validBarcode descarta "SEM GTIN", códigos vazios e códigos curtos, que
+// costumam ser reaproveitados entre produtos diferentes.
+func validBarcode(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(s) >= 8 && brdoc.OnlyDigits(s) == s
+}
+
+// productCodeKey só unifica por código interno quando a descrição também
+// bate: códigos como "1" ou "100" se repetem entre instalações.
+func productCodeKey(doc bson.Raw) string {
+	code, _ := doc.Lookup("CodigoInterno").StringValueOK()
+	desc, _ := doc.Lookup("Descricao").StringValueOK()
+	code, desc = strings.TrimSpace(code), normalizeName(desc)
+	if code == "" || desc == "" {
+		return ""
+	}
+	return code + " / " + desc
+}
+
+func (g *merger) dedupeProducts(ctx context.Context) error {
+	coll := database.CollectionProdutosServicos
+	projection := bson.M{"CodigoBarras": 1, "CodigoInterno": 1, "Descricao": 1}
+	barcodes := make(map[string]bson.RawValue)
+	codes := make(map[string]bson.RawValue)
+	err := each(ctx, g.dst.Collection(coll), bson.M{}, projection, func(doc bson.Raw) {
+		id := copyRawValue(doc.Lookup("_id"))
+		if b, _ := doc.Lookup("CodigoBarras").StringValueOK(); validBarcode(b) {
+			if _, ok := barcodes[strings.TrimSpace(b)]; !ok {
+				barcodes[strings.TrimSpace(b)] = id
+			}
+		}
+		if key := productCodeKey(doc); key != "" {
+			if _, ok := codes[key]; !ok {
+				codes[key] = id
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return each(ctx, g.src.Collection(coll), bson.M{}, projection, func(doc bson.Raw) {
+		id := copyRawValue(doc.Lookup("_id"))
+		if b, _ := doc.Lookup("CodigoBarras").StringValueOK(); validBarcode(b) {
+			if dst, ok := barcodes[strings.TrimSpace(b)]; ok {
+				g.match(coll, "CodigoBarras "+strings.TrimSpace(b), id, dst)
+				return
+			}
+		}
+		if key := productCodeKey(doc); key != "" {
+			if dst, ok := codes[key]; ok {
+				g.match(coll, "CodigoInterno "+key, id, dst)
+			}
+		}
+	})
+}
+
+// dedupeProductsEmpresa unifica o ProdutosServicosEmpresa (e o estoque dele)
+// quando produto e empresa já foram unificados: o destino já tem preço e
+// estoque daquele produto naquela empresa, que prevalecem.
+func (g *merger) dedupeProductsEmpresa(ctx context.Context) error {
+	coll := database.CollectionProdutosServicosEmpresa
+	type pse struct{ id, estoque bson.RawValue }
+	projection := bson.M{"ProdutoServicoReferencia": 1, "EmpresaReferencia": 1, "EstoqueReferencia": 1}
+	key := func(doc bson.Raw, mapped bool) (string, bool) {
+		produto, ok1 := doc.Lookup("ProdutoServicoReferencia").ObjectIDOK()
+		empresa, ok2 := doc.Lookup("EmpresaReferencia").ObjectIDOK()
+		if !ok1 || !ok2 {
+			return "", false
+		}
+		if mapped {
+			var okP, okE bool
+			if produto, okP = g.ids[produto]; !okP {
+				return "", false
+			}
+			if empresa, okE = g.ids[empresa]; !okE {
+				return "", false
+			}
+		}
+		return produto.Hex() + "/" + empresa.Hex(), true
+	}
+
+	existing := make(map[string]pse)
+	err := each(ctx, g.dst.Collection(coll), bson.M{}, projection, func(doc bson.Raw) {
+		if k, ok := key(doc, false); ok {
+			if _, dup := existing[k]; !dup {
+				existing[k] = pse{copyRawValue(doc.Lookup("_id")), copyRawValue(doc.Lookup("EstoqueReferencia"))}
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return each(ctx, g.src.Collection(coll), bson.M{}, projection, func(doc bson.Raw) {
+		k, ok := key(doc, true)
+		if !ok {
+			return
+		}
+		dst, ok := existing[k]
+		if !ok {
+			return
+		}
+		g.match(coll, "Produto/Empresa "+k, copyRawValue(doc.Lookup("_id")), dst.id)
+		if estoque, err := doc.LookupErr("EstoqueReferencia"); err == nil && dst.estoque.Type != 0 {
+			g.match(database.CollectionEstoques, "Estoque de "+k, copyRawValue(estoque), dst.estoque)
+		}
+	})
+}
+
+// skipSharedConfig deixa de fora configurações, sequências e tokens de
+// emitentes unificados (o destino já tem os seus) e a versão do Digisat.
+func (g *merger) skipSharedConfig(ctx context.Context) error {
+	for _, group := range emitenteConfigGroups {
+		for _, coll := range group.collections {
+			err := each(ctx, g.src.Collection(coll), bson.M{}, bson.M{"EmpresaReferencia": 1}, func(doc bson.Raw) {
+				empresa, ok := doc.Lookup("EmpresaReferencia").ObjectIDOK()
+				if _, unified := g.ids[empresa]; ok && unified {
+					g.skip(coll, copyRawValue(doc.Lookup("_id")), "configuração de emitente já existente no destino")
+				}
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return each(ctx, g.src.Collection(digisatUpdateCollection), bson.M{}, bson.M{"_id": 1}, func(doc bson.Raw) {
+		g.skip(digisatUpdateCollection, copyRawValue(doc.Lookup("_id")), "versão do Digisat do destino prevalece")
+	})
+}
+
+const mergeLookupBatch = 1000
+
+// findConflicts procura, em lotes, _id da origem que já existem no destino.
+// O conteúdo é comparado por hash: documento idêntico não é copiado, e só os
+// diferentes recebem um _id novo.
+func (g *merger) findConflicts(ctx context.Context, collections []string) error {
+	for _, coll := range collections {
+		if g.m.state.ShouldStop() {
+			return fmt.Errorf("operação cancelada")
+		}
+
+		var batch []bson.RawValue
+		hashes := make(map[string][sha256.Size]byte)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			in := make(bson.A, len(batch))
+			for i, id := range batch {
+				in[i] = id
+			}
+			batch = batch[:0]
+			defer func() { hashes = make(map[string][sha256.Size]byte) }()
+			return each(ctx, g.dst.Collection(coll), bson.M{"_id": bson.M{"$in": in}}, nil, func(doc bson.Raw) {
+				id := copyRawValue(doc.Lookup("_id"))
+				if hash, ok := hashes[id.String()]; ok && hash == sha256.Sum256(doc) {
+					g.identical(coll, id)
+					return
+				}
+				if oid, ok := id.ObjectIDOK(); ok {
+					if _, mapped := g.ids[oid]; !mapped {
+						g.ids[oid] = primitive.NewObjectID()
+						g.stat(coll).Remapped++
+					}
+					return
+				}
+				g.skip(coll, id, "_id já existe no destino")
+			})
+		}
+
+		var flushErr error
+		err := each(ctx, g.src.Collection(coll), bson.M{}, nil, func(doc bson.Raw) {
+			id := copyRawValue(doc.Lookup("_id"))
+			if flushErr != nil || g.dropped(coll, id) {
+				return
+			}
+			batch = append(batch, id)
+			hashes[id.String()] = sha256.Sum256(doc)
+			if len(batch) >= mergeLookupBatch {
+				flushErr = flush()
+			}
+		})
+		if err == nil {
+			err = flushErr
+		}
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewrite troca, em qualquer profundidade, os ObjectId mapeados.
+func (g *merger) rewrite(v interface{}) (interface{}, int64) {
+	switch x := v.(type) {
+	case primitive.ObjectID:
+		if id, ok := g.ids[x]; ok && id != x {
+			return id, 1
+		}
+	case bson.D:
+		var n int64
+		for i := range x {
+			var c int64
+			x[i].Value, c = g.rewrite(x[i].Value)
+			n += c
+		}
+		return x, n
+	case primitive.A:
+		var n int64
+		for i := range x {
+			var c int64
+			x[i], c = g.rewrite(x[i])
+			n += c
+		}
+		return x, n
+	}
+	return v, 0
+}
+
+// copyAll grava os documentos da origem no destino e devolve os _id
+// inseridos por coleção, para o desfazer.
+func (g *merger) copyAll(ctx context.Context, collections []string, dryRun bool, log LogFunc) (map[string][]interface{}, error) {
+	inserted := make(map[string][]interface{})
+	sink := &databaseSink{db: g.dst, pending: make(map[string][]interface{})}
+
+	existing, err := g.dst.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return inserted, fmt.Errorf("erro ao listar coleções do destino: %w", err)
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	for _, coll := range collections {
+		if g.m.state.ShouldStop() {
+			return inserted, fmt.Errorf("operação cancelada")
+		}
+
+		if !dryRun && !exists[coll] {
+			specs, err := listIndexSpecs(ctx, g.src.Collection(coll))
+			if err != nil {
+				return inserted, fmt.Errorf("erro ao ler índices de %s: %w", coll, err)
+			}
+			if err := sink.indexes(ctx, coll, specs); err != nil {
+				return inserted, fmt.Errorf("erro ao criar índices de %s: %w", coll, err)
+			}
+		}
+
+		stat := g.stat(coll)
+		var writeErr error
+		err := each(ctx, g.src.Collection(coll), bson.M{}, nil, func(raw bson.Raw) {
+			stat.Source++
+			if writeErr != nil || g.dropped(coll, raw.Lookup("_id")) {
+				return
+			}
+
+			var doc bson.D
+			if writeErr = bson.Unmarshal(raw, &doc); writeErr != nil {
+				return
+			}
+			v, n := g.rewrite(doc)
+			g.report.References += n
+			stat.Inserted++
+			g.report.Inserted++
+			if dryRun {
+				return
+			}
+
+			out, err := bson.Marshal(v)
+			if err != nil {
+				writeErr = err
+				return
+			}
+			inserted[coll] = append(inserted[coll], bson.Raw(out).Lookup("_id"))
+			writeErr = sink.write(ctx, coll, out)
+		})
+		if err == nil {
+			err = writeErr
+		}
+		if err == nil && !dryRun {
+			err = sink.flush(ctx, coll)
+		}
+		if err != nil {
+			return inserted, fmt.Errorf("erro ao mesclar %s: %w", coll, err)
+		}
+
+		if stat.Inserted > 0 || stat.Deduplicated > 0 || stat.Identical > 0 || stat.Skipped > 0 {
+			log(fmt.Sprintf("   ✓ %s: %d inseridos, %d com novo _id, %d já existentes, %d unificados, %d ignorados",
+				coll, stat.Inserted, stat.Remapped, stat.Identical, stat.Deduplicated, stat.Skipped))
+		}
+	}
+	return inserted, nil
+}
@@ -0,0 +1,229 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	OrphanFixDelete = "excluir"
+	OrphanFixRelink = "religar"
+)
+
+const orphanSampleSize = 20
+
+// OrphanReference descreve um campo de referência: Field em Collection deve
+// apontar para o _id de um documento existente em Target.
+type OrphanReference struct {
+	Collection string `json:"collection"`
+	Field      string `json:"field"`
+	Target     string `json:"target"`
+}
+
+type OrphanReport struct {
+	OrphanReference
+	Count   int      `json:"count"`
+	Samples []string `json:"samples"`
+}
+
+func OrphanReferences() []OrphanReference {
+	pse := database.CollectionProdutosServicosEmpresa
+	return []OrphanReference{
+		{pse, "ProdutoServicoReferencia", database.CollectionProdutosServicos},
+		{pse, "EstoqueReferencia", database.CollectionEstoques},
+		{pse, "TributacaoEstadualReferencia", database.CollectionTributacoesEstadual},
+		{pse, "TributacaoFederalReferencia", database.CollectionTributacoesFederal},
+		{pse, "TributacaoIbsCbsReferencia", database.CollectionTributacoesIbsCbs},
+		{pse, "TributacaoMunicipalReferencia", database.CollectionTributacoesMunicipal},
+		{pse, "PrecoReferencia", database.CollectionPrecos},
+		{pse, "EmpresaReferencia", database.CollectionPessoas},
+		{database.CollectionProdutosServicos, "MarcaReferencia", database.CollectionMarcas},
+		{database.CollectionProdutosServicos, "TipoItemReferencia", database.CollectionTiposItem},
+		{database.CollectionProdutosServicos, "GeneroItemReferencia", database.CollectionGenerosItem},
+		{database.CollectionEstoques, "EmpresaReferencia", database.CollectionPessoas},
+		{database.CollectionMovimentacoes, "EmpresaReferencia", database.CollectionPessoas},
+	}
+}
+
+func findOrphanReference(collection, field string) (OrphanReference, bool) {
+	for _, ref := range OrphanReferences() {
+		if ref.Collection == collection && ref.Field == field {
+			return ref, true
+		}
+	}
+	return OrphanReference{}, false
+}
+
+// orphanPipeline seleciona os documentos cujo campo de referência está
+// preenchido mas não encontra documento correspondente na coleção alvo.
+func (r OrphanReference) orphanPipeline() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{r.Field: bson.M{"$exists": true, "$ne": nil}}}},
+		{{Key: "$project", Value: bson.M{"_id": 1, r.Field: 1}}},
+		{{Key: "$lookup", Value: bson.M{"from": r.Target, "localField": r.Field, "foreignField": "_id", "as": "_alvo"}}},
+		{{Key: "$match", Value: bson.M{"_alvo": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"_alvo": 0}}},
+	}
+}
+
+type orphanDocument struct {
+	ID    interface{}
+	Value interface{}
+}
+
+func (m *Manager) findOrphans(ctx context.Context, ref OrphanReference) ([]orphanDocument, error) {
+	cursor, err := m.conn.GetCollection(ref.Collection).Aggregate(ctx, ref.orphanPipeline())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orphans []orphanDocument
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, orphanDocument{ID: doc["_id"], Value: doc[ref.Field]})
+	}
+	return orphans, cursor.Err()
+}
+
+func formatID(id interface{}) string {
+	if oid, ok := id.(primitive.ObjectID); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprint(id)
+}
+
+// ScanOrphanReferences percorre os campos de referência conhecidos e relata
+// os documentos que apontam para registros inexistentes.
+func (m *Manager) ScanOrphanReferences(log LogFunc) ([]OrphanReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	log("🔍 Procurando referências órfãs...")
+
+	reports := make([]OrphanReport, 0)
+	total := 0
+	for _, ref := range OrphanReferences() {
+		if m.state.ShouldStop() {
+			log("Operação cancelada")
+			break
+		}
+
+		orphans, err := m.findOrphans(ctx, ref)
+		if err != nil {
+			log(fmt.Sprintf("   ⚠️ %s.%s: %v", ref.Collection, ref.Field, err))
+			continue
+		}
+		if len(orphans) == 0 {
+			continue
+		}
+
+		report := OrphanReport{OrphanReference: ref, Count: len(orphans), Samples: make([]string, 0)}
+		for i := 0; i < len(orphans) && i < orphanSampleSize; i++ {
+			report.Samples = append(report.Samples, formatID(orphans[i].ID))
+		}
+		reports = append(reports, report)
+		total += len(orphans)
+
+		log(fmt.Sprintf("   ⚠️ %s.%s → %s: %d órfãos", ref.Collection, ref.Field, ref.Target, len(orphans)))
+	}
+
+	log(fmt.Sprintf("✅ Verificação concluída: %d referências órfãs", total))
+	return reports, nil
+}
+
+// FixOrphanReferences corrige os órfãos de um campo, excluindo os documentos
+// ou religando a referência a targetID. A correção fica registrada para rollback.
+func (m *Manager) FixOrphanReferences(collection, field, mode, targetID string, log LogFunc) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	ref, ok := findOrphanReference(collection, field)
+	if !ok {
+		return 0, fmt.Errorf("referência desconhecida: %s.%s", collection, field)
+	}
+
+	var target primitive.ObjectID
+	if mode == OrphanFixRelink {
+		oid, err := primitive.ObjectIDFromHex(targetID)
+		if err != nil {
+			return 0, fmt.Errorf("ID de destino inválido: %w", err)
+		}
+		n, err := m.conn.GetCollection(ref.Target).CountDocuments(ctx, bson.M{"_id": oid})
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, fmt.Errorf("destino %s não existe em %s", targetID, ref.Target)
+		}
+		target = oid
+	} else if mode != OrphanFixDelete {
+		return 0, fmt.Errorf("modo de correção inválido: %s", mode)
+	}
+
+	orphans, err := m.findOrphans(ctx, ref)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar órfãos: %w", err)
+	}
+	if len(orphans) == 0 {
+		log("Nenhuma referência órfã encontrada")
+		return 0, nil
+	}
+
+	if mode == OrphanFixRelink {
+		coll := m.conn.GetCollection(ref.Collection)
+		fixed := 0
+		log(fmt.Sprintf("🔗 Religando %d documentos de %s.%s a %s...", len(orphans), ref.Collection, ref.Field, targetID))
+
+		documents := make([]map[string]interface{}, 0, len(orphans))
+		for _, o := range orphans {
+			result, err := coll.UpdateOne(ctx, bson.M{"_id": o.ID}, bson.M{"$set": bson.M{ref.Field: target}})
+			if err != nil {
+				log(fmt.Sprintf("   ⚠️ %s: %v", formatID(o.ID), err))
+				continue
+			}
+			if result.ModifiedCount > 0 {
+				fixed++
+				documents = append(documents, map[string]interface{}{"id": o.ID, "prev": o.Value})
+			}
+		}
+
+		if m.rollback != nil && len(documents) > 0 {
+			m.rollback.RecordOperation(OpFixOrphanReferences,
+				fmt.Sprintf("Religar %d órfãos de %s.%s", fixed, ref.Collection, ref.Field),
+				map[string]interface{}{"collection": ref.Collection, "field": ref.Field, "mode": mode, "documents": documents},
+				true)
+		}
+
+		log(fmt.Sprintf("✅ %d documentos religados", fixed))
+		return fixed, nil
+	}
+
+	log(fmt.Sprintf("🗑️ Excluindo %d documentos órfãos de %s...", len(orphans), ref.Collection))
+
+	ids := make([]interface{}, len(orphans))
+	for i, o := range orphans {
+		ids[i] = o.ID
+	}
+	backup, fixed := m.deleteWithBackup(ctx, ref.Collection, ids, log)
+
+	if m.rollback != nil && len(backup) > 0 {
+		m.rollback.RecordOperation(OpFixOrphanReferences,
+			fmt.Sprintf("Excluir %d órfãos de %s.%s", fixed, ref.Collection, ref.Field),
+			map[string]interface{}{"collection": ref.Collection, "field": ref.Field, "mode": mode, "deleted": backup},
+			true)
+	}
+
+	log(fmt.Sprintf("✅ %d documentos órfãos excluídos", fixed))
+	return fixed, nil
+}
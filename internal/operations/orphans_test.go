@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOrphanReferencesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, ref := range OrphanReferences() {
+		key := ref.Collection + "." + ref.Field
+		if seen[key] {
+			t.Errorf("referência repetida: %s", key)
+		}
+		seen[key] = true
+		if ref.Target == "" {
+			t.Errorf("%s sem coleção alvo", key)
+		}
+		if got, ok := findOrphanReference(ref.Collection, ref.Field); !ok || got != ref {
+			t.Errorf("findOrphanReference(%s) = %v, %v", key, got, ok)
+		}
+	}
+	if _, ok := findOrphanReference(database.CollectionPessoas, "Nome"); ok {
+		t.Error("referência desconhecida encontrada")
+	}
+}
+
+func TestOrphanPipeline(t *testing.T) {
+	ref, _ := findOrphanReference(database.CollectionProdutosServicosEmpresa, "EstoqueReferencia")
+	pipeline := ref.orphanPipeline()
+
+	var stages []string
+	for _, stage := range pipeline {
+		stages = append(stages, stage[0].Key)
+	}
+	if want := []string{"$match", "$project", "$lookup", "$match", "$project"}; !reflect.DeepEqual(stages, want) {
+		t.Fatalf("estágios = %v, esperado %v", stages, want)
+	}
+
+	// Referências vazias ou nulas não são órfãs: só entram campos preenchidos.
+	if got, want := pipeline[0][0].Value, (bson.M{"EstoqueReferencia": bson.M{"$exists": true, "$ne": nil}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("$match = %v", got)
+	}
+	lookup := pipeline[2][0].Value.(bson.M)
+	if lookup["from"] != database.CollectionEstoques || lookup["localField"] != "EstoqueReferencia" || lookup["foreignField"] != "_id" {
+		t.Errorf("$lookup = %v", lookup)
+	}
+}
+
+func TestFixOrphanReferencesValidation(t *testing.T) {
+	m := &Manager{}
+	pse := database.CollectionProdutosServicosEmpresa
+
+	tests := []struct {
+		name       string
+		collection string
+		field      string
+		mode       string
+		target     string
+		wantErr    string
+	}{
+		{"referência desconhecida", pse, "Ncm", OrphanFixDelete, "", "referência desconhecida"},
+		{"modo inválido", pse, "EstoqueReferencia", "zerar", "", "modo de correção inválido"},
+		{"destino inválido", pse, "EstoqueReferencia", OrphanFixRelink, "abc", "ID de destino inválido"},
+	}
+	for _, tt := range tests {
+		_, err := m.FixOrphanReferences(tt.collection, tt.field, tt.mode, tt.target, func(string) {})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: erro = %v, esperado %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatID(t *testing.T) {
+	oid := primitive.NewObjectID()
+	tests := []struct {
+		id   interface{}
+		want string
+	}{
+		{oid, oid.Hex()},
+		{"texto", "texto"},
+		{int64(7), "7"},
+	}
+	for _, tt := range tests {
+		if got := formatID(tt.id); got != tt.want {
+			t.Errorf("formatID(%v) = %s, esperado %s", tt.id, got, tt.want)
+		}
+	}
+}
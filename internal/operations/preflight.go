@@ -0,0 +1,242 @@
+package operations
+
+import (
+	"BMongo-VIP/internal/windows"
+	"archive/zip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	PreflightOK      = "ok"
+	PreflightWarning = "warning"
+	PreflightBlocked = "blocked"
+
+	// Margem sobre o espaço estimado abaixo da qual a operação segue, mas com aviso.
+	preflightWarnMargin = 1.2
+)
+
+type PreflightCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type PreflightResult struct {
+	Operation     string           `json:"operation"`
+	RequiredBytes uint64           `json:"requiredBytes"`
+	Status        string           `json:"status"`
+	Checks        []PreflightCheck `json:"checks"`
+}
+
+func newPreflight(operation string) *PreflightResult {
+	return &PreflightResult{
+		Operation: operation,
+		Status:    PreflightOK,
+		Checks:    make([]PreflightCheck, 0),
+	}
+}
+
+func (r *PreflightResult) add(name, status, message string) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Status: status, Message: message})
+
+	switch {
+	case status == PreflightBlocked:
+		r.Status = PreflightBlocked
+	case status == PreflightWarning && r.Status == PreflightOK:
+		r.Status = PreflightWarning
+	}
+}
+
+func (r *PreflightResult) checkSpace(name, dir string, required uint64) {
+	r.RequiredBytes += required
+
+	free, err := windows.FreeDiskSpace(dir)
+	if err != nil {
+		r.add(name, PreflightWarning, fmt.Sprintf("não foi possível verificar o espaço livre em %s: %v", dir, err))
+		return
+	}
+
+	msg := fmt.Sprintf("%s: necessário ~%s, livre %s", dir, formatBytes(required), formatBytes(free))
+	switch {
+	case free < required:
+		r.add(name, PreflightBlocked, "espaço insuficiente em "+msg)
+	case float64(free) < float64(required)*preflightWarnMargin:
+		r.add(name, PreflightWarning, "pouca folga em "+msg)
+	default:
+		r.add(name, PreflightOK, msg)
+	}
+}
+
+func (r *PreflightResult) Log(log LogFunc) {
+	for _, c := range r.Checks {
+		icon := "✅"
+		switch c.Status {
+		case PreflightWarning:
+			icon = "⚠️"
+		case PreflightBlocked:
+			icon = "⛔"
+		}
+		log(fmt.Sprintf("%s [%s] %s", icon, c.Name, c.Message))
+	}
+}
+
+func (r *PreflightResult) Err() error {
+	if r.Status != PreflightBlocked {
+		return nil
+	}
+
+	var reasons []string
+	for _, c := range r.Checks {
+		if c.Status == PreflightBlocked {
+			reasons = append(reasons, c.Message)
+		}
+	}
+	return fmt.Errorf("verificação prévia bloqueou %s: %s", r.Operation, strings.Join(reasons, "; "))
+}
+
+func formatBytes(n uint64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.2f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(n)/(1<<20))
+	default:
+		return fmt.Sprintf("%.2f KB", float64(n)/(1<<10))
+	}
+}
+
+func (m *Manager) PreflightBackup(outputDir string) *PreflightResult {
+	return m.preflightBackup(outputDir, true)
+}
+
+// preflightBackup verifica o espaço para um backup. O incremental não usa o
+// mongodump; o espaço exigido é o do banco inteiro, o pior caso.
+func (m *Manager) preflightBackup(outputDir string, dump bool) *PreflightResult {
+	result := newPreflight("backup")
+
+	if dump && findMongoTool("mongodump") == "" {
+		result.add("ferramentas", PreflightBlocked, "mongodump não encontrado")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var stats struct {
+		DataSize float64 `bson:"dataSize"`
+	}
+	if err := m.conn.Database.RunCommand(ctx, bson.D{{Key: "dbStats", Value: 1}, {Key: "scale", Value: 1}}).Decode(&stats); err != nil {
+		result.add("banco", PreflightWarning, fmt.Sprintf("não foi possível obter estatísticas do banco: %v", err))
+		return result
+	}
+
+	// O mongodump grava os documentos em BSON sem compressão, próximo ao dataSize do banco.
+	required := uint64(stats.DataSize * 1.1)
+	result.checkSpace("destino", outputDir, required)
+
+	return result
+}
+
+func (m *Manager) PreflightRestore(backupPath string) *PreflightResult {
+	result := newPreflight("restauração")
+
+	if findMongoTool("mongorestore") == "" {
+		result.add("ferramentas", PreflightBlocked, "mongorestore não encontrado")
+	}
+
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		result.add("origem", PreflightBlocked, fmt.Sprintf("backup não encontrado: %s", backupPath))
+		return result
+	}
+
+	var dataSize uint64
+	switch detectArchiveKind(backupPath) {
+	case archiveZip:
+		extracted, err := zipUncompressedSize(backupPath)
+		if err != nil {
+			result.add("origem", PreflightBlocked, fmt.Sprintf("ZIP inválido: %v", err))
+			return result
+		}
+		result.checkSpace("extração temporária", os.TempDir(), extracted)
+		dataSize = extracted
+	case archiveTarGz:
+		dataSize = gzipUncompressedSize(backupPath, uint64(info.Size()))
+	case archiveTar:
+		dataSize = uint64(info.Size())
+	default:
+		dataSize = dumpFolderSize(backupPath)
+	}
+
+	dbPath := windows.MongoDBDataPath()
+	if dbPath == "" {
+		result.add("dados do MongoDB", PreflightWarning, "diretório de dados do MongoDB não localizado; espaço não verificado")
+		return result
+	}
+	result.checkSpace("dados do MongoDB", dbPath, dataSize)
+
+	return result
+}
+
+func zipUncompressedSize(zipPath string) (uint64, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var total uint64
+	for _, f := range r.File {
+		total += f.UncompressedSize64
+	}
+	return total, nil
+}
+
+// gzipUncompressedSize lê o campo ISIZE do rodapé gzip (tamanho módulo 2^32)
+// e o ajusta para nunca ficar abaixo do tamanho comprimido.
+func gzipUncompressedSize(filePath string, compressed uint64) uint64 {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return compressed
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(-4, io.SeekEnd); err != nil {
+		return compressed
+	}
+
+	var trailer [4]byte
+	if _, err := io.ReadFull(f, trailer[:]); err != nil {
+		return compressed
+	}
+
+	size := uint64(binary.LittleEndian.Uint32(trailer[:]))
+	for size < compressed {
+		size += 1 << 32
+	}
+	return size
+}
+
+func dumpFolderSize(dir string) uint64 {
+	var total uint64
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(info.Name(), ".gz") {
+			total += gzipUncompressedSize(p, uint64(info.Size()))
+		} else {
+			total += uint64(info.Size())
+		}
+		return nil
+	})
+	return total
+}
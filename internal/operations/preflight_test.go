@@ -0,0 +1,154 @@
+package operations
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGzip(t *testing.T, path string, data []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGzipUncompressedSize(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "Pessoas.bson.gz")
+	writeGzip(t, plain, bytes.Repeat([]byte("a"), 100000))
+
+	// ISIZE menor que o arquivo comprimido: o tamanho real passou de 4 GiB.
+	wrapped := filepath.Join(dir, "wrapped.gz")
+	trailer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(trailer, 10)
+	if err := os.WriteFile(wrapped, append(bytes.Repeat([]byte{0}, 60), trailer...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		compressed uint64
+		want       uint64
+	}{
+		{"ISIZE do rodapé", plain, 200, 100000},
+		{"acima de 4 GiB", wrapped, 64, 1<<32 + 10},
+		{"arquivo ausente", filepath.Join(dir, "ausente.gz"), 123, 123},
+	}
+	for _, tt := range tests {
+		if got := gzipUncompressedSize(tt.path, tt.compressed); got != tt.want {
+			t.Errorf("%s: gzipUncompressedSize = %d, esperado %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestZipUncompressedSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, size := range map[string]int{"DigisatServer/Pessoas.bson": 3000, "DigisatServer/manifest.json": 20} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(bytes.Repeat([]byte("x"), size))
+	}
+	zw.Close()
+	f.Close()
+
+	got, err := zipUncompressedSize(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 3020 {
+		t.Errorf("zipUncompressedSize = %d, esperado 3020", got)
+	}
+	if _, err := zipUncompressedSize(filepath.Join(t.TempDir(), "ausente.zip")); err == nil {
+		t.Error("ZIP ausente aceito")
+	}
+}
+
+func TestDumpFolderSize(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "DigisatServer")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "Estados.bson"), make([]byte, 500), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeGzip(t, filepath.Join(sub, "Pessoas.bson.gz"), make([]byte, 8000))
+
+	if got := dumpFolderSize(dir); got != 8500 {
+		t.Errorf("dumpFolderSize = %d, esperado 8500 (gz contado pelo tamanho descompactado)", got)
+	}
+}
+
+func TestPreflightStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     string
+	}{
+		{"sem verificações", nil, PreflightOK},
+		{"aviso", []string{PreflightOK, PreflightWarning, PreflightOK}, PreflightWarning},
+		{"bloqueio prevalece", []string{PreflightBlocked, PreflightWarning}, PreflightBlocked},
+	}
+	for _, tt := range tests {
+		r := newPreflight("backup")
+		for i, status := range tt.statuses {
+			r.add("check", status, strings.Repeat("m", i+1))
+		}
+		if r.Status != tt.want {
+			t.Errorf("%s: status = %s, esperado %s", tt.name, r.Status, tt.want)
+		}
+		if (r.Err() != nil) != (tt.want == PreflightBlocked) {
+			t.Errorf("%s: Err = %v", tt.name, r.Err())
+		}
+	}
+}
+
+func TestCheckSpaceBlocksWhenInsufficient(t *testing.T) {
+	r := newPreflight("extração")
+	r.checkSpace("extração", t.TempDir(), 1<<62)
+	if r.Status != PreflightBlocked || r.RequiredBytes != 1<<62 {
+		t.Errorf("status = %s, necessário = %d", r.Status, r.RequiredBytes)
+	}
+
+	r = newPreflight("extração")
+	r.checkSpace("extração", t.TempDir(), 1)
+	if r.Status != PreflightOK {
+		t.Errorf("status = %s para 1 byte", r.Status)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:           "0.50 KB",
+		3 << 20:       "3.00 MB",
+		5<<30 + 1<<29: "5.50 GB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %s, esperado %s", n, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package operations
 
 import (
 	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/models"
 	"context"
 	"fmt"
 	"regexp"
@@ -114,9 +115,6 @@ func (m *Manager) FilterProducts(filter ProductFilter, log LogFunc) (FilterResul
 		log(fmt.Sprintf("🔢 Codes: %v, Barcodes: %v", codes, barcodes))
 	}
 
-	produtosEmpresa := m.conn.GetCollection(database.CollectionProdutosServicosEmpresa)
-	produtosServicos := m.conn.GetCollection(database.CollectionProdutosServicos)
-
 	// 2. Decide Strategy
 	// Strategy A: Product-First (Best for specific searches like Code, Name, Brand, Type)
 	// Strategy B: Company-First (Best for general browsing like "Active", "Price", or Empty)
@@ -127,23 +125,16 @@ func (m *Manager) FilterProducts(filter ProductFilter, log LogFunc) (FilterResul
 		filter.ProductType != "" || filter.ProductTypeID != ""
 
 	var results []FilteredProduct
-	empresaMap := make(map[string]bson.M)
+	empresaMap := make(map[string]models.ProdutoEmpresa)
 
 	if hasProductFilters {
 		log("🚀 Estratégia: BUSCA POR PRODUTO PRIMEIRO")
 
 		// Step 1: Find matching products (limit 2000 to prevent overflow on broad text searches)
-		optsProd := options.Find().SetLimit(2000)
-		cursorProd, err := produtosServicos.Find(ctx, produtoFilter, optsProd)
+		matchedProducts, err := m.repos.Products.FindProducts(ctx, produtoFilter, 2000)
 		if err != nil {
 			return FilterResult{}, fmt.Errorf("erro ao buscar produtos (strategy A): %w", err)
 		}
-		defer cursorProd.Close(ctx)
-
-		var matchedProducts []bson.M
-		if err = cursorProd.All(ctx, &matchedProducts); err != nil {
-			return FilterResult{}, fmt.Errorf("erro ao decodificar produtos: %w", err)
-		}
 
 		if len(matchedProducts) == 0 {
 			log("❌ Nenhum produto encontrado na busca primária.")
@@ -154,11 +145,11 @@ func (m *Manager) FilterProducts(filter ProductFilter, log LogFunc) (FilterResul
 
 		// Step 2: Extract IDs and Filter by Empresa availability
 		productIDs := make([]primitive.ObjectID, 0, len(matchedProducts))
-		productMap := make(map[string]bson.M)
+		productMap := make(map[string]models.Produto)
 		for _, p := range matchedProducts {
-			if id, ok := p["_id"].(primitive.ObjectID); ok {
-				productIDs = append(productIDs, id)
-				productMap[id.Hex()] = p
+			if !p.ID.IsZero() {
+				productIDs = append(productIDs, p.ID)
+				productMap[p.ID.Hex()] = p
 			}
 		}
 
@@ -168,23 +159,17 @@ func (m *Manager) FilterProducts(filter ProductFilter, log LogFunc) (FilterResul
 		}
 
 		// Fetch from Empresa
-		cursorEmp, err := produtosEmpresa.Find(ctx, empresaFilter) // Valid filters + ID restriction
+		empresaDocs, err := m.repos.Products.FindProductsEmpresa(ctx, empresaFilter, 0) // Valid filters + ID restriction
 		if err != nil {
 			return FilterResult{}, fmt.Errorf("erro ao cruzar com empresa: %w", err)
 		}
-		defer cursorEmp.Close(ctx)
 
-		for cursorEmp.Next(ctx) {
-			var empDoc bson.M
-			if err := cursorEmp.Decode(&empDoc); err != nil {
-				continue
-			}
+		for i := range empresaDocs {
+			empDoc := &empresaDocs[i]
 
 			// Join Logic
-			if ref, ok := empDoc["ProdutoServicoReferencia"].(primitive.ObjectID); ok {
-				if prodDoc, exists := productMap[ref.Hex()]; exists {
-					results = append(results, m.mapToFilteredProduct(prodDoc, empDoc))
-				}
+			if prodDoc, exists := productMap[empDoc.ProdutoServicoReferencia.Hex()]; exists && !empDoc.ProdutoServicoReferencia.IsZero() {
+				results = append(results, m.mapToFilteredProduct(prodDoc, empDoc))
 			}
 		}
 
@@ -192,24 +177,17 @@ func (m *Manager) FilterProducts(filter ProductFilter, log LogFunc) (FilterResul
 		log("🏢 Estratégia: BUSCA POR EMPRESA PRIMEIRO (Filtros Genéricos)")
 
 		// Step 1: Find company docs (Limit 2000)
-		optsEmp := options.Find().SetLimit(2000)
-		cursorEmp, err := produtosEmpresa.Find(ctx, empresaFilter, optsEmp)
+		empresaDocs, err := m.repos.Products.FindProductsEmpresa(ctx, empresaFilter, 2000)
 		if err != nil {
 			return FilterResult{}, fmt.Errorf("erro ao buscar produtos empresa: %w", err)
 		}
-		defer cursorEmp.Close(ctx)
-
-		var empresaDocs []bson.M
-		if err = cursorEmp.All(ctx, &empresaDocs); err != nil {
-			return FilterResult{}, fmt.Errorf("erro ao decodificar empresa: %w", err)
-		}
 
 		log(fmt.Sprintf("📦 Carregados %d produtos empresa. Aplicando filtro de produto...", len(empresaDocs)))
 
 		// Create Map and ID list
 		produtoRefs := make([]primitive.ObjectID, 0, len(empresaDocs))
 		for _, doc := range empresaDocs {
-			if ref, ok := doc["ProdutoServicoReferencia"].(primitive.ObjectID); ok {
+			if ref := doc.ProdutoServicoReferencia; !ref.IsZero() {
 				produtoRefs = append(produtoRefs, ref)
 				empresaMap[ref.Hex()] = doc
 			}
@@ -222,21 +200,14 @@ func (m *Manager) FilterProducts(filter ProductFilter, log LogFunc) (FilterResul
 		}
 
 		// Step 2: Find Products matching
-		cursorProd, err := produtosServicos.Find(ctx, produtoFilter)
+		produtos, err := m.repos.Products.FindProducts(ctx, produtoFilter, 0)
 		if err != nil {
 			return FilterResult{}, fmt.Errorf("erro ao buscar produtos: %w", err)
 		}
-		defer cursorProd.Close(ctx)
 
-		for cursorProd.Next(ctx) {
-			var prodDoc bson.M
-			if err := cursorProd.Decode(&prodDoc); err != nil {
-				continue
-			}
-
-			prodID, _ := prodDoc["_id"].(primitive.ObjectID)
-			if empDoc, exists := empresaMap[prodID.Hex()]; exists {
-				results = append(results, m.mapToFilteredProduct(prodDoc, empDoc))
+		for _, prodDoc := range produtos {
+			if empDoc, exists := empresaMap[prodDoc.ID.Hex()]; exists {
+				results = append(results, m.mapToFilteredProduct(prodDoc, &empDoc))
 			}
 		}
 	}
@@ -252,122 +223,62 @@ func (m *Manager) FilterProducts(filter ProductFilter, log LogFunc) (FilterResul
 	}, nil
 }
 
-func (m *Manager) mapToFilteredProduct(produto, empresaDoc bson.M) FilteredProduct {
-	// Extract basic fields
-	prodID, _ := produto["_id"].(primitive.ObjectID)
-
-	internalCode := getString(produto, "CodigoInterno")
-	barcode := getString(produto, "CodigoBarras")
-
-	brandID := ""
-	brandName := ""
-	if marcaRef, ok := produto["MarcaReferencia"].(primitive.ObjectID); ok {
-		brandID = marcaRef.Hex()
-	}
-	if marca, ok := produto["Marca"].(bson.M); ok {
-		brandName = getString(marca, "Descricao")
-		// Fallback: If no MarcaReferencia, try to get ID from nested Marca object
-		if brandID == "" {
-			if id, ok := marca["_id"].(primitive.ObjectID); ok {
-				brandID = id.Hex()
-			}
-		}
-	}
-
-	itemTypeID := ""
-	itemTypeName := ""
-	if tipoRef, ok := produto["TipoItemReferencia"].(primitive.ObjectID); ok {
-		itemTypeID = tipoRef.Hex()
-	}
-	if tipoItem, ok := produto["TipoItem"].(bson.M); ok {
-		itemTypeName = getString(tipoItem, "Descricao")
-	}
-
-	productTypeID := ""
-	productTypeName := ""
-	if generoRef, ok := produto["GeneroItemReferencia"].(primitive.ObjectID); ok {
-		productTypeID = generoRef.Hex()
-	}
-	if generoItem, ok := produto["GeneroItem"].(bson.M); ok {
-		productTypeName = getString(generoItem, "Descricao")
-	}
-
-	// Get stock quantity (need context but helper prevents it easily without passing ctx everywhere)
-	// For now, let's assume we can get it from 'EstoqueAtual' if present in empresaDoc,
-	// or we need to pass context.
-	// Since I cannot change the signature easily in this edit without ensuring I pass ctx in call sites.
-	// I DID pass ctx in the calls above? Let's check the previous edit.
-	// No, I called `m.mapToFilteredProduct(prodDoc, empDoc)`. I missed ctx.
-
-	// FIX: I will use a simplified quantity extraction if possible, or I need to fix the call sites too.
-	// The original code used `m.getStockQuantity(ctx, estoqueRef)`.
-	// I will just use `EstoqueAtual` from empresaDoc if available, as 'getStockQuantity' just checks the collection if not embedded.
-	// Actually, usually `EstoqueAtual` is in the doc.
-
-	// Get stock quantity (requires context and manager access)
-	// We need to fetch it from the database if not present in the doc
-	qty := 0.0
-	if empresaDoc != nil {
-		// Try to get from embedded field first (optimization)
-		if q, ok := empresaDoc["EstoqueAtual"]; ok {
-			qty = toFloat64(q)
-		} else if estoqueRef, ok := empresaDoc["EstoqueReferencia"].(primitive.ObjectID); ok {
-			// Fallback to fetching from Estoques collection
-			// We need context here. Creating a temporary context for this fetch.
-			// This is necessary because helper signature doesn't take context,
-			// and refactoring it everywhere takes more steps.
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			qty = m.getStockQuantity(ctx, estoqueRef)
-		}
-	}
-
+func (m *Manager) mapToFilteredProduct(produto models.Produto, empresa *models.ProdutoEmpresa) FilteredProduct {
 	fp := FilteredProduct{
-		ID:            prodID.Hex(),
-		Name:          getString(produto, "Descricao"),
-		InternalCode:  internalCode,
-		Barcode:       barcode,
-		Brand:         brandName,
-		BrandID:       brandID,
-		Active:        getBool(produto, "Ativo"),
-		Weighable:     getBool(produto, "Pesavel"),
-		ItemType:      itemTypeName,
-		ItemTypeID:    itemTypeID,
-		ProductType:   productTypeName,
-		ProductTypeID: productTypeID,
-		Quantity:      qty,
-	}
-
-	if empresaDoc != nil {
-		empID, _ := empresaDoc["_id"].(primitive.ObjectID)
-		fp.EmpresaID = empID.Hex()
-		fp.NCM = getNestedString(empresaDoc, "NcmNbs", "Codigo")
-		fp.CostPrice = getNestedFloat(empresaDoc, "PrecosCustos", 0, "Valor")
-		fp.SalePrice = getNestedFloat(empresaDoc, "PrecosVendas", 0, "Valor")
-
-		// DEBUG: Log price data just to see what's inside
-		// fmt.Printf("DEBUG: %s -> Cost: %v, Sale: %v, RawCost: %v, RawSale: %v\n",
-		// 	fp.InternalCode, fp.CostPrice, fp.SalePrice, empresaDoc["PrecosCustos"], empresaDoc["PrecosVendas"])
-
-		if tribRef, ok := empresaDoc["TributacaoEstadualReferencia"].(primitive.ObjectID); ok {
-			fp.StateTribID = tribRef.Hex()
-		}
-		if tribRef, ok := empresaDoc["TributacaoFederalReferencia"].(primitive.ObjectID); ok {
-			fp.FederalTribID = tribRef.Hex()
-		}
-		if tribRef, ok := empresaDoc["TributacaoIbsCbsReferencia"].(primitive.ObjectID); ok {
-			fp.IbsCbsTribID = tribRef.Hex()
-		}
-		if tribRef, ok := empresaDoc["TributacaoMunicipalReferencia"].(primitive.ObjectID); ok {
-			fp.MunicipalTribID = tribRef.Hex()
-		}
-
+		ID:           produto.ID.Hex(),
+		Name:         produto.Descricao,
+		InternalCode: produto.CodigoInterno,
+		Barcode:      produto.CodigoBarras,
+		Brand:        produto.Marca,
+		Active:       produto.Ativo,
+		Weighable:    produto.Pesavel,
+		ItemType:     produto.TipoItem,
+		ProductType:  produto.GeneroItem,
+	}
+	if !produto.MarcaReferencia.IsZero() {
+		fp.BrandID = produto.MarcaReferencia.Hex()
+	}
+	if !produto.TipoItemReferencia.IsZero() {
+		fp.ItemTypeID = produto.TipoItemReferencia.Hex()
+	}
+	if !produto.GeneroItemReferencia.IsZero() {
+		fp.ProductTypeID = produto.GeneroItemReferencia.Hex()
+	}
+
+	if empresa == nil {
+		return fp
+	}
+
+	// Get stock quantity: embedded EstoqueAtual when present, otherwise the
+	// referenced Estoques document.
+	if empresa.EstoqueAtual != nil {
+		fp.Quantity = *empresa.EstoqueAtual
+	} else if !empresa.EstoqueReferencia.IsZero() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		fp.Quantity = m.getStockQuantity(ctx, empresa.EstoqueReferencia)
+	}
+
+	fp.EmpresaID = empresa.ID.Hex()
+	fp.NCM = empresa.Ncm
+	fp.CostPrice = empresa.PrecoCusto
+	fp.SalePrice = empresa.PrecoVenda
+
+	refs := []struct {
+		id  primitive.ObjectID
+		dst *string
+	}{
+		{empresa.TributacaoEstadualReferencia, &fp.StateTribID},
+		{empresa.TributacaoFederalReferencia, &fp.FederalTribID},
+		{empresa.TributacaoIbsCbsReferencia, &fp.IbsCbsTribID},
+		{empresa.TributacaoMunicipalReferencia, &fp.MunicipalTribID},
 		// Reference IDs for enrichment
-		if ref, ok := empresaDoc["PrecoReferencia"].(primitive.ObjectID); ok {
-			fp.PrecoRefID = ref.Hex()
-		}
-		if ref, ok := empresaDoc["EstoqueReferencia"].(primitive.ObjectID); ok {
-			fp.EstoqueRefID = ref.Hex()
+		{empresa.PrecoReferencia, &fp.PrecoRefID},
+		{empresa.EstoqueReferencia, &fp.EstoqueRefID},
+	}
+	for _, ref := range refs {
+		if !ref.id.IsZero() {
+			*ref.dst = ref.id.Hex()
 		}
 	}
 
@@ -681,38 +592,25 @@ func (m *Manager) BulkActivateProducts(productIDs []string, activate bool, log L
 		}
 	}
 
-	produtosServicos := m.conn.GetCollection(database.CollectionProdutosServicos)
-
 	var productsBackup []map[string]interface{}
 	if m.rollback != nil {
-		cursor, err := produtosServicos.Find(ctx, bson.M{"_id": bson.M{"$in": oids}})
+		produtos, err := m.repos.Products.FindProducts(ctx, bson.M{"_id": bson.M{"$in": oids}}, 0)
 		if err == nil {
-			defer cursor.Close(ctx)
-			for cursor.Next(ctx) {
-				var doc bson.M
-				if err := cursor.Decode(&doc); err != nil {
-					continue
-				}
-				id, _ := doc["_id"].(primitive.ObjectID)
-				wasActive, _ := doc["Ativo"].(bool)
+			for _, produto := range produtos {
 				productsBackup = append(productsBackup, map[string]interface{}{
-					"id":        id.Hex(),
-					"wasActive": wasActive,
+					"id":        produto.ID.Hex(),
+					"wasActive": produto.Ativo,
 				})
 			}
 		}
 	}
 
-	result, err := produtosServicos.UpdateMany(ctx,
-		bson.M{"_id": bson.M{"$in": oids}},
-		bson.M{"$set": bson.M{"Ativo": activate}},
-	)
-
+	modified, err := m.repos.Products.SetActive(ctx, oids, activate)
 	if err != nil {
 		return 0, fmt.Errorf("erro ao atualizar: %w", err)
 	}
 
-	count := int(result.ModifiedCount)
+	count := int(modified)
 
 	if m.rollback != nil && len(productsBackup) > 0 {
 		actionLabel := "Inativou"
@@ -895,21 +793,11 @@ func matchesComparison(value float64, op string, target float64) bool {
 }
 
 func (m *Manager) getStockQuantity(ctx context.Context, estoqueID primitive.ObjectID) float64 {
-	estoques := m.conn.GetCollection(database.CollectionEstoques)
-	var estoque bson.M
-	err := estoques.FindOne(ctx, bson.M{"_id": estoqueID}).Decode(&estoque)
-	if err != nil {
+	estoques, err := m.repos.Stock.FindStocks(ctx, bson.M{"_id": estoqueID})
+	if err != nil || len(estoques) == 0 {
 		return 0
 	}
-
-	if quantidades, ok := estoque["Quantidades"].(primitive.A); ok && len(quantidades) > 0 {
-		if q0, ok := quantidades[0].(bson.M); ok {
-			if qty, ok := q0["Quantidade"].(float64); ok {
-				return qty
-			}
-		}
-	}
-	return 0
+	return estoques[0].Quantidade()
 }
 
 func (m *Manager) enrichProducts(ctx context.Context, products []FilteredProduct) {
@@ -944,42 +832,22 @@ func (m *Manager) enrichProducts(ctx context.Context, products []FilteredProduct
 
 	// 2. Fetch Prices
 	if len(priceIDs) > 0 {
-		coll := m.conn.GetCollection(database.CollectionPrecos)
-		cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": priceIDs}})
-		if err == nil {
-			defer cursor.Close(ctx)
-			fetchedPrices := make(map[string]bson.M)
-			for cursor.Next(ctx) {
-				var doc bson.M
-				if err := cursor.Decode(&doc); err == nil {
-					id, _ := doc["_id"].(primitive.ObjectID)
-					fetchedPrices[id.Hex()] = doc
-					// DEBUG
-					// if len(fetchedPrices) == 1 {
-					// 	fmt.Printf("DEBUG PRICE DOC: %+v\n", doc)
-					// }
-				}
-			}
-			// DEBUG
-			fmt.Printf("Fetched %d price documents\n", len(fetchedPrices))
-			if len(fetchedPrices) > 0 {
-				for _, d := range fetchedPrices {
-					fmt.Printf("Sample Price Doc: %+v\n", d)
-					break
-				}
+		if precos, err := m.repos.Products.FindPrices(ctx, priceIDs); err == nil {
+			fetchedPrices := make(map[string]models.Preco, len(precos))
+			for _, preco := range precos {
+				fetchedPrices[preco.ID.Hex()] = preco
 			}
 
 			// Apply to products
 			for i := range products {
 				p := &products[i]
 				if p.PrecoRefID != "" {
-					if doc, found := fetchedPrices[p.PrecoRefID]; found {
-						// Try different structures
+					if preco, found := fetchedPrices[p.PrecoRefID]; found {
 						if p.CostPrice == 0 {
-							p.CostPrice = getNestedFloat(doc, "Custos", 0, "Valor")
+							p.CostPrice = preco.Custo
 						}
 						if p.SalePrice == 0 {
-							p.SalePrice = getNestedFloat(doc, "Vendas", 0, "Valor")
+							p.SalePrice = preco.Venda
 						}
 					}
 				}
@@ -989,25 +857,10 @@ func (m *Manager) enrichProducts(ctx context.Context, products []FilteredProduct
 
 	// 3. Fetch Stocks
 	if len(stockIDs) > 0 {
-		coll := m.conn.GetCollection(database.CollectionEstoquesQuantidade)
-		cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": stockIDs}})
-		if err == nil {
-			defer cursor.Close(ctx)
-			fetchedStocks := make(map[string]bson.M)
-			for cursor.Next(ctx) {
-				var doc bson.M
-				if err := cursor.Decode(&doc); err == nil {
-					id, _ := doc["_id"].(primitive.ObjectID)
-					fetchedStocks[id.Hex()] = doc
-				}
-			}
-			// DEBUG
-			fmt.Printf("Fetched %d stock documents\n", len(fetchedStocks))
-			if len(fetchedStocks) > 0 {
-				for _, d := range fetchedStocks {
-					fmt.Printf("Sample Stock Doc: %+v\n", d)
-					break
-				}
+		if estoques, err := m.repos.Stock.FindStockQuantities(ctx, stockIDs); err == nil {
+			fetchedStocks := make(map[string]models.Estoque, len(estoques))
+			for _, estoque := range estoques {
+				fetchedStocks[estoque.ID.Hex()] = estoque
 			}
 
 			// Apply to products
@@ -1016,7 +869,7 @@ func (m *Manager) enrichProducts(ctx context.Context, products []FilteredProduct
 				if p.EstoqueRefID != "" {
 					if doc, found := fetchedStocks[p.EstoqueRefID]; found {
 						if p.Quantity == 0 {
-							p.Quantity = getNestedFloat(doc, "Quantidades", 0, "Quantidade")
+							p.Quantity = doc.Quantidade()
 						}
 					}
 				}
@@ -1025,71 +878,6 @@ func (m *Manager) enrichProducts(ctx context.Context, products []FilteredProduct
 	}
 }
 
-func getString(doc bson.M, field string) string {
-	if v, ok := doc[field].(string); ok {
-		return v
-	}
-	return ""
-}
-
-func getBool(doc bson.M, field string) bool {
-	if v, ok := doc[field].(bool); ok {
-		return v
-	}
-	return false
-}
-
-func getNestedString(doc bson.M, field1, field2 string) string {
-	if nested, ok := doc[field1].(bson.M); ok {
-		if v, ok := nested[field2].(string); ok {
-			return v
-		}
-	}
-	return ""
-}
-
-func getNestedFloat(doc bson.M, field string, index int, subfield string) float64 {
-	if arr, ok := doc[field].(primitive.A); ok && len(arr) > index {
-		if item, ok := arr[index].(bson.M); ok {
-			return toFloat64(item[subfield])
-		}
-	}
-	// Also try generic slice interface
-	if arr, ok := doc[field].([]interface{}); ok && len(arr) > index {
-		if item, ok := arr[index].(map[string]interface{}); ok {
-			return toFloat64(item[subfield])
-		}
-		if item, ok := arr[index].(bson.M); ok {
-			return toFloat64(item[subfield])
-		}
-	}
-	return 0
-}
-
-func toFloat64(v interface{}) float64 {
-	if v == nil {
-		return 0
-	}
-	switch val := v.(type) {
-	case float64:
-		return val
-	case float32:
-		return float64(val)
-	case int:
-		return float64(val)
-	case int32:
-		return float64(val)
-	case int64:
-		return float64(val)
-	case primitive.Decimal128:
-		f := val.String()
-		if fval, err := strconv.ParseFloat(f, 64); err == nil {
-			return fval
-		}
-	}
-	return 0
-}
-
 // splitAndTrim splits string by comma or semicolon and trims spaces
 func splitAndTrim(s string) []string {
 	if s == "" {
@@ -0,0 +1,465 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Categorias de problema entre ProdutosServicos, ProdutosServicosEmpresa e Estoques.
+const (
+	IntegrityProductWithoutEmpresa = "produto_sem_empresa"
+	IntegrityEmpresaWithoutProduct = "empresa_sem_produto"
+	IntegrityEmpresaWithoutStock   = "empresa_sem_estoque"
+	IntegrityDuplicateEmpresa      = "empresa_duplicada"
+	IntegritySharedStock           = "estoque_compartilhado"
+	IntegrityStockWithoutEmpresa   = "estoque_sem_empresa"
+)
+
+const integritySampleSize = 20
+
+type IntegrityIssue struct {
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Count       int      `json:"count"`
+	Samples     []string `json:"samples"`
+	Repairable  bool     `json:"repairable"`
+}
+
+type ProductIntegrityReport struct {
+	Products int `json:"products"`
+	Empresas int `json:"empresas"`
+	Estoques int `json:"estoques"`
+	// Unreadable conta os ProdutosServicosEmpresa que não puderam ser lidos.
+	Unreadable int              `json:"unreadable"`
+	Issues     []IntegrityIssue `json:"issues"`
+}
+
+var integrityDescriptions = map[string]string{
+	IntegrityProductWithoutEmpresa: "Produtos sem documento em ProdutosServicosEmpresa",
+	IntegrityEmpresaWithoutProduct: "ProdutosServicosEmpresa apontando para produto inexistente",
+	IntegrityEmpresaWithoutStock:   "ProdutosServicosEmpresa sem estoque ou com estoque inexistente",
+	IntegrityDuplicateEmpresa:      "Mais de um ProdutosServicosEmpresa para o mesmo produto e empresa",
+	IntegritySharedStock:           "Estoque compartilhado por mais de um ProdutosServicosEmpresa",
+	IntegrityStockWithoutEmpresa:   "Estoques sem ProdutosServicosEmpresa",
+}
+
+// Somente estas categorias têm correção automática: as demais exigem decisão
+// sobre dados fiscais ou de empresa.
+var integrityRepairable = map[string]bool{
+	IntegrityEmpresaWithoutProduct: true,
+	IntegritySharedStock:           true,
+	IntegrityStockWithoutEmpresa:   true,
+}
+
+var integrityOrder = []string{
+	IntegrityProductWithoutEmpresa,
+	IntegrityEmpresaWithoutProduct,
+	IntegrityEmpresaWithoutStock,
+	IntegrityDuplicateEmpresa,
+	IntegritySharedStock,
+	IntegrityStockWithoutEmpresa,
+}
+
+type triadScan struct {
+	products int
+	empresas int
+	estoques int
+	issues   map[string][]primitive.ObjectID
+
+	// Estoque compartilhado → documentos de empresa que o referenciam, em ordem de _id.
+	shared map[primitive.ObjectID][]primitive.ObjectID
+
+	// Documentos de empresa que não puderam ser lidos: o estoque deles é
+	// desconhecido, então as categorias de estoque não podem ser corrigidas.
+	unreadable int
+
+	productIDs     map[primitive.ObjectID]bool
+	stockIDs       map[primitive.ObjectID]bool
+	linkedProducts map[primitive.ObjectID]bool
+	stockUsers     map[primitive.ObjectID][]primitive.ObjectID
+	pairs          map[[2]primitive.ObjectID]int
+}
+
+// Categorias cuja correção depende de conhecer todos os estoques referenciados.
+var integrityNeedsAllLinks = map[string]bool{
+	IntegritySharedStock:         true,
+	IntegrityStockWithoutEmpresa: true,
+}
+
+func newTriadScan(products, stocks map[primitive.ObjectID]bool) *triadScan {
+	return &triadScan{
+		products:       len(products),
+		estoques:       len(stocks),
+		issues:         make(map[string][]primitive.ObjectID),
+		shared:         make(map[primitive.ObjectID][]primitive.ObjectID),
+		productIDs:     products,
+		stockIDs:       stocks,
+		linkedProducts: make(map[primitive.ObjectID]bool),
+		stockUsers:     make(map[primitive.ObjectID][]primitive.ObjectID),
+		pairs:          make(map[[2]primitive.ObjectID]int),
+	}
+}
+
+// readEmpresaLink decodifica um ProdutosServicosEmpresa de forma tolerante.
+// O documento só é aceito quando o _id e as referências presentes são
+// ObjectIds legíveis (ou o hex deles em texto).
+func readEmpresaLink(raw bson.Raw) (models.ProdutoEmpresa, bool) {
+	var link models.ProdutoEmpresa
+	if err := bson.Unmarshal(raw, &link); err != nil || link.ID.IsZero() {
+		return link, false
+	}
+	for _, field := range []string{"ProdutoServicoReferencia", "EmpresaReferencia", "EstoqueReferencia"} {
+		v, err := raw.LookupErr(field)
+		if err == nil && v.Type != bsontype.Null && models.ObjectID(v).IsZero() {
+			return link, false
+		}
+	}
+	return link, true
+}
+
+func (s *triadScan) add(link models.ProdutoEmpresa) {
+	s.empresas++
+
+	if s.productIDs[link.ProdutoServicoReferencia] {
+		s.linkedProducts[link.ProdutoServicoReferencia] = true
+	} else {
+		s.issues[IntegrityEmpresaWithoutProduct] = append(s.issues[IntegrityEmpresaWithoutProduct], link.ID)
+	}
+
+	if link.EstoqueReferencia.IsZero() || !s.stockIDs[link.EstoqueReferencia] {
+		s.issues[IntegrityEmpresaWithoutStock] = append(s.issues[IntegrityEmpresaWithoutStock], link.ID)
+	} else {
+		s.stockUsers[link.EstoqueReferencia] = append(s.stockUsers[link.EstoqueReferencia], link.ID)
+	}
+
+	pair := [2]primitive.ObjectID{link.ProdutoServicoReferencia, link.EmpresaReferencia}
+	s.pairs[pair]++
+	if s.pairs[pair] > 1 {
+		s.issues[IntegrityDuplicateEmpresa] = append(s.issues[IntegrityDuplicateEmpresa], link.ID)
+	}
+}
+
+func (s *triadScan) finish() {
+	for id := range s.productIDs {
+		if !s.linkedProducts[id] {
+			s.issues[IntegrityProductWithoutEmpresa] = append(s.issues[IntegrityProductWithoutEmpresa], id)
+		}
+	}
+	for id := range s.stockIDs {
+		users := s.stockUsers[id]
+		switch {
+		case len(users) == 0:
+			s.issues[IntegrityStockWithoutEmpresa] = append(s.issues[IntegrityStockWithoutEmpresa], id)
+		case len(users) > 1:
+			s.issues[IntegritySharedStock] = append(s.issues[IntegritySharedStock], id)
+			s.shared[id] = users
+		}
+	}
+
+	for _, ids := range s.issues {
+		sort.Slice(ids, func(i, j int) bool { return ids[i].Hex() < ids[j].Hex() })
+	}
+}
+
+// repairable informa se a categoria pode ser corrigida com o resultado desta varredura.
+func (s *triadScan) repairable(category string) bool {
+	return integrityRepairable[category] && (s.unreadable == 0 || !integrityNeedsAllLinks[category])
+}
+
+func (m *Manager) loadIDSet(ctx context.Context, collection string) (map[primitive.ObjectID]bool, error) {
+	cursor, err := m.conn.GetCollection(collection).Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	set := make(map[primitive.ObjectID]bool)
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if cursor.Decode(&doc) == nil {
+			set[doc.ID] = true
+		}
+	}
+	return set, cursor.Err()
+}
+
+func (m *Manager) scanProductTriad(ctx context.Context) (*triadScan, error) {
+	products, err := m.loadIDSet(ctx, database.CollectionProdutosServicos)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler produtos: %w", err)
+	}
+	stocks, err := m.loadIDSet(ctx, database.CollectionEstoques)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler estoques: %w", err)
+	}
+
+	cursor, err := m.conn.GetCollection(database.CollectionProdutosServicosEmpresa).Find(ctx, bson.M{},
+		options.Find().
+			SetProjection(bson.M{"ProdutoServicoReferencia": 1, "EmpresaReferencia": 1, "EstoqueReferencia": 1}).
+			SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler ProdutosServicosEmpresa: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	scan := newTriadScan(products, stocks)
+	for cursor.Next(ctx) {
+		if m.state.ShouldStop() {
+			return nil, fmt.Errorf("operação cancelada")
+		}
+
+		link, ok := readEmpresaLink(cursor.Current)
+		if !ok {
+			scan.unreadable++
+			continue
+		}
+		scan.add(link)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	scan.finish()
+	return scan, nil
+}
+
+// ValidateProductIntegrity verifica os vínculos entre produtos, documentos de
+// empresa e estoques, sem alterar a base.
+func (m *Manager) ValidateProductIntegrity(log LogFunc) (*ProductIntegrityReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	log("🔍 Validando vínculos entre produtos, empresas e estoques...")
+
+	scan, err := m.scanProductTriad(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProductIntegrityReport{
+		Products:   scan.products,
+		Empresas:   scan.empresas,
+		Estoques:   scan.estoques,
+		Unreadable: scan.unreadable,
+		Issues:     make([]IntegrityIssue, 0),
+	}
+	if scan.unreadable > 0 {
+		log(fmt.Sprintf("   ⚠️ %d ProdutosServicosEmpresa ilegíveis: correção de estoques desativada", scan.unreadable))
+	}
+
+	for _, category := range integrityOrder {
+		ids := scan.issues[category]
+		if len(ids) == 0 {
+			continue
+		}
+
+		issue := IntegrityIssue{
+			Category:    category,
+			Description: integrityDescriptions[category],
+			Count:       len(ids),
+			Samples:     make([]string, 0),
+			Repairable:  scan.repairable(category),
+		}
+		for i := 0; i < len(ids) && i < integritySampleSize; i++ {
+			issue.Samples = append(issue.Samples, ids[i].Hex())
+		}
+		report.Issues = append(report.Issues, issue)
+
+		log(fmt.Sprintf("   ⚠️ %s: %d", issue.Description, issue.Count))
+	}
+
+	if len(report.Issues) == 0 {
+		log("✅ Nenhum problema encontrado")
+	} else {
+		log(fmt.Sprintf("✅ Validação concluída: %d categorias com problemas", len(report.Issues)))
+	}
+	return report, nil
+}
+
+// RepairProductIntegrity aplica as correções seguras das categorias pedidas:
+// remove estoques e documentos de empresa soltos e separa estoques
+// compartilhados em cópias zeradas. Tudo fica registrado para rollback.
+func (m *Manager) RepairProductIntegrity(categories []string, log LogFunc) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	for _, category := range categories {
+		if !integrityRepairable[category] {
+			return 0, fmt.Errorf("categoria sem correção automática: %s", category)
+		}
+	}
+
+	scan, err := m.scanProductTriad(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, category := range categories {
+		if !scan.repairable(category) {
+			return 0, fmt.Errorf("correção de %s recusada: %d ProdutosServicosEmpresa ilegíveis podem referenciar esses estoques", category, scan.unreadable)
+		}
+	}
+
+	details := map[string]interface{}{
+		"deleted":       map[string][]string{},
+		"relinked":      []map[string]interface{}{},
+		"createdStocks": []primitive.ObjectID{},
+	}
+	deleted := details["deleted"].(map[string][]string)
+	repaired := 0
+
+	for _, category := range categories {
+		if m.state.ShouldStop() {
+			log("Operação cancelada")
+			break
+		}
+
+		ids := scan.issues[category]
+		if len(ids) == 0 {
+			continue
+		}
+
+		switch category {
+		case IntegrityEmpresaWithoutProduct:
+			backup, n := m.deleteWithBackup(ctx, database.CollectionProdutosServicosEmpresa, objectIDList(ids), log)
+			deleted[database.CollectionProdutosServicosEmpresa] = append(deleted[database.CollectionProdutosServicosEmpresa], backup...)
+			repaired += n
+			log(fmt.Sprintf("   ✓ %d ProdutosServicosEmpresa sem produto removidos", n))
+
+		case IntegrityStockWithoutEmpresa:
+			backup, n := m.deleteWithBackup(ctx, database.CollectionEstoques, objectIDList(ids), log)
+			deleted[database.CollectionEstoques] = append(deleted[database.CollectionEstoques], backup...)
+			repaired += n
+			log(fmt.Sprintf("   ✓ %d estoques sem empresa removidos", n))
+
+		case IntegritySharedStock:
+			relinked, created, err := m.splitSharedStocks(ctx, scan.shared, log)
+			details["relinked"] = append(details["relinked"].([]map[string]interface{}), relinked...)
+			details["createdStocks"] = append(details["createdStocks"].([]primitive.ObjectID), created...)
+			repaired += len(relinked)
+			if err != nil {
+				log(fmt.Sprintf("   ⚠️ %v", err))
+			}
+			log(fmt.Sprintf("   ✓ %d ProdutosServicosEmpresa receberam estoque próprio", len(relinked)))
+		}
+	}
+
+	if m.rollback != nil && repaired > 0 {
+		m.rollback.RecordOperation(OpRepairProductIntegrity,
+			fmt.Sprintf("Corrigir vínculos de produtos (%d registros)", repaired),
+			details, true)
+	}
+
+	log(fmt.Sprintf("✅ %d registros corrigidos", repaired))
+	return repaired, nil
+}
+
+// splitSharedStocks mantém o estoque com o primeiro documento de empresa e cria,
+// para cada um dos demais, uma cópia com quantidades zeradas.
+func (m *Manager) splitSharedStocks(ctx context.Context, shared map[primitive.ObjectID][]primitive.ObjectID, log LogFunc) ([]map[string]interface{}, []primitive.ObjectID, error) {
+	estoques := m.conn.GetCollection(database.CollectionEstoques)
+	pse := m.conn.GetCollection(database.CollectionProdutosServicosEmpresa)
+
+	relinked := make([]map[string]interface{}, 0)
+	created := make([]primitive.ObjectID, 0)
+
+	for stockID, users := range shared {
+		var stock bson.M
+		if err := estoques.FindOne(ctx, bson.M{"_id": stockID}).Decode(&stock); err != nil {
+			return relinked, created, fmt.Errorf("erro ao ler estoque %s: %w", stockID.Hex(), err)
+		}
+
+		for _, empresaID := range users[1:] {
+			clone := zeroStockQuantities(stock)
+			newID := primitive.NewObjectID()
+			clone["_id"] = newID
+
+			if _, err := estoques.InsertOne(ctx, clone); err != nil {
+				return relinked, created, fmt.Errorf("erro ao criar estoque: %w", err)
+			}
+			created = append(created, newID)
+
+			if _, err := pse.UpdateOne(ctx, bson.M{"_id": empresaID}, bson.M{"$set": bson.M{"EstoqueReferencia": newID}}); err != nil {
+				return relinked, created, fmt.Errorf("erro ao religar %s: %w", empresaID.Hex(), err)
+			}
+			relinked = append(relinked, map[string]interface{}{"id": empresaID, "prev": stockID})
+		}
+	}
+	return relinked, created, nil
+}
+
+func zeroStockQuantities(stock bson.M) bson.M {
+	clone := make(bson.M, len(stock))
+	for k, v := range stock {
+		clone[k] = v
+	}
+
+	if quantidades, ok := stock["Quantidades"].(primitive.A); ok {
+		zeroed := make(primitive.A, 0, len(quantidades))
+		for _, q := range quantidades {
+			if qm, ok := q.(bson.M); ok {
+				copyQ := make(bson.M, len(qm))
+				for k, v := range qm {
+					copyQ[k] = v
+				}
+				copyQ["Quantidade"] = 0.0
+				zeroed = append(zeroed, copyQ)
+				continue
+			}
+			zeroed = append(zeroed, q)
+		}
+		clone["Quantidades"] = zeroed
+	}
+	return clone
+}
+
+func objectIDList(ids []primitive.ObjectID) []interface{} {
+	list := make([]interface{}, len(ids))
+	for i, id := range ids {
+		list[i] = id
+	}
+	return list
+}
+
+// deleteWithBackup exclui os documentos um a um, guardando cada um em JSON
+// estendido canônico para que o rollback possa reinseri-los.
+func (m *Manager) deleteWithBackup(ctx context.Context, collection string, ids []interface{}, log LogFunc) ([]string, int) {
+	coll := m.conn.GetCollection(collection)
+	backup := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		raw, err := coll.FindOne(ctx, bson.M{"_id": id}).DecodeBytes()
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				log(fmt.Sprintf("   ⚠️ %s: %v", formatID(id), err))
+			}
+			continue
+		}
+		data, err := bson.MarshalExtJSON(raw, true, false)
+		if err != nil {
+			continue
+		}
+
+		result, err := coll.DeleteOne(ctx, bson.M{"_id": id})
+		if err != nil {
+			log(fmt.Sprintf("   ⚠️ %s: %v", formatID(id), err))
+			continue
+		}
+		if result.DeletedCount > 0 {
+			backup = append(backup, string(data))
+		}
+	}
+	return backup, len(backup)
+}
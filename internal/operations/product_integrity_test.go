@@ -0,0 +1,116 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func oid(n byte) primitive.ObjectID {
+	var id primitive.ObjectID
+	id[11] = n
+	return id
+}
+
+func idSet(ids ...primitive.ObjectID) map[primitive.ObjectID]bool {
+	set := make(map[primitive.ObjectID]bool)
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func TestTriadScanClassifies(t *testing.T) {
+	prodA, prodB, prodSemEmpresa := oid(1), oid(2), oid(3)
+	empresa := oid(10)
+	estoqueA, estoqueCompartilhado, estoqueSolto := oid(20), oid(21), oid(22)
+
+	scan := newTriadScan(idSet(prodA, prodB, prodSemEmpresa), idSet(estoqueA, estoqueCompartilhado, estoqueSolto))
+	links := []bson.M{
+		{"_id": oid(100), "ProdutoServicoReferencia": prodA, "EmpresaReferencia": empresa, "EstoqueReferencia": estoqueA},
+		{"_id": oid(101), "ProdutoServicoReferencia": prodB, "EmpresaReferencia": empresa, "EstoqueReferencia": estoqueCompartilhado},
+		// Mesmo produto e empresa do anterior, com o mesmo estoque.
+		{"_id": oid(102), "ProdutoServicoReferencia": prodB, "EmpresaReferencia": empresa, "EstoqueReferencia": estoqueCompartilhado},
+		{"_id": oid(103), "ProdutoServicoReferencia": oid(99), "EmpresaReferencia": empresa},
+	}
+	for _, doc := range links {
+		raw, _ := bson.Marshal(doc)
+		link, ok := readEmpresaLink(raw)
+		if !ok {
+			t.Fatalf("documento %v não lido", doc)
+		}
+		scan.add(link)
+	}
+	scan.finish()
+
+	want := map[string][]primitive.ObjectID{
+		IntegrityProductWithoutEmpresa: {prodSemEmpresa},
+		IntegrityEmpresaWithoutProduct: {oid(103)},
+		IntegrityEmpresaWithoutStock:   {oid(103)},
+		IntegrityDuplicateEmpresa:      {oid(102)},
+		IntegritySharedStock:           {estoqueCompartilhado},
+		IntegrityStockWithoutEmpresa:   {estoqueSolto},
+	}
+	if !reflect.DeepEqual(scan.issues, want) {
+		t.Errorf("issues = %v, esperado %v", scan.issues, want)
+	}
+	if got := scan.shared[estoqueCompartilhado]; !reflect.DeepEqual(got, []primitive.ObjectID{oid(101), oid(102)}) {
+		t.Errorf("shared = %v", got)
+	}
+	if scan.empresas != 4 {
+		t.Errorf("empresas = %d", scan.empresas)
+	}
+}
+
+func TestReadEmpresaLink(t *testing.T) {
+	estoque := oid(20)
+	tests := []struct {
+		name        string
+		doc         bson.M
+		ok          bool
+		wantEstoque primitive.ObjectID
+	}{
+		{"ObjectIds", bson.M{"_id": oid(1), "EstoqueReferencia": estoque}, true, estoque},
+		{"referência em texto", bson.M{"_id": oid(1), "EstoqueReferencia": estoque.Hex()}, true, estoque},
+		{"sem estoque", bson.M{"_id": oid(1)}, true, primitive.NilObjectID},
+		{"estoque nulo", bson.M{"_id": oid(1), "EstoqueReferencia": nil}, true, primitive.NilObjectID},
+		{"estoque ilegível", bson.M{"_id": oid(1), "EstoqueReferencia": int32(7)}, false, primitive.NilObjectID},
+		{"produto ilegível", bson.M{"_id": oid(1), "ProdutoServicoReferencia": "abc"}, false, primitive.NilObjectID},
+		{"_id que não é ObjectId", bson.M{"_id": int32(1), "EstoqueReferencia": estoque}, false, estoque},
+	}
+	for _, tt := range tests {
+		raw, _ := bson.Marshal(tt.doc)
+		link, ok := readEmpresaLink(raw)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, esperado %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && link.EstoqueReferencia != tt.wantEstoque {
+			t.Errorf("%s: estoque = %s", tt.name, link.EstoqueReferencia.Hex())
+		}
+	}
+}
+
+func TestTriadScanUnreadableBlocksStockRepairs(t *testing.T) {
+	tests := []struct {
+		unreadable int
+		category   string
+		want       bool
+	}{
+		{0, IntegrityStockWithoutEmpresa, true},
+		{0, IntegritySharedStock, true},
+		{1, IntegrityStockWithoutEmpresa, false},
+		{1, IntegritySharedStock, false},
+		{1, IntegrityEmpresaWithoutProduct, true},
+		{0, IntegrityDuplicateEmpresa, false},
+	}
+	for _, tt := range tests {
+		scan := newTriadScan(nil, nil)
+		scan.unreadable = tt.unreadable
+		if got := scan.repairable(tt.category); got != tt.want {
+			t.Errorf("repairable(%s) com %d ilegíveis = %v, esperado %v", tt.category, tt.unreadable, got, tt.want)
+		}
+	}
+}
@@ -17,9 +17,9 @@ func (m *Manager) InactivateZeroProducts(log LogFunc) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	estoques := m.conn.GetCollection(database.CollectionEstoques)
-	produtosServicos := m.conn.GetCollection(database.CollectionProdutosServicos)
-	produtosEmpresa := m.conn.GetCollection(database.CollectionProdutosServicosEmpresa)
+	if err := m.requireCompatibility(OpInactivateProducts, log); err != nil {
+		return 0, err
+	}
 
 	filter := bson.M{
 		"$or": []bson.M{
@@ -28,52 +28,41 @@ func (m *Manager) InactivateZeroProducts(log LogFunc) (int, error) {
 		},
 	}
 
-	cursor, err := estoques.Find(ctx, filter)
+	stocks, err := m.repos.Stock.FindStocks(ctx, filter)
 	if err != nil {
 		return 0, fmt.Errorf("erro ao buscar estoques: %w", err)
 	}
-	defer cursor.Close(ctx)
 
 	count := 0
 	var inactivatedIDs []string
 
-	for cursor.Next(ctx) {
+	for _, estoque := range stocks {
 		if m.state.ShouldStop() {
 			log("Operação cancelada")
 			return count, nil
 		}
 
-		var estoque bson.M
-		if err := cursor.Decode(&estoque); err != nil {
-			continue
-		}
-
-		estoqueID, ok := estoque["_id"].(primitive.ObjectID)
-		if !ok {
+		if estoque.ID.IsZero() {
 			continue
 		}
 
-		var produtoEmpresa bson.M
-		err := produtosEmpresa.FindOne(ctx, bson.M{"EstoqueReferencia": estoqueID}).Decode(&produtoEmpresa)
-		if err != nil {
+		empresas, err := m.repos.Products.FindProductsEmpresa(ctx, bson.M{"EstoqueReferencia": estoque.ID}, 1)
+		if err != nil || len(empresas) == 0 {
 			continue
 		}
 
-		produtoRef, ok := produtoEmpresa["ProdutoServicoReferencia"].(primitive.ObjectID)
-		if !ok {
+		produtoRef := empresas[0].ProdutoServicoReferencia
+		if produtoRef.IsZero() {
 			continue
 		}
 
-		result, err := produtosServicos.UpdateOne(ctx,
-			bson.M{"_id": produtoRef},
-			bson.M{"$set": bson.M{"Ativo": false}},
-		)
+		modified, err := m.repos.Products.SetActive(ctx, []primitive.ObjectID{produtoRef}, false)
 		if err != nil {
 			log(fmt.Sprintf("Erro ao atualizar produto %s: %s", produtoRef.Hex(), err.Error()))
 			continue
 		}
 
-		if result.ModifiedCount > 0 {
+		if modified > 0 {
 			count++
 			inactivatedIDs = append(inactivatedIDs, produtoRef.Hex())
 			log(fmt.Sprintf("Produto %s inativado", produtoRef.Hex()))
@@ -96,6 +85,10 @@ func (m *Manager) ChangeTributationByNCM(ncms []string, tributationID string, lo
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
+	if err := m.requireCompatibility(OpChangeTributation, log); err != nil {
+		return 0, err
+	}
+
 	tribID, err := primitive.ObjectIDFromHex(tributationID)
 	if err != nil {
 		return 0, fmt.Errorf("ID de tributação inválido: %w", err)
@@ -180,33 +173,20 @@ func (m *Manager) GetTributations() ([]map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	tributacoes := m.conn.GetCollection(database.CollectionTributacoesEstadual)
-
-	cursor, err := tributacoes.Find(ctx, bson.M{"Ativo": true})
+	tributacoes, err := m.repos.Tributations.FindTributations(ctx, database.CollectionTributacoesEstadual, bson.M{"Ativo": true})
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
 	var results []map[string]interface{}
-	for cursor.Next(ctx) {
-		var trib bson.M
-		if err := cursor.Decode(&trib); err != nil {
-			continue
-		}
-
-		id := ""
-		if oid, ok := trib["_id"].(primitive.ObjectID); ok {
-			id = oid.Hex()
-		}
-
-		desc := "Sem descrição"
-		if d, ok := trib["Descricao"].(string); ok {
-			desc = d
+	for _, trib := range tributacoes {
+		desc := trib.Descricao
+		if desc == "" {
+			desc = "Sem descrição"
 		}
 
 		results = append(results, map[string]interface{}{
-			"id":        id,
+			"id":        trib.ID.Hex(),
 			"Descricao": desc,
 		})
 	}
@@ -218,6 +198,10 @@ func (m *Manager) EnableMEI(log LogFunc) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if err := m.requireCompatibility(OpEnableMEI, log); err != nil {
+		return 0, err
+	}
+
 	pessoas := m.conn.GetCollection(database.CollectionPessoas)
 
 	filter := bson.M{"_t.2": "Emitente"}
@@ -331,6 +315,10 @@ func (m *Manager) ChangeFederalTributationByNCM(ncms []string, tributationID str
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
+	if err := m.requireCompatibility(OpChangeTribFederal, log); err != nil {
+		return err
+	}
+
 	if len(ncms) == 0 {
 		return fmt.Errorf("nenhum NCM informado")
 	}
@@ -450,6 +438,10 @@ func (m *Manager) ChangeIbsCbsTributationByNCM(ncms []string, tributationID stri
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
+	if err := m.requireCompatibility(OpChangeTribIbsCbs, log); err != nil {
+		return err
+	}
+
 	if len(ncms) == 0 {
 		return fmt.Errorf("nenhum NCM informado")
 	}
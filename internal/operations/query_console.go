@@ -0,0 +1,398 @@
+package operations
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	QueryKindFind      = "find"
+	QueryKindAggregate = "aggregate"
+)
+
+const (
+	defaultQueryLimit   = 200
+	maxQueryLimit       = 5000
+	maxExportLimit      = 100000
+	defaultQueryTimeout = 30 * time.Second
+	maxQueryTimeout     = 2 * time.Minute
+)
+
+// Estágios que gravam dados; o console é somente leitura.
+var forbiddenQueryOperators = map[string]bool{
+	"$out":   true,
+	"$merge": true,
+}
+
+// QueryRequest descreve uma consulta do console. Filter, Projection, Sort e
+// Pipeline são textos em JSON estendido (Pipeline é um array de estágios).
+type QueryRequest struct {
+	Collection     string `json:"collection"`
+	Kind           string `json:"kind"`
+	Filter         string `json:"filter"`
+	Projection     string `json:"projection"`
+	Sort           string `json:"sort"`
+	Pipeline       string `json:"pipeline"`
+	Limit          int    `json:"limit"`
+	TimeoutSeconds int    `json:"timeoutSeconds"`
+}
+
+type QueryResult struct {
+	Documents []string `json:"documents"`
+	Count     int      `json:"count"`
+	Truncated bool     `json:"truncated"`
+	ElapsedMs int64    `json:"elapsedMs"`
+}
+
+func parseExtJSONDocument(text string) (bson.D, error) {
+	doc := bson.D{}
+	if strings.TrimSpace(text) == "" {
+		return doc, nil
+	}
+	if err := bson.UnmarshalExtJSON([]byte(text), false, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func parseExtJSONPipeline(text string) ([]bson.D, error) {
+	if strings.TrimSpace(text) == "" {
+		return []bson.D{}, nil
+	}
+	var wrapper struct {
+		Stages []bson.D `bson:"v"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"v":`+text+`}`), false, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Stages, nil
+}
+
+// findForbiddenOperator procura estágios de escrita em qualquer nível,
+// inclusive dentro de $facet e de pipelines de $lookup/$unionWith.
+func findForbiddenOperator(v interface{}) string {
+	switch val := v.(type) {
+	case bson.D:
+		for _, e := range val {
+			if forbiddenQueryOperators[e.Key] {
+				return e.Key
+			}
+			if op := findForbiddenOperator(e.Value); op != "" {
+				return op
+			}
+		}
+	case bson.M:
+		for k, item := range val {
+			if forbiddenQueryOperators[k] {
+				return k
+			}
+			if op := findForbiddenOperator(item); op != "" {
+				return op
+			}
+		}
+	case bson.A:
+		for _, item := range val {
+			if op := findForbiddenOperator(item); op != "" {
+				return op
+			}
+		}
+	case []bson.D:
+		for _, item := range val {
+			if op := findForbiddenOperator(item); op != "" {
+				return op
+			}
+		}
+	}
+	return ""
+}
+
+func (req QueryRequest) timeout() time.Duration {
+	t := time.Duration(req.TimeoutSeconds) * time.Second
+	if t <= 0 {
+		return defaultQueryTimeout
+	}
+	if t > maxQueryTimeout {
+		return maxQueryTimeout
+	}
+	return t
+}
+
+// openQuery valida a consulta e abre o cursor, pedindo um documento a mais
+// que o limite para saber se o resultado foi truncado.
+func (m *Manager) openQuery(ctx context.Context, req QueryRequest, limit int) (*mongo.Cursor, error) {
+	if req.Collection == "" {
+		return nil, fmt.Errorf("informe a coleção")
+	}
+	coll := m.conn.GetCollection(req.Collection)
+
+	switch req.Kind {
+	case "", QueryKindFind:
+		filter, err := parseExtJSONDocument(req.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filtro inválido: %w", err)
+		}
+		projection, err := parseExtJSONDocument(req.Projection)
+		if err != nil {
+			return nil, fmt.Errorf("projeção inválida: %w", err)
+		}
+		sortDoc, err := parseExtJSONDocument(req.Sort)
+		if err != nil {
+			return nil, fmt.Errorf("ordenação inválida: %w", err)
+		}
+		if op := findForbiddenOperator(filter); op != "" {
+			return nil, fmt.Errorf("operador não permitido no console: %s", op)
+		}
+
+		opts := options.Find().SetLimit(int64(limit + 1)).SetMaxTime(req.timeout())
+		if len(projection) > 0 {
+			opts.SetProjection(projection)
+		}
+		if len(sortDoc) > 0 {
+			opts.SetSort(sortDoc)
+		}
+		return coll.Find(ctx, filter, opts)
+
+	case QueryKindAggregate:
+		pipeline, err := parseExtJSONPipeline(req.Pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline inválido: %w", err)
+		}
+		if op := findForbiddenOperator(pipeline); op != "" {
+			return nil, fmt.Errorf("estágio não permitido no console: %s", op)
+		}
+
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit + 1}})
+		return coll.Aggregate(ctx, pipeline, options.Aggregate().SetMaxTime(req.timeout()))
+	}
+	return nil, fmt.Errorf("tipo de consulta inválido: %s", req.Kind)
+}
+
+func clampLimit(limit, def, max int) int {
+	if limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
+
+// RunQuery executa uma consulta somente leitura e devolve os documentos em
+// JSON estendido relaxado.
+func (m *Manager) RunQuery(req QueryRequest, log LogFunc) (*QueryResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), req.timeout())
+	defer cancel()
+
+	limit := clampLimit(req.Limit, defaultQueryLimit, maxQueryLimit)
+	start := time.Now()
+
+	cursor, err := m.openQuery(ctx, req, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := &QueryResult{Documents: make([]string, 0)}
+	for cursor.Next(ctx) {
+		if len(result.Documents) >= limit {
+			result.Truncated = true
+			break
+		}
+		data, err := bson.MarshalExtJSON(cursor.Current, false, false)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao converter documento: %w", err)
+		}
+		result.Documents = append(result.Documents, string(data))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("erro na consulta: %w", err)
+	}
+
+	result.Count = len(result.Documents)
+	result.ElapsedMs = time.Since(start).Milliseconds()
+
+	msg := fmt.Sprintf("🔎 %s.%s: %d documentos em %d ms", req.Collection, req.Kind, result.Count, result.ElapsedMs)
+	if result.Truncated {
+		msg += fmt.Sprintf(" (limitado a %d)", limit)
+	}
+	log(msg)
+	return result, nil
+}
+
+// flattenDocument achata subdocumentos em colunas com notação de ponto;
+// arrays viram texto em JSON estendido para caber em uma célula.
+func flattenDocument(doc bson.Raw, prefix string, row map[string]string, columns *[]string, seen map[string]bool) {
+	elements, err := doc.Elements()
+	if err != nil {
+		return
+	}
+	for _, el := range elements {
+		key := el.Key()
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		v := el.Value()
+		if v.Type == bsontype.EmbeddedDocument {
+			flattenDocument(v.Document(), key, row, columns, seen)
+			continue
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			*columns = append(*columns, key)
+		}
+		switch v.Type {
+		case bsontype.Array:
+			data, _ := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, false, false)
+			row[key] = strings.TrimSuffix(strings.TrimPrefix(string(data), `{"v":`), "}")
+		case bsontype.DateTime:
+			row[key] = v.Time().Local().Format("2006-01-02 15:04:05")
+		case bsontype.Null:
+			row[key] = ""
+		case bsontype.Double:
+			row[key] = strconv.FormatFloat(v.Double(), 'f', -1, 64)
+		case bsontype.Int32, bsontype.Int64:
+			row[key] = fmt.Sprint(v.AsInt64())
+		case bsontype.Decimal128:
+			row[key] = v.Decimal128().String()
+		default:
+			row[key] = formatRawValue(v)
+		}
+	}
+}
+
+// ExportQuery executa a consulta (até 100.000 documentos) e grava o resultado
+// em CSV ou XLSX, uma coluna por campo.
+func (m *Manager) ExportQuery(req QueryRequest, format, outputPath string, log LogFunc) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), req.timeout())
+	defer cancel()
+
+	limit := clampLimit(req.Limit, maxExportLimit, maxExportLimit)
+	cursor, err := m.openQuery(ctx, req, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []map[string]string
+	var columns []string
+	seen := make(map[string]bool)
+	for cursor.Next(ctx) {
+		if len(rows) >= limit {
+			log(fmt.Sprintf("⚠️ Exportação limitada a %d documentos", limit))
+			break
+		}
+		row := make(map[string]string)
+		flattenDocument(cursor.Current, "", row, &columns, seen)
+		rows = append(rows, row)
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, fmt.Errorf("erro na consulta: %w", err)
+	}
+
+	switch strings.ToUpper(format) {
+	case "CSV":
+		err = writeQueryCSV(outputPath, columns, rows)
+	case "XLSX", "EXCEL":
+		err = writeQueryXLSX(outputPath, req.Collection, columns, rows)
+	default:
+		return 0, fmt.Errorf("formato de exportação inválido: %s", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	log(fmt.Sprintf("✅ %d documentos exportados para %s", len(rows), filepath.Base(outputPath)))
+	return len(rows), nil
+}
+
+func writeQueryCSV(path string, columns []string, rows []map[string]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo: %w", err)
+	}
+	defer f.Close()
+
+	// BOM para o Excel reconhecer UTF-8; ';' é o separador padrão no Excel em pt-BR.
+	f.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(f)
+	w.Comma = ';'
+
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = row[col]
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeQueryXLSX(path, sheetName string, columns []string, rows []map[string]string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	// Nomes de planilha no Excel têm no máximo 31 caracteres.
+	if len(sheetName) > 31 {
+		sheetName = sheetName[:31]
+	}
+	if sheetName == "" {
+		sheetName = "Consulta"
+	}
+	if err := f.SetSheetName("Sheet1", sheetName); err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(sheetName)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = col
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for r, row := range rows {
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			values[i] = row[col]
+		}
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, values); err != nil {
+			return err
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("erro ao salvar planilha: %w", err)
+	}
+	return nil
+}
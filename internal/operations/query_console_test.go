@@ -0,0 +1,112 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFindForbiddenOperator(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline string
+		want     string
+	}{
+		{"somente leitura", `[{"$match": {"Ativo": true}}, {"$group": {"_id": "$Ncm", "n": {"$sum": 1}}}]`, ""},
+		{"$out", `[{"$match": {}}, {"$out": "Copia"}]`, "$out"},
+		{"$merge", `[{"$merge": {"into": "Copia"}}]`, "$merge"},
+		{"dentro de $facet", `[{"$facet": {"a": [{"$match": {}}], "b": [{"$out": "Copia"}]}}]`, "$out"},
+		{"dentro de $lookup", `[{"$lookup": {"from": "Estoques", "pipeline": [{"$merge": "Copia"}], "as": "e"}}]`, "$merge"},
+		{"dentro de $unionWith", `[{"$unionWith": {"coll": "Pessoas", "pipeline": [{"$out": "Copia"}]}}]`, "$out"},
+		{"nome de campo parecido", `[{"$project": {"out": 1, "merge": 1}}]`, ""},
+	}
+	for _, tt := range tests {
+		pipeline, err := parseExtJSONPipeline(tt.pipeline)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := findForbiddenOperator(pipeline); got != tt.want {
+			t.Errorf("%s: operador = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+
+	filter, err := parseExtJSONDocument(`{"$expr": {"$eq": [1, 1]}, "Itens": {"$elemMatch": {"$out": 1}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := findForbiddenOperator(filter); got != "$out" {
+		t.Errorf("filtro: operador = %q, esperado $out", got)
+	}
+}
+
+func TestParseExtJSON(t *testing.T) {
+	doc, err := parseExtJSONDocument("  ")
+	if err != nil || len(doc) != 0 {
+		t.Errorf("texto vazio = %v, %v", doc, err)
+	}
+	if _, err := parseExtJSONDocument(`{"Ativo": }`); err == nil {
+		t.Error("filtro inválido aceito")
+	}
+	pipeline, err := parseExtJSONPipeline(`[{"$match": {"_id": {"$oid": "5f0000000000000000000001"}}}]`)
+	if err != nil || len(pipeline) != 1 {
+		t.Errorf("pipeline = %v, %v", pipeline, err)
+	}
+	if _, err := parseExtJSONPipeline(`{"$match": {}}`); err == nil {
+		t.Error("pipeline que não é array aceito")
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	limits := []struct{ in, want int }{
+		{0, defaultQueryLimit},
+		{-5, defaultQueryLimit},
+		{50, 50},
+		{maxQueryLimit + 1, maxQueryLimit},
+	}
+	for _, l := range limits {
+		if got := clampLimit(l.in, defaultQueryLimit, maxQueryLimit); got != l.want {
+			t.Errorf("clampLimit(%d) = %d, esperado %d", l.in, got, l.want)
+		}
+	}
+
+	timeouts := []struct {
+		seconds int
+		want    time.Duration
+	}{
+		{0, defaultQueryTimeout},
+		{10, 10 * time.Second},
+		{3600, maxQueryTimeout},
+	}
+	for _, tt := range timeouts {
+		if got := (QueryRequest{TimeoutSeconds: tt.seconds}).timeout(); got != tt.want {
+			t.Errorf("timeout(%d) = %v, esperado %v", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestFlattenDocument(t *testing.T) {
+	doc, _ := bson.Marshal(bson.D{
+		{Key: "Nome", Value: "Cliente"},
+		{Key: "Endereco", Value: bson.D{{Key: "Cidade", Value: "Curitiba"}, {Key: "Numero", Value: int32(10)}}},
+		{Key: "Telefones", Value: bson.A{"1", "2"}},
+		{Key: "Saldo", Value: 10.5},
+		{Key: "Observacao", Value: nil},
+	})
+	row := make(map[string]string)
+	var columns []string
+	flattenDocument(doc, "", row, &columns, make(map[string]bool))
+
+	wantColumns := []string{"Nome", "Endereco.Cidade", "Endereco.Numero", "Telefones", "Saldo", "Observacao"}
+	if !reflect.DeepEqual(columns, wantColumns) {
+		t.Errorf("colunas = %v, esperado %v", columns, wantColumns)
+	}
+	wantRow := map[string]string{
+		"Nome": "Cliente", "Endereco.Cidade": "Curitiba", "Endereco.Numero": "10",
+		"Telefones": `["1","2"]`, "Saldo": "10.5", "Observacao": "",
+	}
+	if !reflect.DeepEqual(row, wantRow) {
+		t.Errorf("linha = %v, esperado %v", row, wantRow)
+	}
+}
@@ -0,0 +1,173 @@
+package operations
+
+import (
+	"BMongo-VIP/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const remoteBackupScheme = "s3://"
+
+func remoteStorage() (*storage.S3Client, error) {
+	cfg, err := storage.S3ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewS3Client(*cfg), nil
+}
+
+func isRemoteBackupPath(p string) bool {
+	return strings.HasPrefix(p, remoteBackupScheme)
+}
+
+// UploadBackup compacta uma pasta de backup em .tar.gz e envia o arquivo e o
+// manifest para o bucket configurado. O .tar.gz local é removido ao final
+// somente se o envio for concluído, permitindo retomar uploads interrompidos.
+func (m *Manager) UploadBackup(backupPath string, log LogFunc) (*BackupResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+
+	client, err := remoteStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(backupPath)
+	archivePath := backupPath + ".tar.gz"
+
+	if _, err := os.Stat(archivePath); err == nil {
+		if err := verifyBackupArchive(archivePath); err != nil {
+			log(fmt.Sprintf("⚠️ Arquivo compactado existente está incompleto (%v): compactando de novo", err))
+			os.Remove(archivePath)
+		}
+	}
+
+	if _, err := os.Stat(archivePath); err != nil {
+		check := newPreflight("compactação")
+		check.checkSpace("arquivo .tar.gz", filepath.Dir(backupPath), dumpFolderSize(backupPath)/2)
+		check.Log(log)
+		if err := check.Err(); err != nil {
+			return nil, err
+		}
+
+		log(fmt.Sprintf("🗜️ Compactando %s...", name))
+		if err := packBackupArchive(backupPath, archivePath); err != nil {
+			return nil, err
+		}
+	} else {
+		log(fmt.Sprintf("♻️ Reaproveitando arquivo já compactado: %s", filepath.Base(archivePath)))
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := client.Key(name + ".tar.gz")
+	log(fmt.Sprintf("☁️ Enviando %s (%.2f MB) para %s...", filepath.Base(archivePath), float64(info.Size())/1024/1024, client.URL(key)))
+
+	if err := client.UploadFile(ctx, key, archivePath, log); err != nil {
+		return nil, fmt.Errorf("erro no upload do backup: %w", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(backupPath, backupManifestFile)); err == nil {
+		if err := client.PutObject(ctx, client.Key(name+".manifest.json"), data, "application/json"); err != nil {
+			log(fmt.Sprintf("⚠️ Falha ao enviar manifest: %v", err))
+		}
+	}
+
+	os.Remove(archivePath)
+	log("✅ Backup enviado para o armazenamento remoto!")
+
+	return &BackupResult{
+		Path:      client.URL(key),
+		Size:      info.Size(),
+		Timestamp: strings.TrimPrefix(name, "backup_"),
+		Remote:    true,
+	}, nil
+}
+
+func (m *Manager) ListRemoteBackups() ([]BackupResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	client, err := remoteStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := client.ListObjects(ctx, client.Key("backup_"))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar backups remotos: %w", err)
+	}
+
+	backups := make([]BackupResult, 0)
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, ".tar.gz") {
+			continue
+		}
+
+		name := strings.TrimSuffix(filepath.Base(obj.Key), ".tar.gz")
+		backups = append(backups, BackupResult{
+			Path:      client.URL(obj.Key),
+			Size:      obj.Size,
+			Timestamp: strings.TrimPrefix(name, "backup_"),
+			Remote:    true,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Timestamp > backups[j].Timestamp })
+	return backups, nil
+}
+
+// DownloadRemoteBackup baixa um backup s3://bucket/chave para destDir.
+// Downloads interrompidos continuam de onde pararam na próxima chamada.
+func (m *Manager) DownloadRemoteBackup(remotePath, destDir string, log LogFunc) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+
+	client, err := remoteStorage()
+	if err != nil {
+		return "", err
+	}
+
+	rest := strings.TrimPrefix(remotePath, remoteBackupScheme)
+	bucket, key, found := strings.Cut(rest, "/")
+	if !found || key == "" {
+		return "", fmt.Errorf("caminho remoto inválido: %s", remotePath)
+	}
+	if bucket != client.Bucket() {
+		return "", fmt.Errorf("bucket %s diferente do configurado (%s)", bucket, client.Bucket())
+	}
+
+	head, err := client.HeadObject(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("backup remoto não encontrado: %w", err)
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("erro ao criar diretório: %w", err)
+	}
+
+	check := newPreflight("download")
+	check.checkSpace("download", destDir, uint64(head.Size))
+	check.Log(log)
+	if err := check.Err(); err != nil {
+		return "", err
+	}
+
+	destPath := filepath.Join(destDir, filepath.Base(key))
+	log(fmt.Sprintf("☁️ Baixando %s (%.2f MB)...", remotePath, float64(head.Size)/1024/1024))
+
+	if err := client.DownloadFile(ctx, key, destPath, log); err != nil {
+		return "", fmt.Errorf("erro no download do backup: %w", err)
+	}
+
+	log(fmt.Sprintf("✅ Backup baixado para %s", destPath))
+	return destPath, nil
+}
@@ -2,6 +2,7 @@ package operations
 
 import (
 	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/repository"
 	"context"
 	"fmt"
 	"sync"
@@ -33,6 +34,14 @@ const (
 	OpChangeItemType     OperationType = "ChangeItemType"
 	OpChangeProductType  OperationType = "ChangeProductType"
 	OpClearCodigoTribMun OperationType = "ClearCodigoTribMun"
+
+	OpFixOrphanReferences    OperationType = "FixOrphanReferences"
+	OpRepairProductIntegrity OperationType = "RepairProductIntegrity"
+	OpEditDocumentField      OperationType = "EditDocumentField"
+	OpMergeDatabase          OperationType = "MergeDatabase"
+	// Sem desfazer: aparecem no histórico só com o relatório do snapshot.
+	OpCleanupByDate  OperationType = "CleanupByDate"
+	OpDeleteEmitente OperationType = "DeleteEmitente"
 )
 
 type OperationRecord struct {
@@ -42,19 +51,38 @@ type OperationRecord struct {
 	Label     string                 `json:"label"`
 	Details   map[string]interface{} `json:"details"`
 	Undoable  bool                   `json:"undoable"`
+	// Changes é o relatório do snapshot tirado em volta da operação, quando houver.
+	Changes *SnapshotDiff `json:"changes,omitempty"`
 }
 
 type RollbackManager struct {
 	history []OperationRecord
 	mu      sync.RWMutex
 	conn    *database.Connection
+	repos   *repository.Repositories
 	maxOps  int
+
+	// compat confere a versão do Digisat antes de desfazer operações que
+	// gravam em caminhos fixos; é ligado pelo Manager.
+	compat func(op OperationType, log LogFunc) error
 }
 
 func NewRollbackManager(conn *database.Connection) *RollbackManager {
 	return &RollbackManager{
 		history: make([]OperationRecord, 0),
 		conn:    conn,
+		repos:   repository.NewMongo(conn.Database),
+		maxOps:  20,
+	}
+}
+
+// NewRollbackManagerWithRepositories é o equivalente de
+// NewManagerWithRepositories para o histórico de rollback. Sem conexão, só
+// as operações de repositoryUndos podem ser desfeitas.
+func NewRollbackManagerWithRepositories(repos *repository.Repositories) *RollbackManager {
+	return &RollbackManager{
+		history: make([]OperationRecord, 0),
+		repos:   repos,
 		maxOps:  20,
 	}
 }
@@ -82,6 +110,59 @@ func (rm *RollbackManager) RecordOperation(opType OperationType, label string, d
 	return id
 }
 
+// LatestOperationID devolve o ID da última operação registrada.
+func (rm *RollbackManager) LatestOperationID() string {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	if len(rm.history) == 0 {
+		return ""
+	}
+	return rm.history[len(rm.history)-1].ID
+}
+
+// AttachChanges anexa o relatório de alterações a uma operação do histórico.
+func (rm *RollbackManager) AttachChanges(opID string, diff *SnapshotDiff) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	for i := range rm.history {
+		if rm.history[i].ID == opID {
+			rm.history[i].Changes = diff
+			return true
+		}
+	}
+	return false
+}
+
+func (rm *RollbackManager) GetOperation(opID string) (OperationRecord, bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	for _, op := range rm.history {
+		if op.ID == opID {
+			return op, true
+		}
+	}
+	return OperationRecord{}, false
+}
+
+// GetReportedOperations devolve as operações com relatório de alterações,
+// da mais recente para a mais antiga, inclusive as que não podem ser
+// desfeitas.
+func (rm *RollbackManager) GetReportedOperations() []OperationRecord {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	reported := make([]OperationRecord, 0)
+	for i := len(rm.history) - 1; i >= 0; i-- {
+		if rm.history[i].Changes != nil {
+			reported = append(reported, rm.history[i])
+		}
+	}
+	return reported
+}
+
 func (rm *RollbackManager) GetUndoableOperations() []OperationRecord {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
@@ -95,6 +176,16 @@ func (rm *RollbackManager) GetUndoableOperations() []OperationRecord {
 	return undoable
 }
 
+// repositoryUndos são as operações cujo rollback passa só pelos repositórios
+// e, portanto, funciona também sem conexão (NewRollbackManagerWithRepositories).
+var repositoryUndos = map[OperationType]bool{
+	OpInactivateProducts: true,
+	OpBulkActivate:       true,
+	OpZeroStock:          true,
+	OpZeroNegativeStock:  true,
+	OpAdjustInventory:    true,
+}
+
 func (rm *RollbackManager) UndoOperation(opID string, log LogFunc) error {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
@@ -117,6 +208,16 @@ func (rm *RollbackManager) UndoOperation(opID string, log LogFunc) error {
 		return fmt.Errorf("esta operação não pode ser revertida")
 	}
 
+	if rm.conn == nil && !repositoryUndos[target.Type] {
+		return fmt.Errorf("desfazer %s exige conexão direta com o MongoDB", target.Type)
+	}
+
+	if rm.compat != nil {
+		if err := rm.compat(target.Type, log); err != nil {
+			return err
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
@@ -148,6 +249,14 @@ func (rm *RollbackManager) UndoOperation(opID string, log LogFunc) error {
 		err = rm.undoChangeNCM(ctx, target.Details, log)
 	case OpChangeBrand:
 		err = rm.undoChangeBrand(ctx, target.Details, log)
+	case OpFixOrphanReferences:
+		err = rm.undoFixOrphanReferences(ctx, target.Details, log)
+	case OpRepairProductIntegrity:
+		err = rm.undoRepairProductIntegrity(ctx, target.Details, log)
+	case OpEditDocumentField:
+		err = rm.undoEditDocumentField(ctx, target.Details, log)
+	case OpMergeDatabase:
+		err = rm.undoMergeDatabase(ctx, target.Details, log)
 	default:
 		return fmt.Errorf("tipo de operação não suportado para rollback: %s", target.Type)
 	}
@@ -177,22 +286,16 @@ func (rm *RollbackManager) undoInactivateProducts(ctx context.Context, details m
 
 	log(fmt.Sprintf("🔄 Reativando %d produtos...", len(productIDs)))
 
-	produtosServicos := rm.conn.GetCollection(database.CollectionProdutosServicos)
-	count := 0
-
+	oids := make([]primitive.ObjectID, 0, len(productIDs))
 	for _, idHex := range productIDs {
-		oid, err := primitive.ObjectIDFromHex(idHex)
-		if err != nil {
-			continue
+		if oid, err := primitive.ObjectIDFromHex(idHex); err == nil {
+			oids = append(oids, oid)
 		}
+	}
 
-		result, err := produtosServicos.UpdateOne(ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{"Ativo": true}},
-		)
-		if err == nil && result.ModifiedCount > 0 {
-			count++
-		}
+	count, err := rm.repos.Products.SetActive(ctx, oids, true)
+	if err != nil {
+		return fmt.Errorf("erro ao reativar produtos: %w", err)
 	}
 
 	log(fmt.Sprintf("✅ %d produtos reativados", count))
@@ -328,9 +431,8 @@ func (rm *RollbackManager) undoBulkActivate(ctx context.Context, details map[str
 
 	log(fmt.Sprintf("🔄 Revertendo status de %d produtos...", len(products)))
 
-	produtosServicos := rm.conn.GetCollection(database.CollectionProdutosServicos)
-	count := 0
-
+	// Agrupa pelo status anterior para restaurar com uma atualização por grupo.
+	byStatus := map[bool][]primitive.ObjectID{}
 	for _, prod := range products {
 		idHex, _ := prod["id"].(string)
 		wasActive, _ := prod["wasActive"].(bool)
@@ -343,14 +445,16 @@ func (rm *RollbackManager) undoBulkActivate(ctx context.Context, details map[str
 		if err != nil {
 			continue
 		}
+		byStatus[wasActive] = append(byStatus[wasActive], oid)
+	}
 
-		result, err := produtosServicos.UpdateOne(ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{"Ativo": wasActive}},
-		)
-		if err == nil && result.ModifiedCount > 0 {
-			count++
+	var count int64
+	for wasActive, oids := range byStatus {
+		modified, err := rm.repos.Products.SetActive(ctx, oids, wasActive)
+		if err != nil {
+			return fmt.Errorf("erro ao restaurar status: %w", err)
 		}
+		count += modified
 	}
 
 	log(fmt.Sprintf("✅ Status revertido para %d produtos", count))
@@ -374,7 +478,6 @@ func (rm *RollbackManager) undoZeroStock(ctx context.Context, details map[string
 
 	log(fmt.Sprintf("🔄 Restaurando %d estoques...", len(stocks)))
 
-	estoques := rm.conn.GetCollection(database.CollectionEstoques)
 	count := 0
 
 	for _, stock := range stocks {
@@ -390,11 +493,8 @@ func (rm *RollbackManager) undoZeroStock(ctx context.Context, details map[string
 			continue
 		}
 
-		result, err := estoques.UpdateOne(ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{"Quantidades.0.Quantidade": prevQty}},
-		)
-		if err == nil && result.ModifiedCount > 0 {
+		modified, err := rm.repos.Stock.SetQuantity(ctx, bson.M{"_id": oid}, prevQty)
+		if err == nil && modified > 0 {
 			count++
 		}
 	}
@@ -593,7 +693,6 @@ func (rm *RollbackManager) undoAdjustInventory(ctx context.Context, details map[
 
 	log(fmt.Sprintf("🔄 Restaurando %d estoques do ajuste de inventário...", len(stocks)))
 
-	estoques := rm.conn.GetCollection(database.CollectionEstoques)
 	count := 0
 
 	for _, stock := range stocks {
@@ -609,11 +708,8 @@ func (rm *RollbackManager) undoAdjustInventory(ctx context.Context, details map[
 			continue
 		}
 
-		result, err := estoques.UpdateOne(ctx,
-			bson.M{"_id": oid},
-			bson.M{"$set": bson.M{"Quantidades.0.Quantidade": prevQty}},
-		)
-		if err == nil && result.ModifiedCount > 0 {
+		modified, err := rm.repos.Stock.SetQuantity(ctx, bson.M{"_id": oid}, prevQty)
+		if err == nil && modified > 0 {
 			count++
 		}
 	}
@@ -769,6 +865,180 @@ func (rm *RollbackManager) undoChangeBrand(ctx context.Context, details map[stri
 	return nil
 }
 
+func (rm *RollbackManager) undoFixOrphanReferences(ctx context.Context, details map[string]interface{}, log LogFunc) error {
+	collection, _ := details["collection"].(string)
+	field, _ := details["field"].(string)
+	if collection == "" || field == "" {
+		return fmt.Errorf("detalhes da correção incompletos")
+	}
+
+	coll := rm.conn.GetCollection(collection)
+	count := 0
+
+	if deleted, ok := details["deleted"].([]string); ok {
+		log(fmt.Sprintf("🔄 Restaurando %d documentos excluídos de %s...", len(deleted), collection))
+		count = rm.reinsertDocuments(ctx, collection, deleted)
+		log(fmt.Sprintf("✅ %d documentos restaurados", count))
+		return nil
+	}
+
+	documents, _ := details["documents"].([]map[string]interface{})
+	if len(documents) == 0 {
+		return fmt.Errorf("nenhum documento para reverter")
+	}
+
+	log(fmt.Sprintf("🔄 Restaurando %s de %d documentos...", field, len(documents)))
+	for _, doc := range documents {
+		result, err := coll.UpdateOne(ctx, bson.M{"_id": doc["id"]}, bson.M{"$set": bson.M{field: doc["prev"]}})
+		if err == nil && result.ModifiedCount > 0 {
+			count++
+		}
+	}
+
+	log(fmt.Sprintf("✅ Referência restaurada em %d documentos", count))
+	return nil
+}
+
+func (rm *RollbackManager) undoRepairProductIntegrity(ctx context.Context, details map[string]interface{}, log LogFunc) error {
+	if created, ok := details["createdStocks"].([]primitive.ObjectID); ok && len(created) > 0 {
+		log(fmt.Sprintf("🔄 Removendo %d estoques criados na correção...", len(created)))
+		rm.conn.GetCollection(database.CollectionEstoques).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": created}})
+	}
+
+	if relinked, ok := details["relinked"].([]map[string]interface{}); ok && len(relinked) > 0 {
+		pse := rm.conn.GetCollection(database.CollectionProdutosServicosEmpresa)
+		count := 0
+		for _, r := range relinked {
+			result, err := pse.UpdateOne(ctx, bson.M{"_id": r["id"]}, bson.M{"$set": bson.M{"EstoqueReferencia": r["prev"]}})
+			if err == nil && result.ModifiedCount > 0 {
+				count++
+			}
+		}
+		log(fmt.Sprintf("   ✓ Estoque original restaurado em %d produtos", count))
+	}
+
+	if deleted, ok := details["deleted"].(map[string][]string); ok {
+		for collection, docs := range deleted {
+			if len(docs) == 0 {
+				continue
+			}
+			count := rm.reinsertDocuments(ctx, collection, docs)
+			log(fmt.Sprintf("   ✓ %s: %d documentos restaurados", collection, count))
+		}
+	}
+
+	log("✅ Correção de vínculos revertida")
+	return nil
+}
+
+func (rm *RollbackManager) undoEditDocumentField(ctx context.Context, details map[string]interface{}, log LogFunc) error {
+	collection, _ := details["collection"].(string)
+	field, _ := details["field"].(string)
+	existed, _ := details["existed"].(bool)
+	prev, _ := details["prev"].(string)
+	after, _ := details["after"].(string)
+	idText, _ := details["idText"].(string)
+	id := details["id"]
+
+	if collection == "" || field == "" || id == nil {
+		return fmt.Errorf("detalhes da edição incompletos")
+	}
+
+	var update bson.M
+	if existed {
+		var wrapper bson.Raw
+		if err := bson.UnmarshalExtJSON([]byte(prev), true, &wrapper); err != nil {
+			return fmt.Errorf("valor anterior inválido: %w", err)
+		}
+		update = bson.M{"$set": bson.M{field: wrapper.Lookup("v")}}
+	} else {
+		update = bson.M{"$unset": bson.M{field: 1}}
+	}
+
+	// Só desfaz se o campo ainda tem o valor gravado pela edição.
+	filter := bson.M{"_id": id}
+	if after != "" {
+		var wrapper bson.Raw
+		if err := bson.UnmarshalExtJSON([]byte(after), true, &wrapper); err != nil {
+			return fmt.Errorf("valor gravado inválido: %w", err)
+		}
+		filter[field] = wrapper.Lookup("v")
+	}
+
+	result, err := rm.conn.GetCollection(collection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("erro ao restaurar campo: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("o campo %s foi alterado depois da edição; nada foi restaurado", field)
+	}
+
+	if idText == "" {
+		idText = fmt.Sprint(id)
+	}
+	if err := appendAudit(AuditEntry{
+		Operation:  "Undo" + string(OpEditDocumentField),
+		Collection: collection,
+		DocumentID: idText,
+		Field:      field,
+		Before:     after,
+		After:      prev,
+	}); err != nil {
+		log(fmt.Sprintf("⚠️ Falha ao gravar auditoria: %v", err))
+	}
+
+	log(fmt.Sprintf("✅ Campo %s restaurado em %s", field, collection))
+	return nil
+}
+
+func (rm *RollbackManager) undoMergeDatabase(ctx context.Context, details map[string]interface{}, log LogFunc) error {
+	inserted, _ := details["inserted"].(map[string][]interface{})
+	if len(inserted) == 0 {
+		return fmt.Errorf("nenhum documento para reverter")
+	}
+
+	log("🔄 Removendo documentos inseridos pela mesclagem...")
+	var total int64
+	for collection, ids := range inserted {
+		coll := rm.conn.GetCollection(collection)
+		var removed int64
+		for start := 0; start < len(ids); start += mergeLookupBatch {
+			end := start + mergeLookupBatch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			result, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids[start:end]}})
+			if err != nil {
+				return fmt.Errorf("erro ao remover de %s: %w", collection, err)
+			}
+			removed += result.DeletedCount
+		}
+		if removed > 0 {
+			log(fmt.Sprintf("   ✓ %s: %d removidos", collection, removed))
+		}
+		total += removed
+	}
+
+	log(fmt.Sprintf("✅ Mesclagem revertida: %d documentos removidos", total))
+	return nil
+}
+
+// reinsertDocuments reinsere documentos guardados em JSON estendido canônico.
+func (rm *RollbackManager) reinsertDocuments(ctx context.Context, collection string, docs []string) int {
+	coll := rm.conn.GetCollection(collection)
+	count := 0
+	for _, data := range docs {
+		var doc bson.D
+		if err := bson.UnmarshalExtJSON([]byte(data), true, &doc); err != nil {
+			continue
+		}
+		if _, err := coll.InsertOne(ctx, doc); err == nil {
+			count++
+		}
+	}
+	return count
+}
+
 func (rm *RollbackManager) ClearHistory() {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
@@ -0,0 +1,363 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultSchemaSampleSize = 500
+
+// SchemaField descreve um caminho de campo encontrado na amostra. Elementos de
+// array aparecem com o sufixo [] (ex.: Itens[].Quantidade).
+type SchemaField struct {
+	Path      string         `json:"path"`
+	Types     map[string]int `json:"types"`
+	Count     int            `json:"count"`
+	Frequency float64        `json:"frequency"`
+}
+
+type SchemaDiscriminator struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type SchemaCollection struct {
+	Name           string                `json:"name"`
+	DocumentCount  int64                 `json:"documentCount"`
+	Sampled        int                   `json:"sampled"`
+	Discriminators []SchemaDiscriminator `json:"discriminators"`
+	Fields         []SchemaField         `json:"fields"`
+}
+
+// SchemaCatalog é o retrato do esquema de uma base, salvo em JSON para servir
+// de referência entre versões do Digisat.
+type SchemaCatalog struct {
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Database    string             `json:"database"`
+	SampleSize  int                `json:"sampleSize"`
+	Collections []SchemaCollection `json:"collections"`
+}
+
+// discriminatorKey representa o _t como texto. Em coleções com herança o _t é
+// um array (ex.: ["Pessoa", "PessoaJuridica", "Emitente"]) e vira "Pessoa > PessoaJuridica > Emitente".
+func discriminatorKey(v bson.RawValue) string {
+	switch v.Type {
+	case bsontype.String:
+		return v.StringValue()
+	case bsontype.Array:
+		values, err := v.Array().Values()
+		if err != nil {
+			return v.String()
+		}
+		parts := make([]string, 0, len(values))
+		for _, item := range values {
+			parts = append(parts, formatRawValue(item))
+		}
+		return strings.Join(parts, " > ")
+	}
+	return v.String()
+}
+
+// collectSchemaPaths acumula, para um documento, o tipo de cada caminho.
+// Cada caminho é contado uma vez por documento, mesmo que apareça em vários
+// elementos de um array.
+func collectSchemaPaths(doc bson.Raw, prefix string, seen map[string]map[string]bool) {
+	elements, err := doc.Elements()
+	if err != nil {
+		return
+	}
+	for _, el := range elements {
+		path := el.Key()
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		collectSchemaValue(el.Value(), path, seen)
+	}
+}
+
+func collectSchemaValue(v bson.RawValue, path string, seen map[string]map[string]bool) {
+	if seen[path] == nil {
+		seen[path] = make(map[string]bool)
+	}
+	seen[path][v.Type.String()] = true
+
+	switch v.Type {
+	case bsontype.EmbeddedDocument:
+		collectSchemaPaths(v.Document(), path, seen)
+	case bsontype.Array:
+		values, err := v.Array().Values()
+		if err != nil {
+			return
+		}
+		for _, item := range values {
+			collectSchemaValue(item, path+"[]", seen)
+		}
+	}
+}
+
+// BuildSchemaCatalog amostra cada coleção ($sample) para inferir caminhos,
+// tipos e frequência dos campos, e conta os valores de _t na coleção inteira.
+func (m *Manager) BuildSchemaCatalog(sampleSize int, log LogFunc) (*SchemaCatalog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	if sampleSize <= 0 {
+		sampleSize = defaultSchemaSampleSize
+	}
+
+	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar coleções: %w", err)
+	}
+	sort.Strings(collections)
+
+	catalog := &SchemaCatalog{
+		GeneratedAt: time.Now(),
+		Database:    m.conn.Database.Name(),
+		SampleSize:  sampleSize,
+		Collections: make([]SchemaCollection, 0, len(collections)),
+	}
+
+	log(fmt.Sprintf("🧭 Mapeando esquema de %d coleções (amostra de %d documentos)...", len(collections), sampleSize))
+
+	for _, colName := range collections {
+		if m.state.ShouldStop() {
+			log("Operação cancelada pelo usuário")
+			return catalog, nil
+		}
+		if isSystemCollection(colName) {
+			continue
+		}
+
+		info, err := m.describeCollection(ctx, colName, sampleSize)
+		if err != nil {
+			log(fmt.Sprintf("⚠️ %s: %v", colName, err))
+			continue
+		}
+		catalog.Collections = append(catalog.Collections, *info)
+		log(fmt.Sprintf("   %s: %d documentos, %d campos, %d tipos (_t)", colName, info.DocumentCount, len(info.Fields), len(info.Discriminators)))
+	}
+
+	log(fmt.Sprintf("✅ Esquema mapeado: %d coleções", len(catalog.Collections)))
+	return catalog, nil
+}
+
+func (m *Manager) describeCollection(ctx context.Context, colName string, sampleSize int) (*SchemaCollection, error) {
+	coll := m.conn.GetCollection(colName)
+
+	total, err := coll.EstimatedDocumentCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar documentos: %w", err)
+	}
+
+	info := &SchemaCollection{
+		Name:           colName,
+		DocumentCount:  total,
+		Discriminators: make([]SchemaDiscriminator, 0),
+		Fields:         make([]SchemaField, 0),
+	}
+
+	cursor, err := coll.Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{"_id": "$_t", "count": bson.M{"$sum": 1}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar _t: %w", err)
+	}
+	for cursor.Next(ctx) {
+		t := cursor.Current.Lookup("_id")
+		if t.Type == bsontype.Null {
+			continue
+		}
+		count, _ := cursor.Current.Lookup("count").AsInt64OK()
+		info.Discriminators = append(info.Discriminators, SchemaDiscriminator{Value: discriminatorKey(t), Count: count})
+	}
+	cursor.Close(ctx)
+	sort.Slice(info.Discriminators, func(i, j int) bool {
+		return info.Discriminators[i].Count > info.Discriminators[j].Count
+	})
+
+	cursor, err = coll.Aggregate(ctx, bson.A{bson.M{"$sample": bson.M{"size": sampleSize}}})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao amostrar documentos: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int)
+	types := make(map[string]map[string]int)
+	for cursor.Next(ctx) {
+		info.Sampled++
+		seen := make(map[string]map[string]bool)
+		collectSchemaPaths(cursor.Current, "", seen)
+		for path, pathTypes := range seen {
+			counts[path]++
+			if types[path] == nil {
+				types[path] = make(map[string]int)
+			}
+			for t := range pathTypes {
+				types[path][t]++
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	for path, count := range counts {
+		info.Fields = append(info.Fields, SchemaField{
+			Path:      path,
+			Types:     types[path],
+			Count:     count,
+			Frequency: float64(count) / float64(info.Sampled),
+		})
+	}
+	sort.Slice(info.Fields, func(i, j int) bool { return info.Fields[i].Path < info.Fields[j].Path })
+
+	return info, nil
+}
+
+func SaveSchemaCatalog(catalog *SchemaCatalog, path string) error {
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("erro ao salvar catálogo: %w", err)
+	}
+	return nil
+}
+
+func LoadSchemaCatalog(path string) (*SchemaCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler catálogo: %w", err)
+	}
+	var catalog SchemaCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("catálogo inválido (%s): %w", path, err)
+	}
+	return &catalog, nil
+}
+
+// Tipos de diferença entre catálogos.
+const (
+	SchemaChangeCollectionAdded      = "colecao_adicionada"
+	SchemaChangeCollectionRemoved    = "colecao_removida"
+	SchemaChangeFieldAdded           = "campo_adicionado"
+	SchemaChangeFieldRemoved         = "campo_removido"
+	SchemaChangeFieldType            = "tipo_alterado"
+	SchemaChangeDiscriminatorAdded   = "tipo_t_adicionado"
+	SchemaChangeDiscriminatorRemoved = "tipo_t_removido"
+)
+
+type SchemaChange struct {
+	Kind       string `json:"kind"`
+	Collection string `json:"collection"`
+	Path       string `json:"path,omitempty"`
+	Before     string `json:"before,omitempty"`
+	After      string `json:"after,omitempty"`
+}
+
+// minSchemaFrequency descarta campos raros (presentes em menos de 5% da
+// amostra) da comparação, já que sua presença depende da amostra sorteada.
+const minSchemaFrequency = 0.05
+
+func typeNames(types map[string]int) string {
+	names := make([]string, 0, len(types))
+	for t := range types {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// CompareSchemaCatalogs lista as diferenças entre um catálogo de referência
+// (before) e um novo (after): coleções, campos, tipos e valores de _t.
+func CompareSchemaCatalogs(before, after *SchemaCatalog) []SchemaChange {
+	changes := make([]SchemaChange, 0)
+
+	beforeColls := make(map[string]SchemaCollection, len(before.Collections))
+	for _, c := range before.Collections {
+		beforeColls[c.Name] = c
+	}
+	afterColls := make(map[string]SchemaCollection, len(after.Collections))
+	for _, c := range after.Collections {
+		afterColls[c.Name] = c
+	}
+
+	for _, b := range before.Collections {
+		if _, ok := afterColls[b.Name]; !ok {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeCollectionRemoved, Collection: b.Name})
+		}
+	}
+
+	for _, a := range after.Collections {
+		b, ok := beforeColls[a.Name]
+		if !ok {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeCollectionAdded, Collection: a.Name})
+			continue
+		}
+		changes = append(changes, compareSchemaCollection(b, a)...)
+	}
+	return changes
+}
+
+func compareSchemaCollection(before, after SchemaCollection) []SchemaChange {
+	var changes []SchemaChange
+
+	beforeFields := make(map[string]SchemaField, len(before.Fields))
+	for _, f := range before.Fields {
+		beforeFields[f.Path] = f
+	}
+	afterFields := make(map[string]SchemaField, len(after.Fields))
+	for _, f := range after.Fields {
+		afterFields[f.Path] = f
+	}
+
+	// Coleções vazias em um dos lados não permitem comparar campos.
+	if before.Sampled > 0 && after.Sampled > 0 {
+		for _, b := range before.Fields {
+			if _, ok := afterFields[b.Path]; !ok && b.Frequency >= minSchemaFrequency {
+				changes = append(changes, SchemaChange{Kind: SchemaChangeFieldRemoved, Collection: after.Name, Path: b.Path, Before: typeNames(b.Types)})
+			}
+		}
+		for _, a := range after.Fields {
+			b, ok := beforeFields[a.Path]
+			if !ok {
+				if a.Frequency >= minSchemaFrequency {
+					changes = append(changes, SchemaChange{Kind: SchemaChangeFieldAdded, Collection: after.Name, Path: a.Path, After: typeNames(a.Types)})
+				}
+				continue
+			}
+			if bt, at := typeNames(b.Types), typeNames(a.Types); bt != at {
+				changes = append(changes, SchemaChange{Kind: SchemaChangeFieldType, Collection: after.Name, Path: a.Path, Before: bt, After: at})
+			}
+		}
+	}
+
+	beforeTypes := make(map[string]bool, len(before.Discriminators))
+	for _, d := range before.Discriminators {
+		beforeTypes[d.Value] = true
+	}
+	afterTypes := make(map[string]bool, len(after.Discriminators))
+	for _, d := range after.Discriminators {
+		afterTypes[d.Value] = true
+		if !beforeTypes[d.Value] {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeDiscriminatorAdded, Collection: after.Name, After: d.Value})
+		}
+	}
+	for _, d := range before.Discriminators {
+		if !afterTypes[d.Value] {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeDiscriminatorRemoved, Collection: after.Name, Before: d.Value})
+		}
+	}
+	return changes
+}
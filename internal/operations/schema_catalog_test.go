@@ -0,0 +1,125 @@
+package operations
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCompareSchemaCatalogs(t *testing.T) {
+	field := func(path string, freq float64, types ...string) SchemaField {
+		f := SchemaField{Path: path, Types: map[string]int{}, Frequency: freq}
+		for _, typ := range types {
+			f.Types[typ] = 1
+		}
+		return f
+	}
+
+	before := &SchemaCatalog{Collections: []SchemaCollection{
+		{
+			Name: "Pessoas", Sampled: 100,
+			Discriminators: []SchemaDiscriminator{{Value: "Pessoa > Cliente"}, {Value: "Pessoa > Fornecedor"}},
+			Fields: []SchemaField{
+				field("Nome", 1, "string"),
+				field("Cnpj", 0.8, "string"),
+				field("Saldo", 1, "double"),
+				field("Raro", 0.01, "string"),
+			},
+		},
+		{Name: "Antiga", Sampled: 1},
+		{Name: "Vazia", Sampled: 0},
+	}}
+	after := &SchemaCatalog{Collections: []SchemaCollection{
+		{
+			Name: "Pessoas", Sampled: 100,
+			Discriminators: []SchemaDiscriminator{{Value: "Pessoa > Cliente"}, {Value: "Pessoa > Emitente"}},
+			Fields: []SchemaField{
+				field("Nome", 1, "string"),
+				field("Saldo", 1, "decimal"),
+				field("Documento", 0.9, "string"),
+				field("Novo raro", 0.02, "string"),
+			},
+		},
+		{Name: "Nova", Sampled: 5},
+		{Name: "Vazia", Sampled: 10, Fields: []SchemaField{field("X", 1, "string")}},
+	}}
+
+	got := CompareSchemaCatalogs(before, after)
+	want := []SchemaChange{
+		{Kind: SchemaChangeCollectionRemoved, Collection: "Antiga"},
+		{Kind: SchemaChangeFieldRemoved, Collection: "Pessoas", Path: "Cnpj", Before: "string"},
+		{Kind: SchemaChangeFieldType, Collection: "Pessoas", Path: "Saldo", Before: "double", After: "decimal"},
+		{Kind: SchemaChangeFieldAdded, Collection: "Pessoas", Path: "Documento", After: "string"},
+		{Kind: SchemaChangeDiscriminatorAdded, Collection: "Pessoas", After: "Pessoa > Emitente"},
+		{Kind: SchemaChangeDiscriminatorRemoved, Collection: "Pessoas", Before: "Pessoa > Fornecedor"},
+		{Kind: SchemaChangeCollectionAdded, Collection: "Nova"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diferenças:\n%+v\nesperado:\n%+v", got, want)
+	}
+
+	if changes := CompareSchemaCatalogs(before, before); len(changes) != 0 {
+		t.Errorf("catálogo comparado com ele mesmo: %+v", changes)
+	}
+}
+
+func TestCollectSchemaPaths(t *testing.T) {
+	doc, _ := bson.Marshal(bson.D{
+		{Key: "Nome", Value: "Cliente"},
+		{Key: "Itens", Value: bson.A{
+			bson.D{{Key: "Quantidade", Value: int32(1)}},
+			bson.D{{Key: "Quantidade", Value: 2.5}},
+		}},
+	})
+	seen := make(map[string]map[string]bool)
+	collectSchemaPaths(doc, "", seen)
+
+	want := map[string]map[string]bool{
+		"Nome":               {"string": true},
+		"Itens":              {"array": true},
+		"Itens[]":            {"embedded document": true},
+		"Itens[].Quantidade": {"32-bit integer": true, "double": true},
+	}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("caminhos = %v, esperado %v", seen, want)
+	}
+}
+
+func TestDiscriminatorKey(t *testing.T) {
+	doc, _ := bson.Marshal(bson.D{
+		{Key: "a", Value: "Emitente"},
+		{Key: "b", Value: bson.A{"Pessoa", "PessoaJuridica", "Emitente"}},
+		{Key: "c", Value: int32(3)},
+	})
+	raw := bson.Raw(doc)
+	tests := map[string]string{
+		"a": "Emitente",
+		"b": "Pessoa > PessoaJuridica > Emitente",
+		"c": `{"$numberInt":"3"}`,
+	}
+	for key, want := range tests {
+		if got := discriminatorKey(raw.Lookup(key)); got != want {
+			t.Errorf("discriminatorKey(%s) = %q, esperado %q", key, got, want)
+		}
+	}
+}
+
+func TestSchemaCatalogSaveLoad(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "catalogo.json")
+	catalog := &SchemaCatalog{Database: "DigisatServer", SampleSize: 10, Collections: []SchemaCollection{{Name: "Pessoas", Sampled: 3}}}
+	if err := SaveSchemaCatalog(catalog, p); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSchemaCatalog(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, catalog) {
+		t.Errorf("catálogo lido = %+v", loaded)
+	}
+	if _, err := LoadSchemaCatalog(filepath.Join(t.TempDir(), "ausente.json")); err == nil {
+		t.Error("catálogo ausente aceito")
+	}
+}
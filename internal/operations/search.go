@@ -1,113 +1,357 @@
 package operations
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"BMongo-VIP/internal/database"
+
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const defaultSearchWorkers = 4
+
+type SearchMatch struct {
+	Collection string `json:"collection"`
+	ID         string `json:"id"`
+	Field      string `json:"field"`
+	Value      string `json:"value,omitempty"`
+}
+
+type fieldMatch struct {
+	Path  string
+	Value bson.RawValue
+}
+
+type ObjectIdSearchOptions struct {
+	// Por padrão só _id e os caminhos de referência conhecidos são
+	// consultados, usando índices. Exhaustive varre todas as coleções por
+	// completo e encontra o valor em qualquer campo.
+	Exhaustive bool `json:"exhaustive"`
+	Workers    int  `json:"workers"`
+}
+
+// searchReferencePaths completa as referências já conhecidas (órfãos, limpeza
+// em cascata e coleções por emitente) com os ObjectIds embutidos: produtos e
+// estoques nos itens das movimentações, pessoas e perfis de usuário. Os nomes
+// variam entre versões do Digisat, como nos modelos.
+var searchReferencePaths = map[string][]string{
+	database.CollectionMovimentacoes: {
+		"Empresa._id", "Pessoa._id", "PessoaReferencia",
+		"Itens.Produto._id", "Itens.ProdutoServico._id", "Itens.ProdutoServicoReferencia", "Itens.EstoqueReferencia",
+		"ItensBase.Produto._id", "ItensBase.ProdutoServico._id", "ItensBase.ProdutoServicoReferencia", "ItensBase.EstoqueReferencia",
+	},
+	database.CollectionRecebimentos: {"Pessoa._id", "PessoaReferencia", "EmpresaReferencia"},
+	database.CollectionPagamentos:   {"Pessoa._id", "PessoaReferencia", "EmpresaReferencia"},
+	database.CollectionBoletos:      {"Pessoa._id", "PessoaReferencia", "EmpresaReferencia"},
+	database.CollectionEstoques:     {"ProdutoServicoReferencia", "Quantidades.EmpresaReferencia"},
+	database.CollectionUsuarios:     {"Perfis.EmpresaReferencia"},
+}
+
+// referencePaths lista, por coleção, os campos conhecidos que guardam ObjectIds
+// de outros documentos. Usado pela busca indexada.
+func referencePaths() map[string][]string {
+	paths := make(map[string][]string)
+	seen := make(map[string]bool)
+	add := func(collection string, fields ...string) {
+		for _, field := range fields {
+			if key := collection + "." + field; !seen[key] {
+				seen[key] = true
+				paths[collection] = append(paths[collection], field)
+			}
+		}
+	}
+
+	for _, ref := range OrphanReferences() {
+		add(ref.Collection, ref.Field)
+	}
+	for _, groups := range [][]emitenteCollectionGroup{emitenteDataGroups, emitenteConfigGroups} {
+		for _, group := range groups {
+			for _, name := range group.collections {
+				add(name, "EmpresaReferencia")
+			}
+		}
+	}
+	var addDependents func(deps []CleanupDependency)
+	addDependents = func(deps []CleanupDependency) {
+		for _, dep := range deps {
+			add(dep.Collection, dep.ReferenceFields...)
+			addDependents(dep.Dependents)
+		}
+	}
+	addDependents(defaultMovementDependents())
+	for collection, fields := range searchReferencePaths {
+		add(collection, fields...)
+	}
+	return paths
+}
 
 func (m *Manager) FindObjectIdInDatabase(searchID string, log LogFunc) ([]map[string]string, error) {
+	matches, err := m.SearchObjectId(searchID, ObjectIdSearchOptions{}, nil, log)
+
+	results := make([]map[string]string, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, map[string]string{
+			"collection": match.Collection,
+			"field":      match.Field,
+			"id":         match.ID,
+		})
+	}
+	return results, err
+}
+
+// SearchObjectId procura um ObjectId (ou seu hex) em todas as coleções com um
+// pool de workers. Cada ocorrência é entregue a onMatch assim que encontrada.
+func (m *Manager) SearchObjectId(searchID string, opts ObjectIdSearchOptions, onMatch func(SearchMatch), log LogFunc) ([]SearchMatch, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
+	searchOID, oidErr := primitive.ObjectIDFromHex(searchID)
+	searchAsOID := oidErr == nil
+	match := objectIDMatcher(searchID)
 
-	var searchOID primitive.ObjectID
-	var searchAsOID bool
-	if oid, err := primitive.ObjectIDFromHex(searchID); err == nil {
-		searchOID = oid
-		searchAsOID = true
+	// Prefiltro nos bytes do documento: só decodifica o que pode conter o valor.
+	needles := [][]byte{[]byte(searchID)}
+	if searchAsOID {
+		needles = append(needles, searchOID[:])
+	}
+	prefilter := func(doc bson.Raw) bool {
+		for _, n := range needles {
+			if bytes.Contains(doc, n) {
+				return true
+			}
+		}
+		return false
 	}
-
 
 	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
 	if err != nil {
 		return nil, fmt.Errorf("erro ao listar coleções: %w", err)
 	}
+	sort.Strings(collections)
 
-	var results []map[string]string
-
-	for _, colName := range collections {
-		if m.state.ShouldStop() {
-			log("Operação cancelada pelo usuário")
-			return results, nil
+	paths := referencePaths()
+	return m.searchCollections(ctx, collections, opts.Workers, 0, func(colName string) (*mongo.Cursor, error) {
+		col := m.conn.GetCollection(colName)
+		if opts.Exhaustive {
+			return col.Find(ctx, bson.M{}, options.Find().SetBatchSize(1000))
 		}
+		return col.Find(ctx, objectIDQuery(searchID, paths[colName]))
+	}, prefilter, match, onMatch, log)
+}
 
-		col := m.conn.Database.Collection(colName)
-		cursor, err := col.Find(ctx, bson.M{})
-		if err != nil {
-			continue
+// objectIDMatcher aceita o ObjectId procurado ou o seu hex gravado como texto.
+func objectIDMatcher(searchID string) func(bson.RawValue) bool {
+	searchOID, err := primitive.ObjectIDFromHex(searchID)
+	searchAsOID := err == nil
+	return func(v bson.RawValue) bool {
+		switch v.Type {
+		case bsontype.ObjectID:
+			return searchAsOID && v.ObjectID() == searchOID
+		case bsontype.String:
+			return v.StringValue() == searchID
 		}
+		return false
+	}
+}
 
-		for cursor.Next(ctx) {
-			if m.state.ShouldStop() {
-				cursor.Close(ctx)
-				return results, nil
-			}
-
-			var doc bson.M
-			if err := cursor.Decode(&doc); err != nil {
-				continue
-			}
+// objectIDQuery consulta _id e os caminhos de referência da coleção, tanto
+// pelo ObjectId quanto pelo hex em texto.
+func objectIDQuery(searchID string, paths []string) bson.M {
+	values := []interface{}{searchID}
+	if oid, err := primitive.ObjectIDFromHex(searchID); err == nil {
+		values = append(values, oid)
+	}
+	conditions := []bson.M{{"_id": bson.M{"$in": values}}}
+	for _, p := range paths {
+		conditions = append(conditions, bson.M{p: bson.M{"$in": values}})
+	}
+	return bson.M{"$or": conditions}
+}
 
+type searchCursorFunc func(colName string) (*mongo.Cursor, error)
 
-			matches := searchDocument(doc, searchID, searchOID, searchAsOID)
-			for _, field := range matches {
-				result := map[string]string{
-					"collection": colName,
-					"field":      field,
-				}
-				results = append(results, result)
-				log(fmt.Sprintf("Encontrado na coleção %s, campo %s", colName, field))
-			}
-		}
-		cursor.Close(ctx)
+// searchCollections distribui as coleções entre workers, percorre os
+// documentos devolvidos por open e registra cada campo aceito por match,
+// parando ao atingir limit ocorrências (0 = sem limite).
+func (m *Manager) searchCollections(ctx context.Context, collections []string, workers, limit int, open searchCursorFunc, prefilter func(bson.Raw) bool, match func(bson.RawValue) bool, onMatch func(SearchMatch), log LogFunc) ([]SearchMatch, error) {
+	if workers <= 0 {
+		workers = defaultSearchWorkers
 	}
 
-	return results, nil
-}
+	var (
+		mu      sync.Mutex
+		results = make([]SearchMatch, 0)
+		wg      sync.WaitGroup
+		jobs    = make(chan string)
+		full    bool
+		failed  []string
+	)
 
+	// O LogFunc e o onMatch do chamador não são seguros para uso concorrente.
+	report := func(found SearchMatch) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if full {
+			return false
+		}
+		results = append(results, found)
+		if limit > 0 && len(results) >= limit {
+			full = true
+			log(fmt.Sprintf("⚠️ Limite de %d resultados atingido", limit))
+		}
+		if onMatch != nil {
+			onMatch(found)
+		}
+		log(fmt.Sprintf("Encontrado na coleção %s, documento %s, campo %s", found.Collection, found.ID, found.Field))
+		return true
+	}
+	fail := func(colName string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, colName)
+		log(fmt.Sprintf("⚠️ %s: %v", colName, err))
+	}
+	stopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return full || m.state.ShouldStop()
+	}
 
-func searchDocument(doc bson.M, searchStr string, searchOID primitive.ObjectID, searchAsOID bool) []string {
-	var matches []string
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for colName := range jobs {
+				if stopped() {
+					continue
+				}
 
-	for key, value := range doc {
-		switch v := value.(type) {
-		case primitive.ObjectID:
-			if searchAsOID && v == searchOID {
-				matches = append(matches, key)
-			} else if v.Hex() == searchStr {
-				matches = append(matches, key)
-			}
-		case string:
-			if v == searchStr {
-				matches = append(matches, key)
-			}
-		case bson.M:
+				cursor, err := open(colName)
+				if err != nil {
+					fail(colName, err)
+					continue
+				}
 
-			nested := searchDocument(v, searchStr, searchOID, searchAsOID)
-			for _, n := range nested {
-				matches = append(matches, key+"."+n)
-			}
-		case bson.A:
+			docs:
+				for cursor.Next(ctx) {
+					if stopped() {
+						break
+					}
+					doc := cursor.Current
+					if prefilter != nil && !prefilter(doc) {
+						continue
+					}
 
-			for i, item := range v {
-				if m, ok := item.(bson.M); ok {
-					nested := searchDocument(m, searchStr, searchOID, searchAsOID)
-					for _, n := range nested {
-						matches = append(matches, fmt.Sprintf("%s[%d].%s", key, i, n))
+					var fields []fieldMatch
+					walkRawDocument(doc, "", match, &fields)
+					if len(fields) == 0 {
+						continue
 					}
-				} else if oid, ok := item.(primitive.ObjectID); ok {
-					if searchAsOID && oid == searchOID {
-						matches = append(matches, fmt.Sprintf("%s[%d]", key, i))
+
+					id := formatRawID(doc.Lookup("_id"))
+					for _, field := range fields {
+						if !report(SearchMatch{Collection: colName, ID: id, Field: field.Path, Value: formatRawValue(field.Value)}) {
+							break docs
+						}
 					}
 				}
+				if err := cursor.Err(); err != nil {
+					fail(colName, err)
+				}
+				cursor.Close(ctx)
 			}
+		}()
+	}
+
+	for _, colName := range collections {
+		if strings.HasPrefix(colName, "system.") {
+			continue
+		}
+		jobs <- colName
+	}
+	close(jobs)
+	wg.Wait()
+
+	if m.state.ShouldStop() {
+		log("Operação cancelada pelo usuário")
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Collection != results[j].Collection {
+			return results[i].Collection < results[j].Collection
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return results, fmt.Errorf("busca incompleta: erro ao ler %d coleção(ões): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return results, nil
+}
+
+// walkRawDocument percorre o documento sem decodificá-lo, descendo em
+// subdocumentos e arrays (inclusive arrays de arrays) e acumulando o caminho
+// de cada valor aceito por match, no formato Itens[0].Produto.
+func walkRawDocument(doc bson.Raw, prefix string, match func(bson.RawValue) bool, out *[]fieldMatch) {
+	elements, err := doc.Elements()
+	if err != nil {
+		return
+	}
+	for _, el := range elements {
+		path := el.Key()
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		walkRawValue(el.Value(), path, match, out)
+	}
+}
+
+func walkRawValue(v bson.RawValue, path string, match func(bson.RawValue) bool, out *[]fieldMatch) {
+	switch v.Type {
+	case bsontype.EmbeddedDocument:
+		walkRawDocument(v.Document(), path, match, out)
+	case bsontype.Array:
+		values, err := v.Array().Values()
+		if err != nil {
+			return
+		}
+		for i, item := range values {
+			walkRawValue(item, fmt.Sprintf("%s[%d]", path, i), match, out)
+		}
+	default:
+		if match(v) {
+			*out = append(*out, fieldMatch{Path: path, Value: v})
 		}
 	}
+}
+
+func formatRawID(v bson.RawValue) string {
+	if oid, ok := v.ObjectIDOK(); ok {
+		return oid.Hex()
+	}
+	if s, ok := v.StringValueOK(); ok {
+		return s
+	}
+	return v.String()
+}
 
-	return matches
+func formatRawValue(v bson.RawValue) string {
+	switch v.Type {
+	case bsontype.String:
+		return v.StringValue()
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex()
+	}
+	return v.String()
 }
@@ -0,0 +1,90 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReferencePaths(t *testing.T) {
+	paths := referencePaths()
+
+	for collection, fields := range paths {
+		seen := make(map[string]bool)
+		for _, f := range fields {
+			if seen[f] {
+				t.Errorf("%s.%s repetido", collection, f)
+			}
+			seen[f] = true
+		}
+	}
+
+	contains := func(collection, field string) bool {
+		for _, f := range paths[collection] {
+			if f == field {
+				return true
+			}
+		}
+		return false
+	}
+	for _, want := range [][2]string{
+		{database.CollectionProdutosServicosEmpresa, "EstoqueReferencia"},
+		{database.CollectionMovimentacoes, "Itens.ProdutoServicoReferencia"},
+		{database.CollectionRecebimentos, "Parcelas.MovimentacaoReferencia"},
+		{database.CollectionUsuarios, "Perfis.EmpresaReferencia"},
+	} {
+		if !contains(want[0], want[1]) {
+			t.Errorf("caminho %s.%s ausente", want[0], want[1])
+		}
+	}
+}
+
+func TestObjectIDQuery(t *testing.T) {
+	id := primitive.NewObjectID()
+	values := []interface{}{id.Hex(), id}
+
+	got := objectIDQuery(id.Hex(), []string{"EmpresaReferencia"})
+	want := bson.M{"$or": []bson.M{
+		{"_id": bson.M{"$in": values}},
+		{"EmpresaReferencia": bson.M{"$in": values}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("consulta = %v, esperado %v", got, want)
+	}
+
+	// Um texto que não é ObjectId só é procurado como texto.
+	got = objectIDQuery("codigo-123", nil)
+	want = bson.M{"$or": []bson.M{{"_id": bson.M{"$in": []interface{}{"codigo-123"}}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("consulta = %v, esperado %v", got, want)
+	}
+}
+
+func TestObjectIDMatcherWalk(t *testing.T) {
+	id := primitive.NewObjectID()
+	doc, _ := bson.Marshal(bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "EmpresaReferencia", Value: id},
+		{Key: "Itens", Value: bson.A{
+			bson.D{{Key: "Produto", Value: bson.D{{Key: "_id", Value: id.Hex()}}}},
+			bson.A{id},
+		}},
+		{Key: "Outro", Value: primitive.NewObjectID()},
+		{Key: "Numero", Value: int32(1)},
+	})
+
+	var matches []fieldMatch
+	walkRawDocument(doc, "", objectIDMatcher(id.Hex()), &matches)
+
+	var paths []string
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	if want := []string{"EmpresaReferencia", "Itens[0].Produto._id", "Itens[1][0]"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("caminhos = %v, esperado %v", paths, want)
+	}
+}
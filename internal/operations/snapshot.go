@@ -0,0 +1,525 @@
+package operations
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/database"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SnapshotSpec indica uma coleção e os campos a registrar dela. Sem Fields o
+// documento inteiro entra no snapshot; Filter limita os documentos e precisa
+// continuar valendo depois da operação (ex.: o _id do documento editado).
+type SnapshotSpec struct {
+	Collection string   `json:"collection"`
+	Fields     []string `json:"fields"`
+	Filter     bson.M   `json:"filter,omitempty"`
+}
+
+// idOnlyFields registra só o _id, o que basta para operações que apenas
+// criam ou removem documentos.
+var idOnlyFields = []string{"_id"}
+
+// snapshotSpecs são as coleções e campos gravados por cada operação.
+var snapshotSpecs = map[OperationType][]SnapshotSpec{
+	OpInactivateProducts: {{Collection: database.CollectionProdutosServicos, Fields: []string{"Ativo"}}},
+	OpBulkActivate:       {{Collection: database.CollectionProdutosServicos, Fields: []string{"Ativo"}}},
+	OpZeroStock:          {{Collection: database.CollectionEstoques, Fields: []string{"Quantidades"}}},
+	OpZeroNegativeStock:  {{Collection: database.CollectionEstoques, Fields: []string{"Quantidades"}}},
+	OpZeroAllPrices:      {{Collection: database.CollectionProdutosServicosEmpresa, Fields: []string{"PrecosCustos", "PrecosVendas"}}},
+	OpEnableMEI:          {{Collection: database.CollectionPessoas, Fields: []string{"MicroempreendedorIndividual"}}},
+	OpChangeTributation:  {{Collection: database.CollectionProdutosServicosEmpresa, Fields: []string{"TributacaoEstadualReferencia"}}},
+	OpChangeTribFederal:  {{Collection: database.CollectionProdutosServicosEmpresa, Fields: []string{"TributacaoFederal", "TributacaoFederalReferencia"}}},
+	OpChangeTribIbsCbs:   {{Collection: database.CollectionProdutosServicosEmpresa, Fields: []string{"TributacaoIbsCbsReferencia"}}},
+	OpRepairProductIntegrity: {
+		{Collection: database.CollectionProdutosServicosEmpresa, Fields: []string{"ProdutoServicoReferencia", "EstoqueReferencia"}},
+		{Collection: database.CollectionEstoques, Fields: []string{"Quantidades"}},
+	},
+	OpAdjustPrices:        {{Collection: database.CollectionProdutosServicosEmpresa, Fields: []string{"PrecosCustos", "PrecosVendas"}}},
+	OpChangeNCM:           {{Collection: database.CollectionProdutosServicosEmpresa, Fields: []string{"NcmNbs"}}},
+	OpChangeBrand:         {{Collection: database.CollectionProdutosServicos, Fields: []string{"MarcaReferencia", "Marca"}}},
+	OpAdjustInventory:     {{Collection: database.CollectionEstoques, Fields: []string{"Quantidades"}}},
+	OpChangeInvoiceKey:    {{Collection: database.CollectionMovimentacoes, Fields: []string{"ChaveAcesso"}}},
+	OpChangeInvoiceStatus: {{Collection: database.CollectionMovimentacoes, Fields: []string{"Situacao", "SituacaoMovimentacao"}}},
+}
+
+// SnapshotSpecsFor devolve as coleções e campos que a operação altera. As
+// operações cujo alcance depende dos parâmetros (limpeza, exclusão de
+// emitente, mesclagem, vínculos, editor) usam as funções *SnapshotSpecs.
+func SnapshotSpecsFor(op OperationType) []SnapshotSpec {
+	return snapshotSpecs[op]
+}
+
+// CleanupSnapshotSpecs cobre as coleções das regras de limpeza por data e
+// dos seus dependentes.
+func CleanupSnapshotSpecs() ([]SnapshotSpec, error) {
+	rules, _, err := LoadCleanupRules()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var specs []SnapshotSpec
+	add := func(collection string) {
+		if !seen[collection] {
+			seen[collection] = true
+			specs = append(specs, SnapshotSpec{Collection: collection, Fields: idOnlyFields})
+		}
+	}
+	var addDependents func(deps []CleanupDependency)
+	addDependents = func(deps []CleanupDependency) {
+		for _, dep := range deps {
+			add(dep.Collection)
+			addDependents(dep.Dependents)
+		}
+	}
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		add(rule.Collection)
+		addDependents(rule.Dependents)
+	}
+	return specs, nil
+}
+
+// EmitenteSnapshotSpecs cobre os dados removidos por DeleteEmitente.
+func EmitenteSnapshotSpecs(emitenteID string) ([]SnapshotSpec, error) {
+	oid, err := primitive.ObjectIDFromHex(emitenteID)
+	if err != nil {
+		return nil, fmt.Errorf("ID inválido: %v", err)
+	}
+	byEmpresa := bson.M{"EmpresaReferencia": oid}
+	specs := []SnapshotSpec{
+		{Collection: database.CollectionPessoas, Fields: idOnlyFields, Filter: bson.M{"_id": oid}},
+		{Collection: database.CollectionProdutosServicosEmpresa, Fields: idOnlyFields, Filter: byEmpresa},
+		{Collection: database.CollectionEstoques, Fields: idOnlyFields},
+		{Collection: database.CollectionUsuarios, Fields: []string{"Perfis"}},
+	}
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		seen[spec.Collection] = true
+	}
+	for _, groups := range [][]emitenteCollectionGroup{emitenteDataGroups, emitenteConfigGroups} {
+		for _, group := range groups {
+			for _, collection := range group.collections {
+				if !seen[collection] {
+					seen[collection] = true
+					specs = append(specs, SnapshotSpec{Collection: collection, Fields: idOnlyFields, Filter: byEmpresa})
+				}
+			}
+		}
+	}
+	return specs, nil
+}
+
+// MergeSnapshotSpecs cobre as coleções que a mesclagem pode preencher.
+func (m *Manager) MergeSnapshotSpecs(sourceDatabase string) ([]SnapshotSpec, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	names, err := m.conn.Client.Database(sourceDatabase).ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar coleções da origem: %w", err)
+	}
+	sort.Strings(names)
+	specs := make([]SnapshotSpec, 0, len(names))
+	for _, name := range names {
+		if !strings.HasPrefix(name, "system.") {
+			specs = append(specs, SnapshotSpec{Collection: name, Fields: idOnlyFields})
+		}
+	}
+	return specs, nil
+}
+
+// OrphanSnapshotSpecs cobre o campo corrigido por FixOrphanReferences.
+func OrphanSnapshotSpecs(collection, field string) []SnapshotSpec {
+	return []SnapshotSpec{{Collection: collection, Fields: []string{topLevelField(field)}}}
+}
+
+// DocumentSnapshotSpecs cobre o campo alterado no editor de documentos.
+func DocumentSnapshotSpecs(collection, id, field string) []SnapshotSpec {
+	return []SnapshotSpec{{
+		Collection: collection,
+		Fields:     []string{topLevelField(field)},
+		Filter:     bson.M{"_id": documentIDFilter(id)},
+	}}
+}
+
+// topLevelField devolve o primeiro nível do caminho: projeções não aceitam
+// índices de array (Itens.0.Quantidade).
+func topLevelField(field string) string {
+	return strings.Split(normalizeFieldPath(field), ".")[0]
+}
+
+// Snapshot guarda em memória só o _id e o hash dos campos registrados de
+// cada documento. O hash basta para saber o que mudou, mas não para mostrar o
+// valor anterior no relatório; por isso os documentos projetados (só os
+// campos da spec) ficam num arquivo temporário e são relidos apenas para os
+// alterados e removidos. Close apaga o arquivo.
+type Snapshot struct {
+	TakenAt time.Time
+	Specs   []SnapshotSpec
+	docs    map[string]map[string]snapshotEntry
+	spool   *os.File
+}
+
+type snapshotEntry struct {
+	hash   [sha256.Size]byte
+	offset int64
+	size   int
+	seen   bool
+}
+
+func (s *Snapshot) Close() {
+	if s.spool != nil {
+		s.spool.Close()
+		os.Remove(s.spool.Name())
+		s.spool = nil
+	}
+}
+
+// document relê do arquivo temporário o documento registrado.
+func (s *Snapshot) document(e snapshotEntry) (bson.Raw, error) {
+	doc := make([]byte, e.size)
+	if _, err := s.spool.ReadAt(doc, e.offset); err != nil {
+		return nil, fmt.Errorf("erro ao ler snapshot: %w", err)
+	}
+	return doc, nil
+}
+
+const (
+	SnapshotCreated = "criado"
+	SnapshotRemoved = "removido"
+)
+
+type SnapshotField struct {
+	Path   string `json:"path"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type SnapshotChange struct {
+	Collection string          `json:"collection"`
+	ID         string          `json:"id"`
+	Kind       string          `json:"kind"`
+	Fields     []SnapshotField `json:"fields"`
+}
+
+type SnapshotCollectionDiff struct {
+	Name    string   `json:"name"`
+	Fields  []string `json:"fields"`
+	Before  int64    `json:"before"`
+	After   int64    `json:"after"`
+	Changed int64    `json:"changed"`
+	Created int64    `json:"created"`
+	Removed int64    `json:"removed"`
+}
+
+// SnapshotDiff é o relatório do que a operação alterou.
+type SnapshotDiff struct {
+	TakenAt     time.Time                `json:"takenAt"`
+	ComparedAt  time.Time                `json:"comparedAt"`
+	Collections []SnapshotCollectionDiff `json:"collections"`
+	Changes     []SnapshotChange         `json:"changes"`
+	// Truncated indica que houve mais alterações que as listadas em Changes.
+	Truncated bool `json:"truncated"`
+}
+
+const maxSnapshotChanges = 5000
+
+// TakeSnapshot registra as coleções de specs; o chamador deve chamar Close.
+func (m *Manager) TakeSnapshot(specs []SnapshotSpec, log LogFunc) (*Snapshot, error) {
+	if m.conn == nil {
+		return nil, fmt.Errorf("snapshot requer conexão com o MongoDB")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	spool, err := os.CreateTemp("", "digisat_snapshot_*.bson")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo do snapshot: %w", err)
+	}
+	snap := &Snapshot{TakenAt: time.Now(), Specs: specs, docs: make(map[string]map[string]snapshotEntry), spool: spool}
+
+	w := bufio.NewWriterSize(spool, 1<<20)
+	var offset int64
+	total := 0
+	for _, spec := range specs {
+		entries := make(map[string]snapshotEntry)
+		var writeErr error
+		err := m.scanSnapshot(ctx, spec, func(doc bson.Raw) {
+			if writeErr != nil {
+				return
+			}
+			if _, writeErr = w.Write(doc); writeErr != nil {
+				return
+			}
+			entries[doc.Lookup("_id").String()] = snapshotEntry{hash: sha256.Sum256(doc), offset: offset, size: len(doc)}
+			offset += int64(len(doc))
+		})
+		if err == nil {
+			err = writeErr
+		}
+		if err != nil {
+			snap.Close()
+			return nil, err
+		}
+		snap.docs[spec.Collection] = entries
+		total += len(entries)
+	}
+	if err := w.Flush(); err != nil {
+		snap.Close()
+		return nil, fmt.Errorf("erro ao gravar snapshot: %w", err)
+	}
+
+	log(fmt.Sprintf("📸 Snapshot de %d documentos em %d coleções", total, len(specs)))
+	return snap, nil
+}
+
+func (m *Manager) scanSnapshot(ctx context.Context, spec SnapshotSpec, fn func(bson.Raw)) error {
+	var projection interface{}
+	if len(spec.Fields) > 0 {
+		fields := bson.D{}
+		for _, field := range spec.Fields {
+			fields = append(fields, bson.E{Key: field, Value: 1})
+		}
+		projection = fields
+	}
+	filter := spec.Filter
+	if filter == nil {
+		filter = bson.M{}
+	}
+	return each(ctx, m.conn.GetCollection(spec.Collection), filter, projection, fn)
+}
+
+func snapshotValues(doc bson.Raw) map[string]string {
+	values := make(map[string]string)
+	var columns []string
+	flattenDocument(doc, "", values, &columns, make(map[string]bool))
+	delete(values, "_id")
+	return values
+}
+
+// CompareSnapshot lê de novo as coleções do snapshot e lista os documentos
+// alterados, criados e removidos, com o valor anterior e o atual de cada campo.
+// Os valores só são montados para os documentos que entram no relatório.
+func (m *Manager) CompareSnapshot(before *Snapshot, log LogFunc) (*SnapshotDiff, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	diff := &SnapshotDiff{TakenAt: before.TakenAt, ComparedAt: time.Now()}
+	room := func() bool {
+		if len(diff.Changes) >= maxSnapshotChanges {
+			diff.Truncated = true
+			return false
+		}
+		return true
+	}
+
+	for _, spec := range before.Specs {
+		old := before.docs[spec.Collection]
+		for key, e := range old {
+			e.seen = false
+			old[key] = e
+		}
+		c := SnapshotCollectionDiff{Name: spec.Collection, Fields: spec.Fields, Before: int64(len(old))}
+
+		var readErr error
+		err := m.scanSnapshot(ctx, spec, func(doc bson.Raw) {
+			c.After++
+			id := doc.Lookup("_id")
+			key := id.String()
+			prev, ok := old[key]
+			if !ok {
+				c.Created++
+				if room() {
+					diff.Changes = append(diff.Changes, SnapshotChange{Collection: spec.Collection, ID: formatRawID(id), Kind: SnapshotCreated, Fields: compareSnapshotValues(nil, snapshotValues(doc))})
+				}
+				return
+			}
+			prev.seen = true
+			old[key] = prev
+			if prev.hash == sha256.Sum256(doc) {
+				return
+			}
+			c.Changed++
+			if readErr != nil || !room() {
+				return
+			}
+			var prevDoc bson.Raw
+			if prevDoc, readErr = before.document(prev); readErr != nil {
+				return
+			}
+			diff.Changes = append(diff.Changes, SnapshotChange{Collection: spec.Collection, ID: formatRawID(id), Kind: DiffChanged, Fields: compareSnapshotValues(snapshotValues(prevDoc), snapshotValues(doc))})
+		})
+		if err == nil {
+			err = readErr
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range sortedSnapshotKeys(old) {
+			prev := old[key]
+			if prev.seen {
+				continue
+			}
+			c.Removed++
+			if !room() {
+				continue
+			}
+			prevDoc, err := before.document(prev)
+			if err != nil {
+				return nil, err
+			}
+			diff.Changes = append(diff.Changes, SnapshotChange{Collection: spec.Collection, ID: formatRawID(prevDoc.Lookup("_id")), Kind: SnapshotRemoved, Fields: compareSnapshotValues(snapshotValues(prevDoc), nil)})
+		}
+		diff.Collections = append(diff.Collections, c)
+	}
+	diff.ComparedAt = time.Now()
+
+	var changed, created, removed int64
+	for _, c := range diff.Collections {
+		changed += c.Changed
+		created += c.Created
+		removed += c.Removed
+	}
+	log(fmt.Sprintf("🔎 Comparação com o snapshot: %d alterados, %d criados, %d removidos", changed, created, removed))
+	return diff, nil
+}
+
+func sortedSnapshotKeys(entries map[string]snapshotEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compareSnapshotValues(before, after map[string]string) []SnapshotField {
+	paths := make(map[string]bool)
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var fields []SnapshotField
+	for _, path := range sorted {
+		b, okB := before[path]
+		a, okA := after[path]
+		if okB && okA && a == b {
+			continue
+		}
+		fields = append(fields, SnapshotField{Path: path, Before: b, After: a})
+	}
+	return fields
+}
+
+// RunWithSnapshot executa run entre um snapshot e a comparação das coleções
+// que a operação op altera (ou de specs, quando informado) e anexa o relatório
+// ao registro que a operação gravar no histórico. A comparação é feita mesmo
+// se run falhar, pois parte das alterações pode já ter sido gravada.
+func (m *Manager) RunWithSnapshot(op OperationType, specs []SnapshotSpec, run func() error, log LogFunc) (*SnapshotDiff, error) {
+	if len(specs) == 0 {
+		specs = SnapshotSpecsFor(op)
+	}
+	if len(specs) == 0 || m.conn == nil {
+		return nil, run()
+	}
+
+	before, err := m.TakeSnapshot(specs, log)
+	if err != nil {
+		log(fmt.Sprintf("⚠️ Snapshot não capturado: %v (a operação segue sem relatório)", err))
+		return nil, run()
+	}
+	defer before.Close()
+
+	lastID := ""
+	if m.rollback != nil {
+		lastID = m.rollback.LatestOperationID()
+	}
+
+	runErr := run()
+
+	diff, err := m.CompareSnapshot(before, log)
+	if err != nil {
+		log(fmt.Sprintf("⚠️ Erro ao comparar com o snapshot: %v", err))
+		return nil, runErr
+	}
+	if m.rollback != nil {
+		// Operações que não entram no histórico (limpeza, exclusão de
+		// emitente) ganham um registro só com o relatório, sem desfazer.
+		id := m.rollback.LatestOperationID()
+		if id == "" || id == lastID {
+			id = m.rollback.RecordOperation(op, fmt.Sprintf("%s (relatório de alterações)", op), map[string]interface{}{}, false)
+		}
+		if m.rollback.AttachChanges(id, diff) {
+			log("📎 Relatório de alterações anexado ao histórico da operação")
+		}
+	}
+	return diff, runErr
+}
+
+// ExportSnapshotDiff grava o relatório em XLSX, no mesmo formato da
+// comparação de bancos: resumo por coleção e uma linha por campo alterado.
+func ExportSnapshotDiff(diff *SnapshotDiff, label, path string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", "Resumo"); err != nil {
+		return err
+	}
+	rows := [][]interface{}{
+		{"Operação", label},
+		{"Antes", diff.TakenAt.Format("2006-01-02 15:04:05")},
+		{"Depois", diff.ComparedAt.Format("2006-01-02 15:04:05")},
+		{},
+		{"Coleção", "Antes", "Depois", "Alterados", "Criados", "Removidos"},
+	}
+	for _, c := range diff.Collections {
+		rows = append(rows, []interface{}{c.Name, c.Before, c.After, c.Changed, c.Created, c.Removed})
+	}
+	if err := setSheetRows(f, "Resumo", rows); err != nil {
+		return err
+	}
+
+	if _, err := f.NewSheet("Alterações"); err != nil {
+		return err
+	}
+	rows = [][]interface{}{{"Coleção", "_id", "Tipo", "Campo", "Antes", "Depois"}}
+	for _, change := range diff.Changes {
+		if len(change.Fields) == 0 {
+			rows = append(rows, []interface{}{change.Collection, change.ID, change.Kind})
+			continue
+		}
+		for _, field := range change.Fields {
+			rows = append(rows, []interface{}{change.Collection, change.ID, change.Kind, field.Path, field.Before, field.After})
+		}
+	}
+	if err := setSheetRows(f, "Alterações", rows); err != nil {
+		return err
+	}
+
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("erro ao salvar planilha: %w", err)
+	}
+	return nil
+}
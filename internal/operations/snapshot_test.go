@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"BMongo-VIP/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSnapshotSpoolReadBack(t *testing.T) {
+	spool, err := os.CreateTemp(t.TempDir(), "snapshot_*.bson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := &Snapshot{spool: spool}
+
+	var entries []snapshotEntry
+	var offset int64
+	for _, doc := range []bson.M{{"_id": 1, "Ativo": true}, {"_id": 2, "Ativo": false}} {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := spool.Write(raw); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, snapshotEntry{offset: offset, size: len(raw)})
+		offset += int64(len(raw))
+	}
+
+	doc, err := snap.document(entries[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshotValues(doc); got["Ativo"] != "false" || len(got) != 1 {
+		t.Errorf("snapshotValues = %v, want só Ativo=false", got)
+	}
+
+	snap.Close()
+	if _, err := os.Stat(spool.Name()); !os.IsNotExist(err) {
+		t.Errorf("arquivo do snapshot não foi removido: %v", err)
+	}
+}
+
+func TestEmitenteSnapshotSpecs(t *testing.T) {
+	specs, err := EmitenteSnapshotSpecs("5f0000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		if seen[spec.Collection] {
+			t.Errorf("coleção %s repetida", spec.Collection)
+		}
+		seen[spec.Collection] = true
+		unfiltered := spec.Collection == database.CollectionEstoques || spec.Collection == database.CollectionUsuarios
+		if unfiltered != (spec.Filter == nil) {
+			t.Errorf("%s: filtro %v", spec.Collection, spec.Filter)
+		}
+	}
+	if _, err := EmitenteSnapshotSpecs("invalido"); err == nil {
+		t.Error("ID inválido aceito")
+	}
+}
+
+func TestDocumentSnapshotSpecsUsesTopLevelField(t *testing.T) {
+	specs := DocumentSnapshotSpecs(database.CollectionMovimentacoes, "abc", "Itens[0].Quantidade")
+	if len(specs) != 1 || len(specs[0].Fields) != 1 || specs[0].Fields[0] != "Itens" {
+		t.Errorf("specs = %+v", specs)
+	}
+}
+
+func TestGetReportedOperations(t *testing.T) {
+	rm := NewRollbackManagerWithRepositories(nil)
+	first := rm.RecordOperation(OpZeroStock, "Zerou estoques", map[string]interface{}{}, true)
+	rm.RecordOperation(OpBulkActivate, "Ativou produtos", map[string]interface{}{}, true)
+	report := rm.RecordOperation(OpCleanupByDate, "Limpeza (relatório de alterações)", map[string]interface{}{}, false)
+
+	if rm.AttachChanges("inexistente", &SnapshotDiff{}) {
+		t.Error("relatório anexado a operação inexistente")
+	}
+	rm.AttachChanges(first, &SnapshotDiff{})
+	rm.AttachChanges(report, &SnapshotDiff{})
+
+	var got []string
+	for _, op := range rm.GetReportedOperations() {
+		got = append(got, op.ID)
+	}
+	if want := []string{report, first}; !reflect.DeepEqual(got, want) {
+		t.Errorf("relatórios = %v, esperado %v (mais recente primeiro, inclusive não reversíveis)", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package operations
 
 import (
 	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/models"
 	"context"
 	"fmt"
 	"time"
@@ -14,51 +15,28 @@ func (m *Manager) ZeroAllStock(log LogFunc) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	log("🔄 Zerando TODO o estoque...")
+	if err := m.requireCompatibility(OpZeroStock, log); err != nil {
+		return 0, err
+	}
 
-	estoques := m.conn.GetCollection(database.CollectionEstoques)
+	log("🔄 Zerando TODO o estoque...")
 
 	var stocksBackup []map[string]interface{}
 	if m.rollback != nil {
 		log("📋 Capturando estoques anteriores para rollback...")
-		cursor, err := estoques.Find(ctx, bson.M{"Quantidades.0.Quantidade": bson.M{"$ne": 0}})
+		stocks, err := m.repos.Stock.FindStocks(ctx, bson.M{"Quantidades.0.Quantidade": bson.M{"$ne": 0}})
 		if err == nil {
-			defer cursor.Close(ctx)
-			for cursor.Next(ctx) {
-				var doc bson.M
-				if err := cursor.Decode(&doc); err != nil {
-					continue
-				}
-				id, _ := doc["_id"].(primitive.ObjectID)
-				qty := 0.0
-				if quantidades, ok := doc["Quantidades"].(primitive.A); ok && len(quantidades) > 0 {
-					if q0, ok := quantidades[0].(bson.M); ok {
-						qty, _ = q0["Quantidade"].(float64)
-					}
-				}
-				stocksBackup = append(stocksBackup, map[string]interface{}{
-					"id":           id.Hex(),
-					"prevQuantity": qty,
-				})
-			}
+			stocksBackup = stockBackup(stocks)
 		}
 		log(fmt.Sprintf("📋 Capturados %d estoques para backup", len(stocksBackup)))
 	}
 
-	result, err := estoques.UpdateMany(ctx,
-		bson.M{},
-		bson.M{
-			"$set": bson.M{
-				"Quantidades.0.Quantidade": 0.0,
-			},
-		},
-	)
-
+	modified, err := m.repos.Stock.SetQuantity(ctx, bson.M{}, 0.0)
 	if err != nil {
 		return 0, fmt.Errorf("erro ao zerar estoque: %w", err)
 	}
 
-	count := int(result.ModifiedCount)
+	count := int(modified)
 
 	if m.rollback != nil && len(stocksBackup) > 0 {
 		m.rollback.RecordOperation(
@@ -77,9 +55,11 @@ func (m *Manager) ZeroNegativeStock(log LogFunc) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	log("🔄 Zerando estoques NEGATIVOS...")
+	if err := m.requireCompatibility(OpZeroNegativeStock, log); err != nil {
+		return 0, err
+	}
 
-	estoques := m.conn.GetCollection(database.CollectionEstoques)
+	log("🔄 Zerando estoques NEGATIVOS...")
 
 	filter := bson.M{
 		"Quantidades.0.Quantidade": bson.M{"$lt": 0},
@@ -88,44 +68,19 @@ func (m *Manager) ZeroNegativeStock(log LogFunc) (int, error) {
 	var stocksBackup []map[string]interface{}
 	if m.rollback != nil {
 		log("📋 Capturando estoques negativos para rollback...")
-		cursor, err := estoques.Find(ctx, filter)
+		stocks, err := m.repos.Stock.FindStocks(ctx, filter)
 		if err == nil {
-			defer cursor.Close(ctx)
-			for cursor.Next(ctx) {
-				var doc bson.M
-				if err := cursor.Decode(&doc); err != nil {
-					continue
-				}
-				id, _ := doc["_id"].(primitive.ObjectID)
-				qty := 0.0
-				if quantidades, ok := doc["Quantidades"].(primitive.A); ok && len(quantidades) > 0 {
-					if q0, ok := quantidades[0].(bson.M); ok {
-						qty, _ = q0["Quantidade"].(float64)
-					}
-				}
-				stocksBackup = append(stocksBackup, map[string]interface{}{
-					"id":           id.Hex(),
-					"prevQuantity": qty,
-				})
-			}
+			stocksBackup = stockBackup(stocks)
 		}
 		log(fmt.Sprintf("📋 Capturados %d estoques negativos para backup", len(stocksBackup)))
 	}
 
-	result, err := estoques.UpdateMany(ctx,
-		filter,
-		bson.M{
-			"$set": bson.M{
-				"Quantidades.0.Quantidade": 0.0,
-			},
-		},
-	)
-
+	modified, err := m.repos.Stock.SetQuantity(ctx, filter, 0.0)
 	if err != nil {
 		return 0, fmt.Errorf("erro ao zerar estoque negativo: %w", err)
 	}
 
-	count := int(result.ModifiedCount)
+	count := int(modified)
 
 	if m.rollback != nil && len(stocksBackup) > 0 {
 		m.rollback.RecordOperation(
@@ -140,10 +95,27 @@ func (m *Manager) ZeroNegativeStock(log LogFunc) (int, error) {
 	return count, nil
 }
 
+// stockBackup monta os detalhes de rollback (id e quantidade anterior) usados
+// por undoZeroStock.
+func stockBackup(stocks []models.Estoque) []map[string]interface{} {
+	backup := make([]map[string]interface{}, 0, len(stocks))
+	for _, stock := range stocks {
+		backup = append(backup, map[string]interface{}{
+			"id":           stock.ID.Hex(),
+			"prevQuantity": stock.Quantidade(),
+		})
+	}
+	return backup
+}
+
 func (m *Manager) ZeroAllPrices(log LogFunc) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
+	if err := m.requireCompatibility(OpZeroAllPrices, log); err != nil {
+		return 0, err
+	}
+
 	log("🔄 Zerando TODOS os preços...")
 
 	produtosEmpresa := m.conn.GetCollection(database.CollectionProdutosServicosEmpresa)
@@ -0,0 +1,170 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Modos de comparação da busca por valor.
+const (
+	ValueSearchExact       = "exato"
+	ValueSearchInsensitive = "sem_acento"
+	ValueSearchRegex       = "regex"
+	ValueSearchNumber      = "numero"
+)
+
+const defaultValueSearchLimit = 1000
+
+type ValueSearchOptions struct {
+	Mode    string   `json:"mode"`
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+	Limit   int      `json:"limit"`
+	Workers int      `json:"workers"`
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+func normalizeText(s string) string {
+	return accentReplacer.Replace(strings.ToLower(s))
+}
+
+func rawNumber(v bson.RawValue) (float64, bool) {
+	switch v.Type {
+	case bsontype.Double:
+		return v.Double(), true
+	case bsontype.Int32:
+		return float64(v.Int32()), true
+	case bsontype.Int64:
+		return float64(v.Int64()), true
+	case bsontype.Decimal128:
+		f, err := strconv.ParseFloat(v.Decimal128().String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// valueMatcher monta a função de comparação e, quando possível, um prefiltro
+// sobre os bytes do documento para evitar percorrer documentos que não
+// podem conter o valor.
+func valueMatcher(value, mode string) (func(bson.RawValue) bool, func(bson.Raw) bool, error) {
+	switch mode {
+	case "", ValueSearchExact:
+		needle := []byte(value)
+		match := func(v bson.RawValue) bool {
+			s, ok := v.StringValueOK()
+			return ok && s == value
+		}
+		return match, func(doc bson.Raw) bool { return bytes.Contains(doc, needle) }, nil
+
+	case ValueSearchInsensitive:
+		needle := normalizeText(value)
+		match := func(v bson.RawValue) bool {
+			s, ok := v.StringValueOK()
+			return ok && strings.Contains(normalizeText(s), needle)
+		}
+		return match, nil, nil
+
+	case ValueSearchRegex:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("expressão regular inválida: %w", err)
+		}
+		match := func(v bson.RawValue) bool {
+			s, ok := v.StringValueOK()
+			return ok && re.MatchString(s)
+		}
+		return match, nil, nil
+
+	case ValueSearchNumber:
+		n, err := strconv.ParseFloat(models.NormalizeDecimal(value), 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("número inválido: %s", value)
+		}
+		match := func(v bson.RawValue) bool {
+			f, ok := rawNumber(v)
+			return ok && math.Abs(f-n) < 1e-9
+		}
+		return match, nil, nil
+	}
+	return nil, nil, fmt.Errorf("modo de busca inválido: %s", mode)
+}
+
+func filterCollections(collections, include, exclude []string) []string {
+	includeSet := make(map[string]bool, len(include))
+	for _, c := range include {
+		includeSet[c] = true
+	}
+	excludeSet := make(map[string]bool, len(exclude))
+	for _, c := range exclude {
+		excludeSet[c] = true
+	}
+
+	filtered := make([]string, 0, len(collections))
+	for _, c := range collections {
+		if len(includeSet) > 0 && !includeSet[c] {
+			continue
+		}
+		if excludeSet[c] {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
+
+// SearchValue procura um valor qualquer (CNPJ, chave de acesso, código de
+// barras, nome...) em todos os campos de todas as coleções selecionadas,
+// informando o caminho completo do campo e o _id de cada ocorrência.
+func (m *Manager) SearchValue(value string, opts ValueSearchOptions, onMatch func(SearchMatch), log LogFunc) ([]SearchMatch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("informe um valor para a busca")
+	}
+
+	match, prefilter, err := valueMatcher(value, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := m.conn.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar coleções: %w", err)
+	}
+	sort.Strings(collections)
+	collections = filterCollections(collections, opts.Include, opts.Exclude)
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultValueSearchLimit
+	}
+
+	log(fmt.Sprintf("🔍 Buscando \"%s\" (%s) em %d coleções...", value, opts.Mode, len(collections)))
+
+	return m.searchCollections(ctx, collections, opts.Workers, limit, func(colName string) (*mongo.Cursor, error) {
+		return m.conn.GetCollection(colName).Find(ctx, bson.M{}, options.Find().SetBatchSize(1000))
+	}, prefilter, match, onMatch, log)
+}
@@ -0,0 +1,94 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func rawOf(t *testing.T, v interface{}) bson.RawValue {
+	t.Helper()
+	doc, err := bson.Marshal(bson.M{"v": v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bson.Raw(doc).Lookup("v")
+}
+
+func TestValueMatcher(t *testing.T) {
+	price, _ := primitive.ParseDecimal128("1234.56")
+
+	tests := []struct {
+		name  string
+		mode  string
+		value string
+		field interface{}
+		want  bool
+	}{
+		{"exato", ValueSearchExact, "12345678000199", "12345678000199", true},
+		{"exato é parcial não", ValueSearchExact, "1234", "12345678000199", false},
+		{"modo vazio é exato", "", "ABC", "ABC", true},
+		{"sem acento", ValueSearchInsensitive, "joao conceicao", "JOÃO CONCEIÇÃO LTDA", true},
+		{"sem acento em número", ValueSearchInsensitive, "12", int32(12), false},
+		{"regex", ValueSearchRegex, `^789\d{10}$`, "7891234567890", true},
+		{"regex sem casar", ValueSearchRegex, `^789\d{10}$`, "1234", false},
+		{"número com vírgula", ValueSearchNumber, "10,5", 10.5, true},
+		{"número brasileiro com milhar", ValueSearchNumber, "1.234,56", price, true},
+		{"número americano com milhar", ValueSearchNumber, "1,234.56", 1234.56, true},
+		{"número inteiro", ValueSearchNumber, "7", int64(7), true},
+		{"número não casa texto", ValueSearchNumber, "7", "7", false},
+	}
+	for _, tt := range tests {
+		match, _, err := valueMatcher(tt.value, tt.mode)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := match(rawOf(t, tt.field)); got != tt.want {
+			t.Errorf("%s: match(%v) = %v, esperado %v", tt.name, tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestValueMatcherErrors(t *testing.T) {
+	tests := []struct{ value, mode string }{
+		{"(", ValueSearchRegex},
+		{"abc", ValueSearchNumber},
+		{"x", "aproximado"},
+	}
+	for _, tt := range tests {
+		if _, _, err := valueMatcher(tt.value, tt.mode); err == nil {
+			t.Errorf("valueMatcher(%q, %q) aceito", tt.value, tt.mode)
+		}
+	}
+}
+
+func TestValueMatcherExactPrefilter(t *testing.T) {
+	_, prefilter, _ := valueMatcher("35200112345678000199550010000000011000000010", ValueSearchExact)
+	with, _ := bson.Marshal(bson.M{"Chave": "35200112345678000199550010000000011000000010"})
+	without, _ := bson.Marshal(bson.M{"Chave": "outra"})
+	if !prefilter(with) || prefilter(without) {
+		t.Error("prefiltro do modo exato incorreto")
+	}
+}
+
+func TestFilterCollections(t *testing.T) {
+	all := []string{"Estoques", "Movimentacoes", "Pessoas", "ProdutosServicos"}
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"todas", nil, nil, all},
+		{"incluir", []string{"Pessoas", "Inexistente"}, nil, []string{"Pessoas"}},
+		{"excluir", nil, []string{"Movimentacoes"}, []string{"Estoques", "Pessoas", "ProdutosServicos"}},
+		{"excluir vence incluir", []string{"Pessoas", "Estoques"}, []string{"Pessoas"}, []string{"Estoques"}},
+	}
+	for _, tt := range tests {
+		if got := filterCollections(all, tt.include, tt.exclude); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,606 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Memory guarda coleções em memória e interpreta o subconjunto de filtros e
+// atualizações usado pelas operações ($or/$and/$nor, comparações, $in/$nin,
+// $exists, $regex, $set/$unset). Operadores fora desse subconjunto devolvem
+// erro em vez de serem ignorados, para que um teste nunca passe por acaso.
+type Memory struct {
+	mu          sync.Mutex
+	collections map[string][]bson.M
+}
+
+func NewMemory() *Memory {
+	return &Memory{collections: make(map[string][]bson.M)}
+}
+
+// Repositories devolve os repositórios de domínio sobre esta memória.
+func (m *Memory) Repositories() *Repositories {
+	return newRepositories(memoryStore{m})
+}
+
+// Insert grava documentos (structs, bson.M ou bson.D) na coleção. Documentos
+// sem _id recebem um ObjectID novo.
+func (m *Memory) Insert(collection string, docs ...interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range docs {
+		doc, err := normalize(d)
+		if err != nil {
+			return fmt.Errorf("erro ao preparar documento de %s: %w", collection, err)
+		}
+		if _, ok := doc["_id"]; !ok {
+			doc["_id"] = primitive.NewObjectID()
+		}
+		m.collections[collection] = append(m.collections[collection], doc)
+	}
+	return nil
+}
+
+// Documents devolve uma cópia dos documentos da coleção, na ordem de inserção.
+func (m *Memory) Documents(collection string) []bson.M {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]bson.M, 0, len(m.collections[collection]))
+	for _, doc := range m.collections[collection] {
+		c, _ := normalize(doc)
+		out = append(out, c)
+	}
+	return out
+}
+
+// normalize converte qualquer documento para a forma que o driver devolve ao
+// decodificar em bson.M (subdocumentos bson.M, arrays primitive.A), o que
+// também produz uma cópia independente.
+func normalize(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+type memoryStore struct{ m *Memory }
+
+func (s memoryStore) find(_ context.Context, collection string, filter bson.M, opts findOptions) ([]bson.Raw, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	matched, err := s.m.match(collection, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.sort) > 0 {
+		sortDocuments(matched, opts.sort)
+	}
+	if opts.limit > 0 && int64(len(matched)) > opts.limit {
+		matched = matched[:opts.limit]
+	}
+
+	out := make([]bson.Raw, 0, len(matched))
+	for _, doc := range matched {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+func (s memoryStore) updateMany(_ context.Context, collection string, filter, update bson.M) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	matched, err := s.m.match(collection, filter)
+	if err != nil {
+		return 0, err
+	}
+	upd, err := normalize(update)
+	if err != nil {
+		return 0, err
+	}
+
+	var modified int64
+	for _, doc := range matched {
+		changed, err := applyUpdate(doc, upd)
+		if err != nil {
+			return modified, err
+		}
+		if changed {
+			modified++
+		}
+	}
+	return modified, nil
+}
+
+func (s memoryStore) count(_ context.Context, collection string, filter bson.M, limit int64) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	matched, err := s.m.match(collection, filter)
+	if err != nil {
+		return 0, err
+	}
+	n := int64(len(matched))
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	return n, nil
+}
+
+// match devolve os próprios documentos armazenados (não cópias); quem chama
+// precisa estar com o lock.
+func (m *Memory) match(collection string, filter bson.M) ([]bson.M, error) {
+	f, err := normalize(filter)
+	if err != nil {
+		return nil, fmt.Errorf("filtro inválido: %w", err)
+	}
+	var out []bson.M
+	for _, doc := range m.collections[collection] {
+		ok, err := matchDocument(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, doc)
+		}
+	}
+	return out, nil
+}
+
+func matchDocument(doc, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		var ok bool
+		var err error
+		switch key {
+		case "$or", "$and", "$nor":
+			ok, err = matchLogical(doc, key, cond)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("operador não suportado em memória: %s", key)
+			}
+			ok, err = matchField(doc, key, cond)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.M, op string, cond interface{}) (bool, error) {
+	clauses, ok := cond.(primitive.A)
+	if !ok {
+		return false, fmt.Errorf("%s espera um array", op)
+	}
+	for _, c := range clauses {
+		sub, ok := c.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("%s espera documentos", op)
+		}
+		matched, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+func matchField(doc bson.M, path string, cond interface{}) (bool, error) {
+	values := resolvePath(doc, strings.Split(path, "."))
+
+	ops, isOps := cond.(bson.M)
+	if isOps && !hasOperators(ops) {
+		isOps = false
+	}
+	if !isOps {
+		// {campo: null} também casa com campo ausente.
+		if cond == nil && len(values) == 0 {
+			return true, nil
+		}
+		return anyValue(values, func(v interface{}) bool { return equalsCondition(v, cond) }), nil
+	}
+
+	for op, arg := range ops {
+		var ok bool
+		switch op {
+		case "$eq":
+			ok = anyValue(values, func(v interface{}) bool { return equalsCondition(v, arg) })
+		case "$ne":
+			ok = !anyValue(values, func(v interface{}) bool { return equalsCondition(v, arg) })
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = anyValue(values, func(v interface{}) bool {
+				c, comparable := compareValues(v, arg)
+				if !comparable {
+					return false
+				}
+				switch op {
+				case "$gt":
+					return c > 0
+				case "$gte":
+					return c >= 0
+				case "$lt":
+					return c < 0
+				}
+				return c <= 0
+			})
+		case "$in", "$nin":
+			list, isList := arg.(primitive.A)
+			if !isList {
+				return false, fmt.Errorf("%s espera um array", op)
+			}
+			in := anyValue(values, func(v interface{}) bool {
+				for _, candidate := range list {
+					if equalsCondition(v, candidate) {
+						return true
+					}
+				}
+				return false
+			})
+			ok = in == (op == "$in")
+		case "$exists":
+			ok = (len(values) > 0) == truthy(arg)
+		case "$regex":
+			re, err := compileRegex(arg, ops["$options"])
+			if err != nil {
+				return false, err
+			}
+			ok = anyValue(values, func(v interface{}) bool {
+				s, isString := v.(string)
+				return isString && re.MatchString(s)
+			})
+		case "$options":
+			continue
+		default:
+			return false, fmt.Errorf("operador não suportado em memória: %s", op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func hasOperators(m bson.M) bool {
+	for k := range m {
+		if strings.HasPrefix(k, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath segue um caminho com notação de ponto. Como no MongoDB, uma
+// parte numérica indexa arrays e uma parte com nome é aplicada a cada
+// subdocumento do array.
+func resolvePath(v interface{}, parts []string) []interface{} {
+	if len(parts) == 0 {
+		return []interface{}{v}
+	}
+	switch cur := v.(type) {
+	case bson.M:
+		next, ok := cur[parts[0]]
+		if !ok {
+			return nil
+		}
+		return resolvePath(next, parts[1:])
+	case primitive.A:
+		var out []interface{}
+		if idx, err := strconv.Atoi(parts[0]); err == nil {
+			if idx >= 0 && idx < len(cur) {
+				out = append(out, resolvePath(cur[idx], parts[1:])...)
+			}
+			return out
+		}
+		for _, item := range cur {
+			if _, isDoc := item.(bson.M); isDoc {
+				out = append(out, resolvePath(item, parts)...)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// anyValue testa cada valor e, quando o valor é um array, também cada
+// elemento dele.
+func anyValue(values []interface{}, fn func(interface{}) bool) bool {
+	for _, v := range values {
+		if fn(v) {
+			return true
+		}
+		if arr, ok := v.(primitive.A); ok {
+			for _, item := range arr {
+				if fn(item) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func equalsCondition(v, cond interface{}) bool {
+	if re, ok := cond.(primitive.Regex); ok {
+		s, isString := v.(string)
+		if !isString {
+			return false
+		}
+		compiled, err := compileRegex(re.Pattern, re.Options)
+		return err == nil && compiled.MatchString(s)
+	}
+	if c, comparable := compareValues(v, cond); comparable {
+		return c == 0
+	}
+	return reflect.DeepEqual(v, cond)
+}
+
+func compileRegex(pattern, options interface{}) (*regexp.Regexp, error) {
+	var p, o string
+	switch val := pattern.(type) {
+	case string:
+		p = val
+	case primitive.Regex:
+		p, o = val.Pattern, val.Options
+	default:
+		return nil, fmt.Errorf("$regex inválido: %v", pattern)
+	}
+	if s, ok := options.(string); ok {
+		o += s
+	}
+	flags := ""
+	for _, f := range o {
+		if f == 'i' || f == 'm' || f == 's' {
+			flags += string(f)
+		}
+	}
+	if flags != "" {
+		p = "(?" + flags + ")" + p
+	}
+	return regexp.Compile(p)
+}
+
+// compareValues compara valores escalares do mesmo tipo BSON; números de
+// tipos diferentes (Int32, Int64, Double) são comparados entre si.
+func compareValues(a, b interface{}) (int, bool) {
+	if fa, ok := number(a); ok {
+		fb, ok := number(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	switch va := a.(type) {
+	case string:
+		vb, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(va, vb), true
+	case bool:
+		vb, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		if va == vb {
+			return 0, true
+		}
+		if !va {
+			return -1, true
+		}
+		return 1, true
+	case primitive.ObjectID:
+		vb, ok := b.(primitive.ObjectID)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(va.Hex(), vb.Hex()), true
+	case primitive.DateTime:
+		vb, ok := b.(primitive.DateTime)
+		if !ok {
+			return 0, false
+		}
+		return compareInt64(int64(va), int64(vb)), true
+	case nil:
+		return 0, b == nil
+	}
+	return 0, false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(n.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	if n, ok := number(v); ok {
+		return n != 0
+	}
+	return v != nil
+}
+
+func sortDocuments(docs []bson.M, keys bson.D) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range keys {
+			dir := 1
+			if n, ok := number(key.Value); ok && n < 0 {
+				dir = -1
+			}
+			a := firstValue(docs[i], key.Key)
+			b := firstValue(docs[j], key.Key)
+			c, comparable := compareValues(a, b)
+			if !comparable {
+				// Ausente ordena antes de presente, como null no MongoDB.
+				switch {
+				case a == nil && b != nil:
+					c = -1
+				case a != nil && b == nil:
+					c = 1
+				default:
+					continue
+				}
+			}
+			if c != 0 {
+				return c*dir < 0
+			}
+		}
+		return false
+	})
+}
+
+func firstValue(doc bson.M, path string) interface{} {
+	values := resolvePath(doc, strings.Split(path, "."))
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+func applyUpdate(doc, update bson.M) (bool, error) {
+	changed := false
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("%s espera um documento", op)
+		}
+		for path, value := range fields {
+			parts := strings.Split(path, ".")
+			var c bool
+			var err error
+			switch op {
+			case "$set":
+				_, c, err = setPath(doc, parts, value)
+			case "$unset":
+				c = unsetPath(doc, parts)
+			default:
+				return false, fmt.Errorf("atualização não suportada em memória: %s", op)
+			}
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	}
+	return changed, nil
+}
+
+// setPath devolve o valor atualizado porque arrays podem crescer: como no
+// MongoDB, um índice além do fim completa o array com null até a posição.
+func setPath(v interface{}, parts []string, value interface{}) (interface{}, bool, error) {
+	if len(parts) == 0 {
+		return value, !sameValue(v, value), nil
+	}
+	switch cur := v.(type) {
+	case bson.M:
+		next, exists := cur[parts[0]]
+		if next == nil && len(parts) > 1 {
+			next = bson.M{}
+		}
+		updated, changed, err := setPath(next, parts[1:], value)
+		if err != nil {
+			return v, false, err
+		}
+		cur[parts[0]] = updated
+		return cur, changed || !exists, nil
+	case primitive.A:
+		idx, err := strconv.Atoi(parts[0])
+		if err != nil || idx < 0 {
+			return v, false, fmt.Errorf("índice de array inválido: %s", parts[0])
+		}
+		grew := false
+		for len(cur) <= idx {
+			cur = append(cur, nil)
+			grew = true
+		}
+		next := cur[idx]
+		if next == nil && len(parts) > 1 {
+			next = bson.M{}
+		}
+		updated, changed, err := setPath(next, parts[1:], value)
+		if err != nil {
+			return v, false, err
+		}
+		cur[idx] = updated
+		return cur, changed || grew, nil
+	}
+	return v, false, fmt.Errorf("não é possível definir %s em %T", strings.Join(parts, "."), v)
+}
+
+func unsetPath(doc bson.M, parts []string) bool {
+	if len(parts) == 1 {
+		_, exists := doc[parts[0]]
+		delete(doc, parts[0])
+		return exists
+	}
+	next, ok := doc[parts[0]].(bson.M)
+	if !ok {
+		return false
+	}
+	return unsetPath(next, parts[1:])
+}
+
+// sameValue exige o mesmo tipo: como no MongoDB, trocar 0 (Int32) por 0.0
+// (Double) conta como alteração porque o tipo gravado muda.
+func sameValue(a, b interface{}) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestMemory(t *testing.T) *Memory {
+	t.Helper()
+	mem := NewMemory()
+	err := mem.Insert("Produtos",
+		bson.M{"_id": "a", "Nome": "Arroz", "Ativo": true, "Preco": int32(10), "Tags": bson.A{"grao", "cesta"},
+			"Itens": bson.A{bson.M{"Qtd": 1.0}, bson.M{"Qtd": 5.0}}},
+		bson.M{"_id": "b", "Nome": "Feijão", "Ativo": false, "Preco": 7.5, "Tags": bson.A{"grao"},
+			"Itens": bson.A{bson.M{"Qtd": 2.0}}},
+		bson.M{"_id": "c", "Nome": "arroz integral", "Ativo": true, "Preco": int64(12), "Observacao": nil},
+		bson.M{"_id": "d", "Nome": "Sal"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mem
+}
+
+func findIDs(t *testing.T, mem *Memory, filter bson.M, sort bson.D) ([]string, error) {
+	t.Helper()
+	raws, err := memoryStore{mem}.find(context.Background(), "Produtos", filter, findOptions{sort: sort})
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, raw := range raws {
+		ids = append(ids, raw.Lookup("_id").StringValue())
+	}
+	return ids, nil
+}
+
+func TestMemoryMatch(t *testing.T) {
+	mem := newTestMemory(t)
+	tests := []struct {
+		name   string
+		filter bson.M
+		want   []string
+	}{
+		{"vazio", bson.M{}, []string{"a", "b", "c", "d"}},
+		{"igualdade", bson.M{"Ativo": true}, []string{"a", "c"}},
+		{"$eq", bson.M{"Nome": bson.M{"$eq": "Sal"}}, []string{"d"}},
+		{"$ne inclui ausente", bson.M{"Ativo": bson.M{"$ne": true}}, []string{"b", "d"}},
+		{"$gt entre tipos numéricos", bson.M{"Preco": bson.M{"$gt": 9}}, []string{"a", "c"}},
+		{"$gte", bson.M{"Preco": bson.M{"$gte": int64(10)}}, []string{"a", "c"}},
+		{"$lt", bson.M{"Preco": bson.M{"$lt": 10}}, []string{"b"}},
+		{"$lte", bson.M{"Preco": bson.M{"$lte": 10.0}}, []string{"a", "b"}},
+		{"$in", bson.M{"_id": bson.M{"$in": bson.A{"a", "d", "z"}}}, []string{"a", "d"}},
+		{"$nin", bson.M{"_id": bson.M{"$nin": bson.A{"a", "d"}}}, []string{"b", "c"}},
+		{"$exists true", bson.M{"Preco": bson.M{"$exists": true}}, []string{"a", "b", "c"}},
+		{"$exists false", bson.M{"Preco": bson.M{"$exists": false}}, []string{"d"}},
+		{"$exists com campo null", bson.M{"Observacao": bson.M{"$exists": true}}, []string{"c"}},
+		{"null casa ausente e null", bson.M{"Observacao": nil}, []string{"a", "b", "c", "d"}},
+		{"$regex", bson.M{"Nome": bson.M{"$regex": "^arroz"}}, []string{"c"}},
+		{"$regex com $options", bson.M{"Nome": bson.M{"$regex": "^arroz", "$options": "i"}}, []string{"a", "c"}},
+		{"regex primitivo", bson.M{"Nome": primitive.Regex{Pattern: "^s", Options: "i"}}, []string{"d"}},
+		{"elemento de array", bson.M{"Tags": "cesta"}, []string{"a"}},
+		{"subdocumento em array", bson.M{"Itens.Qtd": bson.M{"$gt": 4}}, []string{"a"}},
+		{"índice numérico", bson.M{"Itens.0.Qtd": 2.0}, []string{"b"}},
+		{"índice fora do array", bson.M{"Itens.1.Qtd": bson.M{"$exists": true}}, []string{"a"}},
+		{"$or", bson.M{"$or": bson.A{bson.M{"_id": "a"}, bson.M{"Nome": "Sal"}}}, []string{"a", "d"}},
+		{"$and", bson.M{"$and": bson.A{bson.M{"Ativo": true}, bson.M{"Preco": bson.M{"$lt": 11}}}}, []string{"a"}},
+		{"$nor", bson.M{"$nor": bson.A{bson.M{"Ativo": true}, bson.M{"_id": "d"}}}, []string{"b"}},
+		{"tipos diferentes não casam", bson.M{"Preco": "10"}, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := findIDs(t, mem, tt.filter, nil)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryUnsupportedOperators(t *testing.T) {
+	mem := newTestMemory(t)
+	filters := []bson.M{
+		{"$where": "true"},
+		{"Nome": bson.M{"$elemMatch": bson.M{"$eq": "x"}}},
+		{"$or": bson.M{"_id": "a"}},
+		{"_id": bson.M{"$in": "a"}},
+	}
+	for _, f := range filters {
+		if _, err := findIDs(t, mem, f, nil); err == nil {
+			t.Errorf("filtro %v aceito", f)
+		}
+	}
+
+	_, err := memoryStore{mem}.updateMany(context.Background(), "Produtos", bson.M{}, bson.M{"$inc": bson.M{"Preco": 1}})
+	if err == nil {
+		t.Error("$inc aceito")
+	}
+}
+
+func TestMemorySort(t *testing.T) {
+	mem := newTestMemory(t)
+	got, err := findIDs(t, mem, bson.M{}, bson.D{{Key: "Preco", Value: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"d", "b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("crescente = %v, esperado %v", got, want)
+	}
+	got, _ = findIDs(t, mem, bson.M{}, bson.D{{Key: "Preco", Value: -1}})
+	if want := []string{"c", "a", "b", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decrescente = %v, esperado %v", got, want)
+	}
+}
+
+func TestMemoryUpdate(t *testing.T) {
+	mem := newTestMemory(t)
+	store := memoryStore{mem}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		filter   bson.M
+		update   bson.M
+		modified int64
+	}{
+		{"mesmo valor não altera", bson.M{"_id": "a"}, bson.M{"$set": bson.M{"Preco": int32(10)}}, 0},
+		{"troca de tipo altera", bson.M{"_id": "a"}, bson.M{"$set": bson.M{"Preco": 10.0}}, 1},
+		{"caminho com ponto cria subdocumento", bson.M{"_id": "d"}, bson.M{"$set": bson.M{"Endereco.Cidade": "Curitiba"}}, 1},
+		{"array cresce com null", bson.M{"_id": "b"}, bson.M{"$set": bson.M{"Itens.2.Qtd": 3.0}}, 1},
+		{"$unset", bson.M{"Ativo": false}, bson.M{"$unset": bson.M{"Ativo": ""}}, 1},
+		{"$unset de campo ausente", bson.M{"_id": "d"}, bson.M{"$unset": bson.M{"Ativo": ""}}, 0},
+	}
+	for _, tt := range tests {
+		n, err := store.updateMany(ctx, "Produtos", tt.filter, tt.update)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if n != tt.modified {
+			t.Errorf("%s: %d alterados, esperado %d", tt.name, n, tt.modified)
+		}
+	}
+
+	docs := map[string]bson.M{}
+	for _, d := range mem.Documents("Produtos") {
+		docs[d["_id"].(string)] = d
+	}
+	if p, ok := docs["a"]["Preco"].(float64); !ok || p != 10 {
+		t.Errorf("Preco = %#v, esperado 10.0", docs["a"]["Preco"])
+	}
+	if want := (bson.M{"Cidade": "Curitiba"}); !reflect.DeepEqual(docs["d"]["Endereco"], want) {
+		t.Errorf("Endereco = %#v", docs["d"]["Endereco"])
+	}
+	wantItens := primitive.A{bson.M{"Qtd": 2.0}, nil, bson.M{"Qtd": 3.0}}
+	if !reflect.DeepEqual(docs["b"]["Itens"], wantItens) {
+		t.Errorf("Itens = %#v, esperado %#v", docs["b"]["Itens"], wantItens)
+	}
+	if _, ok := docs["b"]["Ativo"]; ok {
+		t.Error("Ativo continua presente após $unset")
+	}
+
+	if _, err := store.updateMany(ctx, "Produtos", bson.M{"_id": "a"}, bson.M{"$set": bson.M{"Nome.Parte": 1}}); err == nil {
+		t.Error("$set dentro de texto aceito")
+	}
+}
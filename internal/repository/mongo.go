@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewMongo cria os repositórios sobre o banco do Digisat.
+func NewMongo(db *mongo.Database) *Repositories {
+	return newRepositories(mongoStore{db})
+}
+
+type mongoStore struct {
+	db *mongo.Database
+}
+
+func (s mongoStore) find(ctx context.Context, collection string, filter bson.M, opts findOptions) ([]bson.Raw, error) {
+	findOpts := options.Find()
+	if len(opts.sort) > 0 {
+		findOpts.SetSort(opts.sort)
+	}
+	if opts.limit > 0 {
+		findOpts.SetLimit(opts.limit)
+	}
+
+	cursor, err := s.db.Collection(collection).Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.Raw
+	for cursor.Next(ctx) {
+		// cursor.Current é reutilizado entre lotes; guarda uma cópia.
+		docs = append(docs, append(bson.Raw(nil), cursor.Current...))
+	}
+	return docs, cursor.Err()
+}
+
+func (s mongoStore) updateMany(ctx context.Context, collection string, filter, update bson.M) (int64, error) {
+	result, err := s.db.Collection(collection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s mongoStore) count(ctx context.Context, collection string, filter bson.M, limit int64) (int64, error) {
+	opts := options.Count()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	return s.db.Collection(collection).CountDocuments(ctx, filter, opts)
+}
@@ -0,0 +1,191 @@
+// Package repository isola o acesso às coleções do Digisat atrás de
+// interfaces pequenas por domínio. A implementação Mongo é a usada pela
+// aplicação; a implementação em memória permite testar as operações sem um
+// servidor MongoDB.
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ProductRepository interface {
+	FindProducts(ctx context.Context, filter bson.M, limit int64) ([]models.Produto, error)
+	FindProductsEmpresa(ctx context.Context, filter bson.M, limit int64) ([]models.ProdutoEmpresa, error)
+	FindPrices(ctx context.Context, ids []primitive.ObjectID) ([]models.Preco, error)
+	// SetActive altera o campo Ativo em ProdutosServicos e devolve quantos
+	// documentos foram de fato modificados.
+	SetActive(ctx context.Context, ids []primitive.ObjectID, active bool) (int64, error)
+}
+
+type StockRepository interface {
+	FindStocks(ctx context.Context, filter bson.M) ([]models.Estoque, error)
+	FindStockQuantities(ctx context.Context, ids []primitive.ObjectID) ([]models.Estoque, error)
+	// SetQuantity grava a quantidade principal (Quantidades.0.Quantidade) dos
+	// estoques que atendem ao filtro.
+	SetQuantity(ctx context.Context, filter bson.M, quantity float64) (int64, error)
+}
+
+type PersonRepository interface {
+	FindPeople(ctx context.Context, filter bson.M, limit int64) ([]models.Pessoa, error)
+}
+
+type MovementRepository interface {
+	FindMovements(ctx context.Context, filter bson.M, sort bson.D, limit int64) ([]models.Movimentacao, error)
+	GetMovement(ctx context.Context, id primitive.ObjectID) (*models.Movimentacao, error)
+}
+
+type TributationRepository interface {
+	// collection é uma das coleções de tributação (estadual, federal, IBS/CBS).
+	FindTributations(ctx context.Context, collection string, filter bson.M) ([]models.Tributacao, error)
+}
+
+// MetadataRepository expõe leituras genéricas usadas na detecção de versão e
+// na conferência da estrutura da base.
+type MetadataRepository interface {
+	LatestDocuments(ctx context.Context, collection string, limit int64) ([]bson.Raw, error)
+	// HasField informa se a coleção tem documentos e se algum deles tem o caminho.
+	HasField(ctx context.Context, collection, path string) (hasDocuments bool, found bool, err error)
+}
+
+type Repositories struct {
+	Products     ProductRepository
+	Stock        StockRepository
+	People       PersonRepository
+	Movements    MovementRepository
+	Tributations TributationRepository
+	Metadata     MetadataRepository
+}
+
+// ErrNotFound é devolvido quando um documento buscado por _id não existe.
+var ErrNotFound = errors.New("documento não encontrado")
+
+type findOptions struct {
+	sort  bson.D
+	limit int64
+}
+
+// store é o acesso de baixo nível por nome de coleção; os repositórios de
+// domínio são escritos uma vez sobre ele e servem ao Mongo e à memória.
+type store interface {
+	find(ctx context.Context, collection string, filter bson.M, opts findOptions) ([]bson.Raw, error)
+	updateMany(ctx context.Context, collection string, filter, update bson.M) (int64, error)
+	count(ctx context.Context, collection string, filter bson.M, limit int64) (int64, error)
+}
+
+func newRepositories(s store) *Repositories {
+	return &Repositories{
+		Products:     productRepository{s},
+		Stock:        stockRepository{s},
+		People:       personRepository{s},
+		Movements:    movementRepository{s},
+		Tributations: tributationRepository{s},
+		Metadata:     metadataRepository{s},
+	}
+}
+
+func decodeAll[T any](raws []bson.Raw) ([]T, error) {
+	out := make([]T, 0, len(raws))
+	for _, raw := range raws {
+		var v T
+		if err := bson.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func findAll[T any](ctx context.Context, s store, collection string, filter bson.M, opts findOptions) ([]T, error) {
+	raws, err := s.find(ctx, collection, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll[T](raws)
+}
+
+func idFilter(ids []primitive.ObjectID) bson.M {
+	return bson.M{"_id": bson.M{"$in": ids}}
+}
+
+type productRepository struct{ s store }
+
+func (r productRepository) FindProducts(ctx context.Context, filter bson.M, limit int64) ([]models.Produto, error) {
+	return findAll[models.Produto](ctx, r.s, database.CollectionProdutosServicos, filter, findOptions{limit: limit})
+}
+
+func (r productRepository) FindProductsEmpresa(ctx context.Context, filter bson.M, limit int64) ([]models.ProdutoEmpresa, error) {
+	return findAll[models.ProdutoEmpresa](ctx, r.s, database.CollectionProdutosServicosEmpresa, filter, findOptions{limit: limit})
+}
+
+func (r productRepository) FindPrices(ctx context.Context, ids []primitive.ObjectID) ([]models.Preco, error) {
+	return findAll[models.Preco](ctx, r.s, database.CollectionPrecos, idFilter(ids), findOptions{})
+}
+
+func (r productRepository) SetActive(ctx context.Context, ids []primitive.ObjectID, active bool) (int64, error) {
+	return r.s.updateMany(ctx, database.CollectionProdutosServicos, idFilter(ids), bson.M{"$set": bson.M{"Ativo": active}})
+}
+
+type stockRepository struct{ s store }
+
+func (r stockRepository) FindStocks(ctx context.Context, filter bson.M) ([]models.Estoque, error) {
+	return findAll[models.Estoque](ctx, r.s, database.CollectionEstoques, filter, findOptions{})
+}
+
+func (r stockRepository) FindStockQuantities(ctx context.Context, ids []primitive.ObjectID) ([]models.Estoque, error) {
+	return findAll[models.Estoque](ctx, r.s, database.CollectionEstoquesQuantidade, idFilter(ids), findOptions{})
+}
+
+func (r stockRepository) SetQuantity(ctx context.Context, filter bson.M, quantity float64) (int64, error) {
+	return r.s.updateMany(ctx, database.CollectionEstoques, filter, bson.M{"$set": bson.M{"Quantidades.0.Quantidade": quantity}})
+}
+
+type personRepository struct{ s store }
+
+func (r personRepository) FindPeople(ctx context.Context, filter bson.M, limit int64) ([]models.Pessoa, error) {
+	return findAll[models.Pessoa](ctx, r.s, database.CollectionPessoas, filter, findOptions{limit: limit})
+}
+
+type movementRepository struct{ s store }
+
+func (r movementRepository) FindMovements(ctx context.Context, filter bson.M, sort bson.D, limit int64) ([]models.Movimentacao, error) {
+	return findAll[models.Movimentacao](ctx, r.s, database.CollectionMovimentacoes, filter, findOptions{sort: sort, limit: limit})
+}
+
+func (r movementRepository) GetMovement(ctx context.Context, id primitive.ObjectID) (*models.Movimentacao, error) {
+	movs, err := findAll[models.Movimentacao](ctx, r.s, database.CollectionMovimentacoes, bson.M{"_id": id}, findOptions{limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(movs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &movs[0], nil
+}
+
+type tributationRepository struct{ s store }
+
+func (r tributationRepository) FindTributations(ctx context.Context, collection string, filter bson.M) ([]models.Tributacao, error) {
+	return findAll[models.Tributacao](ctx, r.s, collection, filter, findOptions{})
+}
+
+type metadataRepository struct{ s store }
+
+func (r metadataRepository) LatestDocuments(ctx context.Context, collection string, limit int64) ([]bson.Raw, error) {
+	return r.s.find(ctx, collection, bson.M{}, findOptions{sort: bson.D{{Key: "_id", Value: -1}}, limit: limit})
+}
+
+func (r metadataRepository) HasField(ctx context.Context, collection, path string) (bool, bool, error) {
+	total, err := r.s.count(ctx, collection, bson.M{}, 1)
+	if err != nil || total == 0 {
+		return false, false, err
+	}
+	found, err := r.s.count(ctx, collection, bson.M{path: bson.M{"$exists": true}}, 1)
+	return true, found > 0, err
+}
@@ -0,0 +1,150 @@
+package seed
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Listas fixas usadas pelo gerador. A ordem importa: o mesmo seed sempre
+// escolhe os mesmos itens.
+
+type category struct {
+	ncm        string
+	ncmDesc    string
+	genero     int
+	generoDesc string
+	items      []string
+	sizes      []string
+	brands     []string
+	pesavel    bool
+	minCost    float64
+	maxCost    float64
+}
+
+var productCategories = []category{
+	{"10063021", "Arroz semibranqueado ou branqueado, polido ou brunido", 10, "Cereais",
+		[]string{"ARROZ TIPO 1", "ARROZ PARBOILIZADO", "ARROZ INTEGRAL"}, []string{"1KG", "5KG"},
+		[]string{"TIO JOAO", "CAMIL", "PRATO FINO"}, false, 4, 28},
+	{"07133399", "Feijão comum, seco, em grãos", 7, "Produtos hortícolas, plantas, raízes e tubérculos",
+		[]string{"FEIJAO CARIOCA", "FEIJAO PRETO"}, []string{"1KG"},
+		[]string{"CAMIL", "KICALDO"}, false, 5, 10},
+	{"09012100", "Café torrado, não descafeinado", 9, "Café, chá, mate e especiarias",
+		[]string{"CAFE TORRADO E MOIDO", "CAFE EXTRA FORTE"}, []string{"250G", "500G"},
+		[]string{"PILAO", "MELITTA", "TRES CORACOES"}, false, 8, 25},
+	{"17019900", "Outros açúcares de cana", 17, "Açúcares e produtos de confeitaria",
+		[]string{"ACUCAR REFINADO", "ACUCAR CRISTAL"}, []string{"1KG", "5KG"},
+		[]string{"UNIAO", "DA BARRA"}, false, 3, 20},
+	{"15079011", "Óleo de soja refinado, em recipientes com capacidade inferior ou igual a 5 l", 15, "Gorduras e óleos animais ou vegetais",
+		[]string{"OLEO DE SOJA"}, []string{"900ML"},
+		[]string{"SOYA", "LIZA"}, false, 5, 9},
+	{"04012010", "Leite UHT (Ultra High Temperature)", 4, "Leite e laticínios",
+		[]string{"LEITE INTEGRAL", "LEITE DESNATADO"}, []string{"1L"},
+		[]string{"ITALAC", "PIRACANJUBA", "ITAMBE"}, false, 3, 6},
+	{"22021000", "Águas, incluindo as águas minerais e as águas gaseificadas, adicionadas de açúcar", 22, "Bebidas, líquidos alcoólicos e vinagres",
+		[]string{"REFRIGERANTE COLA", "REFRIGERANTE GUARANA", "AGUA TONICA"}, []string{"350ML", "2L"},
+		[]string{"COCA-COLA", "ANTARCTICA", "SCHWEPPES"}, false, 2, 9},
+	{"22030000", "Cervejas de malte", 22, "Bebidas, líquidos alcoólicos e vinagres",
+		[]string{"CERVEJA PILSEN", "CERVEJA PURO MALTE"}, []string{"350ML", "600ML"},
+		[]string{"SKOL", "BRAHMA", "HEINEKEN"}, false, 2, 8},
+	{"19053100", "Bolachas e biscoitos, adicionados de edulcorante", 19, "Preparações à base de cereais",
+		[]string{"BISCOITO RECHEADO CHOCOLATE", "BISCOITO CREAM CRACKER", "BISCOITO MAISENA"}, []string{"140G", "200G"},
+		[]string{"NESTLE", "MARILAN", "VITARELLA"}, false, 1.5, 5},
+	{"34011190", "Outros sabões de toucador", 34, "Sabões, agentes orgânicos de superfície",
+		[]string{"SABONETE HIDRATANTE", "SABONETE ANTIBACTERIANO"}, []string{"85G"},
+		[]string{"DOVE", "LUX", "PROTEX"}, false, 1.2, 4},
+	{"33051000", "Xampus", 33, "Óleos essenciais e resinoides; produtos de perfumaria",
+		[]string{"SHAMPOO", "CONDICIONADOR"}, []string{"350ML"},
+		[]string{"SEDA", "PANTENE", "ELSEVE"}, false, 7, 20},
+	{"34022000", "Preparações acondicionadas para venda a retalho", 34, "Sabões, agentes orgânicos de superfície",
+		[]string{"DETERGENTE LIQUIDO", "LAVA ROUPAS EM PO"}, []string{"500ML", "1KG"},
+		[]string{"YPE", "OMO", "LIMPOL"}, false, 1.5, 18},
+	{"02013000", "Carnes de bovino desossadas, frescas ou refrigeradas", 2, "Carnes e miudezas, comestíveis",
+		[]string{"CARNE BOVINA ACEM", "CARNE BOVINA PATINHO", "ALCATRA BOVINA"}, nil,
+		nil, true, 22, 55},
+	{"08039000", "Bananas frescas ou secas", 8, "Frutas; cascas de citrinos e de melões",
+		[]string{"BANANA PRATA", "BANANA NANICA"}, nil,
+		nil, true, 2, 5},
+}
+
+type serviceItem struct {
+	descricao string
+	atividade string
+	nbs       string
+	minPrice  float64
+	maxPrice  float64
+}
+
+var serviceItems = []serviceItem{
+	{"INSTALACAO DE EQUIPAMENTO", "14.06", "120019000", 80, 350},
+	{"MANUTENCAO PREVENTIVA", "14.01", "120011000", 60, 250},
+	{"MONTAGEM DE MOVEIS", "14.06", "120019000", 50, 200},
+	{"ENTREGA EM DOMICILIO", "16.02", "106012100", 10, 40},
+}
+
+type city struct {
+	nome       string
+	codigoIbge int
+	uf         string
+	ufNome     string
+	ufIbge     int
+	ddd        string
+	cepPrefix  string
+}
+
+var cities = []city{
+	{"São Paulo", 3550308, "SP", "São Paulo", 35, "11", "01"},
+	{"Campinas", 3509502, "SP", "São Paulo", 35, "19", "13"},
+	{"Belo Horizonte", 3106200, "MG", "Minas Gerais", 31, "31", "30"},
+	{"Curitiba", 4106902, "PR", "Paraná", 41, "41", "80"},
+	{"Goiânia", 5208707, "GO", "Goiás", 52, "62", "74"},
+	{"Porto Alegre", 4314902, "RS", "Rio Grande do Sul", 43, "51", "90"},
+	{"Salvador", 2927408, "BA", "Bahia", 29, "71", "40"},
+	{"Recife", 2611606, "PE", "Pernambuco", 26, "81", "50"},
+}
+
+var streets = []string{
+	"Rua das Flores", "Avenida Brasil", "Rua XV de Novembro", "Rua Sete de Setembro",
+	"Avenida Getúlio Vargas", "Rua Dom Pedro II", "Rua Santos Dumont", "Avenida Independência",
+}
+
+var neighborhoods = []string{"Centro", "Jardim América", "Vila Nova", "Santa Mônica", "Boa Vista", "São José"}
+
+var firstNames = []string{
+	"Ana", "Bruno", "Carla", "Daniel", "Eduarda", "Felipe", "Gabriela", "Henrique",
+	"Isabela", "João", "Larissa", "Marcos", "Natália", "Otávio", "Paula", "Rafael",
+	"Sabrina", "Thiago", "Vanessa", "William", "Beatriz", "Caio", "Fernanda", "Lucas",
+}
+
+var lastNames = []string{
+	"Silva", "Santos", "Oliveira", "Souza", "Rodrigues", "Ferreira", "Alves", "Pereira",
+	"Lima", "Gomes", "Costa", "Ribeiro", "Martins", "Carvalho", "Almeida", "Lopes",
+}
+
+var companyNames = []string{
+	"MERCADO BOA VISTA", "SUPERMERCADO CENTRAL", "EMPORIO SAO JOSE", "COMERCIAL ALIANCA",
+	"MERCEARIA PRIMAVERA", "ATACADO PROGRESSO",
+}
+
+var paymentMethods = []string{"Dinheiro", "Cartão de Crédito", "Cartão de Débito", "PIX"}
+
+type tributacaoSeed struct {
+	descricao string
+	ativo     bool
+	fields    bson.D
+}
+
+var tributacoesEstaduais = []tributacaoSeed{
+	{"Tributado integralmente 18%", true, bson.D{{Key: "Cst", Value: "00"}, {Key: "Aliquota", Value: 18.0}}},
+	{"Tributado integralmente 12%", true, bson.D{{Key: "Cst", Value: "00"}, {Key: "Aliquota", Value: 12.0}}},
+	{"Substituição tributária", true, bson.D{{Key: "Cst", Value: "60"}, {Key: "Aliquota", Value: 0.0}}},
+	{"Isento", true, bson.D{{Key: "Cst", Value: "40"}, {Key: "Aliquota", Value: 0.0}}},
+	{"Tributado 17% (antiga)", false, bson.D{{Key: "Cst", Value: "00"}, {Key: "Aliquota", Value: 17.0}}},
+}
+
+var tributacoesFederais = []tributacaoSeed{
+	{"PIS/COFINS cumulativo", true, bson.D{{Key: "CstPis", Value: "01"}, {Key: "CstCofins", Value: "01"}}},
+	{"PIS/COFINS monofásico", true, bson.D{{Key: "CstPis", Value: "04"}, {Key: "CstCofins", Value: "04"}}},
+	{"PIS/COFINS alíquota zero", true, bson.D{{Key: "CstPis", Value: "06"}, {Key: "CstCofins", Value: "06"}}},
+}
+
+var tributacoesIbsCbs = []tributacaoSeed{
+	{"IBS/CBS padrão", true, bson.D{{Key: "Cst", Value: "000"}, {Key: "ClassificacaoTributaria", Value: "000001"}}},
+	{"IBS/CBS redução 60%", true, bson.D{{Key: "Cst", Value: "200"}, {Key: "ClassificacaoTributaria", Value: "200003"}}},
+}
@@ -0,0 +1,31 @@
+package seed
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// Dados fictícios avulsos, usados também pela anonimização de bases
+// (operations.AnonymizeDatabase) para que o resultado continue parecendo real.
+
+var companyActivities = []string{"COMERCIO", "DISTRIBUIDORA", "MATERIAIS", "SERVICOS", "ALIMENTOS", "MERCEARIA"}
+
+// FakePersonName devolve um nome completo em maiúsculas.
+func FakePersonName(r *rand.Rand) string {
+	return strings.ToUpper(firstNames[r.Intn(len(firstNames))] + " " +
+		lastNames[r.Intn(len(lastNames))] + " " + lastNames[r.Intn(len(lastNames))])
+}
+
+// FakeCompanyName devolve razão social e nome fantasia em maiúsculas.
+func FakeCompanyName(r *rand.Rand) (string, string) {
+	fantasia := strings.ToUpper(lastNames[r.Intn(len(lastNames))] + " " + companyActivities[r.Intn(len(companyActivities))])
+	return fantasia + " LTDA", fantasia
+}
+
+func FakeStreet(r *rand.Rand) string {
+	return streets[r.Intn(len(streets))]
+}
+
+func FakeNeighborhood(r *rand.Rand) string {
+	return neighborhoods[r.Intn(len(neighborhoods))]
+}
@@ -0,0 +1,693 @@
+// Package seed gera uma base DigisatServer fictícia e determinística para
+// demonstrações e testes: emitentes, clientes, produtos com
+// ProdutosServicosEmpresa/Estoques/Precos, tributações, notas manuais e
+// movimentações. O mesmo Config sempre produz os mesmos documentos, inclusive
+// os _id.
+package seed
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"BMongo-VIP/internal/brdoc"
+	"BMongo-VIP/internal/database"
+	"BMongo-VIP/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const DigisatUpdateCollection = "DigisatUpdate"
+
+type Config struct {
+	Seed int64
+	// Emitentes é o total de empresas; a primeira é a Matriz e as demais
+	// são filiais com a mesma raiz de CNPJ.
+	Emitentes int
+	// Produtos é o cadastro compartilhado; cada emitente recebe um
+	// ProdutosServicosEmpresa (e um Estoque, para mercadorias) por produto.
+	Produtos       int
+	Clientes       int
+	NotasManuais   int // por emitente
+	Movimentacoes  int // por emitente
+	VersaoDigisat  string
+	DataReferencia time.Time // as datas das movimentações ficam no ano anterior a ela
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Seed:           1,
+		Emitentes:      1,
+		Produtos:       300,
+		Clientes:       80,
+		NotasManuais:   25,
+		Movimentacoes:  200,
+		VersaoDigisat:  "4.2.1.7",
+		DataReferencia: time.Date(2025, 6, 30, 18, 0, 0, 0, time.UTC),
+	}
+}
+
+// Dataset guarda os documentos gerados por coleção, na ordem de criação.
+type Dataset struct {
+	Collections map[string][]interface{}
+	order       []string
+}
+
+func (d *Dataset) add(collection string, doc bson.D) {
+	if _, ok := d.Collections[collection]; !ok {
+		d.order = append(d.order, collection)
+	}
+	d.Collections[collection] = append(d.Collections[collection], doc)
+}
+
+// Names devolve as coleções na ordem em que foram criadas.
+func (d *Dataset) Names() []string {
+	return append([]string(nil), d.order...)
+}
+
+func (d *Dataset) Total() int {
+	total := 0
+	for _, docs := range d.Collections {
+		total += len(docs)
+	}
+	return total
+}
+
+// Load grava o dataset no banco informado, em lotes.
+func (d *Dataset) Load(ctx context.Context, db *mongo.Database, log func(string)) error {
+	const batchSize = 1000
+	for _, name := range d.order {
+		docs := d.Collections[name]
+		coll := db.Collection(name)
+		for start := 0; start < len(docs); start += batchSize {
+			end := start + batchSize
+			if end > len(docs) {
+				end = len(docs)
+			}
+			if _, err := coll.InsertMany(ctx, docs[start:end]); err != nil {
+				return fmt.Errorf("erro ao inserir em %s: %w", name, err)
+			}
+		}
+		log(fmt.Sprintf("📥 %s: %d documentos", name, len(docs)))
+	}
+	return nil
+}
+
+// LoadMemory grava o dataset em um repositório em memória.
+func (d *Dataset) LoadMemory(mem *repository.Memory) error {
+	for _, name := range d.order {
+		if err := mem.Insert(name, d.Collections[name]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type person struct {
+	id       primitive.ObjectID
+	tipo     bson.A
+	nome     string
+	fantasia string
+	cpfCnpj  string
+	juridica bool
+	ie       string
+	email    string
+	telefone string
+	endereco bson.D
+	city     city
+}
+
+type product struct {
+	id          primitive.ObjectID
+	servico     bool
+	descricao   string
+	codigo      string
+	codigoBarra string
+	pesavel     bool
+	marca       string
+	category    *category
+	service     *serviceItem
+	custo       float64
+	venda       float64
+}
+
+type generator struct {
+	cfg      Config
+	rng      *rand.Rand
+	seq      uint32
+	baseTime uint32
+	ds       *Dataset
+
+	estaduais []primitive.ObjectID
+	federais  []primitive.ObjectID
+	ibsCbs    []primitive.ObjectID
+	emitentes []person
+	clientes  []person
+	produtos  []product
+	marcas    map[string]primitive.ObjectID
+}
+
+// Generate monta o dataset completo a partir do Config.
+func Generate(cfg Config) *Dataset {
+	def := DefaultConfig()
+	if cfg.Emitentes <= 0 {
+		cfg.Emitentes = 1
+	}
+	if cfg.VersaoDigisat == "" {
+		cfg.VersaoDigisat = def.VersaoDigisat
+	}
+	if cfg.DataReferencia.IsZero() {
+		cfg.DataReferencia = def.DataReferencia
+	}
+
+	g := &generator{
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(cfg.Seed)),
+		// Os _id começam dois anos antes da data de referência, para que a
+		// ordem por _id acompanhe a ordem de criação.
+		baseTime: uint32(cfg.DataReferencia.AddDate(-2, 0, 0).Unix()),
+		ds:       &Dataset{Collections: make(map[string][]interface{})},
+		marcas:   make(map[string]primitive.ObjectID),
+	}
+
+	g.versao()
+	g.tributacoes()
+	g.pessoas()
+	g.produtosServicos()
+	for _, emitente := range g.emitentes {
+		g.produtosEmpresa(emitente)
+	}
+	for _, emitente := range g.emitentes {
+		g.notasManuais(emitente)
+		g.movimentacoes(emitente)
+	}
+	return g.ds
+}
+
+// id gera ObjectIDs determinísticos: timestamp crescente, bytes aleatórios do
+// seed e contador.
+func (g *generator) id() primitive.ObjectID {
+	g.seq++
+	var oid primitive.ObjectID
+	binary.BigEndian.PutUint32(oid[0:4], g.baseTime+g.seq)
+	for i := 4; i < 9; i++ {
+		oid[i] = byte(g.rng.Intn(256))
+	}
+	oid[9] = byte(g.seq >> 16)
+	oid[10] = byte(g.seq >> 8)
+	oid[11] = byte(g.seq)
+	return oid
+}
+
+func (g *generator) digits(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(byte('0' + g.rng.Intn(10)))
+	}
+	return b.String()
+}
+
+func (g *generator) money(min, max float64) float64 {
+	return math.Round((min+g.rng.Float64()*(max-min))*100) / 100
+}
+
+func (g *generator) chance(p float64) bool {
+	return g.rng.Float64() < p
+}
+
+func pick[T any](g *generator, items []T) T {
+	return items[g.rng.Intn(len(items))]
+}
+
+func (g *generator) versao() {
+	g.ds.add(DigisatUpdateCollection, bson.D{
+		{Key: "_id", Value: g.id()},
+		{Key: "Versao", Value: g.cfg.VersaoDigisat},
+		{Key: "DataAtualizacao", Value: g.cfg.DataReferencia.AddDate(0, -1, 0)},
+	})
+	g.ds.add(database.CollectionConfiguracoesServidor, bson.D{
+		{Key: "_id", Value: g.id()},
+		{Key: "_t", Value: "ConfiguracaoServidor"},
+		{Key: "VersaoBanco", Value: g.cfg.VersaoDigisat},
+		{Key: "NomeServidor", Value: "SERVIDOR-DEMO"},
+	})
+}
+
+func (g *generator) tributacoes() {
+	add := func(collection, tipo string, seeds []tributacaoSeed) []primitive.ObjectID {
+		var active []primitive.ObjectID
+		for _, t := range seeds {
+			id := g.id()
+			doc := bson.D{
+				{Key: "_id", Value: id},
+				{Key: "_t", Value: tipo},
+				{Key: "Descricao", Value: t.descricao},
+				{Key: "Ativo", Value: t.ativo},
+			}
+			doc = append(doc, t.fields...)
+			g.ds.add(collection, doc)
+			if t.ativo {
+				active = append(active, id)
+			}
+		}
+		return active
+	}
+	g.estaduais = add(database.CollectionTributacoesEstadual, "TributacaoEstadual", tributacoesEstaduais)
+	g.federais = add(database.CollectionTributacoesFederal, "TributacaoFederal", tributacoesFederais)
+	g.ibsCbs = add(database.CollectionTributacoesIbsCbs, "TributacaoIbsCbs", tributacoesIbsCbs)
+}
+
+func (g *generator) address(c city) bson.D {
+	return bson.D{
+		{Key: "Logradouro", Value: pick(g, streets)},
+		{Key: "Numero", Value: strconv.Itoa(10 + g.rng.Intn(2000))},
+		{Key: "Complemento", Value: ""},
+		{Key: "Bairro", Value: pick(g, neighborhoods)},
+		{Key: "Cep", Value: c.cepPrefix + g.digits(6)},
+		{Key: "Municipio", Value: bson.D{
+			{Key: "Nome", Value: c.nome},
+			{Key: "CodigoIbge", Value: c.codigoIbge},
+			{Key: "Uf", Value: bson.D{
+				{Key: "Sigla", Value: c.uf},
+				{Key: "Nome", Value: c.ufNome},
+				{Key: "CodigoIbge", Value: c.ufIbge},
+				{Key: "Pais", Value: bson.D{{Key: "Nome", Value: "Brasil"}, {Key: "CodigoBacen", Value: 1058}}},
+			}},
+		}},
+	}
+}
+
+func (g *generator) phone(c city) string {
+	return fmt.Sprintf("(%s) 9%s-%s", c.ddd, g.digits(4), g.digits(4))
+}
+
+func (g *generator) pessoas() {
+	root := g.digits(8)
+	homeCity := pick(g, cities)
+	for i := 0; i < g.cfg.Emitentes; i++ {
+		fantasia := companyNames[i%len(companyNames)]
+		tipo := bson.A{"Pessoa", "PessoaJuridica", "Emitente", "Matriz"}
+		if i > 0 {
+			fantasia = fmt.Sprintf("%s FILIAL %d", companyNames[0], i)
+			tipo = bson.A{"Pessoa", "PessoaJuridica", "Emitente", "Filial"}
+		}
+		p := person{
+			id:       g.id(),
+			tipo:     tipo,
+			nome:     fantasia + " LTDA",
+			fantasia: fantasia,
+			cpfCnpj:  brdoc.CompleteCNPJ(fmt.Sprintf("%s%04d", root, i+1)),
+			juridica: true,
+			ie:       g.digits(12),
+			email:    fmt.Sprintf("contato%d@example.com", i+1),
+			telefone: fmt.Sprintf("(%s) 3%s-%s", homeCity.ddd, g.digits(3), g.digits(4)),
+			endereco: g.address(homeCity),
+			city:     homeCity,
+		}
+		g.emitentes = append(g.emitentes, p)
+		g.ds.add(database.CollectionPessoas, g.personDoc(p, bson.E{
+			Key: "MicroempreendedorIndividual", Value: bson.D{{Key: "Habilitado", Value: false}},
+		}))
+	}
+
+	for i := 0; i < g.cfg.Clientes; i++ {
+		c := pick(g, cities)
+		first, last := pick(g, firstNames), pick(g, lastNames)
+		p := person{
+			id:       g.id(),
+			tipo:     bson.A{"Pessoa", "PessoaFisica", "Cliente"},
+			nome:     strings.ToUpper(first + " " + pick(g, lastNames) + " " + last),
+			cpfCnpj:  brdoc.CompleteCPF(g.digits(9)),
+			email:    strings.ToLower(fmt.Sprintf("%s.%s%d@example.com", first, last, i+1)),
+			telefone: g.phone(c),
+			endereco: g.address(c),
+			city:     c,
+		}
+		// Uma parte dos clientes é pessoa jurídica.
+		if g.chance(0.15) {
+			p.tipo = bson.A{"Pessoa", "PessoaJuridica", "Cliente"}
+			p.juridica = true
+			p.fantasia = strings.ToUpper(last) + " COMERCIO"
+			p.nome = p.fantasia + " EIRELI"
+			p.cpfCnpj = brdoc.CompleteCNPJ(g.digits(8) + "0001")
+			p.ie = g.digits(12)
+		}
+		g.clientes = append(g.clientes, p)
+		g.ds.add(database.CollectionPessoas, g.personDoc(p))
+	}
+}
+
+// personDoc monta o documento de Pessoas; embedded é a cópia gravada nas
+// movimentações (Empresa/Pessoa), sem Ativo e sem os campos extras.
+func (g *generator) personDoc(p person, extra ...bson.E) bson.D {
+	doc := bson.D{{Key: "_id", Value: p.id}, {Key: "_t", Value: p.tipo}}
+	doc = append(doc, g.personFields(p)...)
+	doc = append(doc, bson.E{Key: "Ativo", Value: true})
+	return append(doc, extra...)
+}
+
+func (g *generator) personFields(p person) bson.D {
+	carteira := bson.D{
+		{Key: "EnderecoPrincipal", Value: p.endereco},
+		{Key: "TelefonePrincipal", Value: bson.D{{Key: "Numero", Value: p.telefone}}},
+		{Key: "EmailPrincipal", Value: bson.D{{Key: "Endereco", Value: p.email}}},
+	}
+	doc := bson.D{{Key: "Nome", Value: p.nome}}
+	if p.juridica {
+		carteira = append(carteira, bson.E{Key: "Ie", Value: bson.D{
+			{Key: "Numero", Value: p.ie},
+			{Key: "Uf", Value: bson.D{{Key: "Sigla", Value: p.city.uf}, {Key: "CodigoIbge", Value: p.city.ufIbge}}},
+		}})
+		doc = append(doc, bson.E{Key: "NomeFantasia", Value: p.fantasia}, bson.E{Key: "Cnpj", Value: p.cpfCnpj})
+	} else {
+		doc = append(doc, bson.E{Key: "Cpf", Value: p.cpfCnpj})
+	}
+	return append(doc, bson.E{Key: "Carteira", Value: carteira})
+}
+
+func (g *generator) embedded(p person) bson.D {
+	doc := bson.D{{Key: "_id", Value: p.id}, {Key: "_t", Value: p.tipo}}
+	return append(doc, g.personFields(p)...)
+}
+
+func (g *generator) produtosServicos() {
+	for i := 0; i < g.cfg.Produtos; i++ {
+		p := product{id: g.id(), codigo: strconv.Itoa(i + 1)}
+
+		if g.chance(0.08) {
+			s := serviceItems[g.rng.Intn(len(serviceItems))]
+			p.servico = true
+			p.service = &s
+			p.descricao = s.descricao
+			p.venda = g.money(s.minPrice, s.maxPrice)
+			p.custo = math.Round(p.venda*0.4*100) / 100
+		} else {
+			c := &productCategories[g.rng.Intn(len(productCategories))]
+			p.category = c
+			p.pesavel = c.pesavel
+			p.descricao = pick(g, c.items)
+			if len(c.brands) > 0 {
+				p.marca = pick(g, c.brands)
+				p.descricao += " " + p.marca
+			}
+			if len(c.sizes) > 0 {
+				p.descricao += " " + pick(g, c.sizes)
+			}
+			if !c.pesavel {
+				p.codigoBarra = brdoc.CompleteEAN13("789" + g.digits(9))
+			}
+			p.custo = g.money(c.minCost, c.maxCost)
+			p.venda = math.Round(p.custo*(1.25+g.rng.Float64()*0.55)*100) / 100
+		}
+
+		g.produtos = append(g.produtos, p)
+		g.ds.add(database.CollectionProdutosServicos, g.produtoDoc(p))
+	}
+}
+
+func (g *generator) produtoDoc(p product) bson.D {
+	if p.servico {
+		return bson.D{
+			{Key: "_id", Value: p.id},
+			{Key: "_t", Value: bson.A{"ProdutoServico", "Servico"}},
+			{Key: "Descricao", Value: p.descricao},
+			{Key: "CodigoInterno", Value: p.codigo},
+			{Key: "Ativo", Value: true},
+			{Key: "CodigoAtividade", Value: bson.D{{Key: "Codigo", Value: p.service.atividade}}},
+			{Key: "TipoItem", Value: bson.D{{Key: "Codigo", Value: 9}, {Key: "Descricao", Value: "Serviços"}}},
+		}
+	}
+
+	doc := bson.D{
+		{Key: "_id", Value: p.id},
+		{Key: "_t", Value: bson.A{"ProdutoServico", "Produto"}},
+		{Key: "Descricao", Value: p.descricao},
+		{Key: "CodigoInterno", Value: p.codigo},
+		{Key: "CodigoBarras", Value: p.codigoBarra},
+		{Key: "Ativo", Value: !g.chance(0.05)},
+		{Key: "Pesavel", Value: p.pesavel},
+		{Key: "TipoItem", Value: bson.D{{Key: "Codigo", Value: 0}, {Key: "Descricao", Value: "Mercadoria para Revenda"}}},
+		{Key: "GeneroItem", Value: bson.D{{Key: "Codigo", Value: p.category.genero}, {Key: "Descricao", Value: p.category.generoDesc}}},
+	}
+	if p.marca != "" {
+		doc = append(doc, bson.E{Key: "Marca", Value: bson.D{{Key: "_id", Value: g.brandID(p.marca)}, {Key: "Descricao", Value: p.marca}}})
+	}
+	return doc
+}
+
+// brandID devolve o mesmo _id para todos os produtos da mesma marca.
+func (g *generator) brandID(marca string) primitive.ObjectID {
+	if id, ok := g.marcas[marca]; ok {
+		return id
+	}
+	id := g.id()
+	g.marcas[marca] = id
+	return id
+}
+
+func (g *generator) produtosEmpresa(emitente person) {
+	for _, p := range g.produtos {
+		precoID := g.id()
+		custos := bson.A{bson.D{{Key: "Valor", Value: p.custo}}}
+		vendas := bson.A{bson.D{{Key: "Descricao", Value: "Preço 1"}, {Key: "Valor", Value: p.venda}}}
+		g.ds.add(database.CollectionPrecos, bson.D{
+			{Key: "_id", Value: precoID},
+			{Key: "Custos", Value: custos},
+			{Key: "Vendas", Value: vendas},
+		})
+
+		tipoEmpresa := "ProdutoEmpresa"
+		var ncm bson.D
+		if p.servico {
+			tipoEmpresa = "ServicoEmpresa"
+			ncm = bson.D{{Key: "Codigo", Value: p.service.nbs}, {Key: "Descricao", Value: p.service.descricao}}
+		} else {
+			ncm = bson.D{{Key: "Codigo", Value: p.category.ncm}, {Key: "Descricao", Value: p.category.ncmDesc}}
+		}
+
+		doc := bson.D{
+			{Key: "_id", Value: g.id()},
+			{Key: "_t", Value: bson.A{"ProdutoServicoEmpresa", tipoEmpresa}},
+			{Key: "ProdutoServicoReferencia", Value: p.id},
+			{Key: "EmpresaReferencia", Value: emitente.id},
+			{Key: "PrecoReferencia", Value: precoID},
+			{Key: "NcmNbs", Value: ncm},
+		}
+
+		if !p.servico {
+			estoqueID := g.id()
+			g.ds.add(database.CollectionEstoques, bson.D{
+				{Key: "_id", Value: estoqueID},
+				{Key: "_t", Value: "Estoque"},
+				{Key: "ProdutoServicoReferencia", Value: p.id},
+				{Key: "Quantidades", Value: g.quantidades(emitente, p)},
+			})
+			doc = append(doc, bson.E{Key: "EstoqueReferencia", Value: estoqueID})
+		} else {
+			doc = append(doc, bson.E{Key: "CodigoTributacaoMunicipio", Value: strings.ReplaceAll(p.service.atividade, ".", "")})
+		}
+
+		// Versões antigas só guardam o preço em Precos; 10% dos produtos
+		// seguem esse formato para exercitar o enriquecimento de preços.
+		if !g.chance(0.1) {
+			doc = append(doc,
+				bson.E{Key: "PrecosCustos", Value: custos},
+				bson.E{Key: "PrecosVendas", Value: vendas},
+			)
+		}
+
+		if !g.chance(0.1) {
+			doc = append(doc, bson.E{Key: "TributacaoEstadualReferencia", Value: pick(g, g.estaduais)})
+		}
+		doc = append(doc, bson.E{Key: "TributacaoFederalReferencia", Value: pick(g, g.federais)})
+		if g.chance(0.5) {
+			doc = append(doc, bson.E{Key: "TributacaoIbsCbsReferencia", Value: pick(g, g.ibsCbs)})
+		}
+
+		g.ds.add(database.CollectionProdutosServicosEmpresa, doc)
+	}
+}
+
+// quantidades distribui o estoque entre positivo, zerado, negativo e sem
+// registro, os casos tratados pelas operações de estoque.
+func (g *generator) quantidades(emitente person, p product) bson.A {
+	var qty float64
+	switch r := g.rng.Float64(); {
+	case r < 0.05:
+		return bson.A{}
+	case r < 0.18:
+		qty = 0
+	case r < 0.28:
+		qty = -float64(1 + g.rng.Intn(20))
+	case p.pesavel:
+		qty = math.Round(g.rng.Float64()*80*1000) / 1000
+	default:
+		qty = float64(1 + g.rng.Intn(500))
+	}
+	return bson.A{bson.D{
+		{Key: "EmpresaReferencia", Value: emitente.id},
+		{Key: "Quantidade", Value: qty},
+	}}
+}
+
+func (g *generator) emissao() time.Time {
+	offset := time.Duration(g.rng.Int63n(int64(365 * 24 * time.Hour)))
+	return g.cfg.DataReferencia.Add(-offset).Truncate(time.Second)
+}
+
+// itens escolhe de 1 a 5 produtos; mercadorias pesáveis saem com quantidade
+// fracionada.
+func (g *generator) itens(allowServices bool) (bson.A, float64) {
+	n := 1 + g.rng.Intn(5)
+	itens := make(bson.A, 0, n)
+	total := 0.0
+	for len(itens) < n {
+		p := pick(g, g.produtos)
+		if p.servico && !allowServices {
+			continue
+		}
+		qty := float64(1 + g.rng.Intn(6))
+		if p.pesavel {
+			qty = math.Round((0.2+g.rng.Float64()*3)*1000) / 1000
+		}
+		desconto := 0.0
+		if g.chance(0.1) {
+			desconto = math.Round(p.venda*qty*0.05*100) / 100
+		}
+		valor := math.Round((qty*p.venda-desconto)*100) / 100
+		total += valor
+		itens = append(itens, bson.D{
+			{Key: "Produto", Value: bson.D{
+				{Key: "_id", Value: p.id},
+				{Key: "CodigoInterno", Value: p.codigo},
+				{Key: "Descricao", Value: p.descricao},
+				{Key: "CodigoBarras", Value: p.codigoBarra},
+			}},
+			{Key: "Quantidade", Value: qty},
+			{Key: "ValorUnitario", Value: p.venda},
+			{Key: "ValorDesconto", Value: desconto},
+			{Key: "ValorTotal", Value: valor},
+		})
+	}
+	return itens, math.Round(total*100) / 100
+}
+
+func pagamento(especie string, valor float64) bson.D {
+	return bson.D{{Key: "Parcelas", Value: bson.A{bson.D{
+		{Key: "Numero", Value: 1},
+		{Key: "Valor", Value: valor},
+		{Key: "Historico", Value: bson.A{bson.D{
+			{Key: "EspeciePagamento", Value: bson.D{{Key: "Descricao", Value: especie}}},
+			{Key: "Valor", Value: valor},
+		}}},
+	}}}}
+}
+
+func (g *generator) notasManuais(emitente person) {
+	for i := 0; i < g.cfg.NotasManuais && len(g.clientes) > 0; i++ {
+		cliente := pick(g, g.clientes)
+		itens, total := g.itens(true)
+		desconto := 0.0
+		if g.chance(0.2) {
+			desconto = g.money(1, 10)
+		}
+		total = math.Round((total-desconto)*100) / 100
+
+		g.ds.add(database.CollectionMovimentacoes, bson.D{
+			{Key: "_id", Value: g.id()},
+			{Key: "_t", Value: bson.A{"Movimentacao", "NotaFiscalManual"}},
+			{Key: "Numero", Value: int64(i + 1)},
+			{Key: "Serie", Value: "1"},
+			{Key: "DataHoraEmissao", Value: g.emissao()},
+			{Key: "EmpresaReferencia", Value: emitente.id},
+			{Key: "Empresa", Value: g.embedded(emitente)},
+			{Key: "Pessoa", Value: g.embedded(cliente)},
+			{Key: "Itens", Value: itens},
+			{Key: "TotalDescontoAplicado", Value: desconto},
+			{Key: "TotalOutrasDespesas", Value: 0.0},
+			{Key: "ValorTotal", Value: total},
+			{Key: "Observacao", Value: ""},
+			{Key: "PagamentoRecebimento", Value: pagamento(pick(g, paymentMethods), total)},
+		})
+	}
+}
+
+type movementKind struct {
+	tipo   string
+	modelo int // 0 para movimentações sem documento fiscal
+}
+
+var movementKinds = []movementKind{
+	{"Venda", 0},
+	{"NotaFiscalConsumidorEletronica", 65},
+	{"NotaFiscalEletronica", 55},
+}
+
+func (g *generator) movimentacoes(emitente person) {
+	numeros := make(map[string]int64)
+	for i := 0; i < g.cfg.Movimentacoes; i++ {
+		var kind movementKind
+		switch r := g.rng.Float64(); {
+		case r < 0.5:
+			kind = movementKinds[0]
+		case r < 0.85:
+			kind = movementKinds[1]
+		default:
+			kind = movementKinds[2]
+		}
+		numeros[kind.tipo]++
+		numero := numeros[kind.tipo]
+		emissao := g.emissao()
+		itens, total := g.itens(false)
+
+		doc := bson.D{
+			{Key: "_id", Value: g.id()},
+			{Key: "_t", Value: bson.A{"Movimentacao", kind.tipo}},
+			{Key: "Numero", Value: numero},
+			{Key: "DataHoraEmissao", Value: emissao},
+			{Key: "EmpresaReferencia", Value: emitente.id},
+			{Key: "Empresa", Value: g.embedded(emitente)},
+		}
+		// NFC-e e vendas de balcão podem sair para consumidor não identificado.
+		if len(g.clientes) > 0 && (kind.modelo == 55 || g.chance(0.4)) {
+			doc = append(doc, bson.E{Key: "Pessoa", Value: g.embedded(pick(g, g.clientes))})
+		}
+
+		situacao := bson.D{{Key: "Codigo", Value: 1}, {Key: "Descricao", Value: "Fechada"}}
+		if kind.modelo != 0 {
+			situacao = bson.D{{Key: "Codigo", Value: 100}, {Key: "Descricao", Value: "Autorizada"}}
+			if g.chance(0.05) {
+				situacao = bson.D{{Key: "Codigo", Value: 101}, {Key: "Descricao", Value: "Cancelada"}}
+			}
+			doc = append(doc,
+				bson.E{Key: "Serie", Value: 1},
+				bson.E{Key: "ChaveAcesso", Value: g.chaveAcesso(emitente, emissao, kind.modelo, numero)},
+			)
+		}
+
+		doc = append(doc,
+			bson.E{Key: "Itens", Value: itens},
+			bson.E{Key: "TotalDescontoAplicado", Value: 0.0},
+			bson.E{Key: "TotalOutrasDespesas", Value: 0.0},
+			bson.E{Key: "ValorTotal", Value: total},
+			bson.E{Key: "Situacao", Value: situacao},
+			bson.E{Key: "SituacaoMovimentacao", Value: situacao},
+			bson.E{Key: "PagamentoRecebimento", Value: pagamento(pick(g, paymentMethods), total)},
+		)
+		g.ds.add(database.CollectionMovimentacoes, doc)
+	}
+}
+
+// chaveAcesso monta a chave de 44 dígitos da NF-e/NFC-e (cUF, AAMM, CNPJ,
+// modelo, série, número, tipo de emissão, código numérico e DV módulo 11).
+func (g *generator) chaveAcesso(emitente person, emissao time.Time, modelo int, numero int64) string {
+	return brdoc.CompleteChaveAcesso(fmt.Sprintf("%02d%s%s%02d%03d%09d1%s",
+		emitente.city.ufIbge, emissao.Format("0601"), emitente.cpfCnpj, modelo, 1, numero, g.digits(8)))
+}
@@ -0,0 +1,64 @@
+//go:build minio
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestMinIORoundTrip envia e baixa um arquivo multipart em um servidor real,
+// configurado pelas mesmas variáveis S3_* do aplicativo. Para rodar com um
+// MinIO local:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	S3_ENDPOINT=localhost:9000 S3_USE_SSL=false S3_BUCKET=bmongo-test \
+//	S3_ACCESS_KEY=minioadmin S3_SECRET_KEY=minioadmin go test -tags minio ./internal/storage/
+//
+// O bucket precisa existir.
+func TestMinIORoundTrip(t *testing.T) {
+	cfg, err := S3ConfigFromEnv()
+	if err != nil {
+		t.Skip(err)
+	}
+	c := NewS3Client(*cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	dir := t.TempDir()
+	payload := testPayload(multipartPartSize + 4096)
+	src := filepath.Join(dir, "origem.tar.gz")
+	if err := os.WriteFile(src, payload, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	key := c.Key(fmt.Sprintf("teste_%d.tar.gz", time.Now().UnixNano()))
+	if err := c.UploadFile(ctx, key, src, func(msg string) { t.Log(msg) }); err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := c.HeadObject(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Size != int64(len(payload)) {
+		t.Fatalf("tamanho remoto = %d, esperado %d", head.Size, len(payload))
+	}
+
+	dest := filepath.Join(dir, "destino.tar.gz")
+	if err := c.DownloadFile(ctx, key, dest, func(msg string) { t.Log(msg) }); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Fatal("arquivo baixado difere do enviado")
+	}
+}
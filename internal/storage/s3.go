@@ -0,0 +1,433 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config descreve um bucket compatível com S3 (AWS, MinIO, Wasabi...).
+// O acesso é sempre path-style, o que funciona tanto na AWS quanto no MinIO local.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string
+	UseSSL    bool
+}
+
+func S3ConfigFromEnv() (*S3Config, error) {
+	cfg := &S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		Prefix:    os.Getenv("S3_PREFIX"),
+		UseSSL:    os.Getenv("S3_USE_SSL") != "false",
+	}
+
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("variáveis de ambiente S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY e S3_SECRET_KEY devem estar definidas")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Prefix != "" && !strings.HasSuffix(cfg.Prefix, "/") {
+		cfg.Prefix += "/"
+	}
+
+	return cfg, nil
+}
+
+type S3Client struct {
+	cfg  S3Config
+	http *http.Client
+}
+
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag"`
+}
+
+func NewS3Client(cfg S3Config) *S3Client {
+	return &S3Client{
+		cfg:  cfg,
+		http: &http.Client{Timeout: 30 * time.Minute},
+	}
+}
+
+func (c *S3Client) Bucket() string {
+	return c.cfg.Bucket
+}
+
+// Key aplica o prefixo configurado ao nome do objeto.
+func (c *S3Client) Key(name string) string {
+	return c.cfg.Prefix + name
+}
+
+func (c *S3Client) URL(key string) string {
+	return fmt.Sprintf("s3://%s/%s", c.cfg.Bucket, key)
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (c *S3Client) do(ctx context.Context, method, key string, query url.Values, headers map[string]string, body []byte) (*http.Response, error) {
+	scheme := "https"
+	if !c.cfg.UseSSL {
+		scheme = "http"
+	}
+
+	path := "/" + c.cfg.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	u := &url.URL{Scheme: scheme, Host: c.cfg.Endpoint, Path: path, RawPath: encodePath(path)}
+	if query != nil {
+		u.RawQuery = encodeQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	c.sign(req, body, time.Now().UTC())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var e s3Error
+		if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("S3 %s %s: %s - %s", method, key, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("S3 %s %s: status %d", method, key, resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// sign aplica a assinatura AWS Signature Version 4 à requisição.
+func (c *S3Client) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	var names []string
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + c.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretKey), day)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func encodePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = uriEncode(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func encodeQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode segue a codificação exigida pelo SigV4: apenas caracteres não reservados ficam literais.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func (c *S3Client) PutObject(ctx context.Context, key string, data []byte, contentType string) error {
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, key, nil, headers, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *S3Client) GetObject(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (c *S3Client) HeadObject(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &ObjectInfo{
+		Key:          key,
+		Size:         resp.ContentLength,
+		LastModified: modified,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+	}, nil
+}
+
+func (c *S3Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var result struct {
+		Contents []struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+			ETag         string    `xml:"ETag"`
+		} `xml:"Contents"`
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+	}
+
+	var objects []ObjectInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("resposta inválida do S3: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          obj.Key,
+				Size:         obj.Size,
+				LastModified: obj.LastModified,
+				ETag:         strings.Trim(obj.ETag, `"`),
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+		result.Contents = nil
+	}
+
+	return objects, nil
+}
+
+func (c *S3Client) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("resposta inválida do S3: %w", err)
+	}
+	return result.UploadID, nil
+}
+
+func (c *S3Client) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	resp, err := c.do(ctx, http.MethodPut, key, query, nil, data)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+func (c *S3Client) listParts(ctx context.Context, key, uploadID string) (map[int]uploadedPart, error) {
+	parts := make(map[int]uploadedPart)
+	marker := ""
+	for {
+		query := url.Values{"uploadId": {uploadID}}
+		if marker != "" {
+			query.Set("part-number-marker", marker)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, key, query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Parts []struct {
+				PartNumber int    `xml:"PartNumber"`
+				ETag       string `xml:"ETag"`
+				Size       int64  `xml:"Size"`
+			} `xml:"Part"`
+			IsTruncated          bool   `xml:"IsTruncated"`
+			NextPartNumberMarker string `xml:"NextPartNumberMarker"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("resposta inválida do S3: %w", err)
+		}
+
+		for _, p := range result.Parts {
+			parts[p.PartNumber] = uploadedPart{Number: p.PartNumber, ETag: strings.Trim(p.ETag, `"`), Size: p.Size}
+		}
+
+		if !result.IsTruncated || result.NextPartNumberMarker == "" {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+	return parts, nil
+}
+
+func (c *S3Client) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []uploadedPart) error {
+	type completePart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	payload := struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}{}
+	for _, p := range parts {
+		payload.Parts = append(payload.Parts, completePart{PartNumber: p.Number, ETag: `"` + p.ETag + `"`})
+	}
+
+	body, err := xml.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, map[string]string{"Content-Type": "application/xml"}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// O S3 pode responder 200 com um <Error> no corpo quando a composição falha.
+	data, _ := io.ReadAll(resp.Body)
+	var e s3Error
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return fmt.Errorf("S3 falhou ao concluir upload: %s - %s", e.Code, e.Message)
+	}
+	return nil
+}
+
+func (c *S3Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
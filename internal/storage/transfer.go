@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// Partes de 16 MB mantêm o número de partes bem abaixo do limite de 10.000
+// do S3 para backups de até ~150 GB.
+const multipartPartSize = 16 * 1024 * 1024
+
+type uploadedPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// uploadState é persistido ao lado do arquivo para permitir retomar um upload interrompido.
+type uploadState struct {
+	Key      string    `json:"key"`
+	UploadID string    `json:"uploadId"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"modTime"`
+	PartSize int64     `json:"partSize"`
+}
+
+func uploadStatePath(filePath string) string {
+	return filePath + ".upload.json"
+}
+
+func loadUploadState(filePath string) *uploadState {
+	data, err := os.ReadFile(uploadStatePath(filePath))
+	if err != nil {
+		return nil
+	}
+	var st uploadState
+	if json.Unmarshal(data, &st) != nil {
+		return nil
+	}
+	return &st
+}
+
+func saveUploadState(filePath string, st *uploadState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(uploadStatePath(filePath), data, 0644)
+}
+
+// UploadFile envia um arquivo local para o bucket. Arquivos maiores que uma
+// parte usam multipart upload, retomando as partes já enviadas quando existe
+// estado salvo de uma tentativa anterior.
+func (c *S3Client) UploadFile(ctx context.Context, key, filePath string, log func(string)) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() <= multipartPartSize {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		return c.PutObject(ctx, key, data, "")
+	}
+
+	st := loadUploadState(filePath)
+	done := make(map[int]uploadedPart)
+
+	if st != nil && st.Key == key && st.Size == info.Size() && st.ModTime.Equal(info.ModTime()) && st.PartSize == multipartPartSize {
+		parts, err := c.listParts(ctx, key, st.UploadID)
+		if err == nil {
+			done = parts
+			log(fmt.Sprintf("♻️ Retomando upload: %d partes já enviadas", len(done)))
+		} else {
+			log(fmt.Sprintf("⚠️ Upload anterior não pode ser retomado: %v", err))
+			st = nil
+		}
+	} else {
+		st = nil
+	}
+
+	if st == nil {
+		uploadID, err := c.createMultipartUpload(ctx, key)
+		if err != nil {
+			return fmt.Errorf("erro ao iniciar upload: %w", err)
+		}
+		st = &uploadState{
+			Key:      key,
+			UploadID: uploadID,
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+			PartSize: multipartPartSize,
+		}
+		if err := saveUploadState(filePath, st); err != nil {
+			log(fmt.Sprintf("⚠️ Não foi possível salvar estado do upload: %v", err))
+		}
+	}
+
+	totalParts := int((info.Size() + multipartPartSize - 1) / multipartPartSize)
+	buf := make([]byte, multipartPartSize)
+
+	for number := 1; number <= totalParts; number++ {
+		offset := int64(number-1) * multipartPartSize
+		size := int64(multipartPartSize)
+		if offset+size > info.Size() {
+			size = info.Size() - offset
+		}
+
+		if p, ok := done[number]; ok && p.Size == size {
+			continue
+		}
+
+		if _, err := file.ReadAt(buf[:size], offset); err != nil && err != io.EOF {
+			return fmt.Errorf("erro ao ler parte %d: %w", number, err)
+		}
+
+		etag, err := c.uploadPart(ctx, key, st.UploadID, number, buf[:size])
+		if err != nil {
+			return fmt.Errorf("erro ao enviar parte %d/%d (o upload pode ser retomado): %w", number, totalParts, err)
+		}
+		done[number] = uploadedPart{Number: number, ETag: etag, Size: size}
+		log(fmt.Sprintf("⬆️ Parte %d/%d enviada", number, totalParts))
+	}
+
+	parts := make([]uploadedPart, 0, len(done))
+	for _, p := range done {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+
+	if err := c.completeMultipartUpload(ctx, key, st.UploadID, parts); err != nil {
+		return err
+	}
+
+	os.Remove(uploadStatePath(filePath))
+	return nil
+}
+
+// DownloadFile baixa um objeto para destPath. O conteúdo é gravado em
+// destPath+".part" e, se esse arquivo já existir, o download continua do
+// ponto em que parou usando requisições com Range.
+func (c *S3Client) DownloadFile(ctx context.Context, key, destPath string, log func(string)) error {
+	head, err := c.HeadObject(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	partPath := destPath + ".part"
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+		if offset > head.Size {
+			offset = 0
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if offset > 0 {
+		flags |= os.O_APPEND
+		log(fmt.Sprintf("♻️ Retomando download a partir de %d bytes", offset))
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo: %w", err)
+	}
+
+	if offset < head.Size {
+		headers := map[string]string{}
+		if offset > 0 {
+			headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, key, nil, headers, nil)
+		if err != nil {
+			out.Close()
+			return err
+		}
+
+		// Servidor ignorou o Range: recomeça do zero em vez de duplicar o início do arquivo.
+		if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+			if err := out.Truncate(0); err != nil {
+				resp.Body.Close()
+				out.Close()
+				return err
+			}
+			out.Seek(0, io.SeekStart)
+		}
+
+		_, err = io.Copy(out, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			out.Close()
+			return fmt.Errorf("download interrompido (pode ser retomado): %w", err)
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(partPath)
+	if err != nil {
+		return err
+	}
+	if info.Size() != head.Size {
+		return fmt.Errorf("download incompleto: %d de %d bytes", info.Size(), head.Size)
+	}
+
+	return os.Rename(partPath, destPath)
+}
@@ -0,0 +1,33 @@
+package windows
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// existingVolumePath sobe na árvore até encontrar um diretório existente,
+// já que a consulta de espaço livre exige um caminho válido no volume.
+func existingVolumePath(path string) string {
+	p := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return p
+		}
+		p = parent
+	}
+}
+
+func DirSize(path string) uint64 {
+	var total uint64
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += uint64(info.Size())
+		}
+		return nil
+	})
+	return total
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package windows
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func FreeDiskSpace(path string) (uint64, error) {
+	target := existingVolumePath(path)
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(target, &st); err != nil {
+		return 0, fmt.Errorf("erro ao consultar espaço livre em %s: %w", target, err)
+	}
+
+	return st.Bavail * uint64(st.Bsize), nil
+}
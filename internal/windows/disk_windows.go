@@ -0,0 +1,23 @@
+package windows
+
+import (
+	"fmt"
+
+	syswin "golang.org/x/sys/windows"
+)
+
+func FreeDiskSpace(path string) (uint64, error) {
+	target := existingVolumePath(path)
+
+	p, err := syswin.UTF16PtrFromString(target)
+	if err != nil {
+		return 0, fmt.Errorf("caminho inválido: %w", err)
+	}
+
+	var free uint64
+	if err := syswin.GetDiskFreeSpaceEx(p, &free, nil, nil); err != nil {
+		return 0, fmt.Errorf("erro ao consultar espaço livre em %s: %w", target, err)
+	}
+
+	return free, nil
+}
@@ -73,6 +73,10 @@ func findMongoDBPath() string {
 	return ""
 }
 
+func MongoDBDataPath() string {
+	return findMongoDBPath()
+}
+
 func RepairMongoDB(log func(string)) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
@@ -91,6 +95,19 @@ func RepairMongoDB(log func(string)) error {
 	}
 	log(fmt.Sprintf("📂 Diretório de dados: %s", dbPath))
 
+	// O --repair reconstrói coleções e índices e pode precisar de espaço equivalente ao dbpath.
+	dataSize := DirSize(dbPath)
+	free, err := FreeDiskSpace(dbPath)
+	if err != nil {
+		log(fmt.Sprintf("⚠️ Não foi possível verificar o espaço livre: %v", err))
+	} else {
+		log(fmt.Sprintf("💾 Dados: %.2f MB | Livre: %.2f MB", float64(dataSize)/1024/1024, float64(free)/1024/1024))
+		if free < dataSize {
+			return fmt.Errorf("espaço insuficiente para o reparo: necessário %.2f MB, disponível %.2f MB",
+				float64(dataSize)/1024/1024, float64(free)/1024/1024)
+		}
+	}
+
 	log("🛑 Parando serviços MongoDB...")
 	mongoServices := []string{"MongoDB", "MongoDBDigisat", "DigisatMongoDB"}
 	for _, svc := range mongoServices {
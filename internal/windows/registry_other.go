@@ -0,0 +1,15 @@
+//go:build !windows
+
+package windows
+
+import "fmt"
+
+type RegistryManager struct{}
+
+func NewRegistryManager() *RegistryManager {
+	return &RegistryManager{}
+}
+
+func (r *RegistryManager) CleanDigisatRegistry(log func(string)) error {
+	return fmt.Errorf("limpeza de registros disponível apenas no Windows")
+}